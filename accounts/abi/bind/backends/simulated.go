@@ -155,10 +155,25 @@ func (b *SimulatedBackend) Commit() {
 		allLogs = append(allLogs, r.Logs...)
 	}
 	b.logsFeed.Send(allLogs)
+	b.keepCanonical(b.pendingBlock)
 	b.prependBlock = b.pendingBlock
 	b.emptyPendingBlock()
 }
 
+// keepCanonical makes the block canonical again after it was inserted.  The zkEVM execution writes the header back
+// with the receipt root of its own receipts, which moves the canonical hash off the block the next one is built on.
+func (b *SimulatedBackend) keepCanonical(block *types.Block) {
+	if err := b.m.DB.Update(context.Background(), func(tx kv.RwTx) error {
+		hash, err := rawdb.ReadCanonicalHash(tx, block.NumberU64())
+		if err != nil || hash == block.Hash() {
+			return err
+		}
+		return rawdb.WriteCanonicalHash(tx, block.Hash(), block.NumberU64())
+	}); err != nil {
+		panic(err)
+	}
+}
+
 // Rollback aborts all pending transactions, reverting to the last committed state.
 func (b *SimulatedBackend) Rollback() {
 	b.mu.Lock()
@@ -55,6 +55,14 @@ func runErigon(cliCtx *cli.Context) error {
 
 	logging.SetupLoggerCtx("cdk-erigon", cliCtx)
 
+	if err := erigoncli.ApplyDynamicChainConfig(cliCtx); err != nil {
+		return err
+	}
+
+	if err := erigoncli.ApplyDevnetFlags(cliCtx); err != nil {
+		return err
+	}
+
 	// initializing the node and providing the current git commit there
 	log.Info("Build info", "git_branch", params.GitBranch, "git_tag", params.GitTag, "git_commit", params.GitCommit)
 	log.Info("Poseidon hashing", "Accelerated", vectorizedposeidongold.UsingSimd || vectorizedposeidongold.UsingScalars)
@@ -100,6 +108,14 @@ func setFlagsFromConfigFile(ctx *cli.Context, filePath string) error {
 	} else {
 		return errors.New("config files only accepted are .yaml and .toml")
 	}
+	// dynamic chain configs embedded in the file are not flags so are taken out before setting the flags
+	if embedded, ok := fileConfig[erigoncli.DynamicConfigKey]; ok {
+		delete(fileConfig, erigoncli.DynamicConfigKey)
+		if err := erigoncli.SetEmbeddedDynamicConfig(embedded); err != nil {
+			return err
+		}
+	}
+
 	// sets global flags to value in yaml/toml file
 	for key, value := range fileConfig {
 		if !ctx.IsSet(key) {
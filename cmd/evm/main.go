@@ -185,6 +185,7 @@ func init() {
 		&disasmCommand,
 		&runCommand,
 		&stateTestCommand,
+		&zkStateTestCommand,
 		&stateTransitionCommand,
 	}
 }
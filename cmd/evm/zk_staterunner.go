@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/c2h5oh/datasize"
+	libcommon "github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/kv/mdbx"
+	"github.com/ledgerwatch/log/v3"
+	mdbx2 "github.com/torquem-ch/mdbx-go/mdbx"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/eth/tracers/logger"
+	"github.com/ledgerwatch/erigon/tests"
+	"github.com/ledgerwatch/erigon/zk/sequencer"
+)
+
+var zkStateTestCommand = cli.Command{
+	Action:    zkStateTestCmd,
+	Name:      "zkstatetest",
+	Usage:     "executes the given zkEVM test vectors, checking the SMT root, the rejected transactions and the counters",
+	ArgsUsage: "<file>",
+}
+
+// ZkStatetestResult contains the execution status after running a zkEVM state test
+type ZkStatetestResult struct {
+	Name     string          `json:"name"`
+	Pass     bool            `json:"pass"`
+	Root     *libcommon.Hash `json:"smtRoot,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	Counters map[string]int  `json:"counters,omitempty"`
+}
+
+func zkStateTestCmd(ctx *cli.Context) error {
+	if len(ctx.Args().First()) == 0 {
+		return errors.New("path-to-test argument required")
+	}
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlError, log.StderrHandler))
+
+	// the counters are only collected by a sequencer
+	sequencer.SetRole(sequencer.RoleSequencer, false)
+
+	src, err := os.ReadFile(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	var zkStateTests []tests.ZkStateTest
+	if err = json.Unmarshal(src, &zkStateTests); err != nil {
+		return err
+	}
+
+	cfg := vm.Config{
+		Debug: ctx.Bool(MachineFlag.Name),
+	}
+	if ctx.Bool(MachineFlag.Name) {
+		cfg.Tracer = logger.NewJSONLogger(&logger.LogConfig{
+			DisableMemory:     ctx.Bool(DisableMemoryFlag.Name),
+			DisableStack:      ctx.Bool(DisableStackFlag.Name),
+			DisableStorage:    ctx.Bool(DisableStorageFlag.Name),
+			DisableReturnData: ctx.Bool(DisableReturnDataFlag.Name),
+		}, os.Stderr)
+	}
+
+	results, err := aggregateResultsFromZkStateTests(zkStateTests, cfg)
+	if err != nil {
+		return err
+	}
+
+	out, _ := json.MarshalIndent(results, "", "  ")
+	fmt.Println(string(out))
+	return nil
+}
+
+func aggregateResultsFromZkStateTests(zkStateTests []tests.ZkStateTest, cfg vm.Config) ([]ZkStatetestResult, error) {
+	db := mdbx.NewMDBX(log.New()).
+		Path(filepath.Join(os.TempDir(), "erigon-zkstatetest")).
+		Flags(func(u uint) uint {
+			return u | mdbx2.UtterlyNoSync | mdbx2.NoMetaSync | mdbx2.LifoReclaim | mdbx2.NoMemInit
+		}).
+		GrowthStep(1 * datasize.MB).
+		MustOpen()
+	defer db.Close()
+
+	results := make([]ZkStatetestResult, 0, len(zkStateTests))
+	for _, test := range zkStateTests {
+		// the SMT root is computed from the whole state so every test runs on a fresh tx
+		tx, err := db.BeginRw(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		result := ZkStatetestResult{Name: test.Name(), Pass: true}
+		zkResult, err := test.Run(tx, cfg)
+		if err != nil {
+			result.Pass, result.Error = false, err.Error()
+		}
+		if zkResult != nil {
+			root := zkResult.Root
+			result.Root = &root
+			if zkResult.Counters != nil {
+				result.Counters = make(map[string]int, len(zkResult.Counters))
+				for k, c := range zkResult.Counters {
+					result.Counters[string(k)] = c.Used()
+				}
+			}
+		}
+		tx.Rollback()
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/ledgerwatch/erigon/consensus"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
 	stages3 "github.com/ledgerwatch/erigon/zk/stages"
+	"github.com/ledgerwatch/erigon/zk/gas_price"
 	"github.com/ledgerwatch/erigon/zk/sequencer"
 )
 
@@ -89,6 +90,12 @@ func newSyncZk(ctx context.Context, db kv.RwDB) (consensus.Engine, *vm.Config, *
 			nil,
 			nil,
 			nil,
+			nil,
+			nil,
+			nil,
+			gas_price.NewOracle(gas_price.ConfigFromZk(cfg.Zk), nil),
+			nil,
+			nil,
 		)
 	} else {
 		stages = stages2.NewDefaultZkStages(
@@ -105,6 +112,7 @@ func newSyncZk(ctx context.Context, db kv.RwDB) (consensus.Engine, *vm.Config, *
 			nil,
 			nil,
 			nil,
+			nil,
 			nil)
 	}
 
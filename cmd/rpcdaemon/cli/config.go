@@ -29,6 +29,7 @@ import (
 	"github.com/ledgerwatch/erigon/turbo/debug"
 	"github.com/ledgerwatch/erigon/turbo/logging"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync/snap"
+	"github.com/ledgerwatch/erigon/zk/sequencer"
 
 	"github.com/gateway-fm/cdk-erigon-lib/direct"
 	"github.com/gateway-fm/cdk-erigon-lib/gointerfaces"
@@ -122,6 +123,7 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 	rootCmd.PersistentFlags().IntVar(&cfg.ReturnDataLimit, utils.RpcReturnDataLimit.Name, utils.RpcReturnDataLimit.Value, utils.RpcReturnDataLimit.Usage)
 
 	rootCmd.PersistentFlags().StringVar(&cfg.L2RpcUrl, utils.L2RpcUrlFlag.Name, utils.L2RpcUrlFlag.Value, utils.L2RpcUrlFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&cfg.NodeRole, utils.NodeRoleFlag.Name, utils.NodeRoleFlag.Value, "Role of the node the daemon serves, it has to match the node's: sequencer, rpc, archive or verifier.  Picks the namespaces served and whether transactions are taken")
 
 	if err := rootCmd.MarkPersistentFlagFilename("rpc.accessList", "json"); err != nil {
 		panic(err)
@@ -155,6 +157,12 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 		if cfg.TxPoolApiAddr == "" {
 			cfg.TxPoolApiAddr = cfg.PrivateApiAddr
 		}
+
+		role, err := sequencer.ResolveRole(cfg.NodeRole, false)
+		if err != nil {
+			return err
+		}
+		sequencer.SetRole(role, false)
 		return nil
 	}
 	rootCmd.PersistentPostRunE = func(cmd *cobra.Command, args []string) error {
@@ -513,6 +521,12 @@ func startRegularRpcServer(ctx context.Context, cfg httpcfg.HttpCfg, rpcAPI []rp
 
 	srv.SetBatchLimit(cfg.BatchLimit)
 
+	rateLimiter, err := rpc.NewRateLimiter(cfg.RateLimits)
+	if err != nil {
+		return err
+	}
+	srv.SetRateLimiter(rateLimiter)
+
 	var defaultAPIList []rpc.API
 
 	for _, api := range rpcAPI {
@@ -64,9 +64,11 @@ type HttpCfg struct {
 
 	BatchLimit      int // Maximum number of requests in a batch
 	ReturnDataLimit int // Maximum number of bytes returned from calls (like eth_call)
+	RateLimits      rpccfg.RateLimits
 
 	// zkevm
 	DataStreamPort int
 	DataStreamHost string
 	L2RpcUrl       string
+	NodeRole       string
 }
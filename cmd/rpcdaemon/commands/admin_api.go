@@ -7,6 +7,8 @@ import (
 
 	"github.com/ledgerwatch/erigon/p2p"
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/erigon/zk/sequencer"
+	zktxpool "github.com/ledgerwatch/erigon/zk/txpool"
 )
 
 // AdminAPI the interface for the admin_* RPC commands.
@@ -17,17 +19,33 @@ type AdminAPI interface {
 	// Peers returns information about the connected remote nodes.
 	// https://geth.ethereum.org/docs/rpc/ns-admin#admin_peers
 	Peers(ctx context.Context) ([]*p2p.PeerInfo, error)
+
+	// AclLists returns the access control lists of the txpool and whether each is enforced, they are changed with the
+	// zkevmadmin api.
+	AclLists(ctx context.Context) ([]zktxpool.ACLListContent, error)
+
+	// NodeRole returns the role the node was started with and whether it is sequencing right now.
+	NodeRole(ctx context.Context) (*NodeRoleInfo, error)
+}
+
+// NodeRoleInfo is the result of admin_nodeRole, a sequencer in standby follows the active one until it takes over.
+type NodeRoleInfo struct {
+	Role       sequencer.Role `json:"role"`
+	Sequencing bool           `json:"sequencing"`
+	Standby    bool           `json:"standby"`
 }
 
 // AdminAPIImpl data structure to store things needed for admin_* commands.
 type AdminAPIImpl struct {
 	ethBackend rpchelper.ApiBackend
+	acl        *zktxpool.ACL
 }
 
-// NewAdminAPI returns AdminAPIImpl instance.
-func NewAdminAPI(eth rpchelper.ApiBackend) *AdminAPIImpl {
+// NewAdminAPI returns AdminAPIImpl instance, acl is nil on the nodes without a txpool of their own.
+func NewAdminAPI(eth rpchelper.ApiBackend, acl *zktxpool.ACL) *AdminAPIImpl {
 	return &AdminAPIImpl{
 		ethBackend: eth,
+		acl:        acl,
 	}
 }
 
@@ -47,3 +65,22 @@ func (api *AdminAPIImpl) NodeInfo(ctx context.Context) (*p2p.NodeInfo, error) {
 func (api *AdminAPIImpl) Peers(ctx context.Context) ([]*p2p.PeerInfo, error) {
 	return api.ethBackend.Peers(ctx)
 }
+
+var errNoACL = errors.New("the access control lists are not available on this node")
+
+func (api *AdminAPIImpl) AclLists(ctx context.Context) ([]zktxpool.ACLListContent, error) {
+	if api.acl == nil {
+		return nil, errNoACL
+	}
+	return api.acl.Lists(), nil
+}
+
+func (api *AdminAPIImpl) NodeRole(ctx context.Context) (*NodeRoleInfo, error) {
+	role := sequencer.NodeRole()
+	sequencing := sequencer.IsSequencer()
+	return &NodeRoleInfo{
+		Role:       role,
+		Sequencing: sequencing,
+		Standby:    role == sequencer.RoleSequencer && !sequencing,
+	}, nil
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/gateway-fm/cdk-erigon-lib/kv"
 	"github.com/gateway-fm/cdk-erigon-lib/kv/kvcache"
 	libstate "github.com/gateway-fm/cdk-erigon-lib/state"
+	"github.com/ledgerwatch/log/v3"
 
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/cli/httpcfg"
 	"github.com/ledgerwatch/erigon/consensus"
@@ -12,26 +13,38 @@ import (
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 	"github.com/ledgerwatch/erigon/turbo/services"
+	"github.com/ledgerwatch/erigon/zk/batch_sealing"
+	"github.com/ledgerwatch/erigon/zk/gas_price"
+	"github.com/ledgerwatch/erigon/zk/preconfirmation"
 	"github.com/ledgerwatch/erigon/zk/sequencer"
+	"github.com/ledgerwatch/erigon/zk/sequencer_control"
 	"github.com/ledgerwatch/erigon/zk/syncer"
+	zktxpool "github.com/ledgerwatch/erigon/zk/txpool"
 )
 
 // APIList describes the list of available RPC apis
 func APIList(db kv.RoDB, borDb kv.RoDB, eth rpchelper.ApiBackend, txPool txpool.TxpoolClient, mining txpool.MiningClient,
 	filters *rpchelper.Filters, stateCache kvcache.Cache,
 	blockReader services.FullBlockReader, agg *libstate.AggregatorV3, cfg httpcfg.HttpCfg, engine consensus.EngineReader,
-	ethCfg *ethconfig.Config, l1Syncer *syncer.L1Syncer,
+	ethCfg *ethconfig.Config, l1Syncer *syncer.L1Syncer, acl *zktxpool.ACL, preconfirmations *preconfirmation.Feed,
 ) (list []rpc.API) {
 
-	// non-sequencer nodes should forward on requests to the sequencer
+	// the nodes taking transactions for the sequencer forward on requests to it
+	role := sequencer.NodeRole()
 	rpcUrl := ""
-	if !sequencer.IsSequencer() {
+	if !sequencer.IsSequencer() && role.AcceptsTransactions() {
 		rpcUrl = ethCfg.L2RpcUrl
 	}
 
 	base := NewBaseApi(filters, stateCache, blockReader, agg, cfg.WithDatadir, cfg.EvmCallTimeout, engine, cfg.Dirs)
 	base.SetL2RpcUrl(ethCfg.L2RpcUrl)
 	base.SetGasless(ethCfg.Gasless)
+	// nodes that don't accept transactions have nothing to track
+	if role.AcceptsTransactions() {
+		forwardedTxs := NewForwardedTxs(ethCfg.RpcForwardedTxsTTL)
+		forwardedTxs.PruneMined(db, filters)
+		base.SetForwardedTxs(forwardedTxs)
+	}
 	ethImpl := NewEthAPI(base, db, eth, txPool, mining, cfg.Gascap, cfg.ReturnDataLimit, ethCfg)
 	erigonImpl := NewErigonAPI(base, db, eth)
 	txpoolImpl := NewTxPoolAPI(base, db, txPool, rpcUrl)
@@ -40,12 +53,12 @@ func APIList(db kv.RoDB, borDb kv.RoDB, eth rpchelper.ApiBackend, txPool txpool.
 	traceImpl := NewTraceAPI(base, db, &cfg)
 	web3Impl := NewWeb3APIImpl(eth)
 	dbImpl := NewDBAPIImpl() /* deprecated */
-	adminImpl := NewAdminAPI(eth)
+	adminImpl := NewAdminAPI(eth, acl)
 	parityImpl := NewParityAPIImpl(db)
 	borImpl := NewBorAPI(base, db, borDb) // bor (consensus) specific
 	otsImpl := NewOtterscanAPI(base, db)
 	gqlImpl := NewGraphQLAPI(base, db)
-	zkEvmImpl := NewZkEvmAPI(ethImpl, db, cfg.ReturnDataLimit, ethCfg, l1Syncer)
+	zkEvmImpl := NewZkEvmAPI(ethImpl, db, cfg.ReturnDataLimit, ethCfg, l1Syncer, preconfirmations)
 
 	if cfg.GraphQLEnabled {
 		list = append(list, rpc.API{
@@ -57,6 +70,10 @@ func APIList(db kv.RoDB, borDb kv.RoDB, eth rpchelper.ApiBackend, txPool txpool.
 	}
 
 	for _, enabledAPI := range cfg.API {
+		if !role.ServesNamespace(enabledAPI) {
+			log.Warn("RPC namespace not served by this node role", "namespace", enabledAPI, "role", role)
+			continue
+		}
 		switch enabledAPI {
 		case "eth":
 			list = append(list, rpc.API{
@@ -159,7 +176,8 @@ func AuthAPIList(db kv.RoDB, eth rpchelper.ApiBackend, txPool txpool.TxpoolClien
 	filters *rpchelper.Filters, stateCache kvcache.Cache, blockReader services.FullBlockReader,
 	agg *libstate.AggregatorV3,
 	cfg httpcfg.HttpCfg, engine consensus.EngineReader,
-	ethCfg *ethconfig.Config,
+	ethCfg *ethconfig.Config, acl *zktxpool.ACL, sealer *batch_sealing.Sealer, gasPrice *gas_price.Oracle,
+	control *sequencer_control.Control,
 ) (list []rpc.API) {
 	base := NewBaseApi(filters, stateCache, blockReader, agg, cfg.WithDatadir, cfg.EvmCallTimeout, engine, cfg.Dirs)
 	base.SetL2RpcUrl(ethCfg.L2RpcUrl)
@@ -180,5 +198,21 @@ func AuthAPIList(db kv.RoDB, eth rpchelper.ApiBackend, txPool txpool.TxpoolClien
 		Version:   "1.0",
 	})
 
+	// only a sequencer, active or standby, has the levers
+	if control != nil {
+		zkEvmAdminImpl := NewZkEvmAdminAPI(acl, sealer, gasPrice, control)
+		list = append(list, rpc.API{
+			Namespace: "zkevmadmin",
+			Public:    true,
+			Service:   ZkEvmAdminAPI(zkEvmAdminImpl),
+			Version:   "1.0",
+		}, rpc.API{
+			Namespace: "admin",
+			Public:    true,
+			Service:   AdminSealAPI(NewAdminSealAPI(zkEvmAdminImpl)),
+			Version:   "1.0",
+		})
+	}
+
 	return list
 }
@@ -20,6 +20,7 @@ import (
 	"github.com/ledgerwatch/erigon/eth/tracers"
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/adapter/ethapi"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 	"github.com/ledgerwatch/erigon/turbo/transactions"
 )
 
@@ -114,6 +115,13 @@ func (api *PrivateDebugAPIImpl) AccountRange(ctx context.Context, blockNrOrHash
 			if err != nil {
 				return state.IteratorDump{}, fmt.Errorf("last block has not found: %w", err)
 			}
+		} else if number == rpc.SafeBlockNumber || number == rpc.FinalizedBlockNumber {
+			var err error
+
+			blockNumber, _, _, err = rpchelper.GetBlockNumber(blockNrOrHash, tx, api.filters)
+			if err != nil {
+				return state.IteratorDump{}, err
+			}
 		} else {
 			blockNumber = uint64(number)
 		}
@@ -17,7 +17,6 @@ import (
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/rpc"
-	"github.com/ledgerwatch/erigon/zk/sequencer"
 )
 
 // GetBalance implements eth_getBalance. Returns the balance of an account for a given address.
@@ -47,11 +46,14 @@ func (api *APIImpl) GetBalance(ctx context.Context, address libcommon.Address, b
 // GetTransactionCount implements eth_getTransactionCount. Returns the number of transactions sent from an address (the nonce).
 func (api *APIImpl) GetTransactionCount(ctx context.Context, address libcommon.Address, blockNrOrHash *rpc.BlockNumberOrHash) (*hexutil.Uint64, error) {
 	// zkevm: forward requests to the sequencer
-	if !sequencer.IsSequencer() {
+	if api.forwardsToSequencer() {
 		res, err := api.sendGetTransactionCountToSequencer(api.l2RpcUrl, address, blockNrOrHash)
 		if err != nil {
 			return nil, err
 		}
+		if blockNrOrHash != nil && blockNrOrHash.BlockNumber != nil && *blockNrOrHash.BlockNumber == rpc.PendingBlockNumber {
+			return api.forwardedNonce(ctx, address, res)
+		}
 		return res, nil
 	}
 
@@ -61,7 +63,8 @@ func (api *APIImpl) GetTransactionCount(ctx context.Context, address libcommon.A
 		blockNrOrHash = &tmp
 	}
 
-	if blockNrOrHash.BlockNumber != nil && *blockNrOrHash.BlockNumber == rpc.PendingBlockNumber {
+	// a node without a pool has nothing pending
+	if blockNrOrHash.BlockNumber != nil && *blockNrOrHash.BlockNumber == rpc.PendingBlockNumber && api.acceptsTxs() {
 		reply, err := api.txPool.Nonce(ctx, &txpool_proto.NonceRequest{
 			Address: gointerfaces.ConvertAddressToH160(address),
 		}, &grpc.EmptyCallOption{})
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"github.com/ledgerwatch/erigon/zkevm/jsonrpc/client"
 	"fmt"
 	"github.com/ledgerwatch/erigon/common/hexutil"
@@ -45,3 +46,26 @@ func (api *APIImpl) sendGetTransactionCountToSequencer(rpcUrl string, address li
 
 	return &result, nil
 }
+
+// forwardedNonce returns the pending nonce of the sequencer or, should the sequencer not have seen them yet, the nonce
+// following the transactions of the address this node forwarded
+func (api *APIImpl) forwardedNonce(ctx context.Context, address libcommon.Address, sequencerNonce *hexutil.Uint64) (*hexutil.Uint64, error) {
+	if api.forwardedTxs == nil {
+		return sequencerNonce, nil
+	}
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	acc, err := api.latestAccount(ctx, tx, address)
+	if err != nil {
+		return nil, err
+	}
+	api.forwardedTxs.PruneSender(address, acc.Nonce)
+	nonce := api.forwardedTxs.NextNonce(address, acc.Nonce)
+	if nonce <= uint64(*sequencerNonce) {
+		return sequencerNonce, nil
+	}
+	return (*hexutil.Uint64)(&nonce), nil
+}
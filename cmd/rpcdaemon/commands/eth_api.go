@@ -129,6 +129,7 @@ type BaseAPI struct {
 	dirs           datadir.Dirs
 	l2RpcUrl       string
 	gasless        bool
+	forwardedTxs   *ForwardedTxs
 }
 
 func NewBaseApi(f *rpchelper.Filters, stateCache kvcache.Cache, blockReader services.FullBlockReader, agg *libstate.AggregatorV3, singleNodeMode bool, evmCallTimeout time.Duration, engine consensus.EngineReader, dirs datadir.Dirs) *BaseAPI {
@@ -340,6 +341,7 @@ type APIImpl struct {
 	ReturnDataLimit            int
 	ZkRpcUrl                   string
 	PoolManagerUrl             string
+	SequencerPoolManager       bool
 	AllowFreeTransactions      bool
 	AllowPreEIP155Transactions bool
 	L1RpcUrl                   string
@@ -366,6 +368,7 @@ func NewEthAPI(base *BaseAPI, db kv.RoDB, eth rpchelper.ApiBackend, txPool txpoo
 		ReturnDataLimit:            returnDataLimit,
 		ZkRpcUrl:                   ethCfg.L2RpcUrl,
 		PoolManagerUrl:             ethCfg.PoolManagerUrl,
+		SequencerPoolManager:       ethCfg.SequencerPoolManager,
 		AllowFreeTransactions:      ethCfg.AllowFreeTransactions,
 		AllowPreEIP155Transactions: ethCfg.AllowPreEIP155Transactions,
 		L1RpcUrl:                   ethCfg.L1RpcUrl,
@@ -10,3 +10,8 @@ func (api *BaseAPI) GetL2RpcUrl() string {
 	}
 	return api.l2RpcUrl
 }
+
+// SetForwardedTxs sets the cache of the transactions forwarded to the sequencer, nil to neither validate nor track them
+func (api *BaseAPI) SetForwardedTxs(txs *ForwardedTxs) {
+	api.forwardedTxs = txs
+}
@@ -114,6 +114,10 @@ func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) (t
 		if crit.FromBlock != nil {
 			if crit.FromBlock.Sign() >= 0 {
 				begin = crit.FromBlock.Uint64()
+			} else if isFinalityTag(crit.FromBlock) {
+				if begin, _, _, err = rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(crit.FromBlock.Int64())), tx, nil); err != nil {
+					return nil, err
+				}
 			} else if !crit.FromBlock.IsInt64() || crit.FromBlock.Int64() != int64(rpc.LatestBlockNumber) {
 				return nil, fmt.Errorf("negative value for FromBlock: %v", crit.FromBlock)
 			}
@@ -122,6 +126,10 @@ func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) (t
 		if crit.ToBlock != nil {
 			if crit.ToBlock.Sign() >= 0 {
 				end = crit.ToBlock.Uint64()
+			} else if isFinalityTag(crit.ToBlock) {
+				if end, _, _, err = rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(crit.ToBlock.Int64())), tx, nil); err != nil {
+					return nil, err
+				}
 			} else if !crit.ToBlock.IsInt64() || crit.ToBlock.Int64() != int64(rpc.LatestBlockNumber) {
 				return nil, fmt.Errorf("negative value for ToBlock: %v", crit.ToBlock)
 			}
@@ -287,6 +295,15 @@ func getAddrsBitmap(tx kv.Tx, addrs []common.Address, from, to uint64) (*roaring
 	return roaring.FastOr(rx...), nil
 }
 
+// isFinalityTag reports whether a filter block number is the "safe" or "finalized" tag
+func isFinalityTag(number *big.Int) bool {
+	if !number.IsInt64() {
+		return false
+	}
+	tag := rpc.BlockNumber(number.Int64())
+	return tag == rpc.SafeBlockNumber || tag == rpc.FinalizedBlockNumber
+}
+
 func applyFilters(out *roaring.Bitmap, tx kv.Tx, begin, end uint64, crit filters.FilterCriteria) error {
 	out.AddRange(begin, end+1) // [from,to)
 	topicsBitmap, err := getTopicsBitmap(tx, crit.Topics, begin, end)
@@ -5,26 +5,49 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
-	"strconv"
 	"time"
 
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+
 	"github.com/ledgerwatch/erigon/common/hexutil"
-	"github.com/ledgerwatch/erigon/zkevm/encoding"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/eth/gasprice"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/erigon/zk/gas_price"
+	"github.com/ledgerwatch/erigon/zk/hermez_db"
 	"github.com/ledgerwatch/erigon/zkevm/jsonrpc/client"
-	"github.com/ledgerwatch/log/v3"
 )
 
+// maxFeeHistory is the most blocks eth_feeHistory answers for from the suggested l2 gas prices
+const maxFeeHistory = 1024
+
 type L1GasPrice struct {
 	timestamp time.Time
 	gasPrice  *big.Int
 }
 
 func (api *APIImpl) GasPrice(ctx context.Context) (*hexutil.Big, error) {
+	if api.BaseAPI.gasless {
+		var price hexutil.Big
+		return &price, nil
+	}
+
 	tx, err := api.db.BeginRo(ctx)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
+
+	// every node answers with the price the sequencer suggested for the latest block
+	price, found, err := suggestedGasPrice(tx)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		return (*hexutil.Big)(price), nil
+	}
+
 	cc, err := api.chainConfig(tx)
 	if err != nil {
 		return nil, err
@@ -34,11 +57,6 @@ func (api *APIImpl) GasPrice(ctx context.Context) (*hexutil.Big, error) {
 		return api.GasPrice_nonRedirected(ctx)
 	}
 
-	if api.BaseAPI.gasless {
-		var price hexutil.Big
-		return &price, nil
-	}
-
 	res, err := client.JSONRPCCall(api.l2RpcUrl, "eth_gasPrice")
 	if err != nil {
 		return nil, err
@@ -64,6 +82,7 @@ func (api *APIImpl) GasPrice(ctx context.Context) (*hexutil.Big, error) {
 	return (*hexutil.Big)(price), nil
 }
 
+// GasPrice_nonRedirected works the price out from the L1 gas price, for the blocks of a sequencer that didn't suggest one
 func (api *APIImpl) GasPrice_nonRedirected(ctx context.Context) (*hexutil.Big, error) {
 	if api.BaseAPI.gasless {
 		var price hexutil.Big
@@ -82,42 +101,146 @@ func (api *APIImpl) GasPrice_nonRedirected(ctx context.Context) (*hexutil.Big, e
 		}
 	}
 
-	// Apply factor to calculate l2 gasPrice
-	factor := big.NewFloat(0).SetFloat64(api.GasPriceFactor)
-	res := new(big.Float).Mul(factor, big.NewFloat(0).SetInt(api.L1GasPrice.gasPrice))
-
-	// Store l2 gasPrice calculated
-	result := new(big.Int)
-	res.Int(result)
-	minGasPrice := big.NewInt(0).SetUint64(api.DefaultGasPrice)
-	if minGasPrice.Cmp(result) == 1 { // minGasPrice > result
-		result = minGasPrice
-	}
-	maxGasPrice := new(big.Int).SetUint64(api.MaxGasPrice)
-	if api.MaxGasPrice > 0 && result.Cmp(maxGasPrice) == 1 { // result > maxGasPrice
-		result = maxGasPrice
-	}
-
-	var truncateValue *big.Int
-	log.Debug("Full L2 gas price value: ", result, ". Length: ", len(result.String()))
-	numLength := len(result.String())
-	if numLength > 3 { //nolint:gomnd
-		aux := "%0" + strconv.Itoa(numLength-3) + "d" //nolint:gomnd
-		var ok bool
-		value := result.String()[:3] + fmt.Sprintf(aux, 0)
-		truncateValue, ok = new(big.Int).SetString(value, encoding.Base10)
-		if !ok {
-			return nil, fmt.Errorf("failed to convert result to big.Int")
+	price := gas_price.Suggest(gas_price.Config{
+		Factor:          api.GasPriceFactor,
+		DefaultGasPrice: api.DefaultGasPrice,
+		MaxGasPrice:     api.MaxGasPrice,
+	}, api.L1GasPrice.gasPrice, 0)
+
+	return (*hexutil.Big)(new(big.Int).SetUint64(price)), nil
+}
+
+// MaxPriorityFeePerGas implements eth_maxPriorityFeePerGas.  The L2 has no base fee so the whole suggested l2 gas
+// price of the latest block is the tip.
+func (api *APIImpl) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, error) {
+	if api.BaseAPI.gasless {
+		var price hexutil.Big
+		return &price, nil
+	}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	price, found, err := suggestedGasPrice(tx)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return api.maxPriorityFeePerGas(ctx)
+	}
+	return (*hexutil.Big)(price), nil
+}
+
+// FeeHistory implements eth_feeHistory.  The reward of each block at every percentile is the l2 gas price the
+// sequencer suggested for it, the history stops at the first block without one.
+func (api *APIImpl) FeeHistory(ctx context.Context, blockCount rpc.DecimalOrHex, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*feeHistoryResult, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	last, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(lastBlock), tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	hermezDb := hermez_db.NewHermezDbReader(tx)
+	if _, found, err := hermezDb.GetBlockL2GasPrice(last); err != nil {
+		return nil, err
+	} else if !found {
+		return api.feeHistory(ctx, blockCount, lastBlock, rewardPercentiles)
+	}
+
+	for i, p := range rewardPercentiles {
+		if p < 0 || p > 100 {
+			return nil, fmt.Errorf("%w: %f", gasprice.ErrInvalidPercentile, p)
+		}
+		if i > 0 && p < rewardPercentiles[i-1] {
+			return nil, fmt.Errorf("%w: #%d:%f > #%d:%f", gasprice.ErrInvalidPercentile, i-1, rewardPercentiles[i-1], i, p)
+		}
+	}
+
+	blocks := uint64(blockCount)
+	if blocks > maxFeeHistory {
+		blocks = maxFeeHistory
+	}
+	if blocks > last+1 {
+		blocks = last + 1
+	}
+
+	// walk back from the last block so the history ends where the sequencer started suggesting prices
+	var (
+		reward       [][]*hexutil.Big
+		baseFee      []*hexutil.Big
+		gasUsedRatio []float64
+	)
+	for i := uint64(0); i < blocks; i++ {
+		number := last - i
+		price, found, err := hermezDb.GetBlockL2GasPrice(number)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			break
+		}
+		header := rawdb.ReadHeaderByNumber(tx, number)
+		if header == nil {
+			return nil, fmt.Errorf("header %d not found", number)
+		}
+
+		if len(rewardPercentiles) != 0 {
+			rewards := make([]*hexutil.Big, len(rewardPercentiles))
+			for j := range rewards {
+				rewards[j] = (*hexutil.Big)(new(big.Int).SetUint64(price))
+			}
+			reward = append(reward, rewards)
+		}
+		fee := new(big.Int)
+		if header.BaseFee != nil {
+			fee.Set(header.BaseFee)
+		}
+		baseFee = append(baseFee, (*hexutil.Big)(fee))
+		ratio := float64(0)
+		if header.GasLimit > 0 {
+			ratio = float64(header.GasUsed) / float64(header.GasLimit)
 		}
-	} else {
-		truncateValue = result
+		gasUsedRatio = append(gasUsedRatio, ratio)
 	}
 
-	if truncateValue == nil {
-		return nil, fmt.Errorf("truncateValue nil value detected")
+	results := &feeHistoryResult{OldestBlock: (*hexutil.Big)(new(big.Int))}
+	if len(gasUsedRatio) == 0 {
+		return results, nil
+	}
+	results.OldestBlock = (*hexutil.Big)(new(big.Int).SetUint64(last + 1 - uint64(len(gasUsedRatio))))
+	for i, j := 0, len(gasUsedRatio)-1; i < j; i, j = i+1, j-1 {
+		gasUsedRatio[i], gasUsedRatio[j] = gasUsedRatio[j], gasUsedRatio[i]
+		baseFee[i], baseFee[j] = baseFee[j], baseFee[i]
+		if reward != nil {
+			reward[i], reward[j] = reward[j], reward[i]
+		}
 	}
+	results.GasUsedRatio = gasUsedRatio
+	results.Reward = reward
+	// the base fee of the block after the last doesn't change on the L2
+	results.BaseFee = append(baseFee, baseFee[len(baseFee)-1])
+	return results, nil
+}
 
-	return (*hexutil.Big)(truncateValue), nil
+// suggestedGasPrice returns the l2 gas price the sequencer suggested for the latest block, found is false when it
+// didn't suggest one
+func suggestedGasPrice(tx kv.Tx) (*big.Int, bool, error) {
+	latest, err := rpchelper.GetLatestBlockNumber(tx)
+	if err != nil {
+		return nil, false, err
+	}
+	price, found, err := hermez_db.NewHermezDbReader(tx).GetBlockL2GasPrice(latest)
+	if err != nil || !found {
+		return nil, false, err
+	}
+	return new(big.Int).SetUint64(price), true, nil
 }
 
 func (api *APIImpl) l1GasPrice() (*big.Int, error) {
@@ -49,6 +49,9 @@ func (api *APIImpl) GetTransactionByHash(ctx context.Context, txnHash common.Has
 		}
 	}
 	if ok {
+		// [zkevm] - the transaction is mined, no longer pending after being forwarded
+		api.forwardedTxs.Remove(txnHash)
+
 		block, err := api.blockByNumberWithSenders(tx, blockNum)
 		if err != nil {
 			return nil, err
@@ -93,6 +96,15 @@ func (api *APIImpl) GetTransactionByHash(ctx context.Context, txnHash common.Has
 		return nil, nil
 	}
 
+	// [zkevm] - a transaction this node forwarded to the sequencer is pending there
+	txn, err := api.forwardedTx(ctx, tx, txnHash)
+	if err != nil {
+		return nil, err
+	}
+	if txn != nil {
+		return newRPCPendingTransaction(txn, curHeader, chainConfig), nil
+	}
+
 	// No finalized transaction, try to retrieve it from the pool
 	reply, err := api.txPool.Transactions(ctx, &txpool.TransactionsRequest{Hashes: []*types.H256{gointerfaces.ConvertHashToH256(txnHash)}})
 	if err != nil {
@@ -131,7 +143,14 @@ func (api *APIImpl) GetRawTransactionByHash(ctx context.Context, hash common.Has
 		return nil, err
 	}
 	if !ok {
-		return nil, nil
+		// [zkevm] - a transaction this node forwarded to the sequencer is pending there
+		txn, err := api.forwardedTx(ctx, tx, hash)
+		if txn == nil || err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		err = txn.MarshalBinary(&buf)
+		return buf.Bytes(), err
 	}
 	block, err := api.blockByNumberWithSenders(tx, blockNum)
 	if err != nil {
@@ -0,0 +1,246 @@
+package commands
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/common/debug"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+)
+
+// maxForwardedTxs bounds the cache so a flood of transactions can't exhaust the memory of the node, transactions
+// forwarded once it is full are not tracked
+const maxForwardedTxs = 10_000
+
+// forwardedTxsHeadsBuffer is the new heads PruneMined keeps up with, the transactions of the heads it misses are pruned
+// when they are next looked up
+const forwardedTxsHeadsBuffer = 64
+
+type forwardedTx struct {
+	txn    types.Transaction
+	sender common.Address
+	expiry time.Time
+}
+
+// ForwardedTxs holds the transactions an RPC node has forwarded to the sequencer or the pool manager until they are
+// mined or expire, so the node can answer for them while they are pending somewhere else.  A nil ForwardedTxs tracks
+// nothing.
+type ForwardedTxs struct {
+	ttl time.Duration
+
+	lock     sync.RWMutex
+	txs      map[common.Hash]*forwardedTx
+	bySender map[common.Address]map[uint64]common.Hash
+}
+
+// NewForwardedTxs returns a cache keeping transactions for ttl, nil if ttl is 0
+func NewForwardedTxs(ttl time.Duration) *ForwardedTxs {
+	if ttl == 0 {
+		return nil
+	}
+	return &ForwardedTxs{
+		ttl:      ttl,
+		txs:      make(map[common.Hash]*forwardedTx),
+		bySender: make(map[common.Address]map[uint64]common.Hash),
+	}
+}
+
+// Add tracks a forwarded transaction, replacing any of the sender with the same nonce
+func (f *ForwardedTxs) Add(txn types.Transaction, sender common.Address) {
+	if f == nil {
+		return
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	now := time.Now()
+	f.sweep(now)
+
+	nonce := txn.GetNonce()
+	if replaced, ok := f.bySender[sender][nonce]; ok {
+		delete(f.txs, replaced)
+	} else if len(f.txs) >= maxForwardedTxs {
+		return
+	}
+
+	hash := txn.Hash()
+	f.txs[hash] = &forwardedTx{txn: txn, sender: sender, expiry: now.Add(f.ttl)}
+	if f.bySender[sender] == nil {
+		f.bySender[sender] = make(map[uint64]common.Hash)
+	}
+	f.bySender[sender][nonce] = hash
+}
+
+// Get returns a tracked transaction and its sender
+func (f *ForwardedTxs) Get(hash common.Hash) (types.Transaction, common.Address, bool) {
+	if f == nil {
+		return nil, common.Address{}, false
+	}
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	ftx, ok := f.txs[hash]
+	if !ok || time.Now().After(ftx.expiry) {
+		return nil, common.Address{}, false
+	}
+	return ftx.txn, ftx.sender, true
+}
+
+// Remove stops tracking a transaction, i.e. once it has been seen in a block
+func (f *ForwardedTxs) Remove(hash common.Hash) {
+	if f == nil {
+		return
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if ftx, ok := f.txs[hash]; ok {
+		f.remove(hash, ftx)
+	}
+}
+
+// PruneSender stops tracking the transactions of the sender with a nonce below its nonce in the latest state, which
+// were mined or replaced by one that was
+func (f *ForwardedTxs) PruneSender(sender common.Address, stateNonce uint64) {
+	if f == nil {
+		return
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.pruneSender(sender, stateNonce)
+}
+
+// PruneMined follows the new heads the filters notify and stops tracking the transactions mined in them, along with
+// the transactions their senders sent before or replaced
+func (f *ForwardedTxs) PruneMined(db kv.RoDB, filters *rpchelper.Filters) {
+	if f == nil || filters == nil {
+		return
+	}
+	heads, id := filters.SubscribeNewHeads(forwardedTxsHeadsBuffer)
+	go func() {
+		defer debug.LogPanic()
+		defer filters.UnsubscribeHeads(id)
+		for header := range heads {
+			if err := f.pruneBlock(db, header.Number.Uint64()); err != nil {
+				log.Warn("Failed to prune the forwarded transactions mined", "block", header.Number, "err", err)
+			}
+		}
+	}()
+}
+
+func (f *ForwardedTxs) pruneBlock(db kv.RoDB, number uint64) error {
+	tx, err := db.BeginRo(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	block, err := rawdb.ReadBlockByNumber(tx, number)
+	if err != nil || block == nil {
+		return err
+	}
+	senders, err := rawdb.ReadSenders(tx, block.Hash(), number)
+	if err != nil {
+		return err
+	}
+	f.Mined(block.Transactions(), senders)
+	return nil
+}
+
+// Mined stops tracking the transactions of a block and, for those whose sender is known, the transactions of the
+// sender up to their nonce
+func (f *ForwardedTxs) Mined(txs types.Transactions, senders []common.Address) {
+	if f == nil {
+		return
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for i, txn := range txs {
+		hash := txn.Hash()
+		if ftx, ok := f.txs[hash]; ok {
+			f.remove(hash, ftx)
+		}
+		if i < len(senders) {
+			f.pruneSender(senders[i], txn.GetNonce()+1)
+		}
+	}
+}
+
+// NextNonce returns the nonce following the tracked transactions of the sender that continue on from its nonce in the
+// latest state
+func (f *ForwardedTxs) NextNonce(sender common.Address, stateNonce uint64) uint64 {
+	if f == nil {
+		return stateNonce
+	}
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	now := time.Now()
+	nonce := stateNonce
+	for {
+		hash, ok := f.bySender[sender][nonce]
+		if !ok || now.After(f.txs[hash].expiry) {
+			return nonce
+		}
+		nonce++
+	}
+}
+
+// Content returns the tracked transactions by sender, ordered by nonce
+func (f *ForwardedTxs) Content() map[common.Address][]types.Transaction {
+	content := make(map[common.Address][]types.Transaction)
+	if f == nil {
+		return content
+	}
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	now := time.Now()
+	for _, ftx := range f.txs {
+		if now.After(ftx.expiry) {
+			continue
+		}
+		content[ftx.sender] = append(content[ftx.sender], ftx.txn)
+	}
+	for _, txs := range content {
+		sort.Slice(txs, func(i, j int) bool { return txs[i].GetNonce() < txs[j].GetNonce() })
+	}
+	return content
+}
+
+func (f *ForwardedTxs) sweep(now time.Time) {
+	for hash, ftx := range f.txs {
+		if now.After(ftx.expiry) {
+			f.remove(hash, ftx)
+		}
+	}
+}
+
+func (f *ForwardedTxs) pruneSender(sender common.Address, stateNonce uint64) {
+	for nonce, hash := range f.bySender[sender] {
+		if nonce < stateNonce {
+			f.remove(hash, f.txs[hash])
+		}
+	}
+}
+
+func (f *ForwardedTxs) remove(hash common.Hash, ftx *forwardedTx) {
+	delete(f.txs, hash)
+	nonce := ftx.txn.GetNonce()
+	if f.bySender[ftx.sender][nonce] == hash {
+		delete(f.bySender[ftx.sender], nonce)
+		if len(f.bySender[ftx.sender]) == 0 {
+			delete(f.bySender, ftx.sender)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+func forwardedTestTx(nonce uint64, gasPrice uint64) types.Transaction {
+	return types.NewTransaction(nonce, common.HexToAddress("0x02"), uint256.NewInt(1), 21000, uint256.NewInt(gasPrice), nil)
+}
+
+func TestForwardedTxs(t *testing.T) {
+	sender := common.HexToAddress("0x01")
+	f := NewForwardedTxs(time.Minute)
+
+	tx0, tx1, tx3 := forwardedTestTx(0, 1), forwardedTestTx(1, 1), forwardedTestTx(3, 1)
+	f.Add(tx0, sender)
+	f.Add(tx1, sender)
+	f.Add(tx3, sender)
+
+	got, gotSender, ok := f.Get(tx1.Hash())
+	require.True(t, ok)
+	require.Equal(t, tx1.Hash(), got.Hash())
+	require.Equal(t, sender, gotSender)
+
+	// the gap at nonce 2 stops the run
+	require.Equal(t, uint64(2), f.NextNonce(sender, 0))
+	require.Equal(t, uint64(5), f.NextNonce(sender, 5))
+
+	// a transaction with the same nonce replaces the one tracked
+	tx1b := forwardedTestTx(1, 2)
+	f.Add(tx1b, sender)
+	_, _, ok = f.Get(tx1.Hash())
+	require.False(t, ok)
+	_, _, ok = f.Get(tx1b.Hash())
+	require.True(t, ok)
+
+	// mined nonces are dropped
+	f.PruneSender(sender, 2)
+	_, _, ok = f.Get(tx0.Hash())
+	require.False(t, ok)
+	_, _, ok = f.Get(tx1b.Hash())
+	require.False(t, ok)
+	require.Equal(t, uint64(2), f.NextNonce(sender, 2))
+
+	content := f.Content()
+	require.Len(t, content[sender], 1)
+	require.Equal(t, tx3.Hash(), content[sender][0].Hash())
+
+	f.Remove(tx3.Hash())
+	require.Empty(t, f.Content())
+}
+
+func TestForwardedTxsMined(t *testing.T) {
+	sender, other := common.HexToAddress("0x01"), common.HexToAddress("0x03")
+	f := NewForwardedTxs(time.Minute)
+
+	tx0, tx1, tx2, otherTx := forwardedTestTx(0, 1), forwardedTestTx(1, 1), forwardedTestTx(2, 1), forwardedTestTx(5, 3)
+	f.Add(tx0, sender)
+	f.Add(tx1, sender)
+	f.Add(tx2, sender)
+	f.Add(otherTx, other)
+
+	// a block mining a transaction of the sender that replaced tx1 drops it and tx0 before it
+	replacement := forwardedTestTx(1, 2)
+	f.Mined(types.Transactions{replacement}, []common.Address{sender})
+	_, _, ok := f.Get(tx0.Hash())
+	require.False(t, ok)
+	_, _, ok = f.Get(tx1.Hash())
+	require.False(t, ok)
+	_, _, ok = f.Get(tx2.Hash())
+	require.True(t, ok)
+
+	// without the senders only the mined transactions themselves are dropped
+	f.Mined(types.Transactions{otherTx}, nil)
+	_, _, ok = f.Get(otherTx.Hash())
+	require.False(t, ok)
+	require.Len(t, f.Content(), 1)
+}
+
+func TestForwardedTxsExpire(t *testing.T) {
+	sender := common.HexToAddress("0x01")
+	f := NewForwardedTxs(time.Millisecond)
+
+	tx0 := forwardedTestTx(0, 1)
+	f.Add(tx0, sender)
+	time.Sleep(5 * time.Millisecond)
+
+	_, _, ok := f.Get(tx0.Hash())
+	require.False(t, ok)
+	require.Equal(t, uint64(0), f.NextNonce(sender, 0))
+	require.Empty(t, f.Content())
+}
+
+func TestForwardedTxsDisabled(t *testing.T) {
+	f := NewForwardedTxs(0)
+	require.Nil(t, f)
+
+	tx0 := forwardedTestTx(0, 1)
+	f.Add(tx0, common.Address{})
+	_, _, ok := f.Get(tx0.Hash())
+	require.False(t, ok)
+	require.Equal(t, uint64(7), f.NextNonce(common.Address{}, 7))
+	require.Empty(t, f.Content())
+}
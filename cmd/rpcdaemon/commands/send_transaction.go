@@ -33,14 +33,15 @@ func (api *APIImpl) SendRawTransaction(ctx context.Context, encodedTx hexutility
 	}
 	chainId := cc.ChainID
 
-	// [zkevm] - proxy the request if the chainID is ZK and not a sequencer
-	if api.isZkNonSequencer(chainId) {
-		// [zkevm] - proxy the request to the pool manager if the pool manager is set
-		if api.isPoolManagerAddressSet() {
-			return api.sendTxZk(api.PoolManagerUrl, encodedTx, chainId.Uint64())
-		}
+	// [zkevm] - archive and verifier nodes don't take transactions at all
+	if !api.acceptsTxs() {
+		return common.Hash{}, errTxsNotAccepted
+	}
 
-		return api.sendTxZk(api.l2RpcUrl, encodedTx, chainId.Uint64())
+	// [zkevm] - proxy the request if the chainID is ZK and not a sequencer, or the sequencer takes its transactions
+	// from the pool manager
+	if api.isZkNonSequencer(chainId) || api.isPoolManagerSequencer() {
+		return api.forwardTxZk(ctx, tx, cc, encodedTx)
 	}
 
 	txn, err := types.DecodeTransaction(rlp.NewStream(bytes.NewReader(encodedTx), uint64(len(encodedTx))))
@@ -1,6 +1,9 @@
 package commands
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -8,17 +11,43 @@ import (
 
 	"github.com/gateway-fm/cdk-erigon-lib/common"
 	"github.com/gateway-fm/cdk-erigon-lib/common/hexutility"
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/chain"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 	"github.com/ledgerwatch/erigon/zk/sequencer"
 	"github.com/ledgerwatch/erigon/zk/zkchainconfig"
 	"github.com/ledgerwatch/erigon/zkevm/jsonrpc/client"
 )
 
+var errTxsNotAccepted = errors.New("this node does not accept transactions")
+
+func (api *APIImpl) acceptsTxs() bool {
+	return sequencer.NodeRole().AcceptsTransactions()
+}
+
+// forwardsToSequencer is true for the nodes taking transactions for the sequencer, they ask it for what its pool
+// knows.  The roles taking none have no pool and answer from the chain they followed.
+func (api *APIImpl) forwardsToSequencer() bool {
+	return !sequencer.IsSequencer() && api.acceptsTxs()
+}
+
 func (api *APIImpl) isPoolManagerAddressSet() bool {
 	return api.PoolManagerUrl != ""
 }
 
+func (api *APIImpl) isPoolManagerSequencer() bool {
+	return sequencer.IsSequencer() && api.SequencerPoolManager
+}
+
 func (api *APIImpl) isZkNonSequencer(chainId *big.Int) bool {
-	return !sequencer.IsSequencer() && zkchainconfig.IsZk(chainId.Uint64())
+	return api.forwardsToSequencer() && zkchainconfig.IsZk(chainId.Uint64())
 }
 
 func (api *APIImpl) sendTxZk(rpcUrl string, encodedTx hexutility.Bytes, chainId uint64) (common.Hash, error) {
@@ -37,3 +66,103 @@ func (api *APIImpl) sendTxZk(rpcUrl string, encodedTx hexutility.Bytes, chainId
 
 	return common.HexToHash(hashHex), nil
 }
+
+// forwardTxZk sends the transaction to the pool manager if one is set, else to the sequencer.  When the node keeps
+// the transactions it forwards, it checks them against its latest state first and tracks them once accepted.
+func (api *APIImpl) forwardTxZk(ctx context.Context, tx kv.Tx, cc *chain.Config, encodedTx hexutility.Bytes) (common.Hash, error) {
+	rpcUrl := api.l2RpcUrl
+	if api.isPoolManagerAddressSet() {
+		rpcUrl = api.PoolManagerUrl
+	}
+	if api.forwardedTxs == nil {
+		return api.sendTxZk(rpcUrl, encodedTx, cc.ChainID.Uint64())
+	}
+
+	txn, sender, err := api.validateForwardedTx(ctx, tx, cc, encodedTx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	hash, err := api.sendTxZk(rpcUrl, encodedTx, cc.ChainID.Uint64())
+	if err != nil {
+		return hash, err
+	}
+	api.forwardedTxs.Add(txn, sender)
+	return hash, nil
+}
+
+// validateForwardedTx decodes the transaction and checks its chain id, signature, nonce and cost against the latest
+// state of the node, which may be behind the sequencer, so a nonce ahead of the state is let through
+func (api *APIImpl) validateForwardedTx(ctx context.Context, tx kv.Tx, cc *chain.Config, encodedTx hexutility.Bytes) (types.Transaction, common.Address, error) {
+	txn, err := types.DecodeTransaction(rlp.NewStream(bytes.NewReader(encodedTx), uint64(len(encodedTx))))
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	if err := checkTxFee(txn.GetPrice().ToBig(), txn.GetGas(), ethconfig.Defaults.RPCTxFeeCap); err != nil {
+		return nil, common.Address{}, err
+	}
+	if !api.AllowPreEIP155Transactions && !txn.Protected() {
+		return nil, common.Address{}, errors.New("only replay-protected (EIP-155) transactions allowed over RPC")
+	}
+	if txn.Protected() {
+		if txnChainId := txn.GetChainID(); cc.ChainID.Cmp(txnChainId.ToBig()) != 0 {
+			return nil, common.Address{}, fmt.Errorf("invalid chain id, expected: %d got: %d", cc.ChainID, txnChainId)
+		}
+	}
+
+	blockNum := rawdb.ReadCurrentBlockNumber(tx)
+	if blockNum == nil {
+		return nil, common.Address{}, fmt.Errorf("current block number not found")
+	}
+	sender, err := txn.Sender(*types.MakeSigner(cc, *blockNum))
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+
+	acc, err := api.latestAccount(ctx, tx, sender)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	if txn.GetNonce() < acc.Nonce {
+		return nil, common.Address{}, fmt.Errorf("%w: address %v, tx: %d state: %d", core.ErrNonceTooLow, sender.Hex(), txn.GetNonce(), acc.Nonce)
+	}
+	if acc.Balance.Lt(txn.Cost()) {
+		return nil, common.Address{}, fmt.Errorf("%w: address %v have %v want %v", core.ErrInsufficientFunds, sender.Hex(), &acc.Balance, txn.Cost())
+	}
+
+	api.forwardedTxs.PruneSender(sender, acc.Nonce)
+	return txn, sender, nil
+}
+
+// latestAccount reads the account from the latest state of the node, an empty account if it doesn't exist
+func (api *BaseAPI) latestAccount(ctx context.Context, tx kv.Tx, address common.Address) (*accounts.Account, error) {
+	reader, err := rpchelper.CreateStateReader(ctx, tx, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), 0, api.filters, api.stateCache, api.historyV3(tx), "")
+	if err != nil {
+		return nil, err
+	}
+	acc, err := reader.ReadAccountData(address)
+	if err != nil {
+		return nil, err
+	}
+	if acc == nil {
+		acc = &accounts.Account{}
+	}
+	return acc, nil
+}
+
+// forwardedTx returns a transaction this node forwarded that is still pending, nil once the sender has a later nonce
+// in the latest state as it, or one replacing it, has been mined
+func (api *APIImpl) forwardedTx(ctx context.Context, tx kv.Tx, hash common.Hash) (types.Transaction, error) {
+	txn, sender, ok := api.forwardedTxs.Get(hash)
+	if !ok {
+		return nil, nil
+	}
+	acc, err := api.latestAccount(ctx, tx, sender)
+	if err != nil {
+		return nil, err
+	}
+	if txn.GetNonce() < acc.Nonce {
+		api.forwardedTxs.PruneSender(sender, acc.Nonce)
+		return nil, nil
+	}
+	return txn, nil
+}
@@ -46,7 +46,7 @@ func (api *TxPoolAPIImpl) Content(ctx context.Context) (interface{}, error) {
 		if err != nil {
 			return nil, err
 		}
-		return res.Result, nil
+		return api.withForwardedContent(ctx, res.Result)
 	}
 
 	reply, err := api.pool.All(ctx, &proto_txpool.AllRequest{})
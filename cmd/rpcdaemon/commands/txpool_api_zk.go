@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/core/rawdb"
+)
+
+// withForwardedContent adds the transactions this node forwarded to the content of the pool of the sequencer as
+// pending, for those the sequencer hasn't got yet
+func (api *TxPoolAPIImpl) withForwardedContent(ctx context.Context, sequencerContent json.RawMessage) (interface{}, error) {
+	forwarded := api.forwardedTxs.Content()
+	if len(forwarded) == 0 {
+		return sequencerContent, nil
+	}
+
+	content := map[string]map[string]map[string]interface{}{}
+	if err := json.Unmarshal(sequencerContent, &content); err != nil {
+		return nil, fmt.Errorf("decode the txpool content of the sequencer: %w", err)
+	}
+	if content["pending"] == nil {
+		content["pending"] = make(map[string]map[string]interface{})
+	}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	cc, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+	curHeader := rawdb.ReadCurrentHeader(tx)
+	if curHeader == nil {
+		return sequencerContent, nil
+	}
+
+	for sender, txs := range forwarded {
+		acc, err := api.latestAccount(ctx, tx, sender)
+		if err != nil {
+			return nil, err
+		}
+		api.forwardedTxs.PruneSender(sender, acc.Nonce)
+
+		account := sender.Hex()
+		for _, txn := range txs {
+			if txn.GetNonce() < acc.Nonce {
+				continue
+			}
+			nonce := fmt.Sprintf("%d", txn.GetNonce())
+			if _, ok := content["pending"][account][nonce]; ok {
+				continue
+			}
+			if _, ok := content["queued"][account][nonce]; ok {
+				continue
+			}
+			if content["pending"][account] == nil {
+				content["pending"][account] = make(map[string]interface{})
+			}
+			content["pending"][account][nonce] = newRPCPendingTransaction(txn, curHeader, cc)
+		}
+	}
+	return content, nil
+}
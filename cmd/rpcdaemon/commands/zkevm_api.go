@@ -13,6 +13,8 @@ import (
 	"github.com/gateway-fm/cdk-erigon-lib/kv"
 
 	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/log/v3"
+
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/core/rawdb"
@@ -23,11 +25,14 @@ import (
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 	"github.com/ledgerwatch/erigon/zk/hermez_db"
 	"github.com/ledgerwatch/erigon/zk/legacy_executor_verifier"
+	"github.com/ledgerwatch/erigon/zk/pool_manager"
+	"github.com/ledgerwatch/erigon/zk/preconfirmation"
 	types "github.com/ledgerwatch/erigon/zk/rpcdaemon"
 	"github.com/ledgerwatch/erigon/zk/sequencer"
 	"github.com/ledgerwatch/erigon/zk/syncer"
 	"github.com/ledgerwatch/erigon/zk/witness"
 	"github.com/ledgerwatch/erigon/zkevm/hex"
+	"github.com/ledgerwatch/erigon/zkevm/jsonrpc/client"
 )
 
 var sha3UncleHash = common.HexToHash("0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347")
@@ -54,16 +59,27 @@ type ZkEvmAPI interface {
 	GetLatestGlobalExitRoot(ctx context.Context) (common.Hash, error)
 	GetExitRootsByGER(ctx context.Context, globalExitRoot common.Hash) (*ZkExitRoots, error)
 	GetL2BlockInfoTree(ctx context.Context, blockNum rpc.BlockNumberOrHash) (json.RawMessage, error)
+	GetTransactionByL2Hash(ctx context.Context, l2TxHash common.Hash) (*RPCTransaction, error)
+	GetTransactionReceiptByL2Hash(ctx context.Context, l2TxHash common.Hash) (map[string]interface{}, error)
+	GetTransactionStatus(ctx context.Context, txHash common.Hash) (*pool_manager.TransactionStatus, error)
+	GetPreconfirmation(ctx context.Context, txHash common.Hash) (*preconfirmation.Preconfirmation, error)
+	Preconfirmations(ctx context.Context) (*rpc.Subscription, error)
+	GetForkId(ctx context.Context) (hexutil.Uint64, error)
+	GetForks(ctx context.Context) ([]*ZkForkInfo, error)
+	GetForkIdByBatchNumber(ctx context.Context, batchNumber rpc.BlockNumber) (hexutil.Uint64, error)
 }
 
 // APIImpl is implementation of the ZkEvmAPI interface based on remote Db access
 type ZkEvmAPIImpl struct {
 	ethApi *APIImpl
 
-	db              kv.RoDB
-	ReturnDataLimit int
-	config          *ethconfig.Config
-	l1Syncer        *syncer.L1Syncer
+	db               kv.RoDB
+	ReturnDataLimit  int
+	config           *ethconfig.Config
+	l1Syncer         *syncer.L1Syncer
+	poolManager      *pool_manager.Client
+	preconfirmations *preconfirmation.Feed
+	batches          *batchBroadcaster
 }
 
 // NewEthAPI returns ZkEvmAPIImpl instance
@@ -73,13 +89,30 @@ func NewZkEvmAPI(
 	returnDataLimit int,
 	zkConfig *ethconfig.Config,
 	l1Syncer *syncer.L1Syncer,
+	preconfirmations *preconfirmation.Feed,
 ) *ZkEvmAPIImpl {
+	var poolManager *pool_manager.Client
+	if zkConfig != nil && zkConfig.PoolManagerUrl != "" {
+		var err error
+		if poolManager, err = pool_manager.NewClient(zkConfig.PoolManagerUrl); err != nil {
+			log.Warn("Transaction statuses won't be asked of the pool manager", "err", err)
+		}
+	}
+
+	var filters *rpchelper.Filters
+	if base != nil && base.BaseAPI != nil {
+		filters = base.filters
+	}
+
 	return &ZkEvmAPIImpl{
-		ethApi:          base,
-		db:              db,
-		ReturnDataLimit: returnDataLimit,
-		config:          zkConfig,
-		l1Syncer:        l1Syncer,
+		ethApi:           base,
+		db:               db,
+		ReturnDataLimit:  returnDataLimit,
+		config:           zkConfig,
+		l1Syncer:         l1Syncer,
+		poolManager:      poolManager,
+		preconfirmations: preconfirmations,
+		batches:          newBatchBroadcaster(db, filters),
 	}
 }
 
@@ -146,6 +179,10 @@ func (api *ZkEvmAPIImpl) IsBlockVirtualized(ctx context.Context, blockNumber rpc
 		return false, err
 	}
 
+	if latestSequencedBatch == nil {
+		return false, nil
+	}
+
 	// if the batch is lower than the latest sequenced then it must be virtualized
 	return batchNum <= latestSequencedBatch.BatchNo, nil
 }
@@ -198,6 +235,10 @@ func (api *ZkEvmAPIImpl) VirtualBatchNumber(ctx context.Context) (hexutil.Uint64
 		return hexutil.Uint64(0), err
 	}
 
+	if latestSequencedBatch == nil {
+		return hexutil.Uint64(0), nil
+	}
+
 	// todo: what if this number is the same as the last verified batch number?  do we return 0?
 
 	return hexutil.Uint64(latestSequencedBatch.BatchNo), nil
@@ -411,6 +452,102 @@ func (api *ZkEvmAPIImpl) GetExitRootsByGER(ctx context.Context, globalExitRoot c
 	}, nil
 }
 
+// GetTransactionByL2Hash returns a transaction by its L2 hash as computed by the zkevm executor
+func (api *ZkEvmAPIImpl) GetTransactionByL2Hash(ctx context.Context, l2TxHash common.Hash) (*RPCTransaction, error) {
+	txHash, found, err := api.getTxHashByL2TxHash(ctx, l2TxHash)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return api.ethApi.GetTransactionByHash(ctx, txHash)
+}
+
+// GetTransactionReceiptByL2Hash returns a transaction receipt by the L2 hash of the transaction
+func (api *ZkEvmAPIImpl) GetTransactionReceiptByL2Hash(ctx context.Context, l2TxHash common.Hash) (map[string]interface{}, error) {
+	txHash, found, err := api.getTxHashByL2TxHash(ctx, l2TxHash)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return api.ethApi.GetTransactionReceipt(ctx, txHash)
+}
+
+// GetTransactionStatus returns where a transaction is: included once it's in a block of this node, else as the pool
+// manager knows it or pending if this node forwarded it, nil if it's unknown
+func (api *ZkEvmAPIImpl) GetTransactionStatus(ctx context.Context, txHash common.Hash) (*pool_manager.TransactionStatus, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	blockNum, ok, err := api.ethApi.txnLookup(ctx, tx, txHash)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return &pool_manager.TransactionStatus{Status: pool_manager.StatusIncluded, BlockNumber: blockNum}, nil
+	}
+
+	if api.poolManager != nil {
+		return api.poolManager.TransactionStatus(ctx, txHash)
+	}
+
+	txn, err := api.ethApi.forwardedTx(ctx, tx, txHash)
+	if txn == nil || err != nil {
+		return nil, err
+	}
+	return &pool_manager.TransactionStatus{Status: pool_manager.StatusPending}, nil
+}
+
+var errNoPreconfirmations = errors.New("preconfirmations are not served by this node")
+
+// GetPreconfirmation returns the preconfirmation the sequencer gave for a transaction while the node still has it.  An
+// RPC node that doesn't relay the preconfirmations asks the sequencer.
+func (api *ZkEvmAPIImpl) GetPreconfirmation(ctx context.Context, txHash common.Hash) (*preconfirmation.Preconfirmation, error) {
+	if api.preconfirmations == nil {
+		return nil, errNoPreconfirmations
+	}
+	if p, ok := api.preconfirmations.Get(txHash); ok {
+		return p, nil
+	}
+	if sequencer.IsSequencer() || api.config.PreconfirmationsRelayUrl != "" || api.config.L2RpcUrl == "" {
+		return nil, nil
+	}
+
+	res, err := client.JSONRPCCall(api.config.L2RpcUrl, "zkevm_getPreconfirmation", txHash)
+	if err != nil {
+		return nil, err
+	}
+	if res.Error != nil {
+		return nil, fmt.Errorf("RPC error response: %s", res.Error.Message)
+	}
+	var p *preconfirmation.Preconfirmation
+	if err := json.Unmarshal(res.Result, &p); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal result: %v", err)
+	}
+	return p, nil
+}
+
+// Preconfirmations sends a notification for each preconfirmation the sequencer gives, on an RPC node for those it
+// relays
+func (api *ZkEvmAPIImpl) Preconfirmations(ctx context.Context) (*rpc.Subscription, error) {
+	if api.preconfirmations == nil {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	return preconfirmation.Serve(ctx, api.preconfirmations)
+}
+
+func (api *ZkEvmAPIImpl) getTxHashByL2TxHash(ctx context.Context, l2TxHash common.Hash) (common.Hash, bool, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return common.Hash{}, false, err
+	}
+	defer tx.Rollback()
+
+	return hermez_db.NewHermezDbReader(tx).GetTxHashByL2TxHash(l2TxHash)
+}
+
 func (api *ZkEvmAPIImpl) populateBlockDetail(
 	tx kv.Tx,
 	ctx context.Context,
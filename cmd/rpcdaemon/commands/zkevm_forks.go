@@ -0,0 +1,163 @@
+package commands
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/zk/hermez_db"
+	types "github.com/ledgerwatch/erigon/zk/rpcdaemon"
+	zktypes "github.com/ledgerwatch/erigon/zk/types"
+)
+
+// GetForkId returns the fork id of the latest batch
+func (api *ZkEvmAPIImpl) GetForkId(ctx context.Context) (hexutil.Uint64, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	latestBatch, err := getLatestBatchNumber(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	forkId, err := getForkIdByBatchNo(tx, latestBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	return hexutil.Uint64(forkId), nil
+}
+
+// GetForkIdByBatchNumber returns the fork id that applies to the given batch
+func (api *ZkEvmAPIImpl) GetForkIdByBatchNumber(ctx context.Context, batchNumber rpc.BlockNumber) (hexutil.Uint64, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// looks weird but we're using the rpc.BlockNumber type to represent the batch number, LatestBlockNumber represents latest batch
+	bn := uint64(batchNumber.Int64())
+	if batchNumber == rpc.LatestBlockNumber {
+		if bn, err = getLatestBatchNumber(tx); err != nil {
+			return 0, err
+		}
+	}
+
+	forkId, err := getForkIdByBatchNo(tx, bn)
+	if err != nil {
+		return 0, err
+	}
+
+	return hexutil.Uint64(forkId), nil
+}
+
+// GetForks returns the fork schedule of the chain.  Versions and L1 blocks are only known for forks announced on L1
+// after the node started syncing, the batch and block ranges are filled in from the local batches where possible.
+func (api *ZkEvmAPIImpl) GetForks(ctx context.Context) ([]*ZkForkInfo, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	hermezDb := hermez_db.NewHermezDbReader(tx)
+
+	intervals, err := hermezDb.GetForkIntervals()
+	if err != nil {
+		return nil, err
+	}
+	forkBlocks, err := hermezDb.GetForkIdBlocks()
+	if err != nil {
+		return nil, err
+	}
+
+	forksById := make(map[uint64]*ZkForkInfo)
+	for _, interval := range intervals {
+		forksById[interval.ForkId] = &ZkForkInfo{
+			ForkId:          types.ArgUint64(interval.ForkId),
+			Version:         interval.Version,
+			FromBatchNumber: types.ArgUint64(interval.FromBatchNumber),
+			L1BlockNumber:   types.ArgUint64(interval.L1BlockNumber),
+		}
+	}
+	for forkId, blockNo := range forkBlocks {
+		fork, ok := forksById[forkId]
+		if !ok {
+			lowestBatch, err := hermezDb.GetLowestBatchByFork(forkId)
+			if err != nil {
+				return nil, err
+			}
+			fork = &ZkForkInfo{
+				ForkId:          types.ArgUint64(forkId),
+				FromBatchNumber: types.ArgUint64(lowestBatch),
+			}
+			forksById[forkId] = fork
+		}
+		fork.BlockNumber = types.ArgUint64(blockNo)
+	}
+
+	forks := make([]*ZkForkInfo, 0, len(forksById))
+	for _, fork := range forksById {
+		forks = append(forks, fork)
+	}
+	sort.Slice(forks, func(i, j int) bool {
+		return forks[i].ForkId < forks[j].ForkId
+	})
+
+	// each fork runs until the next one starts, the latest fork is open ended
+	for i, fork := range forks {
+		if i == len(forks)-1 {
+			fork.ToBatchNumber = types.ArgUint64(math.MaxUint64)
+			continue
+		}
+		next := forks[i+1].FromBatchNumber
+		if next <= fork.FromBatchNumber {
+			// superseded from the batch it started at, a fork the chain started past has no batches of its own
+			fork.ToBatchNumber = fork.FromBatchNumber
+			continue
+		}
+		fork.ToBatchNumber = next - 1
+	}
+
+	return forks, nil
+}
+
+// getForkIdByBatchNo returns the fork id recorded for a batch, falling back to the fork schedule seen on L1 for
+// batches that have not been synced yet
+func getForkIdByBatchNo(tx kv.Tx, batchNo uint64) (uint64, error) {
+	hermezDb := hermez_db.NewHermezDbReader(tx)
+
+	forkId, err := hermezDb.GetForkId(batchNo)
+	if err != nil {
+		return 0, err
+	}
+	if forkId != 0 {
+		return forkId, nil
+	}
+
+	intervals, err := hermezDb.GetForkIntervals()
+	if err != nil {
+		return 0, err
+	}
+
+	return forkIdFromIntervals(intervals, batchNo), nil
+}
+
+func forkIdFromIntervals(intervals []zktypes.ForkInterval, batchNo uint64) uint64 {
+	var forkId, fromBatch uint64
+	for _, interval := range intervals {
+		if interval.FromBatchNumber <= batchNo && interval.FromBatchNumber >= fromBatch {
+			forkId = interval.ForkId
+			fromBatch = interval.FromBatchNumber
+		}
+	}
+	return forkId
+}
@@ -0,0 +1,250 @@
+package commands
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/common/debug"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/erigon/zk/hermez_db"
+)
+
+// batchSubscriptionPollInterval is how often the batch progress is read when no new block has been seen, the L1
+// syncer records virtualized and verified batches in cycles that don't add blocks
+const batchSubscriptionPollInterval = time.Second
+
+// batchSubscriptionBuffer is how many notifications a batch subscription may fall behind before it misses some
+const batchSubscriptionBuffer = 32
+
+type batchProgressKind int
+
+const (
+	closedBatches batchProgressKind = iota
+	virtualizedBatches
+	verifiedBatches
+	batchProgressKinds
+)
+
+// NewBatches sends a notification with the batch number each time a batch is closed
+func (api *ZkEvmAPIImpl) NewBatches(ctx context.Context) (*rpc.Subscription, error) {
+	return api.subscribeBatchProgress(ctx, closedBatches)
+}
+
+// VirtualizedBatches sends a notification with the latest virtual batch number each time new batches are
+// sequenced on L1
+func (api *ZkEvmAPIImpl) VirtualizedBatches(ctx context.Context) (*rpc.Subscription, error) {
+	return api.subscribeBatchProgress(ctx, virtualizedBatches)
+}
+
+// VerifiedBatches sends a notification with the latest verified batch number each time new batches are verified
+// on L1
+func (api *ZkEvmAPIImpl) VerifiedBatches(ctx context.Context) (*rpc.Subscription, error) {
+	return api.subscribeBatchProgress(ctx, verifiedBatches)
+}
+
+func (api *ZkEvmAPIImpl) subscribeBatchProgress(ctx context.Context, kind batchProgressKind) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	ch, unsubscribe, err := api.batches.subscribe(ctx, kind)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		defer debug.LogPanic()
+		defer unsubscribe()
+		for {
+			select {
+			case batch := <-ch:
+				if err := notifier.Notify(rpcSub.ID, hexutil.Uint64(batch)); err != nil {
+					log.Warn("error while notifying subscription", "err", err)
+					return
+				}
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// batchBroadcaster reads the batch progress once for all the batch subscriptions, each time the node adds a block
+// or, failing that, every batchSubscriptionPollInterval, and notifies the subscriptions whose progress moved on
+type batchBroadcaster struct {
+	db      kv.RoDB
+	filters *rpchelper.Filters // new blocks wake the broadcaster when set
+	start   sync.Once
+
+	lock    sync.Mutex
+	last    [batchProgressKinds]uint64
+	subs    map[uint64]*batchSubscriber
+	nextSub uint64
+
+	// finding the latest sequence means walking the whole sequences table so it is only looked up again once the L1
+	// syncer has made progress, before that there are no sequences to find
+	l1Progress, virtualBatch uint64
+}
+
+type batchSubscriber struct {
+	kind batchProgressKind
+	ch   chan uint64
+}
+
+func newBatchBroadcaster(db kv.RoDB, filters *rpchelper.Filters) *batchBroadcaster {
+	return &batchBroadcaster{
+		db:      db,
+		filters: filters,
+		subs:    make(map[uint64]*batchSubscriber),
+	}
+}
+
+// subscribe returns a channel of the progress of a kind from now on and the function that ends the subscription.
+// The broadcaster is started with the first subscription.
+func (b *batchBroadcaster) subscribe(ctx context.Context, kind batchProgressKind) (<-chan uint64, func(), error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if len(b.subs) == 0 {
+		// the progress has not been followed while nobody listened
+		if err := b.readLocked(ctx); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	id := b.nextSub
+	b.nextSub++
+	sub := &batchSubscriber{kind: kind, ch: make(chan uint64, batchSubscriptionBuffer)}
+	b.subs[id] = sub
+
+	b.start.Do(func() {
+		go b.run()
+	})
+
+	return sub.ch, func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		delete(b.subs, id)
+	}, nil
+}
+
+func (b *batchBroadcaster) run() {
+	defer debug.LogPanic()
+
+	var heads <-chan *types.Header
+	if b.filters != nil {
+		var id rpchelper.HeadsSubID
+		heads, id = b.filters.SubscribeNewHeads(batchSubscriptionBuffer)
+		defer b.filters.UnsubscribeHeads(id)
+	}
+	ticker := time.NewTicker(batchSubscriptionPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case _, ok := <-heads:
+			if !ok {
+				log.Warn("new heads channel was closed, batch subscriptions fall back to polling")
+				heads = nil
+				continue
+			}
+		case <-ticker.C:
+		}
+		if err := b.check(context.Background()); err != nil {
+			log.Warn("error while reading batch progress for subscriptions", "err", err)
+		}
+	}
+}
+
+// check reads the batch progress and notifies the subscriptions whose progress moved on
+func (b *batchBroadcaster) check(ctx context.Context) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if len(b.subs) == 0 {
+		return nil
+	}
+
+	last := b.last
+	if err := b.readLocked(ctx); err != nil {
+		return err
+	}
+	for _, sub := range b.subs {
+		// the progress is always followed so batches are notified again after an unwind
+		if b.last[sub.kind] <= last[sub.kind] {
+			continue
+		}
+		select {
+		case sub.ch <- b.last[sub.kind]:
+		default:
+			// a subscriber too slow to keep up misses notifications rather than holding up the others
+		}
+	}
+	return nil
+}
+
+func (b *batchBroadcaster) readLocked(ctx context.Context) error {
+	tx, err := b.db.BeginRo(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if b.last[closedBatches], err = closedBatchProgress(tx); err != nil {
+		return err
+	}
+	if b.last[virtualizedBatches], err = b.virtualBatchProgress(tx); err != nil {
+		return err
+	}
+	if b.last[verifiedBatches], err = stages.GetStageProgress(tx, stages.L1VerificationsBatchNo); err != nil {
+		return err
+	}
+	return nil
+}
+
+// closedBatchProgress returns the highest closed batch, a batch is closed once the next one has been started
+func closedBatchProgress(tx kv.Tx) (uint64, error) {
+	highestSeen, err := stages.GetStageProgress(tx, stages.HighestSeenBatchNumber)
+	if err != nil {
+		return 0, err
+	}
+	if highestSeen == 0 {
+		return 0, nil
+	}
+	return highestSeen - 1, nil
+}
+
+func (b *batchBroadcaster) virtualBatchProgress(tx kv.Tx) (uint64, error) {
+	progress, err := stages.GetStageProgress(tx, stages.L1Syncer)
+	if err != nil {
+		return 0, err
+	}
+	if progress == b.l1Progress {
+		return b.virtualBatch, nil
+	}
+
+	latestSequence, err := hermez_db.NewHermezDbReader(tx).GetLatestSequence()
+	if err != nil {
+		return 0, err
+	}
+
+	b.l1Progress = progress
+	b.virtualBatch = 0
+	if latestSequence != nil {
+		b.virtualBatch = latestSequence.BatchNo
+	}
+	return b.virtualBatch, nil
+}
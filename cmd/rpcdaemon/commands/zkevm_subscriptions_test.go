@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+	"github.com/gateway-fm/cdk-erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/zk/hermez_db"
+)
+
+func requireNotified(t *testing.T, ch <-chan uint64, batch uint64) {
+	t.Helper()
+	select {
+	case got := <-ch:
+		require.Equal(t, batch, got)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("batch %d was not notified", batch)
+	}
+	require.Empty(t, ch, "a batch is notified once")
+}
+
+func TestBatchBroadcaster(t *testing.T) {
+	ctx := context.Background()
+	db := memdb.NewTestDB(t)
+	update := func(f func(tx kv.RwTx) error) {
+		require.NoError(t, db.Update(ctx, f))
+	}
+	update(hermez_db.CreateHermezBuckets)
+	update(func(tx kv.RwTx) error {
+		return stages.SaveStageProgress(tx, stages.HighestSeenBatchNumber, 2)
+	})
+
+	b := newBatchBroadcaster(db, nil)
+	subscribe := func(kind batchProgressKind) (<-chan uint64, func()) {
+		ch, unsubscribe, err := b.subscribe(ctx, kind)
+		require.NoError(t, err)
+		return ch, unsubscribe
+	}
+	closed, unsubscribeClosed := subscribe(closedBatches)
+	virtualized, unsubscribeVirtualized := subscribe(virtualizedBatches)
+	verified, unsubscribeVerified := subscribe(verifiedBatches)
+	defer unsubscribeVerified()
+
+	// the progress when subscribing isn't notified
+	require.NoError(t, b.check(ctx))
+	require.Empty(t, closed)
+
+	update(func(tx kv.RwTx) error {
+		if err := stages.SaveStageProgress(tx, stages.HighestSeenBatchNumber, 4); err != nil {
+			return err
+		}
+		if err := hermez_db.NewHermezDb(tx).WriteSequence(10, 3, common.Hash{}, common.Hash{}); err != nil {
+			return err
+		}
+		if err := stages.SaveStageProgress(tx, stages.L1Syncer, 10); err != nil {
+			return err
+		}
+		return stages.SaveStageProgress(tx, stages.L1VerificationsBatchNo, 2)
+	})
+	require.NoError(t, b.check(ctx))
+	requireNotified(t, closed, 3)
+	requireNotified(t, virtualized, 3)
+	requireNotified(t, verified, 2)
+
+	// batches closed again after an unwind are notified again
+	update(func(tx kv.RwTx) error {
+		return stages.SaveStageProgress(tx, stages.HighestSeenBatchNumber, 3)
+	})
+	require.NoError(t, b.check(ctx))
+	require.Empty(t, closed)
+	update(func(tx kv.RwTx) error {
+		return stages.SaveStageProgress(tx, stages.HighestSeenBatchNumber, 4)
+	})
+	require.NoError(t, b.check(ctx))
+	requireNotified(t, closed, 3)
+
+	unsubscribeClosed()
+	unsubscribeVirtualized()
+	b.lock.Lock()
+	require.Len(t, b.subs, 1)
+	b.lock.Unlock()
+}
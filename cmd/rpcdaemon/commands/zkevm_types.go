@@ -11,3 +11,12 @@ type ZkExitRoots struct {
 	MainnetExitRoot common.Hash     `json:"mainnetExitRoot"`
 	RollupExitRoot  common.Hash     `json:"rollupExitRoot"`
 }
+
+type ZkForkInfo struct {
+	ForkId          types.ArgUint64 `json:"forkId"`
+	Version         string          `json:"version"`
+	FromBatchNumber types.ArgUint64 `json:"fromBatchNumber"`
+	ToBatchNumber   types.ArgUint64 `json:"toBatchNumber"`
+	BlockNumber     types.ArgUint64 `json:"blockNumber"`
+	L1BlockNumber   types.ArgUint64 `json:"l1BlockNumber"`
+}
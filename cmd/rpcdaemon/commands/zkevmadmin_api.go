@@ -0,0 +1,256 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	libcommon "github.com/gateway-fm/cdk-erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/zk/batch_sealing"
+	"github.com/ledgerwatch/erigon/zk/gas_price"
+	"github.com/ledgerwatch/erigon/zk/sequencer"
+	"github.com/ledgerwatch/erigon/zk/sequencer_control"
+	zktxpool "github.com/ledgerwatch/erigon/zk/txpool"
+)
+
+// ZkEvmAdminAPI the interface for the zkevmadmin_* RPC commands, they change a running sequencer and are only served
+// on the authenticated port.
+type ZkEvmAdminAPI interface {
+	// Pause stops the sequencer, the open batch is sealed after its current block.
+	Pause(ctx context.Context) error
+
+	// Resume starts sequencing again with a new batch.
+	Resume(ctx context.Context) error
+
+	// Status returns whether the sequencer is sequencing or paused and its seal times.
+	Status(ctx context.Context) (*SequencerStatus, error)
+
+	// CloseBatch seals the open batch once its current block is done.
+	CloseBatch(ctx context.Context) error
+
+	// SealTimers returns the seal times of the sequencer.
+	SealTimers(ctx context.Context) (*SealTimers, error)
+
+	// SetSealTimers changes the seal times of the sequencer, the change applies from the next batch.
+	SetSealTimers(ctx context.Context, timers SealTimers) error
+
+	// GasPriceConfig returns what the suggested gas price is calculated from.
+	GasPriceConfig(ctx context.Context) (*GasPriceConfig, error)
+
+	// SetGasPriceConfig changes what the suggested gas price is calculated from, the change applies from the next block.
+	SetGasPriceConfig(ctx context.Context, cfg GasPriceConfig) error
+
+	// BanTransaction keeps transactions out of the pool and out of blocks until they're unbanned, the sequencer drops
+	// the ones already in the pool as it comes across them.
+	BanTransaction(ctx context.Context, hashes []libcommon.Hash) error
+
+	// UnbanTransaction lets banned transactions into the pool again.
+	UnbanTransaction(ctx context.Context, hashes []libcommon.Hash) error
+
+	// BannedTransactions returns the hashes of the banned transactions.
+	BannedTransactions(ctx context.Context) ([]libcommon.Hash, error)
+
+	// AclAdd adds addresses to an access control list.
+	AclAdd(ctx context.Context, list string, addresses []libcommon.Address) error
+
+	// AclRemove removes addresses from an access control list.
+	AclRemove(ctx context.Context, list string, addresses []libcommon.Address) error
+
+	// AclSetEnabled enforces an access control list or stops enforcing it.
+	AclSetEnabled(ctx context.Context, list string, enabled bool) error
+
+	// UnwindToBatch unwinds the paused sequencer to the end of a batch the L1 doesn't have yet and returns the block
+	// it unwound to.  The transactions of the removed blocks are not put back in the pool.
+	UnwindToBatch(ctx context.Context, batch hexutil.Uint64) (hexutil.Uint64, error)
+}
+
+// SequencerStatus is the result of zkevmadmin_status.
+type SequencerStatus struct {
+	Sequencing bool       `json:"sequencing"`
+	Paused     bool       `json:"paused"`
+	Timers     SealTimers `json:"timers"`
+}
+
+// SealTimers are the seal times of the sequencer as durations such as "500ms" or "3s".
+type SealTimers struct {
+	BlockSealTime         string `json:"blockSealTime"`
+	BatchSealTime         string `json:"batchSealTime"`
+	NonEmptyBatchSealTime string `json:"nonEmptyBatchSealTime"`
+}
+
+func newSealTimers(t sequencer_control.Timers) SealTimers {
+	return SealTimers{
+		BlockSealTime:         t.BlockSealTime.String(),
+		BatchSealTime:         t.BatchSealTime.String(),
+		NonEmptyBatchSealTime: t.NonEmptyBatchSealTime.String(),
+	}
+}
+
+func (t SealTimers) parse() (timers sequencer_control.Timers, err error) {
+	if timers.BlockSealTime, err = time.ParseDuration(t.BlockSealTime); err != nil {
+		return timers, fmt.Errorf("blockSealTime: %w", err)
+	}
+	if timers.BatchSealTime, err = time.ParseDuration(t.BatchSealTime); err != nil {
+		return timers, fmt.Errorf("batchSealTime: %w", err)
+	}
+	if timers.NonEmptyBatchSealTime, err = time.ParseDuration(t.NonEmptyBatchSealTime); err != nil {
+		return timers, fmt.Errorf("nonEmptyBatchSealTime: %w", err)
+	}
+	return timers, nil
+}
+
+// GasPriceConfig is what the suggested gas price is calculated from, see the zkevm.gas-price-* flags.
+type GasPriceConfig struct {
+	Factor              float64 `json:"factor"`
+	DefaultGasPrice     uint64  `json:"defaultGasPrice"`
+	MaxGasPrice         uint64  `json:"maxGasPrice"`
+	L1History           int     `json:"l1History"`
+	CongestionThreshold uint64  `json:"congestionThreshold"`
+}
+
+// ZkEvmAdminAPIImpl data structure to store things needed for zkevmadmin_* commands.
+type ZkEvmAdminAPIImpl struct {
+	acl      *zktxpool.ACL
+	sealer   *batch_sealing.Sealer
+	gasPrice *gas_price.Oracle
+	control  *sequencer_control.Control
+}
+
+// NewZkEvmAdminAPI returns ZkEvmAdminAPIImpl instance, everything it is given belongs to a sequencer.
+func NewZkEvmAdminAPI(acl *zktxpool.ACL, sealer *batch_sealing.Sealer, gasPrice *gas_price.Oracle, control *sequencer_control.Control) *ZkEvmAdminAPIImpl {
+	return &ZkEvmAdminAPIImpl{
+		acl:      acl,
+		sealer:   sealer,
+		gasPrice: gasPrice,
+		control:  control,
+	}
+}
+
+// a standby sequencer has the levers too but they only take effect once it sequences
+var errNotSequencing = errors.New("this node is not sequencing")
+
+func (api *ZkEvmAdminAPIImpl) Pause(ctx context.Context) error {
+	api.control.Pause()
+	return nil
+}
+
+func (api *ZkEvmAdminAPIImpl) Resume(ctx context.Context) error {
+	api.control.Resume()
+	return nil
+}
+
+func (api *ZkEvmAdminAPIImpl) Status(ctx context.Context) (*SequencerStatus, error) {
+	return &SequencerStatus{
+		Sequencing: sequencer.IsSequencer(),
+		Paused:     api.control.Paused(),
+		Timers:     newSealTimers(api.control.Timers()),
+	}, nil
+}
+
+func (api *ZkEvmAdminAPIImpl) CloseBatch(ctx context.Context) error {
+	if !sequencer.IsSequencer() {
+		return errNotSequencing
+	}
+	api.sealer.Request()
+	return nil
+}
+
+// AdminSealAPI is admin_sealBatch, the admin request batch sealing policies came with.  Like the zkevmadmin_* commands
+// it is only served on the authenticated port.
+type AdminSealAPI interface {
+	// SealBatch seals the open batch once its current block is done, as zkevmadmin_closeBatch does.
+	SealBatch(ctx context.Context) error
+}
+
+type AdminSealAPIImpl struct {
+	admin *ZkEvmAdminAPIImpl
+}
+
+func NewAdminSealAPI(admin *ZkEvmAdminAPIImpl) *AdminSealAPIImpl {
+	return &AdminSealAPIImpl{admin: admin}
+}
+
+func (api *AdminSealAPIImpl) SealBatch(ctx context.Context) error {
+	return api.admin.CloseBatch(ctx)
+}
+
+func (api *ZkEvmAdminAPIImpl) SealTimers(ctx context.Context) (*SealTimers, error) {
+	timers := newSealTimers(api.control.Timers())
+	return &timers, nil
+}
+
+func (api *ZkEvmAdminAPIImpl) SetSealTimers(ctx context.Context, timers SealTimers) error {
+	t, err := timers.parse()
+	if err != nil {
+		return err
+	}
+	return api.control.SetTimers(t)
+}
+
+func (api *ZkEvmAdminAPIImpl) GasPriceConfig(ctx context.Context) (*GasPriceConfig, error) {
+	cfg := api.gasPrice.Config()
+	return &GasPriceConfig{
+		Factor:              cfg.Factor,
+		DefaultGasPrice:     cfg.DefaultGasPrice,
+		MaxGasPrice:         cfg.MaxGasPrice,
+		L1History:           cfg.L1History,
+		CongestionThreshold: cfg.CongestionThreshold,
+	}, nil
+}
+
+func (api *ZkEvmAdminAPIImpl) SetGasPriceConfig(ctx context.Context, cfg GasPriceConfig) error {
+	return api.gasPrice.SetConfig(gas_price.Config{
+		Factor:              cfg.Factor,
+		DefaultGasPrice:     cfg.DefaultGasPrice,
+		MaxGasPrice:         cfg.MaxGasPrice,
+		L1History:           cfg.L1History,
+		CongestionThreshold: cfg.CongestionThreshold,
+	})
+}
+
+func (api *ZkEvmAdminAPIImpl) BanTransaction(ctx context.Context, hashes []libcommon.Hash) error {
+	return api.acl.Ban(ctx, hashes...)
+}
+
+func (api *ZkEvmAdminAPIImpl) UnbanTransaction(ctx context.Context, hashes []libcommon.Hash) error {
+	return api.acl.Unban(ctx, hashes...)
+}
+
+func (api *ZkEvmAdminAPIImpl) BannedTransactions(ctx context.Context) ([]libcommon.Hash, error) {
+	return api.acl.Banned(), nil
+}
+
+func (api *ZkEvmAdminAPIImpl) AclAdd(ctx context.Context, list string, addresses []libcommon.Address) error {
+	l, err := zktxpool.ParseACLList(list)
+	if err != nil {
+		return err
+	}
+	return api.acl.Add(ctx, l, addresses...)
+}
+
+func (api *ZkEvmAdminAPIImpl) AclRemove(ctx context.Context, list string, addresses []libcommon.Address) error {
+	l, err := zktxpool.ParseACLList(list)
+	if err != nil {
+		return err
+	}
+	return api.acl.Remove(ctx, l, addresses...)
+}
+
+func (api *ZkEvmAdminAPIImpl) AclSetEnabled(ctx context.Context, list string, enabled bool) error {
+	l, err := zktxpool.ParseACLList(list)
+	if err != nil {
+		return err
+	}
+	return api.acl.SetEnabled(ctx, l, enabled)
+}
+
+func (api *ZkEvmAdminAPIImpl) UnwindToBatch(ctx context.Context, batch hexutil.Uint64) (hexutil.Uint64, error) {
+	if !sequencer.IsSequencer() {
+		return 0, errNotSequencing
+	}
+	block, err := api.control.RequestUnwind(ctx, uint64(batch))
+	return hexutil.Uint64(block), err
+}
@@ -38,6 +38,9 @@ var rootCmd = &cobra.Command{
 			panic(err)
 		}
 
+		if err := core.ValidateDynamicChain(chain); err != nil {
+			utils.Fatalf("%v", err)
+		}
 		genesis, chainConfig = getChainGenesisAndConfig()
 		if genesisPath != "" {
 			genesis = genesisFromFile(genesisPath)
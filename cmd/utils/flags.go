@@ -42,10 +42,6 @@ import (
 	"github.com/spf13/pflag"
 	"github.com/urfave/cli/v2"
 
-	"encoding/json"
-	"os"
-	"path"
-
 	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cmd/downloader/downloadernat"
 	"github.com/ledgerwatch/erigon/common/paths"
@@ -351,6 +347,11 @@ var (
 		Usage: "API's offered over the HTTP-RPC interface",
 		Value: "eth,erigon,engine",
 	}
+	NodeRoleFlag = cli.StringFlag{
+		Name:  "zkevm.role",
+		Usage: "What the node does in the chain: sequencer, rpc, archive or verifier.  A sequencer runs the sequencing stages, the other roles follow a data stream.  The role also picks the RPC namespaces served, whether transactions are taken and the flags required.  Defaults to sequencer if CDK_ERIGON_SEQUENCER=1, rpc otherwise",
+		Value: "",
+	}
 	L2ChainIdFlag = cli.Uint64Flag{
 		Name:  "zkevm.l2-chain-id",
 		Usage: "L2 chain ID",
@@ -416,6 +417,11 @@ var (
 		Usage: "Ger Manager address",
 		Value: "",
 	}
+	AddressDataCommitteeFlag = cli.StringFlag{
+		Name:  "zkevm.address-data-committee",
+		Usage: "Data availability committee address of a validium, needed for L1 recovery of its batches",
+		Value: "",
+	}
 	L1RollupIdFlag = cli.Uint64Flag{
 		Name:  "zkevm.l1-rollup-id",
 		Usage: "Ethereum L1 Rollup ID",
@@ -447,6 +453,11 @@ var (
 		Usage: "First block to start syncing from on the L1",
 		Value: 0,
 	}
+	DynamicConfigDirFlag = cli.StringFlag{
+		Name:  "zkevm.dynamic-config-dir",
+		Usage: "Directory holding the chainspec, allocs and conf files of dynamic chains, defaults to ~/dynamic-configs",
+		Value: "",
+	}
 	RebuildTreeAfterFlag = cli.Uint64Flag{
 		Name:  "zkevm.rebuild-tree-after",
 		Usage: "Rebuild the state tree after this many blocks behind",
@@ -477,6 +488,56 @@ var (
 		Usage: "Batch seal time. Defaults to 3s",
 		Value: "3s",
 	}
+	SequencerSealCounterThreshold = cli.Uint64Flag{
+		Name:  "zkevm.sequencer-seal-counter-threshold",
+		Usage: "Seal the batch once any counter used this percentage of its limit, 0 to seal on overflow only",
+		Value: 0,
+	}
+	SequencerSealL1DataBudget = cli.Uint64Flag{
+		Name:  "zkevm.sequencer-seal-l1-data-budget",
+		Usage: "Seal the batch once its batch L2 data reaches this many bytes of L1 calldata, 0 for no budget",
+		Value: 0,
+	}
+	SequencerSealOnL1InfoTree = cli.BoolFlag{
+		Name:  "zkevm.sequencer-seal-on-l1-info-tree",
+		Usage: "Seal the batch once one of its blocks used a new L1 info tree update",
+		Value: false,
+	}
+	SequencerTxOrdering = cli.StringFlag{
+		Name:  "zkevm.sequencer-tx-ordering",
+		Usage: "Order the sequencer takes the pending transactions in: price (by price then nonce), fifo (in the order they were received) or priority-fee",
+		Value: "price",
+	}
+	SequencerPrioritySenders = cli.StringFlag{
+		Name:  "zkevm.sequencer-priority-senders",
+		Usage: "Comma separated list of senders whose transactions the sequencer takes ahead of the others",
+		Value: "",
+	}
+	SequencerMaxTxsPerSender = cli.Uint64Flag{
+		Name:  "zkevm.sequencer-max-txs-per-sender",
+		Usage: "Most transactions of a sender the sequencer takes from the pool at once, 0 for no limit",
+		Value: 0,
+	}
+	SequencerPoolManager = cli.BoolFlag{
+		Name:  "zkevm.sequencer-pool-manager",
+		Usage: "Take the transactions to sequence from the pool manager at zkevm.pool-manager-url instead of the txpool of the node, reporting back what became of each",
+		Value: false,
+	}
+	SequencerHaLease = cli.StringFlag{
+		Name:  "zkevm.sequencer-ha-lease",
+		Usage: "Run as a standby sequencer competing for the lease at this url (file:///path or memory://), following the leader's datastream until it acquires it and takes over sequencing",
+		Value: "",
+	}
+	SequencerHaId = cli.StringFlag{
+		Name:  "zkevm.sequencer-ha-id",
+		Usage: "Unique name of this sequencer in the leader election",
+		Value: "",
+	}
+	SequencerHaLeaseTTL = cli.DurationFlag{
+		Name:  "zkevm.sequencer-ha-lease-ttl",
+		Usage: "How long the sequencer lease lasts without being renewed, the longest the chain goes without a sequencer when the leader fails",
+		Value: 10 * time.Second,
+	}
 	ExecutorUrls = cli.StringFlag{
 		Name:  "zkevm.executor-urls",
 		Usage: "A comma separated list of grpc addresses that host executors",
@@ -499,9 +560,34 @@ var (
 	}
 	RpcRateLimitsFlag = cli.IntFlag{
 		Name:  "zkevm.rpc-ratelimit",
-		Usage: "RPC rate limit in requests per second.",
+		Usage: "RPC rate limit in requests per second of each client, 0 for no limit. Also the limit of eth_call and of the witness and trace methods unless they have their own.",
 		Value: 0,
 	}
+	RpcRateLimitsCallsFlag = cli.IntFlag{
+		Name:  "zkevm.rpc-ratelimit-calls",
+		Usage: "RPC rate limit of eth_call, eth_estimateGas and eth_createAccessList in requests per second of each client, 0 for the zkevm.rpc-ratelimit limit.",
+		Value: 0,
+	}
+	RpcRateLimitsHeavyFlag = cli.IntFlag{
+		Name:  "zkevm.rpc-ratelimit-heavy",
+		Usage: "RPC rate limit of the witness, prover input and trace methods in requests per second of each client, 0 for the zkevm.rpc-ratelimit limit.",
+		Value: 0,
+	}
+	RpcRateLimitsApiKeysFlag = cli.StringFlag{
+		Name:  "zkevm.rpc-ratelimit-api-keys",
+		Usage: "Comma separated API keys, a client sending one in the X-Api-Key header is rate limited on its own rather than by IP address.",
+		Value: "",
+	}
+	RpcRateLimitsApiKeyMultiplierFlag = cli.IntFlag{
+		Name:  "zkevm.rpc-ratelimit-api-key-multiplier",
+		Usage: "Multiplier of the RPC rate limits of the clients with an API key.",
+		Value: 1,
+	}
+	RpcRateLimitsTrustedProxiesFlag = cli.StringFlag{
+		Name:  "zkevm.rpc-ratelimit-trusted-proxies",
+		Usage: "Comma separated IP addresses and CIDR ranges of the proxies in front of the RPC, the clients of their requests are rate limited by the X-Forwarded-For or X-Real-IP header rather than by the proxy's address.",
+		Value: "",
+	}
 	DatastreamVersionFlag = cli.IntFlag{
 		Name:  "zkevm.datastream-version",
 		Usage: "Stream version indicator 1: PreBigEndian, 2: BigEndian.",
@@ -562,6 +648,16 @@ var (
 		Usage: "Apply factor to L1 gas price to calculate l2 gasPrice",
 		Value: 1,
 	}
+	GasPriceL1HistoryFlag = cli.IntFlag{
+		Name:  "zkevm.gas-price-l1-history",
+		Usage: "Number of L1 blocks the sequencer takes the median L1 gas price over when suggesting the L2 gas price",
+		Value: 10,
+	}
+	GasPriceCongestionThresholdFlag = cli.Uint64Flag{
+		Name:  "zkevm.gas-price-congestion-threshold",
+		Usage: "Pending transactions in the pool above which the suggested L2 gas price rises in proportion, 0 to disable",
+		Value: 0,
+	}
 	WitnessFullFlag = cli.BoolFlag{
 		Name:  "zkevm.witness-full",
 		Usage: "Enable/Diable witness full",
@@ -577,6 +673,26 @@ var (
 		Usage: "The URL of the pool manager. If set, eth_sendRawTransaction will be redirected there.",
 		Value: "",
 	}
+	RpcForwardedTxsTTL = cli.DurationFlag{
+		Name:  "zkevm.rpc-forwarded-txs-ttl",
+		Usage: "How long an RPC node validates and keeps the transactions it forwards to the sequencer or pool manager, answering for them as pending until they are mined. 0 to forward them unchecked",
+		Value: 5 * time.Minute,
+	}
+	SequencerPreconfirmationKey = cli.StringFlag{
+		Name:  "zkevm.sequencer-preconfirmation-key",
+		Usage: "File holding the hex private key the sequencer signs the preconfirmations of the transactions it executes with, none are given without it",
+		Value: "",
+	}
+	PreconfirmationsRelayUrl = cli.StringFlag{
+		Name:  "zkevm.preconfirmations-relay-url",
+		Usage: "Websocket url of the sequencer, or of an RPC node relaying it, whose preconfirmations the node relays to its own subscribers",
+		Value: "",
+	}
+	PreconfirmationsSequencerAddress = cli.StringFlag{
+		Name:  "zkevm.preconfirmations-sequencer-address",
+		Usage: "Address of the sequencer's preconfirmation key, the node relays only the preconfirmations it signed",
+		Value: "",
+	}
 	DisableVirtualCounters = cli.BoolFlag{
 		Name:  "zkevm.disable-virtual-counters",
 		Usage: "Disable the virtual counters. This has an effect on on sequencer node and when external executor is not enabled.",
@@ -592,6 +708,26 @@ var (
 		Usage: "Output the payload of the executor, serialised requests stored to disk by batch number",
 		Value: "",
 	}
+	ExecutorStateless = cli.BoolFlag{
+		Name:  "zkevm.executor-stateless",
+		Usage: "Verify batches by re-executing them in process from their witness when no executor urls are set",
+		Value: false,
+	}
+	DevnetFlag = cli.BoolFlag{
+		Name:  "zkevm.devnet",
+		Usage: "Run a self-contained devnet: the sequencer runs against an in-process mock L1 with a mocked sequence sender and aggregator",
+		Value: false,
+	}
+	DevnetL1RpcAddrFlag = cli.StringFlag{
+		Name:  "zkevm.devnet-l1-rpc-addr",
+		Usage: "Address the devnet mock L1 RPC listens on, empty to not expose it",
+		Value: "localhost:18545",
+	}
+	DevnetL1BlockTimeFlag = cli.DurationFlag{
+		Name:  "zkevm.devnet-l1-block-time",
+		Usage: "Time between the blocks of the devnet mock L1",
+		Value: 2 * time.Second,
+	}
 	DebugNoSync = cli.BoolFlag{
 		Name:  "debug.no-sync",
 		Usage: "Disable syncing",
@@ -1672,13 +1808,6 @@ func setWhitelist(ctx *cli.Context, cfg *ethconfig.Config) {
 	}
 }
 
-type DynamicConfig struct {
-	Root       string `json:"root"`
-	Timestamp  uint64 `json:"timestamp"`
-	GasLimit   uint64 `json:"gasLimit"`
-	Difficulty int64  `json:"difficulty"`
-}
-
 // CheckExclusive verifies that only a single instance of the provided flags was
 // set by the user. Each flag might optionally be followed by a string type to
 // specialize it further.
@@ -1813,32 +1942,15 @@ func SetEthConfig(ctx *cli.Context, nodeConfig *nodecfg.Config, cfg *ethconfig.C
 	// Override any default configs for hard coded networks.
 	chain := ctx.String(ChainFlag.Name)
 	if strings.HasPrefix(chain, "dynamic") {
-		genesis := core.GenesisBlockByChainName(chain)
-
-		dConf := DynamicConfig{}
-
-		homeDir, err := os.UserHomeDir()
+		genesis, err := core.LoadDynamicGenesis(chain)
 		if err != nil {
-			panic(err)
+			Fatalf("Invalid dynamic chain config: %v", err)
 		}
-
-		basePath := path.Join(homeDir, "dynamic-configs")
-		filename := path.Join(basePath, chain+"-conf.json")
-
-		if _, err := os.Stat(filename); err == nil {
-			dConfBytes, err := os.ReadFile(filename)
-			if err != nil {
-				panic(err)
-			}
-			if err := json.Unmarshal(dConfBytes, &dConf); err != nil {
-				panic(err)
-			}
+		dConf, err := params.LoadDynamicConf(chain)
+		if err != nil {
+			Fatalf("Invalid dynamic chain config: %v", err)
 		}
 
-		genesis.Timestamp = dConf.Timestamp
-		genesis.GasLimit = dConf.GasLimit
-		genesis.Difficulty = big.NewInt(dConf.Difficulty)
-
 		cfg.Genesis = genesis
 
 		genesisHash := libcommon.HexToHash(dConf.Root)
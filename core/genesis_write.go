@@ -677,6 +677,13 @@ func GenesisBlockByChainName(chain string) *types.Genesis {
 	case networkname.XLayerMainnetChainName:
 		return XLayerMainnetGenesisBlock()
 	default:
-		return DynamicGenesisBlock(chain)
+		// like any other unknown chain a dynamic chain without a valid config has no genesis, entrypoints validate
+		// the config up front to report why
+		genesis, err := DynamicGenesisBlock(chain)
+		if err != nil {
+			log.Error("Invalid dynamic chain config", "chain", chain, "err", err)
+			return nil
+		}
+		return genesis
 	}
 }
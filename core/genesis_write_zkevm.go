@@ -9,9 +9,8 @@ import (
 	"github.com/ledgerwatch/erigon/smt/pkg/smt"
 	"github.com/ledgerwatch/erigon/zkevm/hex"
 	"fmt"
-	"os"
-	"path"
 	"encoding/json"
+	"strings"
 )
 
 func HermezMainnetGenesisBlock() *types.Genesis {
@@ -136,35 +135,88 @@ func processAccount(s *smt.SMT, root *big.Int, a *types.GenesisAccount, addr lib
 	return s.LastRoot(), nil
 }
 
-func DynamicGenesisBlock(chain string) *types.Genesis {
-	return &types.Genesis{
-		Config:     params.DynamicChainConfig(chain),
-		Timestamp:  0x0,
-		GasLimit:   0x0,
-		Difficulty: big.NewInt(0x0),
-		Alloc:      dynamicPrealloc(chain),
+// GenesisSmtRoot calculates the SMT state root of the genesis allocs, this is the genesis root the rollup
+// manager holds on L1 for the chain
+func GenesisSmtRoot(alloc types.GenesisAlloc) (libcommon.Hash, error) {
+	sparseTree := smt.NewSMT(nil)
+	root := new(big.Int)
+
+	var err error
+	for _, key := range sortedAllocKeys(alloc) {
+		addr := libcommon.BytesToAddress([]byte(key))
+		account := alloc[addr]
+		if root, err = processAccount(sparseTree, root, &account, addr); err != nil {
+			return libcommon.Hash{}, err
+		}
 	}
+
+	return libcommon.BigToHash(root), nil
+}
+
+// DynamicGenesisBlock returns the genesis of a dynamic chain
+func DynamicGenesisBlock(chain string) (*types.Genesis, error) {
+	return LoadDynamicGenesis(chain)
 }
 
-func dynamicPrealloc(ch string) types.GenesisAlloc {
-	homeDir, err := os.UserHomeDir()
+// ValidateDynamicChain reports what is wrong with the config files of a dynamic chain, every entrypoint taking a
+// chain name checks it so a bad config is reported before the chain config or genesis is needed
+func ValidateDynamicChain(chain string) error {
+	if !strings.HasPrefix(chain, "dynamic") {
+		return nil
+	}
+	if _, err := LoadDynamicGenesis(chain); err != nil {
+		return fmt.Errorf("invalid dynamic chain config: %w", err)
+	}
+	return nil
+}
+
+// LoadDynamicGenesis reads and validates the chainspec, allocs and conf of a dynamic chain
+func LoadDynamicGenesis(chain string) (*types.Genesis, error) {
+	spec, err := params.LoadDynamicChainConfig(chain)
+	if err != nil {
+		return nil, err
+	}
+
+	conf, err := params.LoadDynamicConf(chain)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+
+	alloc, err := loadDynamicAllocs(chain)
+	if err != nil {
+		return nil, err
 	}
 
-	basePath := path.Join(homeDir, "dynamic-configs")
-	filename := path.Join(basePath, ch+"-allocs.json")
+	return &types.Genesis{
+		Config:     spec,
+		Timestamp:  conf.Timestamp,
+		GasLimit:   conf.GasLimit,
+		Difficulty: big.NewInt(conf.Difficulty),
+		Alloc:      alloc,
+	}, nil
+}
 
-	f, err := os.Open(filename)
+func loadDynamicAllocs(chain string) (types.GenesisAlloc, error) {
+	content, found, err := params.ReadDynamicConfigFile(chain, params.DynamicAllocsSuffix)
 	if err != nil {
-		panic(fmt.Sprintf("could not open alloc for %s: %v", filename, err))
+		return nil, err
 	}
-	defer f.Close()
-	decoder := json.NewDecoder(f)
+	if !found {
+		return nil, params.MissingDynamicConfigError(chain, params.DynamicAllocsSuffix)
+	}
+
 	alloc := make(types.GenesisAlloc)
-	err = decoder.Decode(&alloc)
-	if err != nil {
-		panic(fmt.Sprintf("could not parse alloc for %s: %v", filename, err))
+	if err = json.Unmarshal(content, &alloc); err != nil {
+		return nil, fmt.Errorf("invalid allocs for %s: %w", chain, err)
+	}
+	if len(alloc) == 0 {
+		return nil, fmt.Errorf("invalid allocs for %s: no accounts", chain)
 	}
-	return alloc
+	for addr, account := range alloc {
+		if account.Balance == nil {
+			return nil, fmt.Errorf("invalid allocs for %s: account %s has no balance", chain, addr)
+		}
+	}
+
+	return alloc, nil
 }
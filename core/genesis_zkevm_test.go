@@ -35,6 +35,52 @@ func TestGenesisBlockHashesZkevm(t *testing.T) {
 	}
 }
 
+func TestGenesisSmtRootZkevm(t *testing.T) {
+	for _, network := range networkname.Zkevm {
+		t.Run(network, func(t *testing.T) {
+			_, tx := memdb.NewTestTx(t)
+			genesis := core.GenesisBlockByChainName(network)
+			_, block, err := core.WriteGenesisBlock(tx, genesis, nil, "/tmp/"+network)
+			require.NoError(t, err)
+
+			root, err := core.GenesisSmtRoot(genesis.Alloc)
+			require.NoError(t, err)
+			require.Equal(t, block.Root(), root, network)
+		})
+	}
+}
+
+func TestLoadDynamicGenesis(t *testing.T) {
+	params.SetDynamicConfigDir("../zk/examples/dynamic-configs")
+	defer params.SetDynamicConfigDir("")
+
+	genesis, err := core.LoadDynamicGenesis("dynamic-hermez-cardona")
+	require.NoError(t, err)
+	require.Equal(t, uint64(2442), genesis.Config.ChainID.Uint64())
+	require.Equal(t, uint64(123545), genesis.Timestamp)
+	require.NotEmpty(t, genesis.Alloc)
+
+	_, err = core.LoadDynamicGenesis("dynamic-missing")
+	require.ErrorContains(t, err, "no chainspec.json found for dynamic chain dynamic-missing")
+
+	// embedded files take priority over the directory and are validated the same way
+	params.SetEmbeddedDynamicConfig(&params.EmbeddedDynamicConfig{Conf: []byte(`{"root": "0x1234"}`)})
+	defer params.SetEmbeddedDynamicConfig(nil)
+	_, err = core.LoadDynamicGenesis("dynamic-hermez-cardona")
+	require.ErrorContains(t, err, "root \"0x1234\" is not a 32 byte hex hash")
+
+	// unknown fields are warned about, files that loaded before keep loading
+	params.SetEmbeddedDynamicConfig(&params.EmbeddedDynamicConfig{Chainspec: []byte(`{"chainId": 1, "ChainName": "cardona", "londonBlok": 0}`)})
+	genesis, err = core.LoadDynamicGenesis("dynamic-hermez-cardona")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), genesis.Config.ChainID.Uint64())
+	require.Equal(t, "cardona", genesis.Config.ChainName)
+
+	params.SetEmbeddedDynamicConfig(&params.EmbeddedDynamicConfig{Chainspec: []byte(`{"chainId": "1"}`)})
+	_, err = core.LoadDynamicGenesis("dynamic-hermez-cardona")
+	require.ErrorContains(t, err, "invalid chainspec")
+}
+
 func TestCommitGenesisIdempotency2(t *testing.T) {
 	_, tx := memdb.NewTestTx(t)
 	genesis := core.GenesisBlockByChainName(networkname.HermezMainnetChainName)
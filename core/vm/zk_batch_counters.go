@@ -84,12 +84,13 @@ func (bcc *BatchCounterCollector) StartNewBlock() (bool, error) {
 	return bcc.CheckForOverflow()
 }
 
-func (bcc *BatchCounterCollector) processBatchLevelData() error {
+// L2DataSize returns the length of the batch L2 data of the blocks and transactions collected so far
+func (bcc *BatchCounterCollector) L2DataSize() (int, error) {
 	totalEncodedTxLength := 0
 	for _, t := range bcc.transactions {
 		encoded, err := tx.TransactionToL2Data(t.transaction, bcc.forkId, tx.MaxEffectivePercentage)
 		if err != nil {
-			return err
+			return 0, err
 		}
 		totalEncodedTxLength += len(encoded)
 	}
@@ -100,6 +101,15 @@ func (bcc *BatchCounterCollector) processBatchLevelData() error {
 	// 5-9 - l1 info tree index
 	totalEncodedTxLength += 9 * bcc.blockCount
 
+	return totalEncodedTxLength, nil
+}
+
+func (bcc *BatchCounterCollector) processBatchLevelData() error {
+	totalEncodedTxLength, err := bcc.L2DataSize()
+	if err != nil {
+		return err
+	}
+
 	// reset the batch processing counters ready to calc the new values
 	bcc.l2DataCollector = NewCounterCollector(bcc.smtLevels)
 
@@ -86,6 +86,21 @@ func (c Counters) UsedAsMap() map[string]int {
 	}
 }
 
+// HighestUsagePercentage returns the counter that used the most of its limit and the percentage of it used
+func (c Counters) HighestUsagePercentage() (CounterKey, uint64) {
+	var highest CounterKey
+	var percentage uint64
+	for k, v := range c {
+		if v.initialAmount <= 0 || v.used <= 0 {
+			continue
+		}
+		if p := uint64(v.used) * 100 / uint64(v.initialAmount); highest == "" || p > percentage {
+			highest, percentage = k, p
+		}
+	}
+	return highest, percentage
+}
+
 type CounterKey string
 
 var (
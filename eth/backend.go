@@ -33,6 +33,7 @@ import (
 
 	erigonchain "github.com/gateway-fm/cdk-erigon-lib/chain"
 	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon/zk/devnet"
 	"github.com/ledgerwatch/erigon/zk/sequencer"
 	"github.com/ledgerwatch/log/v3"
 	"golang.org/x/exp/slices"
@@ -102,6 +103,7 @@ import (
 	"github.com/ledgerwatch/erigon/p2p/enode"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/rpc/rpccfg"
 	"github.com/ledgerwatch/erigon/smt/pkg/db"
 	"github.com/ledgerwatch/erigon/turbo/engineapi"
 	"github.com/ledgerwatch/erigon/turbo/services"
@@ -110,11 +112,18 @@ import (
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync/snap"
 	stages2 "github.com/ledgerwatch/erigon/turbo/stages"
 	"github.com/ledgerwatch/erigon/turbo/stages/headerdownload"
+	"github.com/ledgerwatch/erigon/zk/batch_sealing"
 	"github.com/ledgerwatch/erigon/zk/contracts"
 	"github.com/ledgerwatch/erigon/zk/datastream/client"
+	"github.com/ledgerwatch/erigon/zk/gas_price"
+	"github.com/ledgerwatch/erigon/zk/ha"
 	"github.com/ledgerwatch/erigon/zk/hermez_db"
 	"github.com/ledgerwatch/erigon/zk/legacy_executor_verifier"
+	"github.com/ledgerwatch/erigon/zk/pool_manager"
+	"github.com/ledgerwatch/erigon/zk/preconfirmation"
+	"github.com/ledgerwatch/erigon/zk/sequencer_control"
 	zkStages "github.com/ledgerwatch/erigon/zk/stages"
+	"github.com/ledgerwatch/erigon/zk/stateless_executor"
 	"github.com/ledgerwatch/erigon/zk/syncer"
 	txpool2 "github.com/ledgerwatch/erigon/zk/txpool"
 	"github.com/ledgerwatch/erigon/zk/witness"
@@ -195,6 +204,14 @@ type Ethereum struct {
 	dataStream      *datastreamer.StreamServer
 	l1Syncer        *syncer.L1Syncer
 	etherManClients []*etherman.Client
+	devnet          *devnet.Devnet
+	leader          *ha.Elector                      // a standby sequencer campaigning for the sequencer lease
+	promote         func() (*stagedsync.Sync, error) // builds the sequencer stages of a standby that won the lease
+	sealer          *batch_sealing.Sealer            // decides when a sequencer seals its batches, nil on other roles
+	gasOracle       *gas_price.Oracle                // the gas price a sequencer suggests, nil on other roles
+	control         *sequencer_control.Control       // pauses a sequencer and changes its seal times, nil on other roles
+
+	preconfirmations *preconfirmation.Feed // the preconfirmations the sequencer gave or the node relays
 
 	preStartTasks *PreStartTasks
 }
@@ -316,7 +333,8 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			Events:      shards.NewEvents(),
 			Accumulator: shards.NewAccumulator(),
 		},
-		preStartTasks: &PreStartTasks{},
+		preStartTasks:    &PreStartTasks{},
+		preconfirmations: preconfirmation.NewFeed(),
 	}
 	blockReader, allSnapshots, agg, err := backend.setUpBlockReader(ctx, config.Dirs, config.Snapshot, config.Downloader, backend.notifications.Events, config.TransactionsV3)
 	if err != nil {
@@ -751,10 +769,37 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		// update the chain config with the zero gas from the flags
 		backend.chainConfig.SupportGasless = cfg.Gasless
 
-		l1Urls := strings.Split(cfg.L1RpcUrl, ",")
-		backend.etherManClients = make([]*etherman.Client, len(l1Urls))
-		for i, url := range l1Urls {
-			backend.etherManClients[i] = newEtherMan(cfg, chainConfig.ChainName, url)
+		var ethermanClients []syncer.IEtherman
+		if cfg.Devnet {
+			// the mock L1 only lives in memory so the chain can't carry on from an earlier run
+			if executionProgress > 0 {
+				return nil, fmt.Errorf("the devnet must be started with an empty datadir, found chain at block %d", executionProgress)
+			}
+			backend.devnet, err = devnet.New(devnet.Config{
+				L1ChainId:     cfg.L1ChainId,
+				L2ChainId:     cfg.L2ChainId,
+				RollupId:      cfg.L1RollupId,
+				ForkId:        cfg.SequencerInitialForkId,
+				RollupManager: cfg.AddressRollup,
+				Rollup:        cfg.AddressZkevm,
+				GerManager:    cfg.AddressGerManager,
+				Sequencer:     cfg.AddressSequencer,
+				GenesisRoot:   genesis.Root(),
+				BlockTime:     cfg.DevnetL1BlockTime,
+				RpcAddr:       cfg.DevnetL1RpcAddr,
+			})
+			if err != nil {
+				return nil, err
+			}
+			ethermanClients = []syncer.IEtherman{backend.devnet.L1()}
+		} else {
+			l1Urls := strings.Split(cfg.L1RpcUrl, ",")
+			backend.etherManClients = make([]*etherman.Client, len(l1Urls))
+			ethermanClients = make([]syncer.IEtherman, len(l1Urls))
+			for i, url := range l1Urls {
+				backend.etherManClients[i] = newEtherMan(cfg, chainConfig.ChainName, url)
+				ethermanClients[i] = backend.etherManClients[i].EthClient
+			}
 		}
 
 		isSequencer := sequencer.IsSequencer()
@@ -764,35 +809,15 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			panic("you cannot launch in l1 sync mode as an RPC node")
 		}
 
-		var l1Topics [][]libcommon.Hash
-		var l1Contracts []libcommon.Address
-		if isSequencer {
-			l1Topics = [][]libcommon.Hash{{contracts.InitialSequenceBatchesTopic}}
-			l1Contracts = []libcommon.Address{cfg.AddressZkevm}
-		} else {
-			l1Topics = [][]libcommon.Hash{{
-				contracts.SequencedBatchTopicPreEtrog,
-				contracts.SequencedBatchTopicEtrog,
-				contracts.VerificationTopicPreEtrog,
-				contracts.VerificationTopicEtrog,
-			}}
-			l1Contracts = []libcommon.Address{cfg.AddressRollup, cfg.AddressAdmin, cfg.AddressZkevm}
-		}
+		backend.l1Syncer = newZkL1Syncer(cfg.Zk, ethermanClients, isSequencer)
 
-		ethermanClients := make([]syncer.IEtherman, len(backend.etherManClients))
-		for i, c := range backend.etherManClients {
-			ethermanClients[i] = c.EthClient
+		// a standby seals with it once it takes over
+		if sequencer.NodeRole() == sequencer.RoleSequencer {
+			backend.sealer = batch_sealing.NewSealer(batch_sealing.ConfigFromZk(cfg.Zk))
+			backend.gasOracle = gas_price.NewOracle(gas_price.ConfigFromZk(cfg.Zk), backend.l1Syncer)
+			backend.control = sequencer_control.NewControl(sequencer_control.TimersFromZk(cfg.Zk))
 		}
 
-		backend.l1Syncer = syncer.NewL1Syncer(
-			ethermanClients,
-			l1Contracts,
-			l1Topics,
-			cfg.L1BlockRange,
-			cfg.L1QueryDelay,
-			cfg.L1HighestBlockType,
-		)
-
 		l1InfoTreeSyncer := syncer.NewL1Syncer(
 			ethermanClients,
 			[]libcommon.Address{cfg.AddressGerManager},
@@ -802,74 +827,14 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			cfg.L1HighestBlockType,
 		)
 
+		// the role composes the stages, a sequencer seals the chain and every other role, or a standby until it takes
+		// over, follows a data stream
 		if isSequencer {
 			// if we are sequencing transactions, we do the sequencing loop...
-			witnessGenerator := witness.NewGenerator(
-				config.Dirs,
-				config.HistoryV3,
-				backend.agg,
-				backend.blockReader,
-				backend.chainConfig,
-				backend.engine,
-			)
-
-			var legacyExecutors []legacy_executor_verifier.ILegacyExecutor
-			if len(cfg.ExecutorUrls) > 0 && cfg.ExecutorUrls[0] != "" {
-				levCfg := legacy_executor_verifier.Config{
-					GrpcUrls:              cfg.ExecutorUrls,
-					Timeout:               cfg.ExecutorRequestTimeout,
-					MaxConcurrentRequests: cfg.ExecutorMaxConcurrentRequests,
-					OutputLocation:        cfg.ExecutorPayloadOutput,
-				}
-				executors := legacy_executor_verifier.NewExecutors(levCfg)
-				for _, e := range executors {
-					legacyExecutors = append(legacyExecutors, e)
-				}
+			backend.syncStages, err = backend.newSequencerStages(config, ethermanClients, backend.l1Syncer, l1InfoTreeSyncer, allSnapshots, executionProgress)
+			if err != nil {
+				return nil, err
 			}
-
-			verifier := legacy_executor_verifier.NewLegacyExecutorVerifier(
-				*cfg.Zk,
-				legacyExecutors,
-				backend.chainConfig,
-				backend.chainDB,
-				witnessGenerator,
-				backend.l1Syncer,
-				backend.dataStream,
-			)
-
-			// we need to make sure the pool is always aware of the latest block for when
-			// we switch context from being an RPC node to a sequencer
-			backend.txPool2.ForceUpdateLatestBlock(executionProgress)
-
-			l1BlockSyncer := syncer.NewL1Syncer(
-				ethermanClients,
-				[]libcommon.Address{cfg.AddressZkevm},
-				[][]libcommon.Hash{{contracts.SequenceBatchesTopic}},
-				cfg.L1BlockRange,
-				cfg.L1QueryDelay,
-				cfg.L1HighestBlockType,
-			)
-
-			backend.syncStages = stages2.NewSequencerZkStages(
-				backend.sentryCtx,
-				backend.chainDB,
-				config,
-				backend.sentriesClient,
-				backend.notifications,
-				backend.downloaderClient,
-				allSnapshots,
-				backend.agg,
-				backend.forkValidator,
-				backend.engine,
-				backend.dataStream,
-				backend.l1Syncer,
-				l1InfoTreeSyncer,
-				l1BlockSyncer,
-				backend.txPool2,
-				backend.txPool2DB,
-				verifier,
-			)
-
 			backend.syncUnwindOrder = zkStages.ZkSequencerUnwindOrder
 
 		} else {
@@ -886,6 +851,34 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 
 			streamClient := initDataStreamClient(ctx, cfg.Zk)
 
+			if cfg.IsSequencerStandby() {
+				// a standby follows the leader as an rpc node would until it wins the lease, it then takes over with
+				// the sequencer stages built on the chain it followed
+				leaseBackend, err := ha.NewBackend(cfg.SequencerHaLease)
+				if err != nil {
+					return nil, err
+				}
+				backend.leader = ha.NewElector(leaseBackend, cfg.SequencerHaId, cfg.SequencerHaLeaseTTL)
+				backend.promote = func() (*stagedsync.Sync, error) {
+					streamClient.Stop()
+					progress, err := backend.executionProgress()
+					if err != nil {
+						return nil, err
+					}
+					sequencerStages, err := backend.newSequencerStages(config, ethermanClients, newZkL1Syncer(cfg.Zk, ethermanClients, true), l1InfoTreeSyncer, allSnapshots, progress)
+					if err != nil {
+						return nil, err
+					}
+					return stagedsync.New(sequencerStages, zkStages.ZkSequencerUnwindOrder, backend.syncPruneOrder), nil
+				}
+			}
+
+			// a verifier checks the batches it follows with the executors, it has no stream of its own to write them to
+			var verifier *legacy_executor_verifier.LegacyExecutorVerifier
+			if sequencer.NodeRole() == sequencer.RoleVerifier {
+				verifier = backend.newExecutorVerifier(config, backend.l1Syncer, nil)
+			}
+
 			backend.syncStages = stages2.NewDefaultZkStages(
 				backend.sentryCtx,
 				backend.chainDB,
@@ -901,6 +894,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 				l1InfoTreeSyncer,
 				streamClient,
 				backend.dataStream,
+				verifier,
 			)
 
 			backend.syncUnwindOrder = zkStages.ZkUnwindOrder
@@ -920,6 +914,133 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	return backend, nil
 }
 
+// newZkL1Syncer creates the syncer for the rollup contract logs a sequencer or an rpc node reads from the L1
+func newZkL1Syncer(cfg *ethconfig.Zk, ethermanClients []syncer.IEtherman, isSequencer bool) *syncer.L1Syncer {
+	var l1Topics [][]libcommon.Hash
+	var l1Contracts []libcommon.Address
+	if isSequencer {
+		l1Topics = [][]libcommon.Hash{{contracts.InitialSequenceBatchesTopic, contracts.ForceBatchTopic, contracts.UpdateZkEVMVersionTopic}}
+		l1Contracts = []libcommon.Address{cfg.AddressAdmin, cfg.AddressZkevm}
+	} else {
+		l1Topics = [][]libcommon.Hash{{
+			contracts.SequencedBatchTopicPreEtrog,
+			contracts.SequencedBatchTopicEtrog,
+			contracts.VerificationTopicPreEtrog,
+			contracts.VerificationTopicEtrog,
+			contracts.UpdateZkEVMVersionTopic,
+		}}
+		l1Contracts = []libcommon.Address{cfg.AddressRollup, cfg.AddressAdmin, cfg.AddressZkevm}
+	}
+
+	return syncer.NewL1Syncer(
+		ethermanClients,
+		l1Contracts,
+		l1Topics,
+		cfg.L1BlockRange,
+		cfg.L1QueryDelay,
+		cfg.L1HighestBlockType,
+	)
+}
+
+// newSequencerStages creates the stages of a sequencer, either on start up or when a standby takes over
+func (backend *Ethereum) newSequencerStages(
+	config *ethconfig.Config,
+	ethermanClients []syncer.IEtherman,
+	l1Syncer *syncer.L1Syncer,
+	l1InfoTreeSyncer *syncer.L1Syncer,
+	allSnapshots *snapshotsync.RoSnapshots,
+	executionProgress uint64,
+) ([]*stagedsync.Stage, error) {
+	verifier := backend.newExecutorVerifier(config, l1Syncer, backend.dataStream)
+
+	// we need to make sure the pool is always aware of the latest block for when
+	// we switch context from being an RPC node to a sequencer
+	backend.txPool2.ForceUpdateLatestBlock(executionProgress)
+
+	l1BlockSyncer := syncer.NewL1Syncer(
+		ethermanClients,
+		[]libcommon.Address{config.AddressZkevm},
+		[][]libcommon.Hash{{contracts.SequenceBatchesTopic}},
+		config.L1BlockRange,
+		config.L1QueryDelay,
+		config.L1HighestBlockType,
+	)
+
+	var poolManager *pool_manager.Client
+	if config.SequencerPoolManager {
+		var err error
+		if poolManager, err = pool_manager.NewClient(config.PoolManagerUrl); err != nil {
+			return nil, err
+		}
+	}
+
+	return stages2.NewSequencerZkStages(
+		backend.sentryCtx,
+		backend.chainDB,
+		config,
+		backend.sentriesClient,
+		backend.notifications,
+		backend.downloaderClient,
+		allSnapshots,
+		backend.agg,
+		backend.forkValidator,
+		backend.engine,
+		backend.dataStream,
+		l1Syncer,
+		l1InfoTreeSyncer,
+		l1BlockSyncer,
+		backend.txPool2,
+		backend.txPool2DB,
+		verifier,
+		poolManager,
+		backend.leader,
+		backend.sealer,
+		backend.gasOracle,
+		backend.control,
+		backend.preconfirmations,
+	), nil
+}
+
+// newExecutorVerifier creates the verifier sending batches to the configured executors, it writes the batches they
+// find valid to stream when set, for as long as a highly available sequencer holds the lease
+func (backend *Ethereum) newExecutorVerifier(config *ethconfig.Config, l1Syncer *syncer.L1Syncer, stream *datastreamer.StreamServer) *legacy_executor_verifier.LegacyExecutorVerifier {
+	witnessGenerator := witness.NewGenerator(
+		config.Dirs,
+		config.HistoryV3,
+		backend.agg,
+		backend.blockReader,
+		backend.chainConfig,
+		backend.engine,
+	)
+
+	var legacyExecutors []legacy_executor_verifier.ILegacyExecutor
+	if len(config.ExecutorUrls) > 0 && config.ExecutorUrls[0] != "" {
+		levCfg := legacy_executor_verifier.Config{
+			GrpcUrls:              config.ExecutorUrls,
+			Timeout:               config.ExecutorRequestTimeout,
+			MaxConcurrentRequests: config.ExecutorMaxConcurrentRequests,
+			OutputLocation:        config.ExecutorPayloadOutput,
+		}
+		executors := legacy_executor_verifier.NewExecutors(levCfg)
+		for _, e := range executors {
+			legacyExecutors = append(legacyExecutors, e)
+		}
+	} else if config.ExecutorStateless {
+		legacyExecutors = append(legacyExecutors, stateless_executor.NewExecutor(backend.chainConfig, backend.engine))
+	}
+
+	return legacy_executor_verifier.NewLegacyExecutorVerifier(
+		*config.Zk,
+		legacyExecutors,
+		backend.chainConfig,
+		backend.chainDB,
+		witnessGenerator,
+		l1Syncer,
+		stream,
+		backend.leader,
+	)
+}
+
 // creates an EtherMan instance with default parameters
 func newEtherMan(cfg *ethconfig.Config, l2ChainName, url string) *etherman.Client {
 	ethmanConf := etherman.Config{
@@ -1004,6 +1125,16 @@ func (backend *Ethereum) Init(stack *node.Node, config *ethconfig.Config) error
 	}
 	// start HTTP API
 	httpRpcCfg := stack.Config().Http
+	if config.Zk != nil {
+		httpRpcCfg.RateLimits = rpccfg.RateLimits{
+			Reads:            config.Zk.RpcRateLimits,
+			Calls:            config.Zk.RpcRateLimitsCalls,
+			Heavy:            config.Zk.RpcRateLimitsHeavy,
+			ApiKeys:          config.Zk.RpcRateLimitsApiKeys,
+			ApiKeyMultiplier: config.Zk.RpcRateLimitsApiKeyMultiplier,
+			TrustedProxies:   config.Zk.RpcRateLimitsTrustedProxies,
+		}
+	}
 	ethRpcClient, txPoolRpcClient, miningRpcClient, stateCache, ff, err := cli.EmbeddedServices(ctx, chainKv, httpRpcCfg.StateCache, blockReader, ethBackendRPC, backend.txPool2GrpcServer, miningRPC, stateDiffClient)
 	if err != nil {
 		return err
@@ -1013,8 +1144,12 @@ func (backend *Ethereum) Init(stack *node.Node, config *ethconfig.Config) error
 	if casted, ok := backend.engine.(*bor.Bor); ok {
 		borDb = casted.DB
 	}
-	apiList := commands.APIList(chainKv, borDb, ethRpcClient, txPoolRpcClient, miningRpcClient, ff, stateCache, blockReader, backend.agg, httpRpcCfg, backend.engine, config, backend.l1Syncer)
-	authApiList := commands.AuthAPIList(chainKv, ethRpcClient, txPoolRpcClient, miningRpcClient, ff, stateCache, blockReader, backend.agg, httpRpcCfg, backend.engine, config)
+	apiList := commands.APIList(chainKv, borDb, ethRpcClient, txPoolRpcClient, miningRpcClient, ff, stateCache, blockReader, backend.agg, httpRpcCfg, backend.engine, config, backend.l1Syncer, backend.txPool2.ACL(), backend.preconfirmations)
+	authApiList := commands.AuthAPIList(chainKv, ethRpcClient, txPoolRpcClient, miningRpcClient, ff, stateCache, blockReader, backend.agg, httpRpcCfg, backend.engine, config, backend.txPool2.ACL(), backend.sealer, backend.gasOracle, backend.control)
+	// an rpc node hands out the preconfirmations of the sequencer it follows
+	if config.PreconfirmationsRelayUrl != "" && sequencer.NodeRole() == sequencer.RoleRpc {
+		go preconfirmation.Relay(ctx, config.PreconfirmationsRelayUrl, backend.chainConfig.ChainID.Uint64(), config.PreconfirmationsSequencerAddress, backend.preconfirmations)
+	}
 	go func() {
 		if err := cli.StartRpcServer(ctx, httpRpcCfg, apiList, authApiList); err != nil {
 			log.Error(err.Error())
@@ -1357,11 +1492,119 @@ func (s *Ethereum) Start() error {
 		return nil
 	}
 
+	if s.devnet != nil {
+		if err := s.devnet.Start(s.chainDB); err != nil {
+			return err
+		}
+	}
+
+	if s.leader != nil {
+		go s.runStandby()
+		return nil
+	}
+
 	go stages2.StageLoop(s.sentryCtx, s.chainConfig, s.chainDB, s.stagedSync, s.sentriesClient.Hd, s.notifications, s.sentriesClient.UpdateHead, s.waitForStageLoopStop, s.config.Sync.LoopThrottle)
 
 	return nil
 }
 
+// maxTakeoverCatchupCycles bounds the cycles a standby runs to take in the last blocks of the old leader, a stream
+// that still grows after them is sealed by a sequencer outside the election
+const maxTakeoverCatchupCycles = 10
+
+// runStandby follows the leader like an rpc node until this node wins the sequencer lease, it then takes in what is
+// left of the old leader's stream and carries on the chain as the sequencer for as long as it holds the lease.  The
+// old leader stops sealing before its lease expires so the two never sequence at once.  A sequencer started without
+// the lease never stops, a standby that finds the stream it follows still growing once it won refuses to take over.
+func (s *Ethereum) runStandby() {
+	electCtx, stopElecting := context.WithCancel(s.sentryCtx)
+	defer stopElecting()
+	go s.leader.Run(electCtx)
+
+	followCtx, stopFollowing := context.WithCancel(s.sentryCtx)
+	followDone := make(chan struct{})
+	go stages2.StageLoop(followCtx, s.chainConfig, s.chainDB, s.stagedSync, s.sentriesClient.Hd, s.notifications, s.sentriesClient.UpdateHead, followDone, s.config.Sync.LoopThrottle)
+
+	term, err := s.leader.WaitForLeadership(s.sentryCtx)
+	stopFollowing()
+	<-followDone
+	if err != nil {
+		close(s.waitForStageLoopStop)
+		return
+	}
+	log.Info("[ha] Taking over sequencing", "id", s.leader.Id(), "term", term)
+
+	if s.catchUpWithOldLeader() {
+		log.Error("[ha] The sequencer this node follows is still sealing blocks although the lease is ours, it isn't in the election. Not taking over, every sequencer must be started with the lease", "id", s.leader.Id())
+		// releases the lease, a sequencer that campaigns can then win it
+		stopElecting()
+		close(s.waitForStageLoopStop)
+		return
+	}
+
+	if !s.leader.IsLeader() {
+		log.Error("[ha] Sequencer lease lost during the takeover, restart the node to rejoin as a standby")
+		close(s.waitForStageLoopStop)
+		return
+	}
+	sequencer.Promote()
+	sync, err := s.promote()
+	if err != nil {
+		log.Error("[ha] Failed to take over sequencing", "err", err)
+		close(s.waitForStageLoopStop)
+		return
+	}
+
+	leadCtx, stopLeading := context.WithCancel(s.sentryCtx)
+	defer stopLeading()
+	go func() {
+		select {
+		case <-s.leader.Lost():
+			// the stages already refuse to seal, this stops the loop retrying
+			log.Error("[ha] Sequencer lease lost, stopped sequencing. Restart the node to rejoin as a standby")
+			stopLeading()
+		case <-leadCtx.Done():
+		}
+	}()
+	stages2.StageLoop(leadCtx, s.chainConfig, s.chainDB, sync, s.sentriesClient.Hd, s.notifications, s.sentriesClient.UpdateHead, s.waitForStageLoopStop, s.config.Sync.LoopThrottle)
+}
+
+// catchUpWithOldLeader runs follower cycles until one adds no block, so whatever the old leader streamed before it
+// was fenced is in the chain before this node seals a block of its own.  It gives up when the old leader's stream is
+// gone, the blocks it never streamed are lost to every node so the chain carries on without them.  It returns true
+// when every cycle added blocks, the old leader is then still sealing.
+func (s *Ethereum) catchUpWithOldLeader() (sealing bool) {
+	for i := 0; i < maxTakeoverCatchupCycles; i++ {
+		before, err := s.executionProgress()
+		if err != nil {
+			log.Warn("[ha] Failed to read the chain progress", "err", err)
+			return false
+		}
+		if _, err = stages2.StageLoopStep(s.sentryCtx, s.chainConfig, s.chainDB, s.stagedSync, s.notifications, false, s.sentriesClient.UpdateHead); err != nil {
+			log.Warn("[ha] Stopped catching up with the old leader", "err", err)
+			return false
+		}
+		after, err := s.executionProgress()
+		if err != nil {
+			log.Warn("[ha] Failed to read the chain progress", "err", err)
+			return false
+		}
+		if after == before {
+			return false
+		}
+		log.Info("[ha] Caught up with blocks of the old leader", "from", before+1, "to", after)
+	}
+	return true
+}
+
+func (s *Ethereum) executionProgress() (progress uint64, err error) {
+	err = s.chainDB.View(s.sentryCtx, func(tx kv.Tx) error {
+		progress, err = stages.GetStageProgress(tx, stages.Execution)
+		return err
+	})
+	return progress, err
+}
+
 // Stop implements node.Service, terminating all internal goroutines used by the
 // Ethereum protocol.
 func (s *Ethereum) Stop() error {
@@ -1389,6 +1632,9 @@ func (s *Ethereum) Stop() error {
 
 	_ = s.engine.Close()
 	<-s.waitForStageLoopStop
+	if s.devnet != nil {
+		s.devnet.Stop()
+	}
 	if s.config.Miner.Enabled {
 		<-s.waitForMiningStop
 	}
@@ -1,12 +1,16 @@
 package ethconfig
 
 import (
+	"crypto/ecdsa"
 	"time"
 
 	"github.com/gateway-fm/cdk-erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/zk/sequencer"
 )
 
 type Zk struct {
+	Role                                   sequencer.Role
 	L2ChainId                              uint64
 	L2RpcUrl                               string
 	L2DataStreamerUrl                      string
@@ -20,6 +24,7 @@ type Zk struct {
 	AddressRollup                          common.Address
 	AddressZkevm                           common.Address
 	AddressGerManager                      common.Address
+	AddressDataCommittee                   common.Address
 	L1RollupId                             uint64
 	L1BlockRange                           uint64
 	L1QueryDelay                           uint64
@@ -27,11 +32,26 @@ type Zk struct {
 	L1MaticContractAddress                 common.Address
 	L1FirstBlock                           uint64
 	RpcRateLimits                          int
+	RpcRateLimitsCalls                     int
+	RpcRateLimitsHeavy                     int
+	RpcRateLimitsApiKeys                   []string
+	RpcRateLimitsApiKeyMultiplier          int
+	RpcRateLimitsTrustedProxies            []string
 	DatastreamVersion                      int
 	SequencerInitialForkId                 uint64
 	SequencerBlockSealTime                 time.Duration
 	SequencerBatchSealTime                 time.Duration
 	SequencerNonEmptyBatchSealTime         time.Duration
+	SequencerSealCounterThreshold          uint64
+	SequencerSealL1DataBudget              uint64
+	SequencerSealOnL1InfoTree              bool
+	SequencerTxOrdering                    string
+	SequencerPrioritySenders               []common.Address
+	SequencerMaxTxsPerSender               uint64
+	SequencerPoolManager                   bool
+	SequencerHaLease                       string
+	SequencerHaId                          string
+	SequencerHaLeaseTTL                    time.Duration
 	ExecutorUrls                           []string
 	ExecutorStrictMode                     bool
 	ExecutorRequestTimeout                 time.Duration
@@ -45,6 +65,8 @@ type Zk struct {
 	DefaultGasPrice                        uint64
 	MaxGasPrice                            uint64
 	GasPriceFactor                         float64
+	GasPriceL1History                      int
+	GasPriceCongestionThreshold            uint64
 
 	RebuildTreeAfter    uint64
 	IncrementTreeAlways bool
@@ -58,8 +80,18 @@ type Zk struct {
 	DebugStepAfter uint64
 
 	PoolManagerUrl         string
+	RpcForwardedTxsTTL     time.Duration
 	DisableVirtualCounters bool
 	ExecutorPayloadOutput  string
+	ExecutorStateless      bool
+
+	Devnet            bool
+	DevnetL1RpcAddr   string
+	DevnetL1BlockTime time.Duration
+
+	PreconfirmationsRelayUrl         string
+	PreconfirmationsSequencerAddress common.Address    // the relay drops the preconfirmations not signed by it
+	SequencerPreconfirmationKey      *ecdsa.PrivateKey // signs the preconfirmations of the sequencer, nil gives none
 }
 
 var DefaultZkConfig = &Zk{}
@@ -68,6 +100,13 @@ func (c *Zk) ShouldCountersBeUnlimited(l1Recovery bool) bool {
 	return l1Recovery || (c.DisableVirtualCounters && !c.ExecutorStrictMode && len(c.ExecutorUrls) != 0)
 }
 
+// IsSequencerStandby is true for a node that follows the sequencer until it wins the lease and takes over from it
+func (c *Zk) IsSequencerStandby() bool {
+	return c.SequencerHaLease != ""
+}
+
+// HasExecutors is true when batches are verified, by the executors at the urls or the stateless executor in process.
+// A sequencer then leaves writing the stream to the verifier.
 func (c *Zk) HasExecutors() bool {
-	return len(c.ExecutorUrls) > 0 && c.ExecutorUrls[0] != ""
+	return (len(c.ExecutorUrls) > 0 && c.ExecutorUrls[0] != "") || c.ExecutorStateless
 }
@@ -30,4 +30,5 @@ var (
 	HighestUsedL1InfoIndex      SyncStage = "HighestUsedL1InfoTree"
 	SequenceExecutorVerify      SyncStage = "SequenceExecutorVerify"
 	L1BlockSync                 SyncStage = "L1BlockSync"
+	FollowerExecutorVerify      SyncStage = "FollowerExecutorVerify"
 )
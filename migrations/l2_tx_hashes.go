@@ -0,0 +1,73 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common/datadir"
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/zk/hermez_db"
+	zktx "github.com/ledgerwatch/erigon/zk/tx"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// l2TxHashes indexes the l2 tx hashes of the blocks executed before the index was written with each block
+var l2TxHashes = Migration{
+	Name: "l2_tx_hashes",
+	Up: func(db kv.RwDB, dirs datadir.Dirs, progress []byte, BeforeCommit Callback) (err error) {
+		tx, err := db.BeginRw(context.Background())
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if err = tx.CreateBucket(hermez_db.L2_TX_HASHES); err != nil {
+			return err
+		}
+		hermezDb := hermez_db.NewHermezDb(tx)
+
+		executed, err := stages.GetStageProgress(tx, stages.Execution)
+		if err != nil {
+			return err
+		}
+
+		logEvery := time.NewTicker(30 * time.Second)
+		defer logEvery.Stop()
+		for blockNo := uint64(1); blockNo <= executed; blockNo++ {
+			hash, err := rawdb.ReadCanonicalHash(tx, blockNo)
+			if err != nil {
+				return err
+			}
+			body, err := rawdb.ReadBodyWithTransactions(tx, hash, blockNo)
+			if err != nil {
+				return err
+			}
+			if body == nil {
+				continue
+			}
+			for _, transaction := range body.Transactions {
+				l2TxHash, err := zktx.ComputeL2TxHashForTransaction(transaction)
+				if err != nil {
+					return fmt.Errorf("compute l2 tx hash of %s: %w", transaction.Hash(), err)
+				}
+				if err = hermezDb.WriteL2TxHash(l2TxHash, transaction.Hash()); err != nil {
+					return err
+				}
+			}
+
+			select {
+			case <-logEvery.C:
+				log.Info("[migration] Indexing l2 tx hashes", "block", blockNo, "of", executed)
+			default:
+			}
+		}
+
+		if err := BeforeCommit(tx, nil, true); err != nil {
+			return err
+		}
+		return tx.Commit()
+	},
+}
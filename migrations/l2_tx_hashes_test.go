@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+
+	libcommon "github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/common/u256"
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+	"github.com/gateway-fm/cdk-erigon-lib/kv/memdb"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/zk/hermez_db"
+	zktx "github.com/ledgerwatch/erigon/zk/tx"
+)
+
+func TestL2TxHashes(t *testing.T) {
+	require, tmpDir, db := require.New(t), t.TempDir(), memdb.NewTestDB(t)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(err)
+	to := libcommon.HexToAddress("0x01")
+	signer := types.LatestSignerForChainID(uint256.NewInt(1001).ToBig())
+	txn, err := types.SignTx(types.NewTransaction(0, to, u256.N1, 21000, u256.N1, nil), *signer, key)
+	require.NoError(err)
+
+	err = db.Update(context.Background(), func(tx kv.RwTx) error {
+		hash := libcommon.Hash{1}
+		if err := rawdb.WriteBody(tx, hash, 1, &types.Body{Transactions: []types.Transaction{txn}}); err != nil {
+			return err
+		}
+		if err := rawdb.WriteCanonicalHash(tx, hash, 1); err != nil {
+			return err
+		}
+		return stages.SaveStageProgress(tx, stages.Execution, 1)
+	})
+	require.NoError(err)
+
+	migrator := NewMigrator(kv.ChainDB)
+	migrator.Migrations = []Migration{l2TxHashes}
+	require.NoError(migrator.Apply(db, tmpDir))
+
+	l2TxHash, err := zktx.ComputeL2TxHashForTransaction(txn)
+	require.NoError(err)
+	err = db.View(context.Background(), func(tx kv.Tx) error {
+		txHash, found, err := hermez_db.NewHermezDbReader(tx).GetTxHashByL2TxHash(l2TxHash)
+		require.NoError(err)
+		require.True(found)
+		require.Equal(txn.Hash(), txHash)
+		return nil
+	})
+	require.NoError(err)
+}
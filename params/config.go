@@ -28,8 +28,7 @@ import (
 	"github.com/ledgerwatch/erigon/chain"
 	"github.com/ledgerwatch/erigon/common/paths"
 	"github.com/ledgerwatch/erigon/params/networkname"
-	"os"
-	"github.com/ledgerwatch/erigon/zk/zkchainconfig"
+	"github.com/ledgerwatch/log/v3"
 )
 
 //go:embed chainspecs
@@ -205,31 +204,9 @@ type ConsensusSnapshotConfig struct {
 
 const cliquePath = "clique"
 
-func DynamicChainConfig(ch string) *chain.Config {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		panic(err)
-	}
-
-	basePath := path.Join(homeDir, "dynamic-configs")
-	filename := path.Join(basePath, ch+"-chainspec.json")
-
-	f, err := os.Open(filename)
-	if err != nil {
-		panic(fmt.Sprintf("could not open chainspec for %s: %v", filename, err))
-	}
-	defer f.Close()
-	decoder := json.NewDecoder(f)
-	spec := &chain.Config{}
-	err = decoder.Decode(&spec)
-	if err != nil {
-		panic(fmt.Sprintf("could not parse chainspec for %s: %v", filename, err))
-	}
-
-	chainId := spec.ChainID.Uint64()
-	zkchainconfig.SetDynamicChainDetails(chainId, spec.ChainName)
-
-	return spec
+// DynamicChainConfig returns the chainspec of a dynamic chain
+func DynamicChainConfig(ch string) (*chain.Config, error) {
+	return LoadDynamicChainConfig(ch)
 }
 
 func NewSnapshotConfig(checkpointInterval uint64, inmemorySnapshots int, inmemorySignatures int, inmemory bool, dbPath string) *ConsensusSnapshotConfig {
@@ -285,7 +262,14 @@ func ChainConfigByChainName(chain string) *chain.Config {
 	case networkname.XLayerMainnetChainName:
 		return XLayerMainnetChainConfig
 	default:
-		return DynamicChainConfig(chain)
+		// like any other unknown chain a dynamic chain without a valid config has no chain config, entrypoints
+		// validate the config up front to report why
+		spec, err := DynamicChainConfig(chain)
+		if err != nil {
+			log.Error("Invalid dynamic chain config", "chain", chain, "err", err)
+			return nil
+		}
+		return spec
 	}
 }
 
@@ -0,0 +1,192 @@
+package params
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common/length"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/chain"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/zk/zkchainconfig"
+)
+
+const (
+	DynamicChainspecSuffix = "-chainspec.json"
+	DynamicAllocsSuffix    = "-allocs.json"
+	DynamicConfSuffix      = "-conf.json"
+)
+
+// DynamicConfig is the additional genesis configuration of a dynamic chain held in the conf file
+type DynamicConfig struct {
+	Root       string `json:"root"`
+	Timestamp  uint64 `json:"timestamp"`
+	GasLimit   uint64 `json:"gasLimit"`
+	Difficulty int64  `json:"difficulty"`
+}
+
+// EmbeddedDynamicConfig holds the dynamic config files when they are supplied in the run config file instead of
+// the dynamic config directory
+type EmbeddedDynamicConfig struct {
+	Chainspec []byte
+	Allocs    []byte
+	Conf      []byte
+}
+
+var (
+	dynamicConfigLock     sync.RWMutex
+	dynamicConfigDir      string
+	embeddedDynamicConfig *EmbeddedDynamicConfig
+)
+
+// SetDynamicConfigDir sets the directory the dynamic config files are read from, an empty dir means
+// ~/dynamic-configs
+func SetDynamicConfigDir(dir string) {
+	dynamicConfigLock.Lock()
+	defer dynamicConfigLock.Unlock()
+	dynamicConfigDir = dir
+}
+
+// SetEmbeddedDynamicConfig sets dynamic config files that take priority over the ones in the dynamic config directory
+func SetEmbeddedDynamicConfig(embedded *EmbeddedDynamicConfig) {
+	dynamicConfigLock.Lock()
+	defer dynamicConfigLock.Unlock()
+	embeddedDynamicConfig = embedded
+}
+
+// DynamicConfigDir returns the directory the dynamic config files are read from
+func DynamicConfigDir() (string, error) {
+	dynamicConfigLock.RLock()
+	dir := dynamicConfigDir
+	dynamicConfigLock.RUnlock()
+	if dir != "" {
+		return dir, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find the home directory for dynamic configs, set --zkevm.dynamic-config-dir: %w", err)
+	}
+	return path.Join(homeDir, "dynamic-configs"), nil
+}
+
+// ReadDynamicConfigFile returns the contents of a dynamic config file for the chain, preferring any embedded config.
+// The returned bool is false when the file does not exist.
+func ReadDynamicConfigFile(ch, suffix string) ([]byte, bool, error) {
+	dynamicConfigLock.RLock()
+	embedded := embeddedDynamicConfig
+	dynamicConfigLock.RUnlock()
+
+	if embedded != nil {
+		var content []byte
+		switch suffix {
+		case DynamicChainspecSuffix:
+			content = embedded.Chainspec
+		case DynamicAllocsSuffix:
+			content = embedded.Allocs
+		case DynamicConfSuffix:
+			content = embedded.Conf
+		}
+		if len(content) > 0 {
+			return content, true, nil
+		}
+	}
+
+	dir, err := DynamicConfigDir()
+	if err != nil {
+		return nil, false, err
+	}
+	filename := path.Join(dir, ch+suffix)
+	content, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("could not read %s: %w", filename, err)
+	}
+	return content, true, nil
+}
+
+// LoadDynamicChainConfig reads and validates the chainspec of a dynamic chain
+func LoadDynamicChainConfig(ch string) (*chain.Config, error) {
+	content, found, err := ReadDynamicConfigFile(ch, DynamicChainspecSuffix)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, MissingDynamicConfigError(ch, DynamicChainspecSuffix)
+	}
+
+	spec := &chain.Config{}
+	if err = decodeWarnUnknown(ch+DynamicChainspecSuffix, content, spec); err != nil {
+		return nil, fmt.Errorf("invalid chainspec for %s: %w", ch, err)
+	}
+	if spec.ChainID == nil || spec.ChainID.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid chainspec for %s: chainId must be set", ch)
+	}
+	if spec.ChainName == "" {
+		spec.ChainName = ch
+	} else if spec.ChainName != ch {
+		log.Warn("The ChainName of the dynamic chainspec doesn't match the chain, it should be changed to the chain name", "chain", ch, "ChainName", spec.ChainName)
+	}
+
+	zkchainconfig.SetDynamicChainDetails(spec.ChainID.Uint64(), spec.ChainName)
+
+	return spec, nil
+}
+
+// LoadDynamicConf reads and validates the conf file of a dynamic chain, the conf file is optional so an empty config
+// is returned when it does not exist
+func LoadDynamicConf(ch string) (*DynamicConfig, error) {
+	conf := &DynamicConfig{}
+
+	content, found, err := ReadDynamicConfigFile(ch, DynamicConfSuffix)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return conf, nil
+	}
+
+	if err = decodeWarnUnknown(ch+DynamicConfSuffix, content, conf); err != nil {
+		return nil, fmt.Errorf("invalid conf for %s: %w", ch, err)
+	}
+	if conf.Root != "" {
+		if b, err := hexutil.Decode(conf.Root); err != nil || len(b) != length.Hash {
+			return nil, fmt.Errorf("invalid conf for %s: root %q is not a 32 byte hex hash", ch, conf.Root)
+		}
+	}
+	if conf.Difficulty < 0 {
+		return nil, fmt.Errorf("invalid conf for %s: difficulty must not be negative", ch)
+	}
+
+	return conf, nil
+}
+
+// MissingDynamicConfigError is returned when a required dynamic config file does not exist
+func MissingDynamicConfigError(ch, suffix string) error {
+	dir, err := DynamicConfigDir()
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("no %s found for dynamic chain %s, expected %s, set --zkevm.dynamic-config-dir or embed it under zkevm.dynamic-config in the config file", suffix[1:], ch, path.Join(dir, ch+suffix))
+}
+
+// decodeWarnUnknown decodes json warning about unknown fields so typos in the config files are reported, files with
+// fields erigon doesn't know of still load as they always have
+func decodeWarnUnknown(name string, content []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(v)
+	if err == nil || !strings.HasPrefix(err.Error(), "json: unknown field") {
+		return err
+	}
+	log.Warn(fmt.Sprintf("Ignoring %s in the dynamic config file %s", strings.TrimPrefix(err.Error(), "json: "), name))
+	return json.Unmarshal(content, v)
+}
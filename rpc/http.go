@@ -254,7 +254,7 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if !s.disableStreaming {
 		stream = jsoniter.NewStream(jsoniter.ConfigDefault, w, 4096)
 	}
-	s.serveSingleRequest(ctx, codec, stream)
+	s.serveSingleRequest(ctx, codec, stream, s.rateLimiter.client(r))
 }
 
 // validateRequest returns a non-zero response code and error message if the
@@ -0,0 +1,241 @@
+package rpc
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/VictoriaMetrics/metrics"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"golang.org/x/time/rate"
+
+	"github.com/ledgerwatch/erigon/rpc/rpccfg"
+)
+
+// ApiKeyHeader is the header a client sends its api key in
+const ApiKeyHeader = "X-Api-Key"
+
+// the headers a trusted proxy tells the client it forwards for in
+const (
+	forwardedForHeader = "X-Forwarded-For"
+	realIPHeader       = "X-Real-IP"
+)
+
+// rateLimitClients bounds the clients whose buckets are kept, the least recently seen are forgotten first
+const rateLimitClients = 100_000
+
+type rateClass int
+
+const (
+	rateClassReads rateClass = iota
+	rateClassCalls
+	rateClassHeavy
+	rateClasses
+)
+
+func (c rateClass) String() string {
+	switch c {
+	case rateClassCalls:
+		return "calls"
+	case rateClassHeavy:
+		return "heavy"
+	default:
+		return "reads"
+	}
+}
+
+var rateLimitedCounters = [rateClasses]*metrics.Counter{
+	rateClassReads: metrics.GetOrCreateCounter(`rpc_rate_limited{class="reads"}`),
+	rateClassCalls: metrics.GetOrCreateCounter(`rpc_rate_limited{class="calls"}`),
+	rateClassHeavy: metrics.GetOrCreateCounter(`rpc_rate_limited{class="heavy"}`),
+}
+
+func methodRateClass(method string) rateClass {
+	switch method {
+	case "eth_call", "eth_estimateGas", "eth_createAccessList":
+		return rateClassCalls
+	case "zkevm_getWitness", "zkevm_getBlockRangeWitness", "zkevm_getBatchWitness", "zkevm_getProverInput":
+		return rateClassHeavy
+	}
+	if strings.HasPrefix(method, "debug_trace") || strings.HasPrefix(method, "trace_") {
+		return rateClassHeavy
+	}
+	return rateClassReads
+}
+
+// RateLimiter keeps a token bucket for each class of method of each client, a bucket holds a second of requests
+type RateLimiter struct {
+	limits       [rateClasses]rate.Limit
+	apiKeyLimits [rateClasses]rate.Limit
+	apiKeys      map[string]struct{}
+	proxies      []*net.IPNet
+	buckets      *lru.Cache[string, *[rateClasses]*rate.Limiter]
+}
+
+// NewRateLimiter creates the limiter of the limits, nil if none is set.  The calls and heavy limits default to the
+// reads limit.
+func NewRateLimiter(cfg rpccfg.RateLimits) (*RateLimiter, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+	if cfg.Reads < 0 || cfg.Calls < 0 || cfg.Heavy < 0 || cfg.ApiKeyMultiplier < 0 {
+		return nil, fmt.Errorf("rate limits can't be negative")
+	}
+	buckets, err := lru.New[string, *[rateClasses]*rate.Limiter](rateLimitClients)
+	if err != nil {
+		return nil, err
+	}
+
+	multiplier := cfg.ApiKeyMultiplier
+	if multiplier == 0 {
+		multiplier = 1
+	}
+	l := &RateLimiter{apiKeys: make(map[string]struct{}, len(cfg.ApiKeys)), buckets: buckets}
+	for class, limit := range [rateClasses]int{cfg.Reads, cfg.Calls, cfg.Heavy} {
+		if limit == 0 {
+			limit = cfg.Reads
+		}
+		if limit == 0 {
+			l.limits[class], l.apiKeyLimits[class] = rate.Inf, rate.Inf
+			continue
+		}
+		l.limits[class] = rate.Limit(limit)
+		l.apiKeyLimits[class] = rate.Limit(limit * multiplier)
+	}
+	for _, key := range cfg.ApiKeys {
+		if key != "" {
+			l.apiKeys[key] = struct{}{}
+		}
+	}
+	for _, proxy := range cfg.TrustedProxies {
+		network, err := parseIPNet(proxy)
+		if err != nil {
+			return nil, err
+		}
+		l.proxies = append(l.proxies, network)
+	}
+	return l, nil
+}
+
+// parseIPNet parses a CIDR range or a single IP address as the range of just that address
+func parseIPNet(s string) (*net.IPNet, error) {
+	if strings.Contains(s, "/") {
+		_, network, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", s, err)
+		}
+		return network, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid trusted proxy %q: not an IP address or CIDR range", s)
+	}
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 8*net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+func (l *RateLimiter) trusted(ip net.IP) bool {
+	for _, network := range l.proxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP is the IP address of the client of a request.  A request from a trusted proxy is of the nearest address in
+// X-Forwarded-For that isn't a trusted proxy, or of X-Real-IP without one, the headers of anyone else could be forged.
+func (l *RateLimiter) clientIP(r *http.Request) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	ip := net.ParseIP(remote)
+	if ip == nil || !l.trusted(ip) {
+		return remote
+	}
+
+	// each proxy appends the address it got the request from, so the client is the last one no proxy added
+	var hops []string
+	for _, header := range r.Header.Values(forwardedForHeader) {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			// the client can put anything in the header, what's before a bad address can't be trusted
+			break
+		}
+		if i == 0 || !l.trusted(hop) {
+			return hop.String()
+		}
+	}
+	if hop := net.ParseIP(strings.TrimSpace(r.Header.Get(realIPHeader))); hop != nil {
+		return hop.String()
+	}
+	return remote
+}
+
+// rateLimitedClient is who the requests of a connection are limited as
+type rateLimitedClient struct {
+	limiter *RateLimiter
+	id      string
+	apiKey  bool
+}
+
+// client is the client of a request, by its api key if the limiter knows it and by its IP address otherwise, see
+// clientIP.  A nil limiter limits no one.
+func (l *RateLimiter) client(r *http.Request) *rateLimitedClient {
+	if l == nil {
+		return nil
+	}
+	if key := r.Header.Get(ApiKeyHeader); key != "" {
+		if _, ok := l.apiKeys[key]; ok {
+			return &rateLimitedClient{limiter: l, id: "key:" + key, apiKey: true}
+		}
+	}
+	return &rateLimitedClient{limiter: l, id: "ip:" + l.clientIP(r)}
+}
+
+// allow takes a token from the bucket of the method's class, a nil client is never limited
+func (c *rateLimitedClient) allow(method string) bool {
+	if c == nil {
+		return true
+	}
+	class := methodRateClass(method)
+	limits := &c.limiter.limits
+	if c.apiKey {
+		limits = &c.limiter.apiKeyLimits
+	}
+	if limits[class] == rate.Inf {
+		return true
+	}
+
+	buckets, ok := c.limiter.buckets.Get(c.id)
+	if !ok {
+		buckets = new([rateClasses]*rate.Limiter)
+		for i, limit := range limits {
+			buckets[i] = rate.NewLimiter(limit, burst(limit))
+		}
+		// another request of the client may have added its buckets meanwhile, keep those
+		if previous, found, _ := c.limiter.buckets.PeekOrAdd(c.id, buckets); found {
+			buckets = previous
+		}
+	}
+	if buckets[class].Allow() {
+		return true
+	}
+	rateLimitedCounters[class].Inc()
+	return false
+}
+
+func burst(limit rate.Limit) int {
+	if limit == rate.Inf || limit < 1 {
+		return 1
+	}
+	return int(limit)
+}
@@ -0,0 +1,107 @@
+package rpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/rpc/rpccfg"
+)
+
+func TestRateLimiter(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	// the test methods again in a namespace of the heavy class
+	require.NoError(t, server.RegisterName("trace", new(testService)))
+
+	limiter, err := NewRateLimiter(rpccfg.RateLimits{Reads: 2, Heavy: 1, ApiKeys: []string{"partner"}, ApiKeyMultiplier: 2})
+	require.NoError(t, err)
+	server.SetRateLimiter(limiter)
+
+	// call returns the error code of the response, 0 for a result
+	call := func(remote, apiKey, method string) int {
+		request := httptest.NewRequest(http.MethodPost, "http://url.com", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"`+method+`"}`))
+		request.Header.Set("Content-Type", contentType)
+		request.RemoteAddr = remote
+		if apiKey != "" {
+			request.Header.Set(ApiKeyHeader, apiKey)
+		}
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+
+		var response jsonrpcMessage
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		if response.Error == nil {
+			return 0
+		}
+		return response.Error.Code
+	}
+
+	// the reads limit of the IP address, each IP address has its own
+	require.Equal(t, 0, call("10.0.0.1:1000", "", "test_noArgsRets"))
+	require.Equal(t, 0, call("10.0.0.1:1001", "", "test_noArgsRets"))
+	require.Equal(t, -32005, call("10.0.0.1:1002", "", "test_noArgsRets"))
+	require.Equal(t, 0, call("10.0.0.2:1000", "", "test_noArgsRets"))
+
+	// the heavy methods have a limit of their own
+	require.Equal(t, 0, call("10.0.0.2:1000", "", "trace_noArgsRets"))
+	require.Equal(t, -32005, call("10.0.0.2:1000", "", "trace_noArgsRets"))
+	require.Equal(t, 0, call("10.0.0.2:1000", "", "test_noArgsRets"))
+
+	// a known api key has its own limits, multiplied, an unknown one is limited by IP address
+	for i := 0; i < 4; i++ {
+		require.Equal(t, 0, call("10.0.0.1:1000", "partner", "test_noArgsRets"))
+	}
+	require.Equal(t, -32005, call("10.0.0.1:1000", "partner", "test_noArgsRets"))
+	require.Equal(t, -32005, call("10.0.0.1:1000", "unknown", "test_noArgsRets"))
+}
+
+func TestMethodRateClass(t *testing.T) {
+	require.Equal(t, rateClassReads, methodRateClass("eth_blockNumber"))
+	require.Equal(t, rateClassCalls, methodRateClass("eth_call"))
+	require.Equal(t, rateClassCalls, methodRateClass("eth_estimateGas"))
+	require.Equal(t, rateClassHeavy, methodRateClass("zkevm_getBatchWitness"))
+	require.Equal(t, rateClassHeavy, methodRateClass("debug_traceTransaction"))
+	require.Equal(t, rateClassHeavy, methodRateClass("trace_block"))
+}
+
+func TestNewRateLimiterDisabled(t *testing.T) {
+	limiter, err := NewRateLimiter(rpccfg.RateLimits{ApiKeys: []string{"partner"}})
+	require.NoError(t, err)
+	require.Nil(t, limiter)
+	require.True(t, limiter.client(httptest.NewRequest(http.MethodPost, "http://url.com", nil)).allow("eth_call"))
+}
+
+func TestRateLimiterClientIP(t *testing.T) {
+	limiter, err := NewRateLimiter(rpccfg.RateLimits{Reads: 1, TrustedProxies: []string{"10.0.0.1", "192.168.0.0/16"}})
+	require.NoError(t, err)
+
+	clientIP := func(remote string, headers map[string]string) string {
+		request := httptest.NewRequest(http.MethodPost, "http://url.com", nil)
+		request.RemoteAddr = remote
+		for name, value := range headers {
+			request.Header.Set(name, value)
+		}
+		return limiter.clientIP(request)
+	}
+
+	// the headers of a client that isn't a trusted proxy are ignored
+	require.Equal(t, "1.1.1.1", clientIP("1.1.1.1:1000", nil))
+	require.Equal(t, "1.1.1.1", clientIP("1.1.1.1:1000", map[string]string{forwardedForHeader: "2.2.2.2", realIPHeader: "2.2.2.2"}))
+
+	// a trusted proxy forwards for the last address no proxy added, what the client put before it can't be trusted
+	require.Equal(t, "2.2.2.2", clientIP("10.0.0.1:1000", map[string]string{forwardedForHeader: "2.2.2.2"}))
+	require.Equal(t, "2.2.2.2", clientIP("10.0.0.1:1000", map[string]string{forwardedForHeader: "3.3.3.3, 2.2.2.2, 192.168.1.1"}))
+	require.Equal(t, "2.2.2.2", clientIP("10.0.0.1:1000", map[string]string{forwardedForHeader: "forged, 2.2.2.2"}))
+	require.Equal(t, "2.2.2.2", clientIP("10.0.0.1:1000", map[string]string{realIPHeader: "2.2.2.2"}))
+
+	// without a forwarded address the proxy is the client
+	require.Equal(t, "10.0.0.1", clientIP("10.0.0.1:1000", nil))
+
+	_, err = NewRateLimiter(rpccfg.RateLimits{Reads: 1, TrustedProxies: []string{"proxy.local"}})
+	require.Error(t, err)
+}
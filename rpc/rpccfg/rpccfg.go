@@ -37,3 +37,27 @@ var DefaultHTTPTimeouts = HTTPTimeouts{
 }
 
 const DefaultEvmCallTimeout = 5 * time.Minute
+
+// RateLimits are the requests per second each client may make to the JSON-RPC server, by class of method.  A client
+// is the IP address of the connection, or of the client a trusted proxy forwards for, or its api key if it has one of
+// ApiKeys.  A limit of 0 is no limit.
+type RateLimits struct {
+	Reads int // every method not in another class
+	Calls int // eth_call, eth_estimateGas and eth_createAccessList
+	Heavy int // witness, prover input and trace methods
+
+	// ApiKeys are the api keys clients can send in the X-Api-Key header to be limited on their own rather than with
+	// the other clients of their IP address, at ApiKeyMultiplier times the limits
+	ApiKeys          []string
+	ApiKeyMultiplier int
+
+	// TrustedProxies are the IP addresses and CIDR ranges of the proxies in front of the server, the X-Forwarded-For
+	// and X-Real-IP headers of their requests tell the client they forward for.  Without them every client behind a
+	// proxy shares its limits.
+	TrustedProxies []string
+}
+
+// Enabled tells whether any limit is set
+func (l RateLimits) Enabled() bool {
+	return l.Reads > 0 || l.Calls > 0 || l.Heavy > 0
+}
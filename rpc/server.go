@@ -46,6 +46,7 @@ const (
 type Server struct {
 	services        serviceRegistry
 	methodAllowList AllowList
+	rateLimiter     *RateLimiter
 	idgen           func() ID
 	run             int32
 	codecs          mapset.Set
@@ -71,6 +72,11 @@ func (s *Server) SetAllowList(allowList AllowList) {
 	s.methodAllowList = allowList
 }
 
+// SetRateLimiter sets the rate limiter of the HTTP and websocket requests, nil limits nothing
+func (s *Server) SetRateLimiter(rateLimiter *RateLimiter) {
+	s.rateLimiter = rateLimiter
+}
+
 // SetBatchLimit sets limit of number of requests in a batch
 func (s *Server) SetBatchLimit(limit int) {
 	s.batchLimit = limit
@@ -90,6 +96,11 @@ func (s *Server) RegisterName(name string, receiver interface{}) error {
 //
 // Note that codec options are no longer supported.
 func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
+	s.serveCodec(codec, nil)
+}
+
+// serveCodec serves a codec with its requests limited as the client, nil for no limit
+func (s *Server) serveCodec(codec ServerCodec, rateLimit *rateLimitedClient) {
 	defer codec.close()
 
 	// Don't serve if server is stopped.
@@ -101,7 +112,7 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 	s.codecs.Add(codec)
 	defer s.codecs.Remove(codec)
 
-	c := initClient(codec, s.idgen, &s.services)
+	c := initClient(codec, s.idgen, &s.services, rateLimit)
 	<-codec.closed()
 	c.Close()
 }
@@ -109,7 +120,7 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 // serveSingleRequest reads and processes a single RPC request from the given codec. This
 // is used to serve HTTP connections. Subscriptions and reverse calls are not allowed in
 // this mode.
-func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec, stream *jsoniter.Stream) {
+func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec, stream *jsoniter.Stream, rateLimit *rateLimitedClient) {
 	// Don't serve if server is stopped.
 	if atomic.LoadInt32(&s.run) == 0 {
 		return
@@ -117,6 +128,7 @@ func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec, stre
 
 	h := newHandler(ctx, codec, s.idgen, &s.services, s.methodAllowList, s.batchConcurrency, s.traceRequests)
 	h.allowSubscribe = false
+	h.rateLimit = rateLimit
 	defer h.close(io.EOF, nil)
 
 	reqs, batch, err := codec.readBatch()
@@ -2,6 +2,8 @@ package smt
 
 import (
 	"context"
+	"fmt"
+	"math/big"
 
 	libcommon "github.com/gateway-fm/cdk-erigon-lib/common"
 	"github.com/ledgerwatch/erigon/smt/pkg/utils"
@@ -112,3 +114,130 @@ func BuildWitness(s *SMT, rd trie.RetainDecider, ctx context.Context) (*trie.Wit
 
 	return trie.NewWitness(operands), err
 }
+
+// BuildSMTfromWitness rebuilds a partial SMT from a witness produced by BuildWitness.  Subtrees that were not
+// retained in the witness are kept as hash-only nodes, so the root of the returned tree matches the root of the
+// tree the witness was generated from and the retained paths can be modified as usual.
+func BuildSMTfromWitness(w *trie.Witness) (*SMT, error) {
+	s := NewSMT(nil)
+
+	b := &witnessTreeBuilder{s: s, operators: w.Operators}
+	root, err := b.buildNode(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.position != len(b.operators) {
+		return nil, fmt.Errorf("unexpected witness operators after the root node: consumed %d of %d", b.position, len(b.operators))
+	}
+
+	s.SetLastRoot(root.ToBigInt())
+
+	return s, nil
+}
+
+type codeAdder interface {
+	AddCode(code []byte) error
+}
+
+type witnessTreeBuilder struct {
+	s         *SMT
+	operators []trie.WitnessOperator
+	position  int
+}
+
+// buildNode consumes the operators for the node at the given depth and returns its hash
+func (b *witnessTreeBuilder) buildNode(depth int) (utils.NodeKey, error) {
+	if b.position >= len(b.operators) {
+		// an empty witness describes an empty tree
+		if depth == 0 {
+			return utils.NodeKey{}, nil
+		}
+		return utils.NodeKey{}, fmt.Errorf("unexpected end of witness at depth %d", depth)
+	}
+
+	operator := b.operators[b.position]
+	b.position++
+
+	switch op := operator.(type) {
+	case *trie.OperatorHash:
+		return utils.ScalarToRoot(op.Hash.Big()), nil
+	case *trie.OperatorCode:
+		if adder, ok := b.s.Db.(codeAdder); ok {
+			if err := adder.AddCode(op.Code); err != nil {
+				return utils.NodeKey{}, err
+			}
+		}
+		// the code operator always precedes the leaf holding the code hash
+		return b.buildNode(depth)
+	case *trie.OperatorSMTLeafValue:
+		return b.buildLeaf(op, depth)
+	case *trie.OperatorBranch:
+		var left, right utils.NodeKey
+		var err error
+		if op.Mask&1 != 0 {
+			if left, err = b.buildNode(depth + 1); err != nil {
+				return utils.NodeKey{}, err
+			}
+		}
+		if op.Mask&2 != 0 {
+			if right, err = b.buildNode(depth + 1); err != nil {
+				return utils.NodeKey{}, err
+			}
+		}
+		return b.s.hashcalcAndSave(utils.ConcatArrays4(left, right), utils.BranchCapacity)
+	default:
+		return utils.NodeKey{}, fmt.Errorf("unsupported witness operator %T", operator)
+	}
+}
+
+func (b *witnessTreeBuilder) buildLeaf(op *trie.OperatorSMTLeafValue, depth int) (utils.NodeKey, error) {
+	address := libcommon.BytesToAddress(op.Address)
+	storageKey := libcommon.BytesToHash(op.StorageKey)
+	ethAddr := address.String()
+
+	var key utils.NodeKey
+	var err error
+	switch int(op.NodeType) {
+	case utils.KEY_BALANCE:
+		key, err = utils.KeyEthAddrBalance(ethAddr)
+	case utils.KEY_NONCE:
+		key, err = utils.KeyEthAddrNonce(ethAddr)
+	case utils.SC_CODE:
+		key, err = utils.KeyContractCode(ethAddr)
+	case utils.SC_LENGTH:
+		key, err = utils.KeyContractLength(ethAddr)
+	case utils.SC_STORAGE:
+		key, err = utils.KeyContractStorage(utils.ScalarToArrayBig(utils.ConvertHexToBigInt(ethAddr)), storageKey.String())
+	default:
+		return utils.NodeKey{}, fmt.Errorf("unknown smt leaf type %d", op.NodeType)
+	}
+	if err != nil {
+		return utils.NodeKey{}, err
+	}
+
+	value, err := utils.NodeValue8FromBigInt(new(big.Int).SetBytes(op.Value))
+	if err != nil {
+		return utils.NodeKey{}, err
+	}
+
+	valueHash, err := b.s.hashcalcAndSave(value.ToUintArray(), utils.BranchCapacity)
+	if err != nil {
+		return utils.NodeKey{}, err
+	}
+
+	remainingKey := utils.RemoveKeyBits(key, depth)
+	leafHash, err := b.s.hashcalcAndSave(utils.ConcatArrays4(remainingKey, valueHash), utils.LeafCapacity)
+	if err != nil {
+		return utils.NodeKey{}, err
+	}
+
+	if err = b.s.Db.InsertHashKey(leafHash, key); err != nil {
+		return utils.NodeKey{}, err
+	}
+	if err = b.s.Db.InsertKeySource(key, utils.EncodeKeySource(int(op.NodeType), address, storageKey)); err != nil {
+		return utils.NodeKey{}, err
+	}
+
+	return leafHash, nil
+}
@@ -158,3 +158,47 @@ func TestSMTWitnessRetainListEmptyVal(t *testing.T) {
 		t.Errorf("witness contains unexpected operator")
 	}
 }
+
+func TestBuildSMTfromWitness(t *testing.T) {
+	smtTrie, rl := prepareSMT(t)
+
+	witness, err := smt.BuildWitness(smtTrie, rl, context.Background())
+	if err != nil {
+		t.Fatalf("error building witness: %v", err)
+	}
+
+	rebuilt, err := smt.BuildSMTfromWitness(witness)
+	if err != nil {
+		t.Fatalf("error building smt from witness: %v", err)
+	}
+
+	if smtTrie.LastRoot().Cmp(rebuilt.LastRoot()) != 0 {
+		t.Fatalf("roots do not match: expected %x, got %x", smtTrie.LastRoot(), rebuilt.LastRoot())
+	}
+
+	// changing a retained value must give the same root in both trees
+	contract := libcommon.HexToAddress("0x71dd1027069078091B3ca48093B00E4735B20624")
+	balance := uint256.NewInt(42)
+
+	if _, err = smtTrie.SetAccountState(contract.String(), balance.ToBig(), uint256.NewInt(2).ToBig()); err != nil {
+		t.Fatalf("error updating original tree: %v", err)
+	}
+	if _, err = rebuilt.SetAccountState(contract.String(), balance.ToBig(), uint256.NewInt(2).ToBig()); err != nil {
+		t.Fatalf("error updating rebuilt tree: %v", err)
+	}
+
+	if smtTrie.LastRoot().Cmp(rebuilt.LastRoot()) != 0 {
+		t.Fatalf("roots do not match after update: expected %x, got %x", smtTrie.LastRoot(), rebuilt.LastRoot())
+	}
+}
+
+func TestBuildSMTfromWitnessEmpty(t *testing.T) {
+	rebuilt, err := smt.BuildSMTfromWitness(trie.NewWitness([]trie.WitnessOperator{}))
+	if err != nil {
+		t.Fatalf("error building smt from witness: %v", err)
+	}
+
+	if rebuilt.LastRoot().Sign() != 0 {
+		t.Fatalf("expected empty root, got %x", rebuilt.LastRoot())
+	}
+}
@@ -0,0 +1,36 @@
+package tests
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ledgerwatch/erigon/chain"
+	"github.com/ledgerwatch/erigon/zk/constants"
+)
+
+// zkChainConfig is the chain config a zkEVM state test runs on.  The zkEVM is Berlin with the fork ids on top, each
+// fork id switched on at genesis with the ones before it.  Only etrog onwards is supported, before it every
+// transaction is a block of its own and the system contract holds the root after each of them.
+func zkChainConfig(forkId uint64, chainId int64) (*chain.Config, error) {
+	if forkId < uint64(constants.ForkID7Etrog) {
+		return nil, UnsupportedForkError{fmt.Sprintf("ForkID%d", forkId)}
+	}
+	config := &chain.Config{
+		ChainID:               big.NewInt(chainId),
+		HomesteadBlock:        big.NewInt(0),
+		TangerineWhistleBlock: big.NewInt(0),
+		SpuriousDragonBlock:   big.NewInt(0),
+		ByzantiumBlock:        big.NewInt(0),
+		ConstantinopleBlock:   big.NewInt(0),
+		PetersburgBlock:       big.NewInt(0),
+		IstanbulBlock:         big.NewInt(0),
+		MuirGlacierBlock:      big.NewInt(0),
+		BerlinBlock:           big.NewInt(0),
+	}
+	for id := constants.ForkID4; id <= constants.ForkId(forkId); id++ {
+		if err := config.SetForkIdBlock(id, 0); err != nil {
+			return nil, UnsupportedForkError{fmt.Sprintf("ForkID%d", forkId)}
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,379 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	libcommon "github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/common/hexutility"
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+
+	"github.com/ledgerwatch/erigon/chain"
+	"github.com/ledgerwatch/erigon/common/math"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/core/vm/evmtypes"
+	"github.com/ledgerwatch/erigon/smt/pkg/blockinfo"
+	smtdb "github.com/ledgerwatch/erigon/smt/pkg/db"
+	dstypes "github.com/ledgerwatch/erigon/zk/datastream/types"
+	"github.com/ledgerwatch/erigon/zk/sequencer"
+	zkStages "github.com/ledgerwatch/erigon/zk/stages"
+	zktx "github.com/ledgerwatch/erigon/zk/tx"
+	zkUtils "github.com/ledgerwatch/erigon/zk/utils"
+)
+
+// changeL2BlockTxType is the type the test vectors give the entry starting a block in the batch
+const changeL2BlockTxType = 11
+
+// ZkStateTest is a state transition test vector of the zkEVM, in the format of the Polygon zkevm-testvectors: a
+// genesis, a batch of blocks run on it and the SMT roots before and after as the prover computes them.  Each
+// transaction of the batch has the reason the ROM rejects it with, empty if it is executed, and the vector has the
+// counters the batch uses.
+type ZkStateTest struct {
+	json stZkJSON
+}
+
+// ZkStateResult is the outcome of running a zkEVM state test
+type ZkStateResult struct {
+	Root libcommon.Hash
+	// Exceptions are the reasons the transactions of the batch were rejected with, in batch order
+	Exceptions []string
+	Counters   vm.Counters
+}
+
+func (t *ZkStateTest) UnmarshalJSON(in []byte) error {
+	return json.Unmarshal(in, &t.json)
+}
+
+// Name is the id and description of the test vector
+func (t *ZkStateTest) Name() string {
+	return fmt.Sprintf("%d %s", t.json.Id, t.json.Description)
+}
+
+type stZkJSON struct {
+	Id               int               `json:"id"`
+	Description      string            `json:"description"`
+	ChainId          int64             `json:"chainID"`
+	ForkId           uint64            `json:"forkID"`
+	SequencerAddress libcommon.Address `json:"sequencerAddress"`
+	Genesis          []stZkAccount     `json:"genesis"`
+	ExpectedOldRoot  libcommon.Hash    `json:"expectedOldRoot"`
+	ExpectedNewRoot  libcommon.Hash    `json:"expectedNewRoot"`
+	Txs              []stZkTransaction `json:"txs"`
+	BatchL2Data      hexutility.Bytes  `json:"batchL2Data"`
+	VirtualCounters  *stZkCounters     `json:"virtualCounters"`
+	SmtDepths        []int             `json:"smtDepths"`
+}
+
+type stZkAccount struct {
+	Address  libcommon.Address                 `json:"address"`
+	Balance  *math.HexOrDecimal256             `json:"balance"`
+	Nonce    math.HexOrDecimal64               `json:"nonce"`
+	Bytecode hexutility.Bytes                  `json:"bytecode"`
+	Storage  map[libcommon.Hash]libcommon.Hash `json:"storage"`
+}
+
+// stZkTransaction is an entry of the batch, either a transaction or the change of block before the transactions of
+// a block.  Entries without customRawTx were left out of the batch data by the vector.
+type stZkTransaction struct {
+	Type            int              `json:"type"`
+	L1Info          *stZkL1Info      `json:"l1Info"`
+	IndexL1InfoTree uint32           `json:"indexL1InfoTree"`
+	CustomRawTx     hexutility.Bytes `json:"customRawTx"`
+	Reason          string           `json:"reason"`
+}
+
+type stZkL1Info struct {
+	GlobalExitRoot libcommon.Hash `json:"globalExitRoot"`
+	BlockHash      libcommon.Hash `json:"blockHash"`
+}
+
+// stZkCounters are the counters of a batch, named the way the zkevm test vectors name them
+type stZkCounters struct {
+	Steps    int `json:"steps"`
+	Arith    int `json:"arith"`
+	Binary   int `json:"binary"`
+	MemAlign int `json:"memAlign"`
+	Keccaks  int `json:"keccaks"`
+	Padding  int `json:"padding"`
+	Poseidon int `json:"poseidon"`
+	Sha256   int `json:"sha256"`
+}
+
+// romReasons are the reasons the ROM rejects a transaction with, keyed by the error erigon rejects it with
+var romReasons = []struct {
+	err    error
+	reason string
+}{
+	{core.ErrNonceTooLow, "TX INVALID: Invalid nonce"},
+	{core.ErrNonceTooHigh, "TX INVALID: Invalid nonce"},
+	{core.ErrInsufficientFunds, "TX INVALID: Not enough funds to pay total transaction cost"},
+	{types.ErrInvalidChainId, "TX INVALID: Chain ID does not match"},
+}
+
+// romReason is the reason the ROM rejects a transaction with for the error erigon rejected it with, the error itself
+// for those the ROM has no reason for so a mismatch shows what happened
+func romReason(err error) string {
+	for _, r := range romReasons {
+		if errors.Is(err, r.err) {
+			return r.reason
+		}
+	}
+	return err.Error()
+}
+
+// Run executes the test vector and verifies the SMT root, the reason every transaction was rejected with and the
+// counters.  Counters are only collected by a sequencer so the node has to have the sequencer role.
+func (t *ZkStateTest) Run(tx kv.RwTx, vmconfig vm.Config) (*ZkStateResult, error) {
+	result, err := t.RunNoVerify(tx, vmconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	var reasons []string
+	for _, entry := range t.json.Txs {
+		if entry.Type != changeL2BlockTxType && len(entry.CustomRawTx) > 0 {
+			reasons = append(reasons, entry.Reason)
+		}
+	}
+	if len(reasons) != len(result.Exceptions) {
+		return result, fmt.Errorf("the batch has %d transactions, the vector %d", len(result.Exceptions), len(reasons))
+	}
+	for i := range reasons {
+		if result.Exceptions[i] != reasons[i] {
+			return result, fmt.Errorf("transaction %d exception mismatch: got %q, want %q", i, result.Exceptions[i], reasons[i])
+		}
+	}
+	if result.Root != t.json.ExpectedNewRoot {
+		return result, fmt.Errorf("post state SMT root mismatch: got %x, want %x", result.Root, t.json.ExpectedNewRoot)
+	}
+	if t.json.VirtualCounters != nil {
+		if err := t.json.VirtualCounters.check(result.Counters); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// RunNoVerify writes the genesis of the vector and runs its batch the way the sequencer does: every block starts by
+// writing the block number, timestamp, previous root and global exit root to the system contracts and ends by
+// writing the root of its block info tree.  It returns the SMT root after the batch.
+func (t *ZkStateTest) RunNoVerify(tx kv.RwTx, vmconfig vm.Config) (*ZkStateResult, error) {
+	config, err := zkChainConfig(t.json.ForkId, t.json.ChainId)
+	if err != nil {
+		return nil, err
+	}
+	if t.json.VirtualCounters != nil && !sequencer.IsSequencer() {
+		return nil, errors.New("counters are only collected by a sequencer, set the sequencer role")
+	}
+
+	for _, table := range kv.ChaindataTables {
+		if err := tx.CreateBucket(table); err != nil {
+			return nil, err
+		}
+	}
+	if err := smtdb.CreateEriDbBuckets(tx); err != nil {
+		return nil, err
+	}
+
+	_, _, sparseTree, err := core.WriteGenesisState(t.genesis(config), tx, "")
+	if err != nil {
+		return nil, err
+	}
+	prevRoot := libcommon.BigToHash(sparseTree.LastRoot())
+	if prevRoot != t.json.ExpectedOldRoot {
+		return nil, fmt.Errorf("genesis SMT root mismatch: got %x, want %x", prevRoot, t.json.ExpectedOldRoot)
+	}
+
+	blocks, err := zktx.DecodeBatchL2Blocks(t.json.BatchL2Data, t.json.ForkId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid batchL2Data: %w", err)
+	}
+	var l1Infos []*stZkL1Info
+	for _, entry := range t.json.Txs {
+		if entry.Type == changeL2BlockTxType {
+			l1Infos = append(l1Infos, entry.L1Info)
+		}
+	}
+	if len(l1Infos) != len(blocks) {
+		return nil, fmt.Errorf("the batch has %d blocks, the vector %d", len(blocks), len(l1Infos))
+	}
+
+	// the smt depths are per entry of the batch, block changes included, as the sequencer saw the tree before it
+	smtDepths := t.json.SmtDepths
+	depth := func(entry int) int {
+		if entry < len(smtDepths) {
+			return smtDepths[entry]
+		}
+		return sparseTree.GetDepth()
+	}
+	batchCounters := vm.NewBatchCounterCollector(depth(0), uint16(t.json.ForkId), false)
+
+	result := &ZkStateResult{}
+	entry := 0
+	for i, block := range blocks {
+		var ger, l1BlockHash libcommon.Hash
+		// index 0 of the l1 info tree leaves the global exit root as it is
+		if block.L1InfoTreeIndex != 0 && l1Infos[i] != nil {
+			ger, l1BlockHash = l1Infos[i].GlobalExitRoot, l1Infos[i].BlockHash
+		}
+		if prevRoot, err = t.runBlock(tx, config, vmconfig, block, ger, l1BlockHash, prevRoot, batchCounters, depth, &entry, result); err != nil {
+			return nil, fmt.Errorf("block %d: %w", i, err)
+		}
+	}
+
+	if result.Counters, err = batchCounters.CombineCollectors(); err != nil {
+		return nil, err
+	}
+	result.Root = prevRoot
+	return result, nil
+}
+
+func (t *ZkStateTest) runBlock(
+	tx kv.RwTx,
+	config *chain.Config,
+	vmconfig vm.Config,
+	block zktx.DecodedBatchL2Data,
+	ger, l1BlockHash, prevRoot libcommon.Hash,
+	batchCounters *vm.BatchCounterCollector,
+	depth func(entry int) int,
+	entry *int,
+	result *ZkStateResult,
+) (libcommon.Hash, error) {
+	ibs := state.New(state.NewPlainStateReader(tx))
+
+	blockNumber := ibs.GetBlockNumber().Uint64() + 1
+	header := &types.Header{
+		Number:     new(big.Int).SetUint64(blockNumber),
+		Time:       ibs.ScalableGetTimestamp() + uint64(block.DeltaTimestamp),
+		Coinbase:   t.json.SequencerAddress,
+		GasLimit:   zkUtils.GetBlockGasLimitForFork(t.json.ForkId),
+		Difficulty: new(big.Int),
+	}
+
+	var gerUpdates []dstypes.GerUpdate
+	ibs.SyncerPreExecuteStateSet(config, blockNumber, header.Time, &prevRoot, &ger, &l1BlockHash, &gerUpdates, false)
+	if _, err := batchCounters.StartNewBlock(); err != nil {
+		return libcommon.Hash{}, err
+	}
+	*entry++
+
+	// block hashes are served from the system contract storage by the zkevm interpreter
+	getHashFn := func(n uint64) libcommon.Hash { return libcommon.Hash{} }
+	blockContext := core.NewEVMBlockContext(header, getHashFn, nil, &t.json.SequencerAddress, nil)
+	gasPool := new(core.GasPool).AddGas(header.GasLimit)
+	signer := types.MakeSigner(config, blockNumber)
+
+	var receipts types.Receipts
+	var transactions types.Transactions
+	var txInfos []blockinfo.ExecutedTxInfo
+	for j, transaction := range block.Transactions {
+		effectiveGas := block.EffectiveGasPricePercentages[j]
+
+		txCounters := vm.NewTransactionCounter(transaction, depth(*entry), false)
+		*entry++
+		if _, err := batchCounters.AddNewTransactionCounters(txCounters); err != nil {
+			return libcommon.Hash{}, err
+		}
+		zkConfig := vm.NewZkConfig(vmconfig, txCounters.ExecutionCounters())
+
+		ibs.Prepare(transaction.Hash(), libcommon.Hash{}, len(transactions))
+		evm := vm.NewZkEVM(blockContext, evmtypes.TxContext{}, ibs, config, zkConfig)
+		snapshot := ibs.Snapshot()
+		receipt, execResult, err := core.ApplyTransaction_zkevm(config, nil, evm, gasPool, ibs, state.NewNoopWriter(), header, transaction, &header.GasUsed, effectiveGas)
+		if err != nil {
+			// the ROM skips a transaction it rejects, the vector has the reason it does
+			ibs.RevertToSnapshot(snapshot)
+			result.Exceptions = append(result.Exceptions, romReason(err))
+			continue
+		}
+		result.Exceptions = append(result.Exceptions, "")
+		if err = txCounters.ProcessTx(ibs, execResult.ReturnData); err != nil {
+			return libcommon.Hash{}, err
+		}
+
+		sender, err := transaction.Sender(*signer)
+		if err != nil {
+			return libcommon.Hash{}, err
+		}
+		receipts = append(receipts, receipt)
+		transactions = append(transactions, transaction)
+		txInfos = append(txInfos, blockinfo.ExecutedTxInfo{
+			Tx:                transaction,
+			EffectiveGasPrice: effectiveGas,
+			Receipt:           receipt,
+			Signer:            &sender,
+		})
+	}
+
+	blockInfoRoot, err := blockinfo.BuildBlockInfoTree(&header.Coinbase, blockNumber, header.Time, header.GasLimit, header.GasUsed, ger, l1BlockHash, prevRoot, &txInfos)
+	if err != nil {
+		return libcommon.Hash{}, err
+	}
+	ibs.PostExecuteStateSet(config, blockNumber, blockInfoRoot)
+
+	w := state.NewPlainStateWriter(tx, nil, blockNumber)
+	if err = ibs.CommitBlock(config.Rules(blockNumber, header.Time), w); err != nil {
+		return libcommon.Hash{}, err
+	}
+	return smtRoot(tx)
+}
+
+// smtRoot is the SMT root of the plain state, computed from scratch
+func smtRoot(tx kv.RwTx) (libcommon.Hash, error) {
+	for _, table := range []string{smtdb.TableSmt, smtdb.TableStats, smtdb.TableAccountValues, smtdb.TableMetadata, smtdb.TableHashKey} {
+		if err := tx.ClearBucket(table); err != nil {
+			return libcommon.Hash{}, err
+		}
+	}
+	root, err := zkStages.RegenerateSmtRoot("zkevm-state-test", tx)
+	if err != nil {
+		return libcommon.Hash{}, fmt.Errorf("error calculating SMT root: %w", err)
+	}
+	return root, nil
+}
+
+func (t *ZkStateTest) genesis(config *chain.Config) *types.Genesis {
+	alloc := make(types.GenesisAlloc, len(t.json.Genesis))
+	for _, account := range t.json.Genesis {
+		alloc[account.Address] = types.GenesisAccount{
+			Balance: (*big.Int)(account.Balance),
+			Nonce:   uint64(account.Nonce),
+			Code:    account.Bytecode,
+			Storage: account.Storage,
+		}
+	}
+	return &types.Genesis{Config: config, Alloc: alloc}
+}
+
+func (c *stZkCounters) check(counters vm.Counters) error {
+	expected := []struct {
+		key  vm.CounterKey
+		want int
+	}{
+		{vm.S, c.Steps},
+		{vm.A, c.Arith},
+		{vm.B, c.Binary},
+		{vm.M, c.MemAlign},
+		{vm.K, c.Keccaks},
+		{vm.D, c.Padding},
+		{vm.P, c.Poseidon},
+		{vm.SHA, c.Sha256},
+	}
+
+	var mismatches []string
+	for _, e := range expected {
+		if got := counters[e.key].Used(); got != e.want {
+			mismatches = append(mismatches, fmt.Sprintf("%s=%d:%d", e.key, got, e.want))
+		}
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("counters mismatch (got:want): %s", strings.Join(mismatches, " "))
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gateway-fm/cdk-erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/zk/sequencer"
+)
+
+// zkStateTestDir holds vectors of the Polygon zkevm-testvectors, whose expected SMT roots and counters come from the
+// zkEVM prover rather than from erigon itself.  ZKEVM_STATE_TESTS points the test at a checkout of more of them.
+var zkStateTestDir = filepath.Join("..", "zk", "tests", "testdata")
+
+func TestZkState(t *testing.T) {
+	defer log.Root().SetHandler(log.Root().GetHandler())
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlError, log.StderrHandler))
+
+	// counters are only collected by a sequencer
+	role := sequencer.NodeRole()
+	sequencer.SetRole(sequencer.RoleSequencer, false)
+	defer sequencer.SetRole(role, false)
+
+	dir := zkStateTestDir
+	if env := os.Getenv("ZKEVM_STATE_TESTS"); env != "" {
+		dir = env
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("no zkEVM state tests in %s", dir)
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tests []ZkStateTest
+		if err := json.Unmarshal(data, &tests); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+
+		for _, test := range tests {
+			test := test
+			t.Run(filepath.Base(file)+"/"+test.Name(), func(t *testing.T) {
+				db := memdb.NewTestDB(t)
+				tx, err := db.BeginRw(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer tx.Rollback()
+
+				if _, err := test.Run(tx, vm.Config{}); err != nil {
+					t.Fatal(err)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/0xPolygonHermez/zkevm-data-streamer/datastreamer"
+	dslog "github.com/0xPolygonHermez/zkevm-data-streamer/log"
+	libcommon "github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/common/datadir"
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+	"github.com/gateway-fm/cdk-erigon-lib/kv/mdbx"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ledgerwatch/erigon/cmd/hack/tool/fromdb"
+	"github.com/ledgerwatch/erigon/cmd/utils"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/eth/stagedsync"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/turbo/debug"
+	"github.com/ledgerwatch/erigon/turbo/logging"
+	"github.com/ledgerwatch/erigon/zk/datastream/archive"
+	"github.com/ledgerwatch/erigon/zk/datastream/client"
+	"github.com/ledgerwatch/erigon/zk/datastream/server"
+	"github.com/ledgerwatch/erigon/zk/hermez_db"
+	zkStages "github.com/ledgerwatch/erigon/zk/stages"
+)
+
+var (
+	DatastreamRepairFromFlag = cli.Uint64Flag{
+		Name:  "from",
+		Usage: "Block to write the stream again from, defaults to the block of the first divergence",
+	}
+	DatastreamRebuildFlag = cli.BoolFlag{
+		Name:  "rebuild",
+		Usage: "Write the whole stream again from genesis",
+	}
+	DatastreamFromBatchFlag = cli.Uint64Flag{
+		Name:  "from-batch",
+		Usage: "First batch to export",
+	}
+	DatastreamToBatchFlag = cli.Uint64Flag{
+		Name:  "to-batch",
+		Usage: "Last batch to export, defaults to the last complete batch of the stream",
+	}
+	DatastreamArchiveFlag = cli.StringFlag{
+		Name:     "archive",
+		Usage:    "Path of the datastream archive",
+		Required: true,
+	}
+	DatastreamCompressFlag = cli.BoolFlag{
+		Name:  "compress",
+		Usage: "Gzip the entries of the archive",
+	}
+)
+
+var datastreamCommand = cli.Command{
+	Name:        "datastream",
+	Description: `Checking and repairing the data stream file against the chain db, the node has to be stopped`,
+	Subcommands: []*cli.Command{
+		{
+			Name:   "inspect",
+			Action: doDatastreamInspect,
+			Usage:  "Check the order of the stream entries and compare every block in the stream against the db",
+			Before: func(ctx *cli.Context) error { return debug.Setup(ctx) },
+			Flags: joinFlags([]cli.Flag{
+				&utils.DataDirFlag,
+				&utils.DatastreamVersionFlag,
+			}, debug.Flags, logging.Flags),
+		},
+		{
+			Name:   "repair",
+			Action: doDatastreamRepair,
+			Usage:  "Unwind the stream to its first divergence from the db and write the blocks from there again",
+			Before: func(ctx *cli.Context) error { return debug.Setup(ctx) },
+			Flags: joinFlags([]cli.Flag{
+				&utils.DataDirFlag,
+				&utils.DatastreamVersionFlag,
+				&DatastreamRepairFromFlag,
+				&DatastreamRebuildFlag,
+			}, debug.Flags, logging.Flags),
+		},
+		{
+			Name:   "export",
+			Action: doDatastreamExport,
+			Usage:  "Export a range of whole batches of the stream to a checksummed archive",
+			Before: func(ctx *cli.Context) error { return debug.Setup(ctx) },
+			Flags: joinFlags([]cli.Flag{
+				&utils.DataDirFlag,
+				&utils.DatastreamVersionFlag,
+				&DatastreamFromBatchFlag,
+				&DatastreamToBatchFlag,
+				&DatastreamArchiveFlag,
+				&DatastreamCompressFlag,
+			}, debug.Flags, logging.Flags),
+		},
+		{
+			Name:   "import",
+			Action: doDatastreamImport,
+			Usage:  "Verify an archive and run its blocks through the batches stage of an rpc node",
+			Before: func(ctx *cli.Context) error { return debug.Setup(ctx) },
+			Flags: joinFlags([]cli.Flag{
+				&utils.DataDirFlag,
+				&utils.DatastreamVersionFlag,
+				&DatastreamArchiveFlag,
+			}, debug.Flags, logging.Flags),
+		},
+	},
+}
+
+func doDatastreamInspect(cliCtx *cli.Context) error {
+	dirs := datadir.New(cliCtx.String(utils.DataDirFlag.Name))
+	chainDB := mdbx.NewMDBX(log.New()).Path(dirs.Chaindata).Readonly().MustOpen()
+	defer chainDB.Close()
+
+	srv, err := openDatastream(cliCtx, dirs, chainDB)
+	if err != nil {
+		return err
+	}
+
+	tx, err := chainDB.BeginRo(cliCtx.Context)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := srv.Check(tx, "datastream")
+	if err != nil {
+		return err
+	}
+	log.Info("Stream checked", "entries", result.TotalEntries, "batches", result.Batches, "blocks", result.Blocks,
+		"transactions", result.Transactions, "highestBlock", result.HighestBlock)
+	if result.Divergence != nil {
+		return fmt.Errorf("stream diverges at %s", result.Divergence)
+	}
+	return nil
+}
+
+func doDatastreamRepair(cliCtx *cli.Context) error {
+	dirs := datadir.New(cliCtx.String(utils.DataDirFlag.Name))
+	chainDB := mdbx.NewMDBX(log.New()).Path(dirs.Chaindata).MustOpen()
+	defer chainDB.Close()
+
+	srv, err := openDatastream(cliCtx, dirs, chainDB)
+	if err != nil {
+		return err
+	}
+
+	tx, err := chainDB.BeginRw(cliCtx.Context)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var from uint64
+	switch {
+	case cliCtx.Bool(DatastreamRebuildFlag.Name):
+		from = 0
+	case cliCtx.IsSet(DatastreamRepairFromFlag.Name):
+		from = cliCtx.Uint64(DatastreamRepairFromFlag.Name)
+	default:
+		result, err := srv.Check(tx, "datastream")
+		if err != nil {
+			return err
+		}
+		if result.Divergence == nil {
+			log.Info("Stream matches the db, nothing to repair", "highestBlock", result.HighestBlock)
+			return nil
+		}
+		log.Warn("Stream diverges", "at", result.Divergence)
+		from = result.Divergence.BlockNum
+	}
+
+	to, err := srv.Repair(tx, from, "datastream")
+	if err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	log.Info("Stream repaired", "from", from, "to", to)
+	return nil
+}
+
+func doDatastreamExport(cliCtx *cli.Context) error {
+	dirs := datadir.New(cliCtx.String(utils.DataDirFlag.Name))
+	chainDB := mdbx.NewMDBX(log.New()).Path(dirs.Chaindata).Readonly().MustOpen()
+	defer chainDB.Close()
+
+	srv, err := openDatastream(cliCtx, dirs, chainDB)
+	if err != nil {
+		return err
+	}
+
+	fromBatch := cliCtx.Uint64(DatastreamFromBatchFlag.Name)
+	toBatch := cliCtx.Uint64(DatastreamToBatchFlag.Name)
+	if !cliCtx.IsSet(DatastreamToBatchFlag.Name) {
+		if toBatch, err = srv.GetHighestClosedBatch(); err != nil {
+			return err
+		}
+	}
+	compression := archive.CompressionNone
+	if cliCtx.Bool(DatastreamCompressFlag.Name) {
+		compression = archive.CompressionGzip
+	}
+
+	path := cliCtx.String(DatastreamArchiveFlag.Name)
+	header, err := srv.ExportArchive(path, fromBatch, toBatch, compression)
+	if err != nil {
+		return err
+	}
+	log.Info("Stream exported", "archive", path, "fromBatch", header.FromBatch, "toBatch", header.ToBatch,
+		"entries", header.Entries, "bytes", header.BodyLength+archive.HeaderSize)
+	return nil
+}
+
+func doDatastreamImport(cliCtx *cli.Context) error {
+	ctx := cliCtx.Context
+	dirs := datadir.New(cliCtx.String(utils.DataDirFlag.Name))
+	chainDB := mdbx.NewMDBX(log.New()).Path(dirs.Chaindata).MustOpen()
+	defer chainDB.Close()
+
+	path := cliCtx.String(DatastreamArchiveFlag.Name)
+	// verifies the checksum before anything is written to the db
+	r, err := archive.Open(path)
+	if err != nil {
+		return err
+	}
+	r.Close()
+
+	tx, err := chainDB.BeginRw(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	genesis, err := rawdb.ReadCanonicalHash(tx, 0)
+	if err != nil {
+		return err
+	}
+	if genesis == (libcommon.Hash{}) {
+		return fmt.Errorf("no genesis in %s, start the node once to initialise the db", dirs.Chaindata)
+	}
+	chainId := fromdb.ChainConfig(chainDB).ChainID.Uint64()
+	if r.Header.ChainId != chainId {
+		return fmt.Errorf("archive is for chain %d, the db is for chain %d", r.Header.ChainId, chainId)
+	}
+
+	progress, err := stages.GetStageProgress(tx, stages.Batches)
+	if err != nil {
+		return err
+	}
+	batch, err := hermez_db.NewHermezDbReader(tx).GetBatchNoByL2Block(progress)
+	if err != nil {
+		return err
+	}
+	if r.Header.ToBatch <= batch {
+		log.Info("The db already has the batches of the archive", "batch", batch, "archiveToBatch", r.Header.ToBatch)
+		return nil
+	}
+	if progress > 0 && r.Header.FromBatch > batch+1 {
+		return fmt.Errorf("archive starts at batch %d, the db is at batch %d", r.Header.FromBatch, batch)
+	}
+
+	dsClient := client.NewClient(ctx, client.FileScheme+path, cliCtx.Int(utils.DatastreamVersionFlag.Name), 0)
+	if err = dsClient.Start(); err != nil {
+		return err
+	}
+	defer dsClient.Stop()
+
+	cfg := zkStages.StageBatchesCfg(chainDB, dsClient, &ethconfig.Zk{L2ChainId: chainId})
+	s := &stagedsync.StageState{ID: stages.Batches, BlockNumber: progress}
+	if err = zkStages.SpawnStageBatches(s, &stagedsync.Sync{}, ctx, tx, cfg, true, true); err != nil {
+		return err
+	}
+
+	imported, err := stages.GetStageProgress(tx, stages.Batches)
+	if err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	log.Info("Archive imported, start the node to execute the blocks", "fromBlock", progress+1, "toBlock", imported)
+	return nil
+}
+
+// openDatastream opens the stream file of the datadir without serving it
+func openDatastream(cliCtx *cli.Context, dirs datadir.Dirs, chainDB kv.RoDB) (*server.DataStreamServer, error) {
+	file := filepath.Join(dirs.DataDir, "data-stream")
+	if _, err := os.Stat(file); err != nil {
+		return nil, fmt.Errorf("no data stream file in %s: %w", dirs.DataDir, err)
+	}
+	chainConfig := fromdb.ChainConfig(chainDB)
+
+	logConfig := &dslog.Config{
+		Environment: "production",
+		Level:       "warn",
+		Outputs:     nil,
+	}
+	stream, err := datastreamer.NewServer(0, uint8(cliCtx.Int(utils.DatastreamVersionFlag.Name)), 1, datastreamer.StreamType(1), file, logConfig)
+	if err != nil {
+		return nil, err
+	}
+	return server.NewDataStreamServer(stream, chainConfig.ChainID.Uint64(), server.StandardOperationMode), nil
+}
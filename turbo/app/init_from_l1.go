@@ -0,0 +1,66 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	libcommon "github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ledgerwatch/erigon/cmd/utils"
+	cli2 "github.com/ledgerwatch/erigon/turbo/cli"
+)
+
+var (
+	dynamicConfigsDirFlag = cli.StringFlag{
+		Name:  "output-dir",
+		Usage: "Directory the dynamic config files are written to, defaults to ~/dynamic-configs",
+	}
+	overwriteFlag = cli.BoolFlag{
+		Name:  "overwrite",
+		Usage: "Overwrite existing dynamic config files for the chain",
+	}
+)
+
+var initFromL1Command = cli.Command{
+	Action:    MigrateFlags(initFromL1),
+	Name:      "init-from-l1",
+	Usage:     "Bootstrap the dynamic config files of a new CDK chain from the L1 rollup contracts",
+	ArgsUsage: "<genesisPath>",
+	Flags: []cli.Flag{
+		&utils.ChainFlag,
+		&utils.L1RpcUrlFlag,
+		&utils.AddressRollupFlag,
+		&utils.L1RollupIdFlag,
+		&dynamicConfigsDirFlag,
+		&overwriteFlag,
+	},
+	Category: "BLOCKCHAIN COMMANDS",
+	Description: `
+The init-from-l1 command creates the chainspec, allocs and conf files for a dynamic chain.
+
+It expects the genesis.json produced by the CDK contracts deployment as argument.  The
+chain id, fork id, genesis root and trusted sequencer are read from the rollup manager
+on L1 and the genesis root is checked against the SMT root computed from the allocs.  The
+chainspec activates the forks up to the fork id of the rollup from genesis.`,
+}
+
+func initFromL1(cliCtx *cli.Context) error {
+	genesisPath := cliCtx.Args().First()
+	if len(genesisPath) == 0 {
+		return fmt.Errorf("must supply path to the CDK genesis JSON file")
+	}
+
+	cfg := cli2.InitFromL1Config{
+		Chain:       cliCtx.String(utils.ChainFlag.Name),
+		L1RpcUrl:    cliCtx.String(utils.L1RpcUrlFlag.Name),
+		GenesisPath: genesisPath,
+		RollupId:    cliCtx.Uint64(utils.L1RollupIdFlag.Name),
+		OutputDir:   cliCtx.String(dynamicConfigsDirFlag.Name),
+		Overwrite:   cliCtx.Bool(overwriteFlag.Name),
+	}
+	if cliCtx.IsSet(utils.AddressRollupFlag.Name) {
+		cfg.RollupManager = libcommon.HexToAddress(cliCtx.String(utils.AddressRollupFlag.Name))
+	}
+	return cli2.InitFromL1(context.Background(), cfg)
+}
@@ -164,6 +164,7 @@ var DefaultFlags = []cli.Flag{
 	&utils.SentinelAddrFlag,
 	&utils.SentinelPortFlag,
 
+	&utils.NodeRoleFlag,
 	&utils.L2ChainIdFlag,
 	&utils.L2RpcUrlFlag,
 	&utils.L2DataStreamerUrlFlag,
@@ -177,13 +178,20 @@ var DefaultFlags = []cli.Flag{
 	&utils.AddressRollupFlag,
 	&utils.AddressZkevmFlag,
 	&utils.AddressGerManagerFlag,
+	&utils.AddressDataCommitteeFlag,
 	&utils.L1RollupIdFlag,
 	&utils.L1BlockRangeFlag,
 	&utils.L1QueryDelayFlag,
 	&utils.L1HighestBlockTypeFlag,
 	&utils.L1MaticContractAddressFlag,
 	&utils.L1FirstBlockFlag,
+	&utils.DynamicConfigDirFlag,
 	&utils.RpcRateLimitsFlag,
+	&utils.RpcRateLimitsCallsFlag,
+	&utils.RpcRateLimitsHeavyFlag,
+	&utils.RpcRateLimitsApiKeysFlag,
+	&utils.RpcRateLimitsApiKeyMultiplierFlag,
+	&utils.RpcRateLimitsTrustedProxiesFlag,
 	&utils.DatastreamVersionFlag,
 	&utils.RebuildTreeAfterFlag,
 	&utils.IncrementTreeAlways,
@@ -191,6 +199,16 @@ var DefaultFlags = []cli.Flag{
 	&utils.SequencerBlockSealTime,
 	&utils.SequencerBatchSealTime,
 	&utils.SequencerNonEmptyBatchSealTime,
+	&utils.SequencerSealCounterThreshold,
+	&utils.SequencerSealL1DataBudget,
+	&utils.SequencerSealOnL1InfoTree,
+	&utils.SequencerTxOrdering,
+	&utils.SequencerPrioritySenders,
+	&utils.SequencerMaxTxsPerSender,
+	&utils.SequencerPoolManager,
+	&utils.SequencerHaLease,
+	&utils.SequencerHaId,
+	&utils.SequencerHaLeaseTTL,
 	&utils.ExecutorUrls,
 	&utils.ExecutorStrictMode,
 	&utils.ExecutorRequestTimeout,
@@ -204,16 +222,26 @@ var DefaultFlags = []cli.Flag{
 	&utils.DefaultGasPrice,
 	&utils.MaxGasPrice,
 	&utils.GasPriceFactor,
+	&utils.GasPriceL1HistoryFlag,
+	&utils.GasPriceCongestionThresholdFlag,
 	&utils.DataStreamHost,
 	&utils.DataStreamPort,
 	&utils.WitnessFullFlag,
 	&utils.SyncLimit,
 	&utils.SupportGasless,
 	&utils.ExecutorPayloadOutput,
+	&utils.ExecutorStateless,
+	&utils.DevnetFlag,
+	&utils.DevnetL1RpcAddrFlag,
+	&utils.DevnetL1BlockTimeFlag,
 	&utils.DebugNoSync,
 	&utils.DebugLimit,
 	&utils.DebugStep,
 	&utils.DebugStepAfter,
 	&utils.PoolManagerUrl,
+	&utils.RpcForwardedTxsTTL,
+	&utils.SequencerPreconfirmationKey,
+	&utils.PreconfirmationsRelayUrl,
+	&utils.PreconfirmationsSequencerAddress,
 	&utils.DisableVirtualCounters,
 }
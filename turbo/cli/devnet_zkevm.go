@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/ledgerwatch/erigon/cmd/utils"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/params/networkname"
+	"github.com/ledgerwatch/erigon/zk/devnet"
+	"github.com/ledgerwatch/erigon/zk/sequencer"
+)
+
+// ApplyDevnetFlags fills in the flags a devnet needs that have not been set, so --zkevm.devnet on its own runs a
+// sequencer of the hermez-dev chain against the in-process mock L1.  It has to run after ApplyDynamicChainConfig so
+// dynamic chains can be used for a devnet too.
+func ApplyDevnetFlags(ctx *cli.Context) error {
+	if !ctx.Bool(utils.DevnetFlag.Name) {
+		return nil
+	}
+
+	setDefault := func(name, value string) error {
+		if ctx.IsSet(name) {
+			return nil
+		}
+		return ctx.Set(name, value)
+	}
+
+	// a devnet always sequences, there is no other node to sync from
+	if role := ctx.String(utils.NodeRoleFlag.Name); role != "" && role != string(sequencer.RoleSequencer) {
+		return fmt.Errorf("a devnet is run by a sequencer, it can't be started with --%s=%s", utils.NodeRoleFlag.Name, role)
+	}
+	if err := setDefault(utils.NodeRoleFlag.Name, string(sequencer.RoleSequencer)); err != nil {
+		return err
+	}
+	sequencer.SetRole(sequencer.RoleSequencer, false)
+
+	if err := setDefault(utils.ChainFlag.Name, networkname.HermezLocalDevnetChainName); err != nil {
+		return err
+	}
+	chainName := ctx.String(utils.ChainFlag.Name)
+	chainConfig := params.ChainConfigByChainName(chainName)
+	if chainConfig == nil && !ctx.IsSet(utils.L2ChainIdFlag.Name) {
+		return fmt.Errorf("unknown chain %s for the devnet, set --%s", chainName, utils.L2ChainIdFlag.Name)
+	}
+
+	l1RpcUrl := "in-process"
+	if addr := ctx.String(utils.DevnetL1RpcAddrFlag.Name); addr != "" {
+		l1RpcUrl = "http://" + addr
+	}
+
+	type flagDefault struct {
+		name  string
+		value string
+	}
+	defaults := []flagDefault{
+		{utils.L1ChainIdFlag.Name, strconv.FormatUint(devnet.DefaultL1ChainId, 10)},
+		{utils.L1RpcUrlFlag.Name, l1RpcUrl},
+		{utils.L1FirstBlockFlag.Name, "1"},
+		{utils.L1QueryDelayFlag.Name, strconv.FormatInt(ctx.Duration(utils.DevnetL1BlockTimeFlag.Name).Milliseconds(), 10)},
+		{utils.AddressSequencerFlag.Name, devnet.SequencerAddress.Hex()},
+		{utils.AddressAdminFlag.Name, devnet.AdminAddress.Hex()},
+		{utils.AddressRollupFlag.Name, devnet.RollupManagerAddress.Hex()},
+		{utils.AddressZkevmFlag.Name, devnet.RollupAddress.Hex()},
+		{utils.AddressGerManagerFlag.Name, devnet.GerManagerAddress.Hex()},
+	}
+	if chainConfig != nil {
+		defaults = append(defaults, flagDefault{utils.L2ChainIdFlag.Name, chainConfig.ChainID.String()})
+	}
+	// without executors the batches are verified in process
+	if !ctx.IsSet(utils.ExecutorUrls.Name) {
+		defaults = append(defaults, flagDefault{utils.ExecutorStateless.Name, "true"})
+	}
+
+	for _, d := range defaults {
+		if err := setDefault(d.name, d.value); err != nil {
+			return fmt.Errorf("could not set --%s for the devnet: %w", d.name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/ledgerwatch/erigon/cmd/utils"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/params"
+)
+
+// DynamicConfigKey is the key in the run config file the dynamic config files can be embedded under as JSON strings
+// in chainspec, allocs and conf fields
+const DynamicConfigKey = "zkevm.dynamic-config"
+
+// ApplyDynamicChainConfig points dynamic chains at the configured config directory and validates the config files
+// up front, so a missing or malformed file is reported as an error instead of a panic during node startup
+func ApplyDynamicChainConfig(ctx *cli.Context) error {
+	params.SetDynamicConfigDir(ctx.String(utils.DynamicConfigDirFlag.Name))
+
+	return core.ValidateDynamicChain(ctx.String(utils.ChainFlag.Name))
+}
+
+// SetEmbeddedDynamicConfig takes the dynamic config embedded in the run config file, value is the parsed
+// yaml or toml map found under DynamicConfigKey
+func SetEmbeddedDynamicConfig(value interface{}) error {
+	fields := make(map[string]interface{})
+	switch v := value.(type) {
+	case map[string]interface{}:
+		fields = v
+	case map[interface{}]interface{}:
+		for key, field := range v {
+			fields[fmt.Sprintf("%v", key)] = field
+		}
+	default:
+		return fmt.Errorf("%s must be a map with chainspec, allocs and conf fields", DynamicConfigKey)
+	}
+
+	embedded := &params.EmbeddedDynamicConfig{}
+	for key, field := range fields {
+		content, ok := field.(string)
+		if !ok {
+			return fmt.Errorf("%s.%s must be a JSON string", DynamicConfigKey, key)
+		}
+		switch key {
+		case "chainspec":
+			embedded.Chainspec = []byte(content)
+		case "allocs":
+			embedded.Allocs = []byte(content)
+		case "conf":
+			embedded.Conf = []byte(content)
+		default:
+			return fmt.Errorf("unknown field %s.%s, expected chainspec, allocs or conf", DynamicConfigKey, key)
+		}
+	}
+
+	params.SetEmbeddedDynamicConfig(embedded)
+	return nil
+}
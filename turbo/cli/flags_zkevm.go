@@ -3,6 +3,7 @@ package cli
 import (
 	"fmt"
 	"math"
+	"os"
 
 	"strings"
 
@@ -10,8 +11,11 @@ import (
 
 	libcommon "github.com/gateway-fm/cdk-erigon-lib/common"
 	"github.com/ledgerwatch/erigon/cmd/utils"
+	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
 	"github.com/ledgerwatch/erigon/zk/sequencer"
+	zkStages "github.com/ledgerwatch/erigon/zk/stages"
+	"github.com/ledgerwatch/log/v3"
 	"github.com/urfave/cli/v2"
 )
 
@@ -53,6 +57,39 @@ func ApplyFlagsForZkConfig(ctx *cli.Context, cfg *ethconfig.Config) {
 		panic(fmt.Sprintf("could not parse sequencer batch seal time timeout value %s", sequencerNonEmptyBatchSealTimeVal))
 	}
 
+	var sequencerPrioritySenders []libcommon.Address
+	if senders := ctx.String(utils.SequencerPrioritySenders.Name); senders != "" {
+		for _, sender := range strings.Split(senders, ",") {
+			sender = strings.TrimSpace(sender)
+			if !libcommon.IsHexAddress(sender) {
+				panic(fmt.Sprintf("invalid priority sender %s", sender))
+			}
+			sequencerPrioritySenders = append(sequencerPrioritySenders, libcommon.HexToAddress(sender))
+		}
+	}
+
+	var rpcRateLimitsApiKeys []string
+	if keys := ctx.String(utils.RpcRateLimitsApiKeysFlag.Name); keys != "" {
+		for _, key := range strings.Split(keys, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				rpcRateLimitsApiKeys = append(rpcRateLimitsApiKeys, key)
+			}
+		}
+	}
+	var rpcRateLimitsTrustedProxies []string
+	if proxies := ctx.String(utils.RpcRateLimitsTrustedProxiesFlag.Name); proxies != "" {
+		for _, proxy := range strings.Split(proxies, ",") {
+			if proxy = strings.TrimSpace(proxy); proxy != "" {
+				rpcRateLimitsTrustedProxies = append(rpcRateLimitsTrustedProxies, proxy)
+			}
+		}
+	}
+	for _, flag := range []string{utils.RpcRateLimitsFlag.Name, utils.RpcRateLimitsCallsFlag.Name, utils.RpcRateLimitsHeavyFlag.Name, utils.RpcRateLimitsApiKeyMultiplierFlag.Name} {
+		if ctx.Int(flag) < 0 {
+			panic(fmt.Sprintf("%s can't be negative", flag))
+		}
+	}
+
 	effectiveGasPriceForEthTransferVal := ctx.Float64(utils.EffectiveGasPriceForEthTransfer.Name)
 	effectiveGasPriceForErc20TransferVal := ctx.Float64(utils.EffectiveGasPriceForErc20Transfer.Name)
 	effectiveGasPriceForContractInvocationVal := ctx.Float64(utils.EffectiveGasPriceForContractInvocation.Name)
@@ -69,6 +106,9 @@ func ApplyFlagsForZkConfig(ctx *cli.Context, cfg *ethconfig.Config) {
 	if effectiveGasPriceForContractDeploymentVal < 0 || effectiveGasPriceForContractDeploymentVal > 1 {
 		panic("Effective gas price for contract deployment must be in interval [0; 1]")
 	}
+	if ctx.Int(utils.GasPriceL1HistoryFlag.Name) < 1 {
+		panic(fmt.Sprintf("%s must be at least 1", utils.GasPriceL1HistoryFlag.Name))
+	}
 
 	cfg.Zk = &ethconfig.Zk{
 		L2ChainId:                              ctx.Uint64(utils.L2ChainIdFlag.Name),
@@ -84,6 +124,7 @@ func ApplyFlagsForZkConfig(ctx *cli.Context, cfg *ethconfig.Config) {
 		AddressRollup:                          libcommon.HexToAddress(ctx.String(utils.AddressRollupFlag.Name)),
 		AddressZkevm:                           libcommon.HexToAddress(ctx.String(utils.AddressZkevmFlag.Name)),
 		AddressGerManager:                      libcommon.HexToAddress(ctx.String(utils.AddressGerManagerFlag.Name)),
+		AddressDataCommittee:                   libcommon.HexToAddress(ctx.String(utils.AddressDataCommitteeFlag.Name)),
 		L1RollupId:                             ctx.Uint64(utils.L1RollupIdFlag.Name),
 		L1BlockRange:                           ctx.Uint64(utils.L1BlockRangeFlag.Name),
 		L1QueryDelay:                           ctx.Uint64(utils.L1QueryDelayFlag.Name),
@@ -91,6 +132,11 @@ func ApplyFlagsForZkConfig(ctx *cli.Context, cfg *ethconfig.Config) {
 		L1MaticContractAddress:                 libcommon.HexToAddress(ctx.String(utils.L1MaticContractAddressFlag.Name)),
 		L1FirstBlock:                           ctx.Uint64(utils.L1FirstBlockFlag.Name),
 		RpcRateLimits:                          ctx.Int(utils.RpcRateLimitsFlag.Name),
+		RpcRateLimitsCalls:                     ctx.Int(utils.RpcRateLimitsCallsFlag.Name),
+		RpcRateLimitsHeavy:                     ctx.Int(utils.RpcRateLimitsHeavyFlag.Name),
+		RpcRateLimitsApiKeys:                   rpcRateLimitsApiKeys,
+		RpcRateLimitsApiKeyMultiplier:          ctx.Int(utils.RpcRateLimitsApiKeyMultiplierFlag.Name),
+		RpcRateLimitsTrustedProxies:            rpcRateLimitsTrustedProxies,
 		DatastreamVersion:                      ctx.Int(utils.DatastreamVersionFlag.Name),
 		RebuildTreeAfter:                       ctx.Uint64(utils.RebuildTreeAfterFlag.Name),
 		IncrementTreeAlways:                    ctx.Bool(utils.IncrementTreeAlways.Name),
@@ -98,6 +144,16 @@ func ApplyFlagsForZkConfig(ctx *cli.Context, cfg *ethconfig.Config) {
 		SequencerBlockSealTime:                 sequencerBlockSealTime,
 		SequencerBatchSealTime:                 sequencerBatchSealTime,
 		SequencerNonEmptyBatchSealTime:         sequencerNonEmptyBatchSealTime,
+		SequencerSealCounterThreshold:          ctx.Uint64(utils.SequencerSealCounterThreshold.Name),
+		SequencerSealL1DataBudget:              ctx.Uint64(utils.SequencerSealL1DataBudget.Name),
+		SequencerSealOnL1InfoTree:              ctx.Bool(utils.SequencerSealOnL1InfoTree.Name),
+		SequencerTxOrdering:                    ctx.String(utils.SequencerTxOrdering.Name),
+		SequencerPoolManager:                   ctx.Bool(utils.SequencerPoolManager.Name),
+		SequencerHaLease:                       ctx.String(utils.SequencerHaLease.Name),
+		SequencerHaId:                          ctx.String(utils.SequencerHaId.Name),
+		SequencerHaLeaseTTL:                    ctx.Duration(utils.SequencerHaLeaseTTL.Name),
+		SequencerPrioritySenders:               sequencerPrioritySenders,
+		SequencerMaxTxsPerSender:               ctx.Uint64(utils.SequencerMaxTxsPerSender.Name),
 		ExecutorUrls:                           strings.Split(ctx.String(utils.ExecutorUrls.Name), ","),
 		ExecutorStrictMode:                     ctx.Bool(utils.ExecutorStrictMode.Name),
 		ExecutorRequestTimeout:                 ctx.Duration(utils.ExecutorRequestTimeout.Name),
@@ -111,6 +167,8 @@ func ApplyFlagsForZkConfig(ctx *cli.Context, cfg *ethconfig.Config) {
 		DefaultGasPrice:                        ctx.Uint64(utils.DefaultGasPrice.Name),
 		MaxGasPrice:                            ctx.Uint64(utils.MaxGasPrice.Name),
 		GasPriceFactor:                         ctx.Float64(utils.GasPriceFactor.Name),
+		GasPriceL1History:                      ctx.Int(utils.GasPriceL1HistoryFlag.Name),
+		GasPriceCongestionThreshold:            ctx.Uint64(utils.GasPriceCongestionThresholdFlag.Name),
 		WitnessFull:                            ctx.Bool(utils.WitnessFullFlag.Name),
 		SyncLimit:                              ctx.Uint64(utils.SyncLimit.Name),
 		Gasless:                                ctx.Bool(utils.SupportGasless.Name),
@@ -119,23 +177,83 @@ func ApplyFlagsForZkConfig(ctx *cli.Context, cfg *ethconfig.Config) {
 		DebugStep:                              ctx.Uint64(utils.DebugStep.Name),
 		DebugStepAfter:                         ctx.Uint64(utils.DebugStepAfter.Name),
 		PoolManagerUrl:                         ctx.String(utils.PoolManagerUrl.Name),
+		RpcForwardedTxsTTL:                     ctx.Duration(utils.RpcForwardedTxsTTL.Name),
+		PreconfirmationsRelayUrl:               ctx.String(utils.PreconfirmationsRelayUrl.Name),
+		PreconfirmationsSequencerAddress:       libcommon.HexToAddress(ctx.String(utils.PreconfirmationsSequencerAddress.Name)),
 		DisableVirtualCounters:                 ctx.Bool(utils.DisableVirtualCounters.Name),
 		ExecutorPayloadOutput:                  ctx.String(utils.ExecutorPayloadOutput.Name),
+		ExecutorStateless:                      ctx.Bool(utils.ExecutorStateless.Name),
+		Devnet:                                 ctx.Bool(utils.DevnetFlag.Name),
+		DevnetL1RpcAddr:                        ctx.String(utils.DevnetL1RpcAddrFlag.Name),
+		DevnetL1BlockTime:                      ctx.Duration(utils.DevnetL1BlockTimeFlag.Name),
 	}
 
+	if file := ctx.String(utils.SequencerPreconfirmationKey.Name); file != "" {
+		key, err := crypto.LoadECDSA(file)
+		if err != nil {
+			panic(fmt.Sprintf("Could not load %s: %v", utils.SequencerPreconfirmationKey.Name, err))
+		}
+		cfg.SequencerPreconfirmationKey = key
+	}
+
+	cfg.Role = nodeRole(ctx.String(utils.NodeRoleFlag.Name), cfg.IsSequencerStandby())
+	sequencer.SetRole(cfg.Role, cfg.IsSequencerStandby())
+	log.Info("Node role", "role", cfg.Role, "standby", cfg.IsSequencerStandby())
+
 	checkFlag(utils.L2ChainIdFlag.Name, cfg.L2ChainId)
+	if cfg.IsSequencerStandby() {
+		// a standby starts as an rpc node following the leader and needs everything to sequence once promoted
+		checkFlag(utils.SequencerHaId.Name, cfg.SequencerHaId)
+		if cfg.SequencerHaLeaseTTL <= 0 {
+			panic(fmt.Sprintf("Flag not set: %s", utils.SequencerHaLeaseTTL.Name))
+		}
+	}
 	if !sequencer.IsSequencer() {
-		checkFlag(utils.L2RpcUrlFlag.Name, cfg.L2RpcUrl)
 		checkFlag(utils.L2DataStreamerUrlFlag.Name, cfg.L2DataStreamerUrl)
 		checkFlag(utils.L2DataStreamerTimeout.Name, cfg.L2DataStreamerTimeout)
-	} else {
+		// the nodes taking transactions forward them to the sequencer
+		if cfg.Role.AcceptsTransactions() {
+			checkFlag(utils.L2RpcUrlFlag.Name, cfg.L2RpcUrl)
+		}
+	}
+	if cfg.PreconfirmationsRelayUrl != "" {
+		if cfg.Role != sequencer.RoleRpc {
+			panic(fmt.Sprintf("Only an rpc node relays preconfirmations, %s is set on a node with --%s=%s", utils.PreconfirmationsRelayUrl.Name, utils.NodeRoleFlag.Name, cfg.Role))
+		}
+		// the relay takes only the preconfirmations signed by the sequencer
+		if address := ctx.String(utils.PreconfirmationsSequencerAddress.Name); !libcommon.IsHexAddress(address) {
+			panic(fmt.Sprintf("Invalid or missing %s: %q", utils.PreconfirmationsSequencerAddress.Name, address))
+		}
+	}
+	// only the nodes taking transactions run a pool, the others neither keep nor gossip any
+	if !cfg.Role.AcceptsTransactions() {
+		cfg.DeprecatedTxPool.Disable = true
+	}
+	if cfg.Role == sequencer.RoleVerifier && !cfg.HasExecutors() {
+		panic(fmt.Sprintf("A verifier checks the batches it follows with the executors, set %s or enable %s", utils.ExecutorUrls.Name, utils.ExecutorStateless.Name))
+	}
+	if cfg.Role == sequencer.RoleArchive {
+		if p := cfg.Prune; p.History.Enabled() || p.Receipts.Enabled() || p.TxIndex.Enabled() || p.CallTraces.Enabled() {
+			panic(fmt.Sprintf("An archive node keeps the whole history, it can't be started with --prune=%s", p.String()))
+		}
+	}
+	if cfg.Role == sequencer.RoleSequencer {
 		checkFlag(utils.SequencerInitialForkId.Name, cfg.SequencerInitialForkId)
 		checkFlag(utils.ExecutorUrls.Name, cfg.ExecutorUrls)
 		checkFlag(utils.ExecutorStrictMode.Name, cfg.ExecutorStrictMode)
+		if _, err := zkStages.NewTxOrderingPolicy(cfg.Zk); err != nil {
+			panic(err)
+		}
+		if cfg.SequencerPoolManager {
+			checkFlag(utils.PoolManagerUrl.Name, cfg.PoolManagerUrl)
+		}
+		if cfg.SequencerSealCounterThreshold > 100 {
+			panic(fmt.Sprintf("%s is a percentage, it can't be %d", utils.SequencerSealCounterThreshold.Name, cfg.SequencerSealCounterThreshold))
+		}
 
 		// if we are running in strict mode, the default, and we have no executor URLs then we panic
 		if cfg.ExecutorStrictMode && !cfg.HasExecutors() {
-			panic("You must set executor urls when running in executor strict mode (zkevm.executor-strict)")
+			panic("You must set executor urls or enable the stateless executor when running in executor strict mode (zkevm.executor-strict)")
 		}
 	}
 
@@ -154,3 +272,15 @@ func ApplyFlagsForZkConfig(ctx *cli.Context, cfg *ethconfig.Config) {
 	checkFlag(utils.L1BlockRangeFlag.Name, cfg.L1BlockRange)
 	checkFlag(utils.L1QueryDelayFlag.Name, cfg.L1QueryDelay)
 }
+
+// nodeRole resolves the role of the node from zkevm.role, falling back to the deprecated CDK_ERIGON_SEQUENCER
+func nodeRole(flag string, standby bool) sequencer.Role {
+	role, err := sequencer.ResolveRole(flag, standby)
+	if err != nil {
+		panic(err)
+	}
+	if flag == "" && os.Getenv(sequencer.SEQUENCER_ENV_KEY) == "1" {
+		log.Warn(fmt.Sprintf("%s is deprecated, use --%s=%s instead", sequencer.SEQUENCER_ENV_KEY, utils.NodeRoleFlag.Name, sequencer.RoleSequencer))
+	}
+	return role
+}
@@ -0,0 +1,245 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path"
+	"strings"
+
+	libcommon "github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/chain"
+	"github.com/ledgerwatch/erigon/cmd/utils"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/ethclient"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/zk/constants"
+	zkUtils "github.com/ledgerwatch/erigon/zk/utils"
+	"github.com/ledgerwatch/erigon/zkevm/etherman"
+)
+
+// InitFromL1Config is what the init-from-l1 command is run with
+type InitFromL1Config struct {
+	Chain       string
+	L1RpcUrl    string
+	GenesisPath string // the genesis.json output by the CDK contracts deployment
+	// RollupManager overrides the rollup manager of the genesis file
+	RollupManager libcommon.Address
+	RollupId      uint64
+	// OutputDir is where the files are written, the dynamic config dir when empty
+	OutputDir string
+	Overwrite bool
+}
+
+// cdkGenesis is the genesis.json output by the CDK contracts deployment
+type cdkGenesis struct {
+	L1Config struct {
+		ChainId                   uint64            `json:"chainId"`
+		RollupAddress             libcommon.Address `json:"polygonZkEVMAddress"`
+		RollupManagerAddress      libcommon.Address `json:"polygonRollupManagerAddress"`
+		GlobalExitRootManagerAddr libcommon.Address `json:"polygonZkEVMGlobalExitRootAddress"`
+	} `json:"l1Config"`
+	GenesisBlockNumber uint64              `json:"genesisBlockNumber"`
+	Root               libcommon.Hash      `json:"root"`
+	Genesis            []cdkGenesisAccount `json:"genesis"`
+}
+
+type cdkGenesisAccount struct {
+	ContractName string            `json:"contractName"`
+	AccountName  string            `json:"accountName"`
+	Balance      string            `json:"balance"`
+	Nonce        string            `json:"nonce"`
+	Address      libcommon.Address `json:"address"`
+	Bytecode     string            `json:"bytecode"`
+	Storage      map[string]string `json:"storage"`
+}
+
+// InitFromL1 writes the chainspec, allocs and conf files of a dynamic chain from the CDK genesis file and the rollup
+// as registered on L1, nothing is written unless the genesis root of the allocs is the one the rollup manager holds
+func InitFromL1(ctx context.Context, cfg InitFromL1Config) error {
+	chainName := cfg.Chain
+	if !strings.HasPrefix(chainName, "dynamic") {
+		return fmt.Errorf("chain name %q must start with dynamic", chainName)
+	}
+
+	if cfg.L1RpcUrl == "" {
+		return fmt.Errorf("--%s must be set", utils.L1RpcUrlFlag.Name)
+	}
+
+	genesisBytes, err := os.ReadFile(cfg.GenesisPath)
+	if err != nil {
+		return fmt.Errorf("failed to read genesis file: %w", err)
+	}
+	genesis := &cdkGenesis{}
+	if err = json.Unmarshal(genesisBytes, genesis); err != nil {
+		return fmt.Errorf("invalid genesis file: %w", err)
+	}
+
+	rollupManager := cfg.RollupManager
+	if rollupManager == (libcommon.Address{}) {
+		rollupManager = genesis.L1Config.RollupManagerAddress
+	}
+	if rollupManager == (libcommon.Address{}) {
+		return fmt.Errorf("--%s must be set when the genesis file has no rollup manager address", utils.AddressRollupFlag.Name)
+	}
+	rollupId := cfg.RollupId
+
+	outputDir := cfg.OutputDir
+	if outputDir == "" {
+		if outputDir, err = params.DynamicConfigDir(); err != nil {
+			return err
+		}
+	}
+
+	client, err := ethclient.Dial(cfg.L1RpcUrl)
+	if err != nil {
+		return fmt.Errorf("failed to connect to L1: %w", err)
+	}
+
+	l1ChainId, err := client.ChainID(ctx)
+	if err != nil {
+		return err
+	}
+	if genesis.L1Config.ChainId != 0 && genesis.L1Config.ChainId != l1ChainId.Uint64() {
+		return fmt.Errorf("genesis file is for L1 chain %d but the L1 RPC is for chain %d", genesis.L1Config.ChainId, l1ChainId.Uint64())
+	}
+
+	rollup, err := etherman.GetRollupInfo(ctx, client, rollupManager, rollupId)
+	if err != nil {
+		return err
+	}
+	if rollup.ForkID < uint64(constants.ForkID4) || rollup.ForkID > uint64(chain.ForkIdsOrdered[0]) {
+		return fmt.Errorf("rollup %d is on fork id %d which is not supported", rollupId, rollup.ForkID)
+	}
+	if genesis.L1Config.RollupAddress != (libcommon.Address{}) && genesis.L1Config.RollupAddress != rollup.RollupContract {
+		return fmt.Errorf("genesis file is for rollup contract %s but rollup %d is %s", genesis.L1Config.RollupAddress, rollupId, rollup.RollupContract)
+	}
+
+	alloc, err := cdkGenesisToAlloc(genesis.Genesis)
+	if err != nil {
+		return err
+	}
+	root, err := core.GenesisSmtRoot(alloc)
+	if err != nil {
+		return err
+	}
+	if root != rollup.GenesisRoot {
+		return fmt.Errorf("genesis root mismatch: allocs give %s but the rollup manager has %s", root, rollup.GenesisRoot)
+	}
+	if genesis.Root != (libcommon.Hash{}) && genesis.Root != root {
+		return fmt.Errorf("genesis root mismatch: allocs give %s but the genesis file has %s", root, genesis.Root)
+	}
+
+	if genesis.GenesisBlockNumber == 0 {
+		return fmt.Errorf("genesis file has no genesisBlockNumber to take the genesis timestamp from")
+	}
+	l1Header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(genesis.GenesisBlockNumber))
+	if err != nil {
+		return fmt.Errorf("failed to get L1 genesis block %d: %w", genesis.GenesisBlockNumber, err)
+	}
+
+	// new chains run the same configuration as the public networks with their own chain id
+	spec := *params.HermezCardonaChainConfig
+	spec.ChainName = chainName
+	spec.ChainID = new(big.Int).SetUint64(rollup.ChainID)
+	// the chain starts on the fork of the rollup, it and the forks before it are active from genesis
+	if err = zkUtils.RecoverySetBlockConfigForks(0, rollup.ForkID, &spec, "init-from-l1"); err != nil {
+		return err
+	}
+
+	conf := params.DynamicConfig{
+		Root:      root.Hex(),
+		Timestamp: l1Header.Time,
+	}
+
+	files := map[string]interface{}{
+		chainName + params.DynamicChainspecSuffix: &spec,
+		chainName + params.DynamicAllocsSuffix:    alloc,
+		chainName + params.DynamicConfSuffix:      &conf,
+	}
+	if err = os.MkdirAll(outputDir, 0755); err != nil {
+		return err
+	}
+	for name := range files {
+		if _, err := os.Stat(path.Join(outputDir, name)); err == nil && !cfg.Overwrite {
+			return fmt.Errorf("%s already exists, use --overwrite to replace it", path.Join(outputDir, name))
+		}
+	}
+	for name, content := range files {
+		b, err := json.MarshalIndent(content, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err = os.WriteFile(path.Join(outputDir, name), b, 0644); err != nil {
+			return err
+		}
+	}
+
+	log.Info("Wrote dynamic chain config", "chain", chainName, "dir", outputDir, "root", root, "timestamp", conf.Timestamp, "forkId", rollup.ForkID)
+	log.Info("Chain config to use in the run config",
+		"chain", chainName,
+		"zkevm.l2-chain-id", rollup.ChainID,
+		"zkevm.l1-chain-id", l1ChainId,
+		"zkevm.l1-rollup-id", rollupId,
+		"zkevm.l1-first-block", genesis.GenesisBlockNumber,
+		"zkevm.address-rollup", rollupManager,
+		"zkevm.address-zkevm", rollup.RollupContract,
+		"zkevm.address-ger-manager", rollup.GlobalExitRootManager,
+		"zkevm.address-sequencer", rollup.TrustedSequencer,
+		"zkevm.sequencer-initial-fork-id", rollup.ForkID,
+	)
+
+	return nil
+}
+
+// cdkGenesisToAlloc converts the accounts of a CDK genesis file to genesis allocs
+func cdkGenesisToAlloc(accounts []cdkGenesisAccount) (types.GenesisAlloc, error) {
+	alloc := make(types.GenesisAlloc, len(accounts))
+	for _, acc := range accounts {
+		balance := new(big.Int)
+		if acc.Balance != "" {
+			if _, ok := balance.SetString(acc.Balance, 0); !ok {
+				return nil, fmt.Errorf("invalid balance %q for %s", acc.Balance, acc.Address)
+			}
+		}
+
+		var nonce uint64
+		if acc.Nonce != "" {
+			n, ok := new(big.Int).SetString(acc.Nonce, 0)
+			if !ok || !n.IsUint64() {
+				return nil, fmt.Errorf("invalid nonce %q for %s", acc.Nonce, acc.Address)
+			}
+			nonce = n.Uint64()
+		}
+
+		var code []byte
+		if acc.Bytecode != "" {
+			var err error
+			if code, err = hexutil.Decode(acc.Bytecode); err != nil {
+				return nil, fmt.Errorf("invalid bytecode for %s: %w", acc.Address, err)
+			}
+		}
+
+		var storage map[libcommon.Hash]libcommon.Hash
+		if len(acc.Storage) > 0 {
+			storage = make(map[libcommon.Hash]libcommon.Hash, len(acc.Storage))
+			for k, v := range acc.Storage {
+				storage[libcommon.HexToHash(k)] = libcommon.HexToHash(v)
+			}
+		}
+
+		alloc[acc.Address] = types.GenesisAccount{
+			Balance: balance,
+			Nonce:   nonce,
+			Code:    code,
+			Storage: storage,
+		}
+	}
+	return alloc, nil
+}
@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	libcommon "github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/zk/devnet"
+)
+
+const initFromL1Chain = "dynamic-init-from-l1"
+
+var initFromL1Accounts = []cdkGenesisAccount{
+	{AccountName: "deployer", Balance: "100000000000000000000", Address: libcommon.HexToAddress("0x1000000000000000000000000000000000000001")},
+	{
+		ContractName: "PolygonZkEVMBridge",
+		Balance:      "0",
+		Nonce:        "1",
+		Address:      libcommon.HexToAddress("0x1000000000000000000000000000000000000002"),
+		Bytecode:     "0x6080604052",
+		Storage:      map[string]string{"0x01": "0x02"},
+	},
+}
+
+// runInitFromL1 runs init-from-l1 against a mock L1 holding the rollup with the given fork id and genesis root,
+// the genesis file it is given is the one of the CDK deployment of initFromL1Accounts
+func runInitFromL1(t *testing.T, forkId uint64, genesisRoot libcommon.Hash, outputDir string, overwrite bool) (*devnet.Devnet, error) {
+	d, err := devnet.New(devnet.Config{
+		L1ChainId:     devnet.DefaultL1ChainId,
+		L2ChainId:     999999,
+		RollupId:      1,
+		ForkId:        forkId,
+		RollupManager: devnet.RollupManagerAddress,
+		Rollup:        devnet.RollupAddress,
+		GerManager:    devnet.GerManagerAddress,
+		Sequencer:     devnet.SequencerAddress,
+		GenesisRoot:   genesisRoot,
+		BlockTime:     time.Second,
+	})
+	require.NoError(t, err)
+	t.Cleanup(d.L1().Close)
+	url, stop := d.L1().Serve()
+	t.Cleanup(stop)
+
+	genesis := &cdkGenesis{GenesisBlockNumber: 1, Genesis: initFromL1Accounts}
+	genesis.L1Config.ChainId = devnet.DefaultL1ChainId
+	genesis.L1Config.RollupAddress = devnet.RollupAddress
+	genesis.L1Config.RollupManagerAddress = devnet.RollupManagerAddress
+	genesis.L1Config.GlobalExitRootManagerAddr = devnet.GerManagerAddress
+	b, err := json.Marshal(genesis)
+	require.NoError(t, err)
+	genesisPath := path.Join(t.TempDir(), "genesis.json")
+	require.NoError(t, os.WriteFile(genesisPath, b, 0644))
+
+	return d, InitFromL1(context.Background(), InitFromL1Config{
+		Chain:       initFromL1Chain,
+		L1RpcUrl:    url,
+		GenesisPath: genesisPath,
+		RollupId:    1,
+		OutputDir:   outputDir,
+		Overwrite:   overwrite,
+	})
+}
+
+func TestInitFromL1(t *testing.T) {
+	alloc, err := cdkGenesisToAlloc(initFromL1Accounts)
+	require.NoError(t, err)
+	root, err := core.GenesisSmtRoot(alloc)
+	require.NoError(t, err)
+
+	outputDir := t.TempDir()
+	d, err := runInitFromL1(t, 8, root, outputDir, false)
+	require.NoError(t, err)
+
+	params.SetDynamicConfigDir(outputDir)
+	defer params.SetDynamicConfigDir("")
+
+	// the chainspec is on the rollup's chain id and fork, the forks after it are left to be scheduled
+	spec, err := params.LoadDynamicChainConfig(initFromL1Chain)
+	require.NoError(t, err)
+	require.Equal(t, uint64(999999), spec.ChainID.Uint64())
+	require.Equal(t, initFromL1Chain, spec.ChainName)
+	for _, forkBlock := range []*big.Int{spec.ForkID4Block, spec.ForkID5DragonfruitBlock, spec.ForkID6IncaBerryBlock, spec.ForkID7EtrogBlock, spec.ForkID88ElderberryBlock} {
+		require.NotNil(t, forkBlock)
+		require.Zero(t, forkBlock.Sign())
+	}
+	require.Nil(t, spec.ForkID9Elderberry2Block)
+
+	conf, err := params.LoadDynamicConf(initFromL1Chain)
+	require.NoError(t, err)
+	require.Equal(t, root.Hex(), conf.Root)
+	l1Genesis, err := d.L1().HeaderByNumber(context.Background(), big.NewInt(1))
+	require.NoError(t, err)
+	require.Equal(t, l1Genesis.Time, conf.Timestamp)
+
+	b, err := os.ReadFile(path.Join(outputDir, initFromL1Chain+params.DynamicAllocsSuffix))
+	require.NoError(t, err)
+	var written types.GenesisAlloc
+	require.NoError(t, json.Unmarshal(b, &written))
+	writtenRoot, err := core.GenesisSmtRoot(written)
+	require.NoError(t, err)
+	require.Equal(t, root, writtenRoot)
+
+	// the files of the chain are only replaced when asked to
+	_, err = runInitFromL1(t, 8, root, outputDir, false)
+	require.ErrorContains(t, err, "already exists")
+	_, err = runInitFromL1(t, 8, root, outputDir, true)
+	require.NoError(t, err)
+}
+
+func TestInitFromL1Refused(t *testing.T) {
+	alloc, err := cdkGenesisToAlloc(initFromL1Accounts)
+	require.NoError(t, err)
+	root, err := core.GenesisSmtRoot(alloc)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		forkId      uint64
+		genesisRoot libcommon.Hash
+		err         string
+	}{
+		{"genesis root", 8, libcommon.HexToHash("0x01"), "genesis root mismatch"},
+		{"fork id", 3, root, "fork id 3 which is not supported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputDir := t.TempDir()
+			_, err := runInitFromL1(t, tt.forkId, tt.genesisRoot, outputDir, false)
+			require.ErrorContains(t, err, tt.err)
+
+			// nothing is written for a chain that doesn't match the L1
+			entries, err := os.ReadDir(outputDir)
+			require.NoError(t, err)
+			require.Empty(t, entries)
+		})
+	}
+}
@@ -56,10 +56,8 @@ func _GetBlockNumber(requireCanonical bool, blockNrOrHash rpc.BlockNumberOrHash,
 				return 0, libcommon.Hash{}, false, err
 			}
 		case rpc.SafeBlockNumber:
-			// [zkevm] safe not available, returns finilized instead
-			// blockNumber, err = GetSafeBlockNumber(tx)
-			blockNumber, err = GetFinalizedBlockNumber(tx)
-
+			// [zkevm] safe is the last block of the latest virtualized batch
+			blockNumber, err = GetSafeBlockNumber(tx)
 			if err != nil {
 				return 0, libcommon.Hash{}, false, err
 			}
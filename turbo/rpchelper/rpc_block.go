@@ -49,9 +49,38 @@ func GetFinalizedBlockNumber(tx kv.Tx) (uint64, error) {
 		return 0, err
 	}
 
+	return getHighestExecutedBlockInBatch(tx, highestVerifiedBatchNo)
+}
+
+func GetSafeBlockNumber(tx kv.Tx) (uint64, error) {
+	forkchoiceSafeHash := rawdb.ReadForkchoiceSafe(tx)
+	if forkchoiceSafeHash != (libcommon.Hash{}) {
+		forkchoiceSafeNum := rawdb.ReadHeaderNumber(tx, forkchoiceSafeHash)
+		if forkchoiceSafeNum != nil {
+			return *forkchoiceSafeNum, nil
+		}
+	}
+
+	// get highest virtualized (sequenced on L1) batch
+	hermezDb := hermez_db.NewHermezDbReader(tx)
+	latestSequence, err := hermezDb.GetLatestSequence()
+	if err != nil {
+		return 0, err
+	}
+	var highestVirtualBatchNo uint64
+	if latestSequence != nil {
+		highestVirtualBatchNo = latestSequence.BatchNo
+	}
+
+	return getHighestExecutedBlockInBatch(tx, highestVirtualBatchNo)
+}
+
+// getHighestExecutedBlockInBatch returns the last block of the batch, capped to the executed blocks.  A batch we have
+// not synced yet is ahead of every local block so the latest executed block is used.
+func getHighestExecutedBlockInBatch(tx kv.Tx, batchNo uint64) (uint64, error) {
 	hermezDb := hermez_db.NewHermezDbReader(tx)
 	// we've got the highest batch to execute to, now get it's highest block
-	highestVerifiedBlock, err := hermezDb.GetHighestBlockInBatch(highestVerifiedBatchNo)
+	highestBlock, err := hermezDb.GetHighestBlockInBatch(batchNo)
 	if err != nil {
 		return 0, err
 	}
@@ -61,7 +90,17 @@ func GetFinalizedBlockNumber(tx kv.Tx) (uint64, error) {
 		return 0, fmt.Errorf("getting latest block number: %w", err)
 	}
 
-	blockNum := highestVerifiedBlock
+	if highestBlock == 0 && batchNo > 0 {
+		latestBatchNo, err := hermezDb.GetBatchNoByL2Block(execBlockNum)
+		if err != nil {
+			return 0, err
+		}
+		if batchNo > latestBatchNo {
+			return execBlockNum, nil
+		}
+	}
+
+	blockNum := highestBlock
 	if execBlockNum < blockNum {
 		blockNum = execBlockNum
 	}
@@ -69,17 +108,6 @@ func GetFinalizedBlockNumber(tx kv.Tx) (uint64, error) {
 	return blockNum, nil
 }
 
-func GetSafeBlockNumber(tx kv.Tx) (uint64, error) {
-	forkchoiceSafeHash := rawdb.ReadForkchoiceSafe(tx)
-	if forkchoiceSafeHash != (libcommon.Hash{}) {
-		forkchoiceSafeNum := rawdb.ReadHeaderNumber(tx, forkchoiceSafeHash)
-		if forkchoiceSafeNum != nil {
-			return *forkchoiceSafeNum, nil
-		}
-	}
-	return 0, UnknownBlockError
-}
-
 func GetLatestExecutedBlockNumber(tx kv.Tx) (uint64, error) {
 	blockNum, err := stages.GetStageProgress(tx, stages.Execution)
 	if err != nil {
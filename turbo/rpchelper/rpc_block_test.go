@@ -0,0 +1,54 @@
+package rpchelper
+
+import (
+	"testing"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/zk/hermez_db"
+)
+
+func TestSafeAndFinalizedBlockNumbers(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	require.NoError(t, hermez_db.CreateHermezBuckets(tx))
+	hermezDb := hermez_db.NewHermezDb(tx)
+
+	// batch 1 -> blocks 1,2  batch 2 -> blocks 3,4  batch 3 -> blocks 5,6
+	for block := uint64(1); block <= 6; block++ {
+		require.NoError(t, hermezDb.WriteBlockBatch(block, (block+1)/2))
+	}
+	require.NoError(t, stages.SaveStageProgress(tx, stages.Execution, 6))
+
+	// nothing sequenced or verified yet
+	safe, err := GetSafeBlockNumber(tx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), safe)
+
+	require.NoError(t, hermezDb.WriteSequence(100, 2, common.HexToHash("0x1"), common.Hash{}))
+	require.NoError(t, hermezDb.WriteVerification(101, 1, common.HexToHash("0x2"), common.Hash{}))
+	require.NoError(t, stages.SaveStageProgress(tx, stages.L1VerificationsBatchNo, 1))
+
+	safe, err = GetSafeBlockNumber(tx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), safe)
+
+	finalized, err := GetFinalizedBlockNumber(tx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), finalized)
+
+	// capped to the executed blocks
+	require.NoError(t, stages.SaveStageProgress(tx, stages.Execution, 3))
+	safe, err = GetSafeBlockNumber(tx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), safe)
+
+	// a batch sequenced on L1 that we have not synced yet covers every local block
+	require.NoError(t, stages.SaveStageProgress(tx, stages.Execution, 6))
+	require.NoError(t, hermezDb.WriteSequence(102, 5, common.HexToHash("0x3"), common.Hash{}))
+	safe, err = GetSafeBlockNumber(tx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), safe)
+}
@@ -15,13 +15,20 @@ import (
 	"github.com/ledgerwatch/erigon/turbo/engineapi"
 	"github.com/ledgerwatch/erigon/turbo/shards"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/ledgerwatch/erigon/zk/batch_sealing"
+	"github.com/ledgerwatch/erigon/zk/gas_price"
+	"github.com/ledgerwatch/erigon/zk/ha"
 	"github.com/ledgerwatch/erigon/zk/legacy_executor_verifier"
+	"github.com/ledgerwatch/erigon/zk/pool_manager"
+	"github.com/ledgerwatch/erigon/zk/preconfirmation"
+	"github.com/ledgerwatch/erigon/zk/sequencer_control"
 	zkStages "github.com/ledgerwatch/erigon/zk/stages"
 	"github.com/ledgerwatch/erigon/zk/syncer"
 	"github.com/ledgerwatch/erigon/zk/txpool"
 )
 
-// NewDefaultZkStages creates stages for zk syncer (RPC mode)
+// NewDefaultZkStages creates stages for zk syncer (RPC mode), the verifier checks the followed batches with the
+// executors when set
 func NewDefaultZkStages(ctx context.Context,
 	db kv.RwDB,
 	cfg *ethconfig.Config,
@@ -36,6 +43,7 @@ func NewDefaultZkStages(ctx context.Context,
 	l1InfoTreeSyncer *syncer.L1Syncer,
 	datastreamClient zkStages.DatastreamClient,
 	datastreamServer *datastreamer.StreamServer,
+	verifier *legacy_executor_verifier.LegacyExecutorVerifier,
 ) []*stagedsync.Stage {
 	dirs := cfg.Dirs
 	blockReader := snapshotsync.NewBlockReaderWithSnapshots(snapshots, cfg.TransactionsV3)
@@ -75,6 +83,7 @@ func NewDefaultZkStages(ctx context.Context,
 		),
 		stagedsync.StageHashStateCfg(db, dirs, cfg.HistoryV3, agg),
 		zkStages.StageZkInterHashesCfg(db, true, true, false, dirs.Tmp, blockReader, controlServer.Hd, cfg.HistoryV3, agg, cfg.Zk),
+		zkStages.StageFollowerExecutorVerifyCfg(db, verifier),
 		stagedsync.StageHistoryCfg(db, cfg.Prune, dirs.Tmp),
 		stagedsync.StageLogIndexCfg(db, cfg.Prune, dirs.Tmp),
 		stagedsync.StageCallTracesCfg(db, cfg.Prune, 0, dirs.Tmp),
@@ -101,6 +110,12 @@ func NewSequencerZkStages(ctx context.Context,
 	txPool *txpool.TxPool,
 	txPoolDb kv.RwDB,
 	verifier *legacy_executor_verifier.LegacyExecutorVerifier,
+	poolManager *pool_manager.Client,
+	leader *ha.Elector,
+	sealer *batch_sealing.Sealer,
+	gasPrice *gas_price.Oracle,
+	control *sequencer_control.Control,
+	preconfirmations *preconfirmation.Feed,
 ) []*stagedsync.Stage {
 	dirs := cfg.Dirs
 	blockReader := snapshotsync.NewBlockReaderWithSnapshots(snapshots, cfg.TransactionsV3)
@@ -109,6 +124,9 @@ func NewSequencerZkStages(ctx context.Context,
 	// Hence we run it in the test mode.
 	runInTestMode := cfg.ImportMode
 
+	// the sequencer stage signs a preconfirmation of each transaction of the pool as it executes it
+	preconfirmationSequencer := preconfirmation.NewSequencer(cfg.Zk.SequencerPreconfirmationKey, controlServer.ChainConfig.ChainID.Uint64(), preconfirmations)
+
 	return zkStages.SequencerZkStages(ctx,
 		stagedsync.StageCumulativeIndexCfg(db),
 		zkStages.StageL1SequencerSyncCfg(db, cfg.Zk, sequencerStageSyncer),
@@ -136,6 +154,12 @@ func NewSequencerZkStages(ctx context.Context,
 			cfg.Zk,
 			txPool,
 			txPoolDb,
+			gasPrice,
+			poolManager,
+			leader,
+			sealer,
+			control,
+			preconfirmationSequencer,
 		),
 		stagedsync.StageHashStateCfg(db, dirs, cfg.HistoryV3, agg),
 		zkStages.StageZkInterHashesCfg(db, true, true, false, dirs.Tmp, blockReader, controlServer.Hd, cfg.HistoryV3, agg, cfg.Zk),
@@ -114,6 +114,8 @@ func NewWitnessFromReader(input io.Reader, trace bool) (*Witness, error) {
 			op = &OperatorLeafValue{}
 		case OpAccountLeaf:
 			op = &OperatorLeafAccount{}
+		case OpSMTLeaf:
+			op = &OperatorSMTLeafValue{}
 		case OpCode:
 			op = &OperatorCode{}
 		case OpBranch:
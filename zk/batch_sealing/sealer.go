@@ -0,0 +1,134 @@
+package batch_sealing
+
+import (
+	"fmt"
+
+	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/ledgerwatch/erigon/eth/ethconfig"
+)
+
+// Reason is why the sequencer sealed a batch
+type Reason string
+
+const (
+	ReasonBatchTimer         Reason = "batch-timer"           // zkevm.sequencer-batch-seal-time ran out
+	ReasonNonEmptyBatchTimer Reason = "non-empty-batch-timer" // zkevm.sequencer-non-empty-batch-seal-time ran out
+	ReasonOverflow           Reason = "overflow"              // a counter ran out
+	ReasonCounterThreshold   Reason = "counter-threshold"     // a counter went over the threshold
+	ReasonL1DataBudget       Reason = "l1-data-budget"        // the batch L2 data reached the budget
+	ReasonL1InfoTree         Reason = "l1-info-tree"          // a block used a new L1 info tree update
+	ReasonAdmin              Reason = "admin"                 // an operator asked for it
+	ReasonForced             Reason = "forced"                // a forced batch is a batch of its own
+	ReasonInjected           Reason = "injected"              // the injected batch is a batch of its own
+	ReasonL1Recovery         Reason = "l1-recovery"           // the batch ends where it ended on the L1
+)
+
+var Reasons = []Reason{
+	ReasonBatchTimer,
+	ReasonNonEmptyBatchTimer,
+	ReasonOverflow,
+	ReasonCounterThreshold,
+	ReasonL1DataBudget,
+	ReasonL1InfoTree,
+	ReasonAdmin,
+	ReasonForced,
+	ReasonInjected,
+	ReasonL1Recovery,
+}
+
+var sealedCounters = func() map[Reason]*metrics.Counter {
+	counters := make(map[Reason]*metrics.Counter, len(Reasons))
+	for _, r := range Reasons {
+		counters[r] = metrics.GetOrCreateCounter(fmt.Sprintf(`sequencer_batches_sealed{reason="%s"}`, r))
+	}
+	return counters
+}()
+
+// Sealed counts a sealed batch against the reason it was sealed for
+func Sealed(reason Reason) {
+	if c, ok := sealedCounters[reason]; ok {
+		c.Inc()
+	}
+}
+
+// Config holds the policies that seal a batch besides the seal timers and counter overflow, the zero value disables
+// all of them
+type Config struct {
+	CounterThreshold   uint64 // percentage of its limit any counter may use before the batch is sealed, 0 to disable
+	L1DataBudget       uint64 // bytes of batch L2 data at which the batch is sealed, 0 to disable
+	OnL1InfoTreeUpdate bool   // seal the batch once one of its blocks used a new L1 info tree update
+}
+
+func ConfigFromZk(zk *ethconfig.Zk) Config {
+	return Config{
+		CounterThreshold:   zk.SequencerSealCounterThreshold,
+		L1DataBudget:       zk.SequencerSealL1DataBudget,
+		OnL1InfoTreeUpdate: zk.SequencerSealOnL1InfoTree,
+	}
+}
+
+// Usage is what the open batch used so far
+type Usage struct {
+	CounterPercentage uint64 // the highest percentage of its limit any counter used
+	L2DataSize        uint64 // bytes of batch L2 data the batch would post to the L1
+}
+
+// Sealer decides when the sequencer seals the open batch so batches are filled as far as pays rather than by the
+// clock.  A nil Sealer seals on the timers and counter overflow only.
+type Sealer struct {
+	cfg       Config
+	requested chan struct{}
+}
+
+func NewSealer(cfg Config) *Sealer {
+	return &Sealer{
+		cfg:       cfg,
+		requested: make(chan struct{}, 1),
+	}
+}
+
+// WatchesUsage reports whether Check needs the usage of the batch, working it out costs a pass over its transactions
+func (s *Sealer) WatchesUsage() bool {
+	return s != nil && (s.cfg.CounterThreshold > 0 || s.cfg.L1DataBudget > 0)
+}
+
+// Check returns why the batch should be sealed after a transaction took it to usage, empty to keep it open
+func (s *Sealer) Check(usage Usage) Reason {
+	if s == nil {
+		return ""
+	}
+	if s.cfg.CounterThreshold > 0 && usage.CounterPercentage >= s.cfg.CounterThreshold {
+		return ReasonCounterThreshold
+	}
+	if s.cfg.L1DataBudget > 0 && usage.L2DataSize >= s.cfg.L1DataBudget {
+		return ReasonL1DataBudget
+	}
+	return ""
+}
+
+// CheckBlock returns why the batch should be sealed after a block, empty to keep it open.  usedL1InfoTreeUpdate is
+// whether the block used a new L1 info tree update.
+func (s *Sealer) CheckBlock(usedL1InfoTreeUpdate bool) Reason {
+	if s != nil && s.cfg.OnL1InfoTreeUpdate && usedL1InfoTreeUpdate {
+		return ReasonL1InfoTree
+	}
+	return ""
+}
+
+// Request asks for the open batch to be sealed once its current block is done.  A request made while no batch is
+// open seals the next batch after its first block, requests made before that one is served are merged into it.
+func (s *Sealer) Request() {
+	select {
+	case s.requested <- struct{}{}:
+	default:
+	}
+}
+
+// Requested delivers a request to seal, nil for a nil Sealer so a select on it never fires
+func (s *Sealer) Requested() <-chan struct{} {
+	if s == nil {
+		return nil
+	}
+	return s.requested
+}
@@ -0,0 +1,55 @@
+package batch_sealing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	s := NewSealer(Config{CounterThreshold: 80, L1DataBudget: 1000})
+	require.True(t, s.WatchesUsage())
+
+	require.Equal(t, Reason(""), s.Check(Usage{CounterPercentage: 79, L2DataSize: 999}))
+	require.Equal(t, ReasonCounterThreshold, s.Check(Usage{CounterPercentage: 80}))
+	require.Equal(t, ReasonL1DataBudget, s.Check(Usage{L2DataSize: 1000}))
+
+	// the zero config seals on the timers and overflow only
+	s = NewSealer(Config{})
+	require.False(t, s.WatchesUsage())
+	require.Equal(t, Reason(""), s.Check(Usage{CounterPercentage: 100, L2DataSize: 1 << 20}))
+	require.Equal(t, Reason(""), s.CheckBlock(true))
+}
+
+func TestCheckBlock(t *testing.T) {
+	s := NewSealer(Config{OnL1InfoTreeUpdate: true})
+	require.False(t, s.WatchesUsage())
+	require.Equal(t, Reason(""), s.CheckBlock(false))
+	require.Equal(t, ReasonL1InfoTree, s.CheckBlock(true))
+}
+
+func TestRequest(t *testing.T) {
+	s := NewSealer(Config{})
+
+	// requests made before one is served are merged
+	s.Request()
+	s.Request()
+	select {
+	case <-s.Requested():
+	default:
+		t.Fatal("request not delivered")
+	}
+	select {
+	case <-s.Requested():
+		t.Fatal("request delivered twice")
+	default:
+	}
+}
+
+func TestNilSealer(t *testing.T) {
+	var s *Sealer
+	require.False(t, s.WatchesUsage())
+	require.Equal(t, Reason(""), s.Check(Usage{CounterPercentage: 100}))
+	require.Equal(t, Reason(""), s.CheckBlock(true))
+	require.Nil(t, s.Requested())
+}
@@ -12,4 +12,8 @@ var (
 	UpdateL1InfoTreeTopic       = common.HexToHash("0xda61aa7823fcd807e37b95aabcbe17f03a6f3efd514176444dae191d27fd66b3")
 	InitialSequenceBatchesTopic = common.HexToHash("0x060116213bcbf54ca19fd649dc84b59ab2bbd200ab199770e4d923e222a28e7f")
 	SequenceBatchesTopic        = common.HexToHash("0x3e54d0825ed78523037d00a81759237eb436ce774bd546993ee67a1b67b6e766")
+	UpdateZkEVMVersionTopic     = common.HexToHash("0xed7be53c9f1a96a481223b15568a5b1a475e01a74b347d6ca187c8bf0c078cd6")
+	ForceBatchTopic             = common.HexToHash("0xf94bb37db835f1ab585ee00041849a09b12cd081d77fa15ca070757619cbc931")
+
+	ForceBatchMethodId = common.FromHex("0xeaeb077b") // forceBatch(bytes,uint256)
 )
@@ -0,0 +1,106 @@
+package datacommittee
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// fetchTimeout bounds the request for the data of a batch to a member
+const fetchTimeout = 30 * time.Second
+
+// Client fetches the data of the batches of a validium from the nodes of its committee and checks the signatures of
+// the sequences against the committee, which it reads from the committee contract on the L1.  It implements
+// l1_data.DataAvailability.
+type Client struct {
+	caller  ContractCaller
+	address common.Address
+
+	lock      sync.Mutex
+	committee *Committee
+}
+
+func NewClient(caller ContractCaller, address common.Address) *Client {
+	return &Client{caller: caller, address: address}
+}
+
+// Committee returns the committee, read from the L1 as it was in an L1 block, 0 for the latest, the first time or
+// again if reload
+func (c *Client) Committee(ctx context.Context, l1BlockNo uint64, reload bool) (*Committee, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.committee == nil || reload {
+		committee, err := ReadCommittee(ctx, c.caller, c.address, l1BlockNo)
+		if err != nil {
+			return nil, fmt.Errorf("read the data availability committee: %w", err)
+		}
+		c.committee = committee
+	}
+	return c.committee, nil
+}
+
+// VerifySequence checks the message against the known committee, or the committee as it was in the L1 block of the
+// sequence should the committee have changed since it was read
+func (c *Client) VerifySequence(ctx context.Context, l1BlockNo uint64, signedHash common.Hash, message []byte) error {
+	committee, err := c.Committee(ctx, l1BlockNo, false)
+	if err != nil {
+		return err
+	}
+	if err = committee.VerifyMessage(signedHash, message); err == nil {
+		return nil
+	}
+
+	if committee, err = c.Committee(ctx, l1BlockNo, true); err != nil {
+		return err
+	}
+	return committee.VerifyMessage(signedHash, message)
+}
+
+// GetBatchData asks the members for the data in turn until one has data matching the hash
+func (c *Client) GetBatchData(ctx context.Context, hash common.Hash) ([]byte, error) {
+	committee, err := c.Committee(ctx, 0, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []string
+	for _, member := range committee.Members {
+		data, err := fetch(ctx, member.Url, hash)
+		if err == nil && crypto.Keccak256Hash(data) != hash {
+			err = fmt.Errorf("data does not match the hash")
+		}
+		if err != nil {
+			log.Debug("Could not get batch data from committee member", "member", member.Addr, "url", member.Url, "hash", hash, "err", err)
+			errs = append(errs, fmt.Sprintf("%s: %v", member.Url, err))
+			continue
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("no committee member has the data of %s: %s", hash, strings.Join(errs, ", "))
+}
+
+func fetch(ctx context.Context, url string, hash common.Hash) ([]byte, error) {
+	client, err := rpc.DialHTTP(url)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	var data hexutility.Bytes
+	if err := client.CallContext(ctx, &data, "sync_getOffChainData", hash); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
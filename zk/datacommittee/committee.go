@@ -0,0 +1,162 @@
+package datacommittee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	ethereum "github.com/ledgerwatch/erigon"
+
+	"github.com/ledgerwatch/erigon/accounts/abi"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/zk/contracts"
+)
+
+const (
+	signatureSize = 65
+	addressSize   = 20
+)
+
+var (
+	ErrUnexpectedMessageLength = errors.New("unexpected length of the data availability message")
+	ErrUnexpectedCommitteeHash = errors.New("the addresses of the message are not those of the committee")
+	ErrNotCommitteeSignature   = errors.New("signature not from a committee member, or not in the order of the members")
+)
+
+// ContractCaller reads from the L1 contracts, syncer.IEtherman is one
+type ContractCaller interface {
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// Member is a member of the committee and the url of its data availability node
+type Member struct {
+	Url  string
+	Addr common.Address
+}
+
+// Committee is the data availability committee of a validium as the committee contract on the L1 has it
+type Committee struct {
+	Members            []Member
+	RequiredSignatures uint64
+	Hash               common.Hash
+}
+
+// ReadCommittee reads the committee from the committee contract at address as it was in an L1 block, 0 for the latest
+func ReadCommittee(ctx context.Context, caller ContractCaller, address common.Address, l1BlockNo uint64) (*Committee, error) {
+	var blockNumber *big.Int
+	if l1BlockNo > 0 {
+		blockNumber = new(big.Int).SetUint64(l1BlockNo)
+	}
+	committeeAbi, err := abi.JSON(strings.NewReader(contracts.DataCommitteeAbi))
+	if err != nil {
+		return nil, err
+	}
+	call := func(method string, args ...interface{}) ([]interface{}, error) {
+		input, err := committeeAbi.Pack(method, args...)
+		if err != nil {
+			return nil, err
+		}
+		output, err := caller.CallContract(ctx, ethereum.CallMsg{To: &address, Data: input}, blockNumber)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", method, err)
+		}
+		return committeeAbi.Unpack(method, output)
+	}
+
+	amount, err := call("getAmountOfMembers")
+	if err != nil {
+		return nil, err
+	}
+	required, err := call("requiredAmountOfSignatures")
+	if err != nil {
+		return nil, err
+	}
+	hash, err := call("committeeHash")
+	if err != nil {
+		return nil, err
+	}
+
+	committee := &Committee{
+		RequiredSignatures: required[0].(*big.Int).Uint64(),
+		Hash:               common.Hash(hash[0].([32]byte)),
+	}
+	for i := uint64(0); i < amount[0].(*big.Int).Uint64(); i++ {
+		member, err := call("members", new(big.Int).SetUint64(i))
+		if err != nil {
+			return nil, err
+		}
+		committee.Members = append(committee.Members, Member{Url: member[0].(string), Addr: member[1].(common.Address)})
+	}
+	return committee, nil
+}
+
+// VerifyMessage checks a data availability message the way the committee contract does.  The message is the
+// required amount of signatures of the signed hash followed by the addresses of the members, the signatures in the
+// order of the members signing.
+func (c *Committee) VerifyMessage(signedHash common.Hash, message []byte) error {
+	split := signatureSize * int(c.RequiredSignatures)
+	if len(message) < split || (len(message)-split)%addressSize != 0 {
+		return ErrUnexpectedMessageLength
+	}
+	addrs := message[split:]
+	if crypto.Keccak256Hash(addrs) != c.Hash {
+		return ErrUnexpectedCommitteeHash
+	}
+
+	next := 0
+	for i := 0; i < int(c.RequiredSignatures); i++ {
+		signer, err := recoverSigner(signedHash, message[i*signatureSize:(i+1)*signatureSize])
+		if err != nil {
+			return err
+		}
+		found := false
+		for ; next < len(addrs)/addressSize; next++ {
+			if common.BytesToAddress(addrs[next*addressSize:(next+1)*addressSize]) == signer {
+				found = true
+				next++
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: %s", ErrNotCommitteeSignature, signer)
+		}
+	}
+	return nil
+}
+
+// MembersHash is the hash of the addresses of the members the committee contract keeps
+func MembersHash(members []Member) common.Hash {
+	addrs := make([]byte, 0, len(members)*addressSize)
+	for _, member := range members {
+		addrs = append(addrs, member.Addr.Bytes()...)
+	}
+	return crypto.Keccak256Hash(addrs)
+}
+
+// Message builds a data availability message out of the signatures of members, in the order of the members
+func Message(signatures [][]byte, members []Member) []byte {
+	message := make([]byte, 0, len(signatures)*signatureSize+len(members)*addressSize)
+	for _, signature := range signatures {
+		message = append(message, signature...)
+	}
+	for _, member := range members {
+		message = append(message, member.Addr.Bytes()...)
+	}
+	return message
+}
+
+// recoverSigner recovers the signer of an L1 signature, its recovery id 27 or 28
+func recoverSigner(hash common.Hash, signature []byte) (common.Address, error) {
+	sig := common.Copy(signature)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	pub, err := crypto.SigToPub(hash.Bytes(), sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
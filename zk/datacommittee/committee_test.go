@@ -0,0 +1,127 @@
+package datacommittee
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	ethereum "github.com/ledgerwatch/erigon"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/accounts/abi"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/zk/contracts"
+)
+
+// committeeContract answers the calls to the committee contract the way it does on the L1, the members join at the
+// L1 block in joined
+type committeeContract struct {
+	members  []Member
+	joined   []uint64
+	required uint64
+}
+
+func (c *committeeContract) membersAt(blockNumber *big.Int) []Member {
+	var members []Member
+	for i, member := range c.members {
+		if blockNumber == nil || i >= len(c.joined) || c.joined[i] <= blockNumber.Uint64() {
+			members = append(members, member)
+		}
+	}
+	return members
+}
+
+func (c *committeeContract) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	members := c.membersAt(blockNumber)
+	committeeAbi, err := abi.JSON(strings.NewReader(contracts.DataCommitteeAbi))
+	if err != nil {
+		return nil, err
+	}
+	method, err := committeeAbi.MethodById(msg.Data[:4])
+	if err != nil {
+		return nil, err
+	}
+	switch method.Name {
+	case "getAmountOfMembers":
+		return method.Outputs.Pack(big.NewInt(int64(len(members))))
+	case "requiredAmountOfSignatures":
+		return method.Outputs.Pack(new(big.Int).SetUint64(c.required))
+	case "committeeHash":
+		return method.Outputs.Pack([32]byte(MembersHash(members)))
+	case "members":
+		args, err := method.Inputs.Unpack(msg.Data[4:])
+		if err != nil {
+			return nil, err
+		}
+		member := members[args[0].(*big.Int).Uint64()]
+		return method.Outputs.Pack(member.Url, member.Addr)
+	}
+	return nil, fmt.Errorf("unsupported call of %s", method.Name)
+}
+
+func newLocalCommittee(t *testing.T, size int) []*LocalMember {
+	locals := make([]*LocalMember, size)
+	for i := range locals {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		local, err := NewLocalMember(key)
+		require.NoError(t, err)
+		t.Cleanup(func() { local.Close() })
+		locals[i] = local
+	}
+	return locals
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	locals := newLocalCommittee(t, 3)
+	members := make([]Member, len(locals))
+	for i, local := range locals {
+		members[i] = local.Member()
+	}
+	contract := &committeeContract{members: members, required: 2}
+	client := NewClient(contract, common.HexToAddress("0xdac"))
+
+	committee, err := client.Committee(ctx, 0, false)
+	require.NoError(t, err)
+	require.Equal(t, members, committee.Members)
+	require.Equal(t, uint64(2), committee.RequiredSignatures)
+
+	signedHash := crypto.Keccak256Hash([]byte("sequence"))
+	sign := func(local *LocalMember) []byte {
+		signature, err := local.Sign(signedHash)
+		require.NoError(t, err)
+		return signature
+	}
+
+	// the signatures of any two members, in the order of the members
+	require.NoError(t, client.VerifySequence(ctx, 10, signedHash, Message([][]byte{sign(locals[0]), sign(locals[2])}, members)))
+	err = client.VerifySequence(ctx, 10, signedHash, Message([][]byte{sign(locals[2]), sign(locals[0])}, members))
+	require.ErrorIs(t, err, ErrNotCommitteeSignature)
+	err = client.VerifySequence(ctx, 10, signedHash, Message([][]byte{sign(locals[0])}, members))
+	require.ErrorIs(t, err, ErrUnexpectedMessageLength)
+	err = client.VerifySequence(ctx, 10, signedHash, Message([][]byte{sign(locals[0]), sign(locals[1])}, members[:2]))
+	require.ErrorIs(t, err, ErrUnexpectedCommitteeHash)
+
+	// a member joining the committee on the L1 is picked up when a message does not match the known committee, but
+	// only for the sequences from the L1 block it joined in
+	joining := newLocalCommittee(t, 1)[0]
+	contract.members = append(contract.members, joining.Member())
+	contract.joined = []uint64{0, 0, 0, 20}
+	message := Message([][]byte{sign(locals[1]), sign(joining)}, contract.members)
+	require.ErrorIs(t, client.VerifySequence(ctx, 19, signedHash, message), ErrUnexpectedCommitteeHash)
+	require.NoError(t, client.VerifySequence(ctx, 20, signedHash, message))
+
+	// the data comes from the first member holding it
+	data := []byte("batch l2 data")
+	hash := locals[1].Store(data)
+	fetched, err := client.GetBatchData(ctx, hash)
+	require.NoError(t, err)
+	require.Equal(t, data, fetched)
+
+	_, err = client.GetBatchData(ctx, crypto.Keccak256Hash([]byte("unknown")))
+	require.ErrorContains(t, err, "no committee member has the data")
+}
@@ -0,0 +1,93 @@
+package datacommittee
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/common/hexutility"
+
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// LocalMember stands in for the data availability node of a committee member in tests and devnets.  It serves the
+// data it stores over HTTP on a loopback port and signs sequences with its key.
+type LocalMember struct {
+	key      *ecdsa.PrivateKey
+	server   *http.Server
+	listener net.Listener
+
+	lock sync.RWMutex
+	data map[common.Hash][]byte
+}
+
+func NewLocalMember(key *ecdsa.PrivateKey) (*LocalMember, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	m := &LocalMember{key: key, listener: listener, data: make(map[common.Hash][]byte)}
+
+	server := rpc.NewServer(16, false, true)
+	if err := server.RegisterName("sync", &localMemberAPI{m: m}); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	m.server = &http.Server{Handler: server, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		_ = m.server.Serve(listener)
+	}()
+	return m, nil
+}
+
+// Member is the member as the committee contract has it
+func (m *LocalMember) Member() Member {
+	return Member{Url: "http://" + m.listener.Addr().String(), Addr: crypto.PubkeyToAddress(m.key.PublicKey)}
+}
+
+// Store keeps the data of a batch and returns its hash
+func (m *LocalMember) Store(data []byte) common.Hash {
+	hash := crypto.Keccak256Hash(data)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.data[hash] = common.Copy(data)
+	return hash
+}
+
+// Sign signs the signed hash of a sequence the way a member does for the L1
+func (m *LocalMember) Sign(signedHash common.Hash) ([]byte, error) {
+	signature, err := crypto.Sign(signedHash.Bytes(), m.key)
+	if err != nil {
+		return nil, err
+	}
+	signature[64] += 27
+	return signature, nil
+}
+
+func (m *LocalMember) Close() error {
+	return m.server.Close()
+}
+
+type localMemberAPI struct {
+	m *LocalMember
+}
+
+var errDataNotFound = errors.New("data not found")
+
+// GetOffChainData serves sync_getOffChainData, as the data availability nodes do
+func (api *localMemberAPI) GetOffChainData(ctx context.Context, hash common.Hash) (hexutility.Bytes, error) {
+	api.m.lock.RLock()
+	defer api.m.lock.RUnlock()
+	data, ok := api.m.data[hash]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errDataNotFound, hash)
+	}
+	return data, nil
+}
@@ -0,0 +1,260 @@
+// Package archive reads and writes datastream archives, a range of whole batches of a data stream in a single file
+// that can be handed out to bootstrap nodes.  An archive is a fixed size header followed by the body, the entries of
+// the batches encoded the way the stream server sends them to its clients, optionally gzip compressed.  The header
+// holds the sha256 of the body as it is stored so an archive can be verified before anything is read from it.
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+)
+
+const (
+	Version = 1
+
+	// HeaderSize is magic, version, compression, chain id, from and to batch, entries, body length and checksum
+	HeaderSize = 8 + 1 + 1 + 8*5 + sha256.Size
+
+	// packetTypeData is the packet type of data entries in the stream
+	packetTypeData = 2
+	// entryMinSize is packet type, length, entry type and entry number
+	entryMinSize = 1 + 4 + 4 + 8
+)
+
+var magic = [8]byte{'C', 'D', 'K', 'D', 'S', 'A', 'R', 'C'}
+
+var ErrChecksumMismatch = errors.New("archive checksum mismatch")
+
+type Compression uint8
+
+const (
+	CompressionNone Compression = iota
+	CompressionGzip
+)
+
+// Header describes the content of an archive
+type Header struct {
+	Version     uint8
+	Compression Compression
+	ChainId     uint64
+	FromBatch   uint64
+	ToBatch     uint64
+	Entries     uint64
+	BodyLength  uint64
+	Checksum    [sha256.Size]byte
+}
+
+func (h *Header) encode() []byte {
+	b := make([]byte, 0, HeaderSize)
+	b = append(b, magic[:]...)
+	b = append(b, h.Version, byte(h.Compression))
+	b = binary.BigEndian.AppendUint64(b, h.ChainId)
+	b = binary.BigEndian.AppendUint64(b, h.FromBatch)
+	b = binary.BigEndian.AppendUint64(b, h.ToBatch)
+	b = binary.BigEndian.AppendUint64(b, h.Entries)
+	b = binary.BigEndian.AppendUint64(b, h.BodyLength)
+	return append(b, h.Checksum[:]...)
+}
+
+func decodeHeader(b []byte) (*Header, error) {
+	if len(b) != HeaderSize {
+		return nil, fmt.Errorf("invalid archive header size %d", len(b))
+	}
+	if !bytes.Equal(b[:8], magic[:]) {
+		return nil, errors.New("not a datastream archive")
+	}
+	h := &Header{
+		Version:     b[8],
+		Compression: Compression(b[9]),
+		ChainId:     binary.BigEndian.Uint64(b[10:18]),
+		FromBatch:   binary.BigEndian.Uint64(b[18:26]),
+		ToBatch:     binary.BigEndian.Uint64(b[26:34]),
+		Entries:     binary.BigEndian.Uint64(b[34:42]),
+		BodyLength:  binary.BigEndian.Uint64(b[42:50]),
+	}
+	copy(h.Checksum[:], b[50:])
+	if h.Version != Version {
+		return nil, fmt.Errorf("unsupported archive version %d", h.Version)
+	}
+	if h.Compression > CompressionGzip {
+		return nil, fmt.Errorf("unsupported archive compression %d", h.Compression)
+	}
+	return h, nil
+}
+
+// Writer writes the entries of an archive, the header is written on Close once the body is complete
+type Writer struct {
+	file   *os.File
+	header Header
+
+	buffered *bufio.Writer
+	hasher   hash.Hash
+	counter  *countingWriter
+	body     io.Writer
+	gzip     *gzip.Writer
+}
+
+// NewWriter creates the archive file, the batch range is what the caller is going to write to it
+func NewWriter(path string, chainId, fromBatch, toBatch uint64, compression Compression) (*Writer, error) {
+	if compression > CompressionGzip {
+		return nil, fmt.Errorf("unsupported archive compression %d", compression)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	// the header is only known once the body is written, keep its space
+	if _, err = file.Write(make([]byte, HeaderSize)); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	w := &Writer{
+		file: file,
+		header: Header{
+			Version:     Version,
+			Compression: compression,
+			ChainId:     chainId,
+			FromBatch:   fromBatch,
+			ToBatch:     toBatch,
+		},
+		buffered: bufio.NewWriterSize(file, 1<<20),
+		hasher:   sha256.New(),
+	}
+	w.counter = &countingWriter{w: io.MultiWriter(w.buffered, w.hasher)}
+	w.body = w.counter
+	if compression == CompressionGzip {
+		w.gzip = gzip.NewWriter(w.counter)
+		w.body = w.gzip
+	}
+	return w, nil
+}
+
+// WriteEntry writes a data entry of the stream
+func (w *Writer) WriteEntry(entryType uint32, entryNum uint64, data []byte) error {
+	b := make([]byte, 0, entryMinSize+len(data))
+	b = append(b, packetTypeData)
+	b = binary.BigEndian.AppendUint32(b, uint32(entryMinSize+len(data)))
+	b = binary.BigEndian.AppendUint32(b, entryType)
+	b = binary.BigEndian.AppendUint64(b, entryNum)
+	b = append(b, data...)
+	if _, err := w.body.Write(b); err != nil {
+		return err
+	}
+	w.header.Entries++
+	return nil
+}
+
+// Close completes the body, writes the header and closes the file
+func (w *Writer) Close() error {
+	defer w.file.Close()
+	if w.gzip != nil {
+		if err := w.gzip.Close(); err != nil {
+			return err
+		}
+	}
+	if err := w.buffered.Flush(); err != nil {
+		return err
+	}
+	w.header.BodyLength = w.counter.n
+	copy(w.header.Checksum[:], w.hasher.Sum(nil))
+	if _, err := w.file.WriteAt(w.header.encode(), 0); err != nil {
+		return err
+	}
+	return w.file.Sync()
+}
+
+// Header is the header of the archive, complete once the writer is closed
+func (w *Writer) Header() Header {
+	return w.header
+}
+
+// Reader reads the entries of an archive
+type Reader struct {
+	Header *Header
+
+	file *os.File
+	body io.Reader
+	gzip *gzip.Reader
+}
+
+// Open opens an archive and verifies the checksum of its body before anything can be read from it
+func Open(path string) (*Reader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := open(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+func open(file *os.File) (*Reader, error) {
+	b := make([]byte, HeaderSize)
+	if _, err := io.ReadFull(file, b); err != nil {
+		return nil, fmt.Errorf("read archive header: %w", err)
+	}
+	header, err := decodeHeader(b)
+	if err != nil {
+		return nil, err
+	}
+
+	hasher := sha256.New()
+	n, err := io.Copy(hasher, bufio.NewReaderSize(file, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(n) != header.BodyLength {
+		return nil, fmt.Errorf("archive body is %d bytes, header says %d", n, header.BodyLength)
+	}
+	if !bytes.Equal(hasher.Sum(nil), header.Checksum[:]) {
+		return nil, ErrChecksumMismatch
+	}
+	if _, err = file.Seek(HeaderSize, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	r := &Reader{Header: header, file: file}
+	r.body = bufio.NewReaderSize(file, 1<<20)
+	if header.Compression == CompressionGzip {
+		if r.gzip, err = gzip.NewReader(r.body); err != nil {
+			return nil, err
+		}
+		r.body = r.gzip
+	}
+	return r, nil
+}
+
+// Read reads the body, the entries encoded the way the stream server sends them
+func (r *Reader) Read(p []byte) (int, error) {
+	return r.body.Read(p)
+}
+
+func (r *Reader) Close() error {
+	if r.gzip != nil {
+		r.gzip.Close()
+	}
+	return r.file.Close()
+}
+
+type countingWriter struct {
+	w io.Writer
+	n uint64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += uint64(n)
+	return n, err
+}
@@ -0,0 +1,77 @@
+package archive
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/0xPolygonHermez/zkevm-data-streamer/datastreamer"
+
+	"github.com/ledgerwatch/erigon/zk/datastream/proto/github.com/0xPolygonHermez/zkevm-node/state/datastream"
+	"github.com/ledgerwatch/erigon/zk/datastream/types"
+)
+
+// Export writes the batches fromBatch to toBatch of the stream to an archive.  Only whole batches are exported so the
+// end of toBatch has to be in the stream already.
+func Export(stream *datastreamer.StreamServer, path string, chainId, fromBatch, toBatch uint64, compression Compression) (*Header, error) {
+	if toBatch < fromBatch {
+		return nil, fmt.Errorf("batch range %d-%d is empty", fromBatch, toBatch)
+	}
+
+	from, err := batchEntry(stream, fromBatch)
+	if err != nil {
+		return nil, fmt.Errorf("batch %d is not in the stream: %w", fromBatch, err)
+	}
+	total := stream.GetHeader().TotalEntries
+
+	w, err := NewWriter(path, chainId, fromBatch, toBatch, compression)
+	if err != nil {
+		return nil, err
+	}
+	header, err := exportEntries(stream, w, from, total, toBatch)
+	if err != nil {
+		// never leave a partial archive behind that looks like a complete one
+		os.Remove(path)
+		return nil, err
+	}
+	return header, nil
+}
+
+func exportEntries(stream *datastreamer.StreamServer, w *Writer, from, total, toBatch uint64) (*Header, error) {
+	complete := false
+	for entryNum := from; entryNum < total && !complete; entryNum++ {
+		entry, err := stream.GetEntry(entryNum)
+		if err != nil {
+			w.Close()
+			return nil, err
+		}
+		if types.EntryType(entry.Type) == types.EntryTypeBatchEnd {
+			batchEnd, err := types.UnmarshalBatchEnd(entry.Data)
+			if err != nil {
+				w.Close()
+				return nil, fmt.Errorf("entry %d: %w", entryNum, err)
+			}
+			complete = batchEnd.Number == toBatch
+		}
+		if err = w.WriteEntry(uint32(entry.Type), entry.Number, entry.Data); err != nil {
+			w.Close()
+			return nil, err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	if !complete {
+		return nil, fmt.Errorf("batch %d is not complete in the stream", toBatch)
+	}
+	header := w.Header()
+	return &header, nil
+}
+
+func batchEntry(stream *datastreamer.StreamServer, batch uint64) (uint64, error) {
+	bookmark, err := types.NewBookmarkProto(batch, datastream.BookmarkType_BOOKMARK_TYPE_BATCH).Marshal()
+	if err != nil {
+		return 0, err
+	}
+	return stream.GetBookmark(bookmark)
+}
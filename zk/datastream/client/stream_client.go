@@ -10,6 +10,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/ledgerwatch/erigon/zk/datastream/archive"
 	"github.com/ledgerwatch/erigon/zk/datastream/types"
 	"github.com/ledgerwatch/erigon/zk/datastream/proto/github.com/0xPolygonHermez/zkevm-node/state/datastream"
 	"github.com/ledgerwatch/log/v3"
@@ -46,6 +47,12 @@ type StreamClient struct {
 
 	// keeps track of the latest fork from the stream to assign to l2 blocks
 	currentFork uint64
+
+	// file mode, the entries are read from an archive instead of a server
+	archive        *archive.Reader
+	archiveEntries uint64
+	skipToBlock    uint64
+	skipToBatch    uint64
 }
 
 const (
@@ -57,10 +64,13 @@ const (
 	PtHeader  = 1    // Just for the header page
 	PtData    = 2    // Data entry
 	PtResult  = 0xff // Not stored/present in file (just for client command result)
+
+	// FileScheme is the prefix of a server address that is a datastream archive to read instead of a server
+	FileScheme = "file://"
 )
 
 // Creates a new client fo datastream
-// server must be in format "url:port"
+// server must be in format "url:port", or "file://path" to read a datastream archive
 func NewClient(ctx context.Context, server string, version int, checkTimeout time.Duration) *StreamClient {
 	c := &StreamClient{
 		ctx:            ctx,
@@ -97,8 +107,12 @@ func (c *StreamClient) GetStreamingAtomic() *atomic.Bool {
 	return &c.streaming
 }
 
-// Opens a TCP connection to the server
+// Opens a TCP connection to the server, or the archive in file mode
 func (c *StreamClient) Start() error {
+	if c.isFileMode() {
+		return c.openArchive()
+	}
+
 	// Connect to server
 	var err error
 	c.conn, err = net.Dial("tcp", c.server)
@@ -112,7 +126,12 @@ func (c *StreamClient) Start() error {
 }
 
 func (c *StreamClient) Stop() {
-	c.conn.Close()
+	if c.conn != nil {
+		c.conn.Close()
+	}
+	if c.archive != nil {
+		c.archive.Close()
+	}
 
 	close(c.l2BlockChan)
 	close(c.gerUpdatesChan)
@@ -184,6 +203,10 @@ func (c *StreamClient) ReadEntries(bookmark *types.BookmarkProto, l2BlocksAmount
 // reads entries to the end of the stream
 // at end will wait for new entries to arrive
 func (c *StreamClient) ReadAllEntriesToChannel(bookmark *types.BookmarkProto) error {
+	if c.isFileMode() {
+		return c.readArchiveToChannel(bookmark)
+	}
+
 	// if connection is lost, try to reconnect
 	// this occurs when all 5 attempts failed on previous run
 	if c.conn == nil {
@@ -261,7 +284,12 @@ LOOP:
 			break LOOP
 		}
 
-		if c.checkTimeout > 0 {
+		if c.archive != nil {
+			// an archive has no new entries to wait for
+			if c.archiveEntries >= c.archive.Header.Entries {
+				break
+			}
+		} else if c.checkTimeout > 0 {
 			c.conn.SetReadDeadline(time.Now().Add(c.checkTimeout))
 		}
 
@@ -305,12 +333,21 @@ LOOP:
 			fullBlock.ForkId = c.currentFork
 		}
 
+		if c.archive != nil && c.skipBlock(fullBlock) {
+			continue
+		}
+
 		c.lastWrittenTime.Store(time.Now().UnixNano())
 		c.streaming.Store(true)
 		log.Trace("writing block to channel", "blockNumber", fullBlock.L2BlockNumber, "batchNumber", fullBlock.BatchNumber)
 		c.l2BlockChan <- *fullBlock
 	}
 
+	if c.archive != nil {
+		// stays streaming so the batches stage ends its cycle once the blocks stop coming instead of waiting for more
+		return err
+	}
+
 	c.streaming.Store(false)
 	if c.conn != nil {
 		if err2 := c.conn.Close(); err2 != nil {
@@ -518,6 +555,10 @@ func (c *StreamClient) readFullBlockProto() (*types.FullL2Block, *types.BatchSta
 // reads file bytes from socket and tries to parse them
 // returns the parsed FileEntry
 func (c *StreamClient) readFileEntry() (*types.FileEntry, error) {
+	if c.archive != nil {
+		return c.readArchiveEntry()
+	}
+
 	// Read packet type
 	packet, err := readBuffer(c.conn, 1)
 	if err != nil {
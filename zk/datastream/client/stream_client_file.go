@@ -0,0 +1,98 @@
+package client
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/zk/datastream/archive"
+	"github.com/ledgerwatch/erigon/zk/datastream/proto/github.com/0xPolygonHermez/zkevm-node/state/datastream"
+	"github.com/ledgerwatch/erigon/zk/datastream/types"
+)
+
+func (c *StreamClient) isFileMode() bool {
+	return strings.HasPrefix(c.server, FileScheme)
+}
+
+// openArchive opens and verifies the archive the client reads in file mode
+func (c *StreamClient) openArchive() error {
+	if c.archive != nil {
+		return nil
+	}
+	path := strings.TrimPrefix(c.server, FileScheme)
+	r, err := archive.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening datastream archive: %w", err)
+	}
+	c.archive = r
+	c.id = path
+	log.Info("[Datastream client] Reading archive", "path", path, "chainId", r.Header.ChainId,
+		"fromBatch", r.Header.FromBatch, "toBatch", r.Header.ToBatch, "entries", r.Header.Entries)
+	return nil
+}
+
+// readArchiveToChannel reads the blocks of the archive to the channels.  There is no server to start from the
+// bookmark so the archive is read from its start and the blocks before the bookmark are dropped, for a block bookmark
+// the block itself too as it is the last block the batches stage already has.
+func (c *StreamClient) readArchiveToChannel(bookmark *types.BookmarkProto) error {
+	if err := c.openArchive(); err != nil {
+		c.errChan <- err
+		return err
+	}
+
+	switch bookmark.BookmarkType() {
+	case datastream.BookmarkType_BOOKMARK_TYPE_L2_BLOCK:
+		c.skipToBlock = bookmark.Value + 1
+	case datastream.BookmarkType_BOOKMARK_TYPE_BATCH:
+		c.skipToBatch = bookmark.Value
+	}
+
+	c.lastWrittenTime.Store(time.Now().UnixNano())
+	c.streaming.Store(true)
+
+	if err := c.readAllFullL2BlocksToChannel(); err != nil {
+		err2 := fmt.Errorf("%s read full L2 blocks error: %v", c.id, err)
+		c.errChan <- err2
+		return err2
+	}
+	return nil
+}
+
+func (c *StreamClient) skipBlock(block *types.FullL2Block) bool {
+	return block.L2BlockNumber < c.skipToBlock || block.BatchNumber < c.skipToBatch
+}
+
+// readArchiveEntry reads the next entry of the archive
+func (c *StreamClient) readArchiveEntry() (*types.FileEntry, error) {
+	if c.archiveEntries >= c.archive.Header.Entries {
+		return &types.FileEntry{}, errors.New("no more entries in the archive")
+	}
+
+	buffer, err := readBuffer(c.archive, types.FileEntryMinSize)
+	if err != nil {
+		return &types.FileEntry{}, fmt.Errorf("error reading file bytes: %v", err)
+	}
+	if buffer[0] != PtData {
+		return &types.FileEntry{}, fmt.Errorf("error expecting data packet type %d and received %d", PtData, buffer[0])
+	}
+
+	length := binary.BigEndian.Uint32(buffer[1:5])
+	if length < types.FileEntryMinSize {
+		return &types.FileEntry{}, errors.New("error reading data entry: wrong data length")
+	}
+	data, err := readBuffer(c.archive, length-types.FileEntryMinSize)
+	if err != nil {
+		return &types.FileEntry{}, fmt.Errorf("error reading file data bytes: %v", err)
+	}
+
+	file, err := types.DecodeFileEntry(append(buffer, data...))
+	if err != nil {
+		return &types.FileEntry{}, fmt.Errorf("decode file entry error: %v", err)
+	}
+	c.archiveEntries++
+	return file, nil
+}
@@ -3,12 +3,15 @@ package server
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 
 	"github.com/0xPolygonHermez/zkevm-data-streamer/datastreamer"
 	libcommon "github.com/gateway-fm/cdk-erigon-lib/common"
 	eritypes "github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/zk/datastream/archive"
 	"github.com/ledgerwatch/erigon/zk/datastream/types"
 	"github.com/ledgerwatch/erigon/zk/hermez_db"
+	zktypes "github.com/ledgerwatch/erigon/zk/types"
 	"github.com/ledgerwatch/erigon/zk/datastream/proto/github.com/0xPolygonHermez/zkevm-node/state/datastream"
 	"github.com/ledgerwatch/erigon/core/state"
 	"github.com/gateway-fm/cdk-erigon-lib/kv"
@@ -100,6 +103,7 @@ func (srv *DataStreamServer) CreateL2BlockProto(
 	l1BlockHash libcommon.Hash,
 	minTimestamp uint64,
 	blockInfoRoot libcommon.Hash,
+	l2GasPrice uint64,
 ) *types.L2BlockProto {
 	return &types.L2BlockProto{
 		L2Block: &datastream.L2Block{
@@ -115,6 +119,7 @@ func (srv *DataStreamServer) CreateL2BlockProto(
 			GlobalExitRoot:  ger.Bytes(),
 			Coinbase:        block.Coinbase().Bytes(),
 			BlockInfoRoot:   blockInfoRoot.Bytes(),
+			L2GasPrice:      l2GasPrice,
 		},
 	}
 }
@@ -145,6 +150,19 @@ func (srv *DataStreamServer) CreateTransactionProto(
 	}, nil
 }
 
+// CreateUnexecutedTransactionProto creates the entry of a transaction of a forced batch the executor skipped, it is in
+// the batch data but not the block
+func (srv *DataStreamServer) CreateUnexecutedTransactionProto(tx zktypes.UnexecutedTransaction, blockNumber uint64) *types.TxProto {
+	return &types.TxProto{
+		Transaction: &datastream.Transaction{
+			EffectiveGasPricePercentage: uint32(tx.EffectiveGasPricePercentage),
+			IsValid:                     false,
+			Encoded:                     tx.Encoded,
+			L2BlockNumber:               blockNumber,
+		},
+	}
+}
+
 func (srv *DataStreamServer) CreateBatchStartProto(batchNo, chainId, forkId uint64, batchType datastream.BatchType) *types.BatchStartProto {
 	return &types.BatchStartProto{
 		BatchStart: &datastream.BatchStart{
@@ -200,11 +218,16 @@ func (srv *DataStreamServer) CreateStreamEntriesProto(
 ) (*[]DataStreamEntryProto, error) {
 	blockNum := block.NumberU64()
 
-	entryCount := 2                         // l2 block bookmark + l2 block
-	entryCount += len(block.Transactions()) // transactions
+	// a forced block carries the transactions of its batch the executor skipped at their index in it
+	unexecuted, err := reader.GetBlockUnexecutedTransactions(blockNum)
+	if err != nil {
+		return nil, err
+	}
+
+	entryCount := 2                                           // l2 block bookmark + l2 block
+	entryCount += len(block.Transactions()) + len(unexecuted) // transactions
 	entryCount += len(gers)
 
-	var err error
 	if lastBatchNumber != batchNumber {
 		// we know we have some batch bookmarks to add, but we need to figure out how many because there
 		// could be empty batches in between blocks that could contain ger updates and we need to handle
@@ -263,6 +286,14 @@ func (srv *DataStreamServer) CreateStreamEntriesProto(
 			batchType := datastream.BatchType_BATCH_TYPE_REGULAR
 			if batchNumber == 1 {
 				batchType = datastream.BatchType_BATCH_TYPE_INJECTED
+			} else {
+				_, forced, err := reader.GetForcedBatchNumberByBatch(nextWorkingBatch)
+				if err != nil {
+					return nil, err
+				}
+				if forced {
+					batchType = datastream.BatchType_BATCH_TYPE_FORCED
+				}
 			}
 			fork, err := reader.GetForkId(nextWorkingBatch)
 			if err != nil {
@@ -323,14 +354,26 @@ func (srv *DataStreamServer) CreateStreamEntriesProto(
 		return nil, err
 	}
 
+	l2GasPrice, _, err := reader.GetBlockL2GasPrice(blockNum)
+	if err != nil {
+		return nil, err
+	}
+
 	blockHash := block.Hash().Bytes()
 
 	// L2 BLOCK
-	l2Block := srv.CreateL2BlockProto(block, blockHash, batchNumber, ger, uint32(deltaTimestamp), uint32(l1InfoIndex), l1BlockHash, l1InfoTreeMinTimestamps[l1InfoIndex], blockInfoRoot)
+	l2Block := srv.CreateL2BlockProto(block, blockHash, batchNumber, ger, uint32(deltaTimestamp), uint32(l1InfoIndex), l1BlockHash, l1InfoTreeMinTimestamps[l1InfoIndex], blockInfoRoot, l2GasPrice)
 	entries[index] = l2Block
 	index++
+	firstTxEntry := index
 
 	for _, tx := range block.Transactions() {
+		for len(unexecuted) > 0 && unexecuted[0].Index <= uint64(index-firstTxEntry) {
+			entries[index] = srv.CreateUnexecutedTransactionProto(unexecuted[0], blockNum)
+			index++
+			unexecuted = unexecuted[1:]
+		}
+
 		effectiveGasPricePercentage, err := reader.GetEffectiveGasPricePercentage(tx.Hash())
 		if err != nil {
 			return nil, err
@@ -349,6 +392,10 @@ func (srv *DataStreamServer) CreateStreamEntriesProto(
 		entries[index] = transaction
 		index++
 	}
+	for _, u := range unexecuted {
+		entries[index] = srv.CreateUnexecutedTransactionProto(u, blockNum)
+		index++
+	}
 
 	return &entries, nil
 }
@@ -414,6 +461,33 @@ func (srv *DataStreamServer) GetHighestBlockNumber() (uint64, error) {
 	return l2Block.L2BlockNumber, nil
 }
 
+// GetHighestClosedBatch returns the number of the last batch with its batch end in the stream
+func (srv *DataStreamServer) GetHighestClosedBatch() (uint64, error) {
+	header := srv.stream.GetHeader()
+
+	for entryNum := header.TotalEntries; entryNum > 0; entryNum-- {
+		entry, err := srv.stream.GetEntry(entryNum - 1)
+		if err != nil {
+			return 0, err
+		}
+		if types.EntryType(entry.Type) != types.EntryTypeBatchEnd {
+			continue
+		}
+		batchEnd, err := types.UnmarshalBatchEnd(entry.Data)
+		if err != nil {
+			return 0, err
+		}
+		return batchEnd.Number, nil
+	}
+
+	return 0, fmt.Errorf("no closed batch in the stream")
+}
+
+// ExportArchive writes the batches fromBatch to toBatch of the stream to an archive
+func (srv *DataStreamServer) ExportArchive(path string, fromBatch, toBatch uint64, compression archive.Compression) (*archive.Header, error) {
+	return archive.Export(srv.stream, path, srv.chainId, fromBatch, toBatch, compression)
+}
+
 // must be done on offline server
 // finds the position of the endBlock entry for the given number
 // and unwinds the datastream file to it
@@ -431,16 +505,20 @@ func (srv *DataStreamServer) UnwindToBlock(blockNumber uint64) error {
 		return err
 	}
 
-	//find end block entry to delete from it onward
+	// find the last entry of the previous block, its l2 block entry when it has no transactions, and delete
+	// everything after it.  The batch end, bookmark and start in between are written again with the block
 	for {
+		if entryNum == 0 {
+			return fmt.Errorf("no block before block %d in the stream to unwind to", blockNumber)
+		}
+		entryNum -= 1
 		entry, err := srv.stream.GetEntry(entryNum)
 		if err != nil {
 			return err
 		}
-		if entry.Type == datastreamer.EntryType(3) {
+		if entry.Type == datastreamer.EntryType(types.EntryTypeL2Tx) || entry.Type == datastreamer.EntryType(types.EntryTypeL2Block) {
 			break
 		}
-		entryNum -= 1
 	}
 
 	return srv.stream.TruncateFile(entryNum + 1)
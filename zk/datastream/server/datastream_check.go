@@ -0,0 +1,366 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/0xPolygonHermez/zkevm-data-streamer/datastreamer"
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	eritypes "github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/zk/datastream/proto/github.com/0xPolygonHermez/zkevm-node/state/datastream"
+	"github.com/ledgerwatch/erigon/zk/datastream/types"
+	"github.com/ledgerwatch/erigon/zk/hermez_db"
+)
+
+// StreamDivergence is the first entry of the stream that is out of order, can't be decoded or doesn't match the db
+type StreamDivergence struct {
+	EntryNum uint64
+	// BlockNum is the block the stream has to be written again from to repair it, 0 when the whole stream has to be
+	BlockNum uint64
+	Reason   string
+}
+
+func (d *StreamDivergence) String() string {
+	return fmt.Sprintf("entry %d: %s (rebuild from block %d)", d.EntryNum, d.Reason, d.BlockNum)
+}
+
+// StreamCheckResult is what a check of the stream found
+type StreamCheckResult struct {
+	TotalEntries uint64
+	Batches      uint64
+	Blocks       uint64
+	Transactions uint64
+	HighestBlock uint64
+	Divergence   *StreamDivergence
+}
+
+// streamChecker walks the entries of the stream in order.  A batch is bookmark, batch start, blocks and ger updates
+// then batch end, a block is bookmark, l2 block then its transactions
+type streamChecker struct {
+	tx     kv.Tx
+	reader *hermez_db.HermezDbReader
+	result *StreamCheckResult
+
+	bookmark  *types.BookmarkProto
+	batch     uint64
+	batchOpen bool
+	seenBatch bool
+	block     *eritypes.Block
+	blockTxs  int
+	lastBlock *eritypes.Block
+}
+
+// Check reads the whole stream, validates the order of its entries and compares every block in it against the db.
+// It stops at the first divergence, which the result holds, errors are only returned when reading fails.
+func (srv *DataStreamServer) Check(tx kv.Tx, logPrefix string) (*StreamCheckResult, error) {
+	header := srv.stream.GetHeader()
+	c := &streamChecker{
+		tx:     tx,
+		reader: hermez_db.NewHermezDbReader(tx),
+		result: &StreamCheckResult{TotalEntries: header.TotalEntries},
+	}
+
+	logTicker := time.NewTicker(10 * time.Second)
+	defer logTicker.Stop()
+
+	for entryNum := uint64(0); entryNum < header.TotalEntries; entryNum++ {
+		select {
+		case <-logTicker.C:
+			log.Info(fmt.Sprintf("[%s] Checking stream", logPrefix), "entry", entryNum, "total", header.TotalEntries, "block", c.result.HighestBlock)
+		default:
+		}
+
+		entry, err := srv.stream.GetEntry(entryNum)
+		if err != nil {
+			return nil, err
+		}
+		if entry.Number != entryNum {
+			c.diverge(entryNum, "entry number %d out of sequence", entry.Number)
+			break
+		}
+
+		reason, err := c.check(entry)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			c.diverge(entryNum, "%s", reason)
+			break
+		}
+	}
+
+	if c.result.Divergence == nil {
+		// a bookmark has to be followed by its entry, the last write of the stream didn't finish otherwise
+		if c.bookmark != nil {
+			c.diverge(header.TotalEntries-1, "stream ends with a bookmark")
+		} else if reason, err := c.endBlock(); err != nil {
+			return nil, err
+		} else if reason != "" {
+			c.diverge(header.TotalEntries-1, "%s", reason)
+		}
+	}
+
+	return c.result, nil
+}
+
+func (c *streamChecker) diverge(entryNum uint64, format string, args ...interface{}) {
+	var blockNum uint64
+	if c.block != nil {
+		blockNum = c.block.NumberU64()
+	} else if c.lastBlock != nil {
+		blockNum = c.lastBlock.NumberU64()
+	}
+	c.result.Divergence = &StreamDivergence{
+		EntryNum: entryNum,
+		BlockNum: blockNum,
+		Reason:   fmt.Sprintf(format, args...),
+	}
+}
+
+// check returns why the entry diverges, if it does
+func (c *streamChecker) check(entry datastreamer.FileEntry) (string, error) {
+	if types.EntryType(entry.Type) == types.BookmarkEntryType {
+		bookmark, err := types.UnmarshalBookmark(entry.Data)
+		if err != nil {
+			return fmt.Sprintf("invalid bookmark: %v", err), nil
+		}
+		if c.bookmark != nil {
+			return "bookmark follows a bookmark", nil
+		}
+		switch bookmark.BookmarkType() {
+		case datastream.BookmarkType_BOOKMARK_TYPE_BATCH:
+			if c.batchOpen {
+				return fmt.Sprintf("batch %d bookmark before the end of batch %d", bookmark.Value, c.batch), nil
+			}
+			if c.seenBatch && bookmark.Value != c.batch+1 {
+				return fmt.Sprintf("batch %d bookmark after batch %d", bookmark.Value, c.batch), nil
+			}
+		case datastream.BookmarkType_BOOKMARK_TYPE_L2_BLOCK:
+			if !c.batchOpen {
+				return fmt.Sprintf("block %d bookmark outside of a batch", bookmark.Value), nil
+			}
+			previous := c.block
+			if previous == nil {
+				previous = c.lastBlock
+			}
+			if previous != nil && bookmark.Value != previous.NumberU64()+1 {
+				return fmt.Sprintf("block %d bookmark after block %d", bookmark.Value, previous.NumberU64()), nil
+			}
+		default:
+			return fmt.Sprintf("unknown bookmark type %d", bookmark.BookmarkType()), nil
+		}
+		c.bookmark = bookmark
+		return "", nil
+	}
+
+	bookmark := c.bookmark
+	c.bookmark = nil
+
+	switch types.EntryType(entry.Type) {
+	case types.EntryTypeBatchStart:
+		batchStart, err := types.UnmarshalBatchStart(entry.Data)
+		if err != nil {
+			return fmt.Sprintf("invalid batch start: %v", err), nil
+		}
+		if bookmark == nil || bookmark.BookmarkType() != datastream.BookmarkType_BOOKMARK_TYPE_BATCH || bookmark.Value != batchStart.Number {
+			return fmt.Sprintf("batch %d start without its bookmark", batchStart.Number), nil
+		}
+		if c.seenBatch {
+			forkId, err := c.reader.GetForkId(batchStart.Number)
+			if err != nil {
+				return "", err
+			}
+			if forkId != batchStart.ForkId {
+				return fmt.Sprintf("batch %d has fork id %d in the stream, %d in the db", batchStart.Number, batchStart.ForkId, forkId), nil
+			}
+		}
+		c.batch, c.batchOpen, c.seenBatch = batchStart.Number, true, true
+		c.result.Batches++
+
+	case types.EntryTypeL2Block:
+		l2Block, err := types.UnmarshalL2Block(entry.Data)
+		if err != nil {
+			return fmt.Sprintf("invalid l2 block: %v", err), nil
+		}
+		if bookmark == nil || bookmark.BookmarkType() != datastream.BookmarkType_BOOKMARK_TYPE_L2_BLOCK || bookmark.Value != l2Block.L2BlockNumber {
+			return fmt.Sprintf("block %d without its bookmark", l2Block.L2BlockNumber), nil
+		}
+		if reason, err := c.endBlock(); err != nil || reason != "" {
+			return reason, err
+		}
+		if l2Block.BatchNumber != c.batch {
+			return fmt.Sprintf("block %d has batch %d in batch %d", l2Block.L2BlockNumber, l2Block.BatchNumber, c.batch), nil
+		}
+		return c.startBlock(l2Block)
+
+	case types.EntryTypeL2Tx:
+		if bookmark != nil {
+			return "transaction after a bookmark", nil
+		}
+		l2Tx, err := types.UnmarshalTx(entry.Data)
+		if err != nil {
+			return fmt.Sprintf("invalid transaction: %v", err), nil
+		}
+		if c.block == nil || l2Tx.L2BlockNumber != c.block.NumberU64() {
+			return fmt.Sprintf("transaction of block %d outside of it", l2Tx.L2BlockNumber), nil
+		}
+		txs := c.block.Transactions()
+		if c.blockTxs >= len(txs) {
+			return fmt.Sprintf("block %d has more transactions in the stream than the %d in the db", c.block.NumberU64(), len(txs)), nil
+		}
+		var encoded bytes.Buffer
+		if err := txs[c.blockTxs].EncodeRLP(&encoded); err != nil {
+			return "", err
+		}
+		if !bytes.Equal(encoded.Bytes(), l2Tx.Encoded) {
+			return fmt.Sprintf("transaction %d of block %d doesn't match the db", c.blockTxs, c.block.NumberU64()), nil
+		}
+		c.blockTxs++
+		c.result.Transactions++
+
+	case types.EntryTypeBatchEnd:
+		if bookmark != nil {
+			return "batch end after a bookmark", nil
+		}
+		batchEnd, err := types.UnmarshalBatchEnd(entry.Data)
+		if err != nil {
+			return fmt.Sprintf("invalid batch end: %v", err), nil
+		}
+		if !c.batchOpen || batchEnd.Number != c.batch {
+			return fmt.Sprintf("batch %d end outside of it", batchEnd.Number), nil
+		}
+		if reason, err := c.endBlock(); err != nil || reason != "" {
+			return reason, err
+		}
+		if c.lastBlock != nil && batchEnd.StateRoot != c.lastBlock.Root() {
+			return fmt.Sprintf("batch %d ends with state root %x, block %d has %x", batchEnd.Number, batchEnd.StateRoot, c.lastBlock.NumberU64(), c.lastBlock.Root()), nil
+		}
+		c.batchOpen = false
+
+	case types.EntryTypeGerUpdate:
+		if bookmark != nil {
+			return "ger update after a bookmark", nil
+		}
+		gerUpdate, err := types.DecodeGerUpdateProto(entry.Data)
+		if err != nil {
+			return fmt.Sprintf("invalid ger update: %v", err), nil
+		}
+		if !c.batchOpen || gerUpdate.BatchNumber != c.batch {
+			return fmt.Sprintf("ger update of batch %d outside of it", gerUpdate.BatchNumber), nil
+		}
+
+	default:
+		return fmt.Sprintf("unknown entry type %d", entry.Type), nil
+	}
+
+	return "", nil
+}
+
+func (c *streamChecker) startBlock(l2Block *types.FullL2Block) (string, error) {
+	blockNum := l2Block.L2BlockNumber
+	block, err := rawdb.ReadBlockByNumber(c.tx, blockNum)
+	if err != nil {
+		return "", err
+	}
+	if block == nil {
+		return fmt.Sprintf("block %d is not in the db", blockNum), nil
+	}
+	c.block, c.blockTxs = block, 0
+
+	batchNo, err := c.reader.GetBatchNoByL2Block(blockNum)
+	if err != nil {
+		return "", err
+	}
+	if batchNo != l2Block.BatchNumber {
+		return fmt.Sprintf("block %d is in batch %d in the stream, %d in the db", blockNum, l2Block.BatchNumber, batchNo), nil
+	}
+	if l2Block.StateRoot != block.Root() {
+		return fmt.Sprintf("block %d has state root %x in the stream, %x in the db", blockNum, l2Block.StateRoot, block.Root()), nil
+	}
+	if uint64(l2Block.Timestamp) != block.Time() {
+		return fmt.Sprintf("block %d has timestamp %d in the stream, %d in the db", blockNum, l2Block.Timestamp, block.Time()), nil
+	}
+	if l2Block.Coinbase != block.Coinbase() {
+		return fmt.Sprintf("block %d has coinbase %x in the stream, %x in the db", blockNum, l2Block.Coinbase, block.Coinbase()), nil
+	}
+	ger, err := c.reader.GetBlockGlobalExitRoot(blockNum)
+	if err != nil {
+		return "", err
+	}
+	if l2Block.GlobalExitRoot != ger {
+		return fmt.Sprintf("block %d has ger %x in the stream, %x in the db", blockNum, l2Block.GlobalExitRoot, ger), nil
+	}
+
+	c.result.Blocks++
+	c.result.HighestBlock = blockNum
+	return "", nil
+}
+
+// endBlock checks that all transactions of the block were in the stream
+func (c *streamChecker) endBlock() (string, error) {
+	if c.block == nil {
+		return "", nil
+	}
+	block := c.block
+	c.block, c.lastBlock = nil, block
+	if txs := len(block.Transactions()); c.blockTxs != txs {
+		c.block = block
+		return fmt.Sprintf("block %d has %d transactions in the stream, %d in the db", block.NumberU64(), c.blockTxs, txs), nil
+	}
+	return "", nil
+}
+
+// Repair unwinds the stream to the block it diverges at and writes the blocks from there up to the last executed
+// block again, the way the sequencer and the datastream stage write them.  From 0 rebuilds the whole stream.  Like
+// unwinding, it has to run on an offline server.
+func (srv *DataStreamServer) Repair(tx kv.RwTx, from uint64, logPrefix string) (uint64, error) {
+	reader := hermez_db.NewHermezDbReader(tx)
+
+	to, err := stages.GetStageProgress(tx, stages.Execution)
+	if err != nil {
+		return 0, err
+	}
+
+	if from == 0 {
+		if srv.stream.GetHeader().TotalEntries > 0 {
+			if err = srv.stream.TruncateFile(0); err != nil {
+				return 0, err
+			}
+		}
+		genesis, err := rawdb.ReadBlockByNumber(tx, 0)
+		if err != nil {
+			return 0, err
+		}
+		if err = WriteGenesisToStream(genesis, reader, srv.stream, srv, srv.chainId); err != nil {
+			return 0, err
+		}
+		from = 1
+	} else if err = srv.UnwindToBlock(from); err != nil {
+		return 0, err
+	}
+
+	// the stream was ahead of the db, nothing to write again
+	if to < from {
+		to = from - 1
+		log.Info(fmt.Sprintf("[%s] Stream unwound", logPrefix), "block", to)
+		progress, err := stages.GetStageProgress(tx, stages.DataStream)
+		if err != nil {
+			return 0, err
+		}
+		if progress > to {
+			return to, stages.SaveStageProgress(tx, stages.DataStream, to)
+		}
+		return to, nil
+	}
+
+	log.Info(fmt.Sprintf("[%s] Writing blocks to the stream", logPrefix), "from", from, "to", to)
+	if err = WriteBlocksToStream(tx, reader, srv, srv.stream, from, to, logPrefix); err != nil {
+		return 0, err
+	}
+	return to, nil
+}
@@ -192,7 +192,7 @@ func WriteGenesisToStream(
 	batchBookmark := srv.CreateBatchBookmarkEntryProto(genesis.NumberU64())
 	l2BlockBookmark := srv.CreateL2BlockBookmarkEntryProto(genesis.NumberU64())
 
-	l2Block := srv.CreateL2BlockProto(genesis, genesis.Hash().Bytes(), batchNo, ger, 0, 0, common.Hash{}, 0, common.Hash{})
+	l2Block := srv.CreateL2BlockProto(genesis, genesis.Hash().Bytes(), batchNo, ger, 0, 0, common.Hash{}, 0, common.Hash{}, 0)
 	batchStart := srv.CreateBatchStartProto(batchNo, chainId, GenesisForkId, datastream.BatchType_BATCH_TYPE_REGULAR)
 
 	if err = srv.CommitEntriesToStreamProto([]DataStreamEntryProto{batchBookmark, batchStart, l2BlockBookmark, l2Block}); err != nil {
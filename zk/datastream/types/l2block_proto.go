@@ -29,6 +29,7 @@ type FullL2Block struct {
 	LocalExitRoot   libcommon.Hash
 	BlockGasLimit   uint64
 	BlockInfoRoot   libcommon.Hash
+	L2GasPrice      uint64
 	Debug           Debug
 }
 
@@ -60,6 +61,7 @@ func UnmarshalL2Block(data []byte) (*FullL2Block, error) {
 		StateRoot:       libcommon.BytesToHash(block.StateRoot),
 		BlockGasLimit:   block.BlockGasLimit,
 		BlockInfoRoot:   libcommon.BytesToHash(block.BlockInfoRoot),
+		L2GasPrice:      block.L2GasPrice,
 		Debug:           ProcessDebug(block.Debug),
 	}
 
@@ -0,0 +1,233 @@
+package devnet
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/core/asm"
+	"github.com/ledgerwatch/erigon/crypto"
+)
+
+// The rollup manager, rollup and GER manager of the mock L1 all run the contract below.  Calls from the owner, the
+// account of the mock L1, either emit an event or set the result of a view call, every other call is a view call
+// answered with the result set for its calldata.
+//
+// An owner call is a word holding the op followed by its arguments.  Op 0 sets the result of a view call: the key word,
+// the hash of the view calldata, then the result.  Op n+1 emits an event with n topics: the topic words then the data.
+// The result of a view call is stored with its length at the key and its words in the slots after it.
+const mockContractSource = `
+	CALLER
+	PUSH %s
+	EQ
+	JUMPI @owner
+
+	;; view call, the result of the calldata is read from the slots at its hash
+	CALLDATASIZE
+	PUSH 0
+	PUSH 0
+	CALLDATACOPY
+	CALLDATASIZE
+	PUSH 0
+	KECCAK256
+	DUP1
+	SLOAD
+	PUSH 0
+view:
+	DUP2
+	DUP2
+	PUSH 32
+	MUL
+	LT
+	ISZERO
+	JUMPI @viewDone
+	DUP1
+	DUP4
+	ADD
+	PUSH 1
+	ADD
+	SLOAD
+	DUP2
+	PUSH 32
+	MUL
+	MSTORE
+	PUSH 1
+	ADD
+	JUMP @view
+viewDone:
+	POP
+	PUSH 0
+	RETURN
+
+owner:
+	PUSH 0
+	CALLDATALOAD
+	DUP1
+	ISZERO
+	JUMPI @set
+
+	;; emit, the data after the topics is copied to memory
+	PUSH 1
+	SWAP1
+	SUB
+	DUP1
+	PUSH 32
+	MUL
+	PUSH 32
+	ADD
+	DUP1
+	CALLDATASIZE
+	SUB
+	DUP1
+	DUP3
+	PUSH 0
+	CALLDATACOPY
+	SWAP1
+	POP
+	DUP2
+	PUSH 0
+	EQ
+	JUMPI @log0
+	DUP2
+	PUSH 1
+	EQ
+	JUMPI @log1
+	DUP2
+	PUSH 2
+	EQ
+	JUMPI @log2
+	DUP2
+	PUSH 3
+	EQ
+	JUMPI @log3
+	DUP2
+	PUSH 4
+	EQ
+	JUMPI @log4
+	PUSH 0
+	DUP1
+	REVERT
+log0:
+	PUSH 0
+	LOG0
+	STOP
+log1:
+	PUSH 32
+	CALLDATALOAD
+	DUP2
+	PUSH 0
+	LOG1
+	STOP
+log2:
+	PUSH 64
+	CALLDATALOAD
+	PUSH 32
+	CALLDATALOAD
+	DUP3
+	PUSH 0
+	LOG2
+	STOP
+log3:
+	PUSH 96
+	CALLDATALOAD
+	PUSH 64
+	CALLDATALOAD
+	PUSH 32
+	CALLDATALOAD
+	DUP4
+	PUSH 0
+	LOG3
+	STOP
+log4:
+	PUSH 128
+	CALLDATALOAD
+	PUSH 96
+	CALLDATALOAD
+	PUSH 64
+	CALLDATALOAD
+	PUSH 32
+	CALLDATALOAD
+	DUP5
+	PUSH 0
+	LOG4
+	STOP
+
+set:
+	POP
+	PUSH 32
+	CALLDATALOAD
+	PUSH 64
+	CALLDATASIZE
+	SUB
+	DUP1
+	DUP3
+	SSTORE
+	PUSH 0
+store:
+	DUP2
+	DUP2
+	PUSH 32
+	MUL
+	LT
+	ISZERO
+	JUMPI @storeDone
+	DUP1
+	PUSH 32
+	MUL
+	PUSH 64
+	ADD
+	CALLDATALOAD
+	DUP2
+	DUP5
+	ADD
+	PUSH 1
+	ADD
+	SSTORE
+	PUSH 1
+	ADD
+	JUMP @store
+storeDone:
+	STOP
+`
+
+// mockContractCode compiles the contract of the mock L1 for an owner
+func mockContractCode(owner common.Address) ([]byte, error) {
+	compiler := asm.NewCompiler(false)
+	compiler.Feed(asm.Lex([]byte(fmt.Sprintf(mockContractSource, owner.Hex())), false))
+	code, errs := compiler.Compile()
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("compile the mock L1 contract: %v", errs)
+	}
+	return common.FromHex(code), nil
+}
+
+// emitCall is the calldata of an owner call emitting an event
+func emitCall(topics []common.Hash, data []byte) []byte {
+	call := common.BigToHash(big.NewInt(int64(len(topics) + 1))).Bytes()
+	for _, topic := range topics {
+		call = append(call, topic.Bytes()...)
+	}
+	return append(call, data...)
+}
+
+// setViewCall is the calldata of an owner call setting the result of a view call
+func setViewCall(viewCall, result []byte) []byte {
+	call := make([]byte, 32, 64+len(result))
+	call = append(call, crypto.Keccak256(viewCall)...)
+	return append(call, result...)
+}
+
+// viewStorage is the storage of a view result set by setViewCall, for the genesis of the mock L1.  The result is abi
+// encoded so it is whole words.
+func viewStorage(viewCall, result []byte) map[common.Hash]common.Hash {
+	key := new(big.Int).SetBytes(crypto.Keccak256(viewCall))
+	storage := map[common.Hash]common.Hash{
+		common.BigToHash(key): common.BigToHash(big.NewInt(int64(len(result)))),
+	}
+	for i := 0; i*32 < len(result); i++ {
+		slot := new(big.Int).Add(key, big.NewInt(int64(i+1)))
+		storage[common.BigToHash(slot)] = common.BytesToHash(result[i*32 : (i+1)*32])
+	}
+	return storage
+}
@@ -0,0 +1,213 @@
+package devnet
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/zk/hermez_db"
+	zktx "github.com/ledgerwatch/erigon/zk/tx"
+)
+
+const DefaultL1ChainId = 1337
+
+var (
+	RollupManagerAddress = common.HexToAddress("0x2F50ef6b8e8Ee4E579B17619A92dE3E2ffbD8AD2")
+	RollupAddress        = common.HexToAddress("0x1Fe038B54aeBf558638CA51C91bC8cCa06609e91")
+	GerManagerAddress    = common.HexToAddress("0x1f7ad7caA53e35b4f0D138dC5CBF91aC108a2674")
+	AdminAddress         = common.HexToAddress("0x9EA9db6af0FEfd30d22F813bE32E4E17A3189E6a")
+	SequencerAddress     = common.HexToAddress("0x67b1d87101671b127f5f8714789C7192f7ad340e")
+)
+
+// sequencerKey is the well known dev key of SequencerAddress, it signs the transaction of the injected batch
+const sequencerKey = "26e86e45f6fc45ec6e2ecd128cec80fa1d1505e5507dcd2ae58c3130a7a97b48"
+
+// Config is the setup of the mock L1 contracts and of the mocked sequence sender and aggregator
+type Config struct {
+	L1ChainId     uint64
+	L2ChainId     uint64
+	RollupId      uint64
+	ForkId        uint64
+	RollupManager common.Address
+	Rollup        common.Address
+	GerManager    common.Address
+	Sequencer     common.Address
+	GenesisRoot   common.Hash
+	BlockTime     time.Duration
+	RpcAddr       string
+}
+
+// Devnet runs a mock L1 next to a sequencer.  Every L1 block the mocked sequence sender sequences the batches the
+// sequencer has closed and the mocked aggregator verifies the batches sequenced in the block before, moving the
+// rollup exit root and so the GER on.
+type Devnet struct {
+	cfg Config
+	l1  *MockL1
+
+	server *http.Server
+	quit   chan struct{}
+	done   chan struct{}
+
+	lastSequenced uint64
+	lastVerified  uint64
+}
+
+// New creates the mock L1 with the rollup already created, the first L1 block holds the first L1 info tree update
+// and the injected batch
+func New(cfg Config) (*Devnet, error) {
+	l1, err := NewMockL1(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	injected, err := injectedBatchL2Data(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ger := l1.UpdateL1InfoTree(common.Hash{}, common.Hash{})
+	l1.InitialSequenceBatches(injected, ger, cfg.Sequencer)
+	l1.Mine()
+
+	return &Devnet{
+		cfg:  cfg,
+		l1:   l1,
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+	}, nil
+}
+
+// L1 returns the mock L1 for the L1 syncers to read from
+func (d *Devnet) L1() *MockL1 {
+	return d.l1
+}
+
+// Start serves the L1 RPC and starts mining L1 blocks, the sequencer progress is read from db
+func (d *Devnet) Start(db kv.RoDB) error {
+	if d.cfg.RpcAddr != "" {
+		listener, err := net.Listen("tcp", d.cfg.RpcAddr)
+		if err != nil {
+			return fmt.Errorf("could not listen for the devnet L1 RPC: %w", err)
+		}
+		d.server = &http.Server{Handler: newRpcServer(d.l1), ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			if err := d.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Error("[devnet] L1 RPC stopped", "err", err)
+			}
+		}()
+		log.Info("[devnet] L1 RPC started", "url", "http://"+listener.Addr().String())
+	}
+
+	go d.run(db)
+
+	return nil
+}
+
+// Stop stops mining L1 blocks, closes the L1 RPC and the mock L1
+func (d *Devnet) Stop() {
+	close(d.quit)
+	<-d.done
+	if d.server != nil {
+		_ = d.server.Close()
+	}
+	d.l1.Close()
+}
+
+func (d *Devnet) run(db kv.RoDB) {
+	defer close(d.done)
+
+	ticker := time.NewTicker(d.cfg.BlockTime)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.quit:
+			return
+		case <-ticker.C:
+			if err := d.sequenceAndVerify(db); err != nil {
+				log.Warn("[devnet] could not sequence and verify batches", "err", err)
+			}
+			block := d.l1.Mine()
+			log.Debug("[devnet] mined L1 block", "number", block.NumberU64(), "sequenced", d.lastSequenced, "verified", d.lastVerified)
+		}
+	}
+}
+
+func (d *Devnet) sequenceAndVerify(db kv.RoDB) error {
+	tx, err := db.BeginRo(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if d.lastSequenced > d.lastVerified {
+		stateRoot, err := batchStateRoot(tx, d.lastSequenced)
+		if err != nil {
+			return err
+		}
+		d.l1.VerifyBatches(d.lastSequenced, stateRoot, common.Hash{}, AdminAddress)
+
+		// a verification updates the rollup exit root which gives the sequencer a new GER to use
+		rollupExitRoot := crypto.Keccak256Hash(binary.BigEndian.AppendUint64(nil, d.lastSequenced), stateRoot.Bytes())
+		d.l1.UpdateL1InfoTree(common.Hash{}, rollupExitRoot)
+
+		d.lastVerified = d.lastSequenced
+	}
+
+	// the batch the sequencer is working on may still be open so only the batches before it are sequenced
+	highestBatch, err := stages.GetStageProgress(tx, stages.HighestSeenBatchNumber)
+	if err != nil {
+		return err
+	}
+	if highestBatch > 1 && highestBatch-1 > d.lastSequenced {
+		// the mocked sequence sender does not track the L1 info tree so no L1 info root is sent
+		d.l1.SequenceBatches(highestBatch-1, common.Hash{})
+		d.lastSequenced = highestBatch - 1
+	}
+
+	return nil
+}
+
+func batchStateRoot(tx kv.Tx, batchNo uint64) (common.Hash, error) {
+	blockNo, err := hermez_db.NewHermezDbReader(tx).GetHighestBlockInBatch(batchNo)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	header := rawdb.ReadHeaderByNumber(tx, blockNo)
+	if header == nil {
+		return common.Hash{}, fmt.Errorf("no header for block %d of batch %d", blockNo, batchNo)
+	}
+	return header.Root, nil
+}
+
+// injectedBatchL2Data builds the injected batch of the rollup creation, a single block with a zero value transfer
+// from the sequencer to itself.  It is free so it executes whatever the balance of the sequencer on the chain.
+func injectedBatchL2Data(cfg Config) ([]byte, error) {
+	key, err := crypto.HexToECDSA(sequencerKey)
+	if err != nil {
+		return nil, err
+	}
+
+	to := crypto.PubkeyToAddress(key.PublicKey)
+	signer := types.LatestSignerForChainID(new(big.Int).SetUint64(cfg.L2ChainId))
+	tx, err := types.SignTx(types.NewTransaction(0, to, uint256.NewInt(0), 21000, uint256.NewInt(0), nil), *signer, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return zktx.GenerateBlockBatchL2Data(uint16(cfg.ForkId), 0, 0, []types.Transaction{tx})
+}
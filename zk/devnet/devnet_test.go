@@ -0,0 +1,204 @@
+package devnet
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+	"github.com/gateway-fm/cdk-erigon-lib/kv/memdb"
+	ethereum "github.com/ledgerwatch/erigon"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/ethclient"
+	"github.com/ledgerwatch/erigon/zk/contracts"
+	"github.com/ledgerwatch/erigon/zk/hermez_db"
+	zkStages "github.com/ledgerwatch/erigon/zk/stages"
+	zktx "github.com/ledgerwatch/erigon/zk/tx"
+	"github.com/ledgerwatch/erigon/zkevm/etherman"
+)
+
+func testConfig() Config {
+	return Config{
+		L1ChainId:     DefaultL1ChainId,
+		L2ChainId:     999999,
+		RollupId:      1,
+		ForkId:        8,
+		RollupManager: RollupManagerAddress,
+		Rollup:        RollupAddress,
+		GerManager:    GerManagerAddress,
+		Sequencer:     SequencerAddress,
+		GenesisRoot:   common.HexToHash("0x01"),
+		BlockTime:     time.Second,
+	}
+}
+
+func TestInjectedBatch(t *testing.T) {
+	d, err := New(testConfig())
+	require.NoError(t, err)
+	defer d.L1().Close()
+
+	logs, err := d.L1().FilterLogs(context.Background(), ethereum.FilterQuery{
+		Addresses: []common.Address{RollupAddress},
+		Topics:    [][]common.Hash{{contracts.InitialSequenceBatchesTopic}},
+	})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, uint64(1), logs[0].BlockNumber)
+
+	header, err := d.L1().HeaderByNumber(context.Background(), big.NewInt(1))
+	require.NoError(t, err)
+	require.Equal(t, header.Hash(), logs[0].BlockHash)
+
+	db := memdb.NewTestDB(t)
+	rwTx, err := db.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer rwTx.Rollback()
+	require.NoError(t, hermez_db.CreateHermezBuckets(rwTx))
+	hermezDb := hermez_db.NewHermezDb(rwTx)
+
+	require.NoError(t, zkStages.HandleInitialSequenceBatches(nil, hermezDb, logs[0], header))
+
+	injected, err := hermezDb.GetL1InjectedBatch(0)
+	require.NoError(t, err)
+	require.Equal(t, SequencerAddress, injected.Sequencer)
+	require.Equal(t, header.Time, injected.Timestamp)
+
+	blocks, err := zktx.DecodeBatchL2Blocks(injected.Transaction, 8)
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+	require.Len(t, blocks[0].Transactions, 1)
+
+	sender, err := blocks[0].Transactions[0].Sender(*types.LatestSignerForChainID(big.NewInt(999999)))
+	require.NoError(t, err)
+	require.Equal(t, SequencerAddress, sender)
+}
+
+func TestRollupInfo(t *testing.T) {
+	cfg := testConfig()
+	d, err := New(cfg)
+	require.NoError(t, err)
+	defer d.L1().Close()
+
+	info, err := etherman.GetRollupInfo(context.Background(), d.L1(), RollupManagerAddress, 1)
+	require.NoError(t, err)
+	require.Equal(t, RollupAddress, info.RollupContract)
+	require.Equal(t, cfg.L2ChainId, info.ChainID)
+	require.Equal(t, cfg.ForkId, info.ForkID)
+	require.Equal(t, cfg.GenesisRoot, info.GenesisRoot)
+	require.Equal(t, SequencerAddress, info.TrustedSequencer)
+	require.Equal(t, GerManagerAddress, info.GlobalExitRootManager)
+
+	_, err = etherman.GetRollupInfo(context.Background(), d.L1(), RollupManagerAddress, 2)
+	require.Error(t, err)
+}
+
+func TestSequenceAndVerify(t *testing.T) {
+	d, err := New(testConfig())
+	require.NoError(t, err)
+	defer d.L1().Close()
+
+	db := memdb.NewTestDB(t)
+	stateRoot := common.HexToHash("0xabcd")
+	require.NoError(t, db.Update(context.Background(), func(tx kv.RwTx) error {
+		if err := hermez_db.CreateHermezBuckets(tx); err != nil {
+			return err
+		}
+		hermezDb := hermez_db.NewHermezDb(tx)
+		for block, batch := range map[uint64]uint64{1: 1, 2: 2, 3: 2, 4: 3} {
+			if err := hermezDb.WriteBlockBatch(block, batch); err != nil {
+				return err
+			}
+		}
+		header := &types.Header{Number: big.NewInt(3), Root: stateRoot}
+		rawdb.WriteHeader(tx, header)
+		if err := rawdb.WriteCanonicalHash(tx, header.Hash(), 3); err != nil {
+			return err
+		}
+		// batch 3 is still open
+		return stages.SaveStageProgress(tx, stages.HighestSeenBatchNumber, 3)
+	}))
+
+	sequenceTopics := [][]common.Hash{{contracts.SequenceBatchesTopic}}
+	verifyTopics := [][]common.Hash{{contracts.VerificationTopicEtrog}}
+	infoTreeTopics := [][]common.Hash{{contracts.UpdateL1InfoTreeTopic}}
+
+	// the closed batches are sequenced first
+	require.NoError(t, d.sequenceAndVerify(db))
+	block := d.L1().Mine()
+	logs, err := d.L1().FilterLogs(context.Background(), ethereum.FilterQuery{FromBlock: block.Number(), Topics: sequenceTopics})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, common.BigToHash(big.NewInt(2)), logs[0].Topics[1])
+
+	// and verified in the next block, which also gives the sequencer a new GER
+	require.NoError(t, d.sequenceAndVerify(db))
+	block = d.L1().Mine()
+	logs, err = d.L1().FilterLogs(context.Background(), ethereum.FilterQuery{FromBlock: block.Number(), Topics: verifyTopics})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, common.BigToHash(big.NewInt(1)), logs[0].Topics[1])
+	require.Equal(t, common.BigToHash(big.NewInt(2)), common.BytesToHash(logs[0].Data[:32]))
+	require.Equal(t, stateRoot, common.BytesToHash(logs[0].Data[32:64]))
+
+	logs, err = d.L1().FilterLogs(context.Background(), ethereum.FilterQuery{FromBlock: block.Number(), Topics: infoTreeTopics})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+
+	// nothing new to sequence or verify
+	require.NoError(t, d.sequenceAndVerify(db))
+	block = d.L1().Mine()
+	logs, err = d.L1().FilterLogs(context.Background(), ethereum.FilterQuery{FromBlock: block.Number()})
+	require.NoError(t, err)
+	require.Empty(t, logs)
+
+	// the rollup manager holds the batches sequenced and verified
+	call, err := d.L1().abi.Pack("rollupIDToRollupData", uint32(1))
+	require.NoError(t, err)
+	to := RollupManagerAddress
+	result, err := d.L1().CallContract(context.Background(), ethereum.CallMsg{To: &to, Data: call}, nil)
+	require.NoError(t, err)
+	rollupData, err := d.L1().abi.Unpack("rollupIDToRollupData", result)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), rollupData[5], "last batch sequenced")
+	require.Equal(t, uint64(2), rollupData[6], "last verified batch")
+}
+
+func TestL1Rpc(t *testing.T) {
+	d, err := New(testConfig())
+	require.NoError(t, err)
+	defer d.L1().Close()
+
+	server := httptest.NewServer(newRpcServer(d.L1()))
+	defer server.Close()
+
+	client, err := ethclient.Dial(server.URL)
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx := context.Background()
+	chainId, err := client.ChainID(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(DefaultL1ChainId), chainId.Uint64())
+
+	header, err := client.HeaderByNumber(ctx, big.NewInt(1))
+	require.NoError(t, err)
+	expected, err := d.L1().HeaderByNumber(ctx, big.NewInt(1))
+	require.NoError(t, err)
+	require.Equal(t, expected.Hash(), header.Hash())
+
+	logs, err := client.FilterLogs(ctx, ethereum.FilterQuery{FromBlock: big.NewInt(0), Addresses: []common.Address{GerManagerAddress}})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, contracts.UpdateL1InfoTreeTopic, logs[0].Topics[0])
+
+	info, err := etherman.GetRollupInfo(ctx, client, RollupManagerAddress, 1)
+	require.NoError(t, err)
+	require.Equal(t, RollupAddress, info.RollupContract)
+}
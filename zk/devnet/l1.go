@@ -0,0 +1,444 @@
+package devnet
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+	"github.com/holiman/uint256"
+	ethereum "github.com/ledgerwatch/erigon"
+	"github.com/ledgerwatch/erigon/accounts/abi"
+	"github.com/ledgerwatch/erigon/accounts/abi/bind/backends"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/zk/contracts"
+	"github.com/ledgerwatch/erigon/zkevm/etherman"
+)
+
+// gasPrice is the gas price the mock L1 answers eth_gasPrice with and pays for its own transactions
+const gasPrice = 1_000_000_000
+
+// callGas is the gas limit of a transaction of the mock L1, enough to set the largest view result
+const callGas = 1_000_000
+
+// rollupTypeId is the rollup type the devnet rollup is registered with in the mock rollup manager
+const rollupTypeId = 1
+
+// MockL1 is an L1 on a simulated backend with the rollup manager, rollup and GER manager contracts deployed.  The
+// contracts emit the events the mock L1 is asked for and answer the view calls used to read the rollup setup, see
+// mockContractSource.  It implements syncer.IEtherman so the L1 syncers can read from it directly.  Events are sent
+// as transactions of the mock L1's account and included in the next block mined.
+//
+// The simulated chain's block times start at zero, so the sequencer, which only uses L1 info tree updates and forced
+// batches once they're 12 minutes old, takes every event straight away.  The simulated backend has no log index so the
+// logs of each block are kept as it is mined.
+type MockL1 struct {
+	cfg     Config
+	abi     abi.ABI
+	backend *backends.SimulatedBackend
+	key     *ecdsa.PrivateKey
+	owner   common.Address
+	signer  *types.Signer
+
+	lock         sync.RWMutex
+	nonce        uint64
+	latest       uint64
+	logs         [][]types.Log
+	lastBatch    uint64
+	lastVerified uint64
+}
+
+// NewMockL1 creates a mock L1 holding only its genesis block, which deploys the contracts
+func NewMockL1(cfg Config) (*MockL1, error) {
+	contractAbi, err := abi.JSON(strings.NewReader(etherman.RollupManagerReadAbi))
+	if err != nil {
+		return nil, err
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	owner := crypto.PubkeyToAddress(key.PublicKey)
+	code, err := mockContractCode(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &MockL1{
+		cfg:   cfg,
+		abi:   contractAbi,
+		key:   key,
+		owner: owner,
+		logs:  [][]types.Log{nil},
+	}
+
+	// the views that never change are set in the genesis, the rollup data moves on with the batches
+	rollupManagerStorage, err := m.viewStorage("rollupTypeMap", []interface{}{uint32(rollupTypeId)},
+		cfg.Rollup, common.Address{}, cfg.ForkId, uint8(0), false, [32]byte(cfg.GenesisRoot))
+	if err != nil {
+		return nil, err
+	}
+	rollupData, err := m.viewStorage("rollupIDToRollupData", m.rollupDataArgs(), m.rollupData(0, 0)...)
+	if err != nil {
+		return nil, err
+	}
+	for slot, value := range rollupData {
+		rollupManagerStorage[slot] = value
+	}
+	rollupStorage, err := m.viewStorage("trustedSequencer", nil, cfg.Sequencer)
+	if err != nil {
+		return nil, err
+	}
+	gerManagerView, err := m.viewStorage("globalExitRootManager", nil, cfg.GerManager)
+	if err != nil {
+		return nil, err
+	}
+	for slot, value := range gerManagerView {
+		rollupStorage[slot] = value
+	}
+
+	// the balance pays for the transactions of the mock L1 for as long as a devnet could run
+	balance, _ := new(big.Int).SetString("1000000000000000000000000000", 10)
+	alloc := types.GenesisAlloc{
+		owner:             {Balance: balance},
+		cfg.RollupManager: {Code: code, Storage: rollupManagerStorage, Balance: new(big.Int)},
+		cfg.Rollup:        {Code: code, Storage: rollupStorage, Balance: new(big.Int)},
+		cfg.GerManager:    {Code: code, Balance: new(big.Int)},
+	}
+	chainConfig := *params.TestChainConfig
+	chainConfig.ChainID = new(big.Int).SetUint64(cfg.L1ChainId)
+	m.backend = backends.NewSimulatedBackendWithConfig(alloc, &chainConfig, 30_000_000)
+	m.signer = types.LatestSignerForChainID(chainConfig.ChainID)
+
+	return m, nil
+}
+
+// Close stops the simulated backend
+func (m *MockL1) Close() {
+	m.backend.Close()
+}
+
+// Mine includes the queued events in a new block and returns it
+func (m *MockL1) Mine() *types.Block {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.backend.Commit()
+	block, err := m.backend.BlockByNumber(context.Background(), nil)
+	if err != nil {
+		panic(err)
+	}
+
+	// every transaction is the mock L1's own, the simulated backend doesn't keep the senders to read the receipts with
+	senders := make([]common.Address, len(block.Transactions()))
+	for i := range senders {
+		senders[i] = m.owner
+	}
+	var receipts types.Receipts
+	if err = m.backend.DB().View(context.Background(), func(tx kv.Tx) error {
+		receipts = rawdb.ReadReceipts(tx, block, senders)
+		return nil
+	}); err != nil {
+		panic(err)
+	}
+	if len(receipts) != len(senders) {
+		panic(fmt.Sprintf("no receipts for block %d of the mock L1", block.NumberU64()))
+	}
+
+	logs := make([]types.Log, 0)
+	for _, receipt := range receipts {
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			// only a broken mock contract fails a transaction of the mock L1
+			panic(fmt.Sprintf("transaction %s of the mock L1 failed", receipt.TxHash))
+		}
+		for _, l := range receipt.Logs {
+			logs = append(logs, *l)
+		}
+	}
+
+	m.latest = block.NumberU64()
+	m.logs = append(m.logs, logs)
+
+	return block
+}
+
+// LatestBlockNumber returns the number of the last block mined
+func (m *MockL1) LatestBlockNumber() uint64 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.latest
+}
+
+// ChainId returns the chain id of the mock L1
+func (m *MockL1) ChainId() uint64 {
+	return m.cfg.L1ChainId
+}
+
+// send sends a transaction of the mock L1 calling one of its contracts, it is included in the next block mined.  The
+// simulated backend only refuses it when the mock itself is broken.
+func (m *MockL1) send(to common.Address, data []byte) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	txn, err := types.SignTx(types.NewTransaction(m.nonce, to, uint256.NewInt(0), callGas, uint256.NewInt(gasPrice), data), *m.signer, m.key)
+	if err != nil {
+		panic(err)
+	}
+	if err = m.backend.SendTransaction(context.Background(), txn); err != nil {
+		panic(err)
+	}
+	m.nonce++
+}
+
+func (m *MockL1) queue(address common.Address, topics []common.Hash, data []byte) {
+	m.send(address, emitCall(topics, data))
+}
+
+// viewCall is the calldata of a view call to the contracts
+func (m *MockL1) viewCall(method string, args []interface{}) ([]byte, error) {
+	return m.abi.Pack(method, args...)
+}
+
+// viewStorage is the storage a contract answers a view call from with the given results
+func (m *MockL1) viewStorage(method string, args []interface{}, results ...interface{}) (map[common.Hash]common.Hash, error) {
+	call, err := m.viewCall(method, args)
+	if err != nil {
+		return nil, err
+	}
+	result, err := m.abi.Methods[method].Outputs.Pack(results...)
+	if err != nil {
+		return nil, err
+	}
+	return viewStorage(call, result), nil
+}
+
+func (m *MockL1) rollupDataArgs() []interface{} {
+	return []interface{}{uint32(m.cfg.RollupId)}
+}
+
+// rollupData is the result of rollupIDToRollupData for the devnet rollup
+func (m *MockL1) rollupData(lastBatch, lastVerified uint64) []interface{} {
+	return []interface{}{m.cfg.Rollup, m.cfg.L2ChainId, common.Address{}, m.cfg.ForkId, [32]byte{}, lastBatch, lastVerified, uint64(0), uint64(0), uint64(0), uint64(rollupTypeId), uint8(0)}
+}
+
+// setRollupData moves the batches in the rollup data of the rollup manager on
+func (m *MockL1) setRollupData(lastBatch, lastVerified uint64) {
+	call, err := m.viewCall("rollupIDToRollupData", m.rollupDataArgs())
+	if err != nil {
+		panic(err)
+	}
+	result, err := m.abi.Methods["rollupIDToRollupData"].Outputs.Pack(m.rollupData(lastBatch, lastVerified)...)
+	if err != nil {
+		panic(err)
+	}
+	m.send(m.cfg.RollupManager, setViewCall(call, result))
+}
+
+// InitialSequenceBatches queues the event of the rollup creation holding the injected first batch
+func (m *MockL1) InitialSequenceBatches(batchL2Data []byte, lastGer common.Hash, sequencer common.Address) {
+	// abi encoding of (bytes transactions, bytes32 lastGlobalExitRoot, address sequencer)
+	data := make([]byte, 0, 128+len(batchL2Data)+32)
+	data = append(data, common.BigToHash(big.NewInt(96)).Bytes()...)
+	data = append(data, lastGer.Bytes()...)
+	data = append(data, common.BytesToHash(sequencer.Bytes()).Bytes()...)
+	data = append(data, common.BigToHash(big.NewInt(int64(len(batchL2Data)))).Bytes()...)
+	data = append(data, batchL2Data...)
+	if rem := len(batchL2Data) % 32; rem != 0 {
+		data = append(data, make([]byte, 32-rem)...)
+	}
+
+	m.queue(m.cfg.Rollup, []common.Hash{contracts.InitialSequenceBatchesTopic}, data)
+}
+
+// ForceBatch queues the event of a batch forced on the rollup by a contract, which carries the transactions of the
+// batch in the event
+func (m *MockL1) ForceBatch(forcedBatch uint64, batchL2Data []byte, lastGer common.Hash, sequencer common.Address) {
+	// abi encoding of (bytes32 lastGlobalExitRoot, address sequencer, bytes transactions)
+	data := make([]byte, 0, 128+len(batchL2Data)+32)
+	data = append(data, lastGer.Bytes()...)
+	data = append(data, common.BytesToHash(sequencer.Bytes()).Bytes()...)
+	data = append(data, common.BigToHash(big.NewInt(96)).Bytes()...)
+	data = append(data, common.BigToHash(big.NewInt(int64(len(batchL2Data)))).Bytes()...)
+	data = append(data, batchL2Data...)
+	if rem := len(batchL2Data) % 32; rem != 0 {
+		data = append(data, make([]byte, 32-rem)...)
+	}
+
+	number := common.BigToHash(new(big.Int).SetUint64(forcedBatch))
+	m.queue(m.cfg.Rollup, []common.Hash{contracts.ForceBatchTopic, number}, data)
+}
+
+// UpdateL1InfoTree queues a GER manager update of the exit roots and returns the new GER
+func (m *MockL1) UpdateL1InfoTree(mainnetExitRoot, rollupExitRoot common.Hash) common.Hash {
+	m.queue(m.cfg.GerManager, []common.Hash{contracts.UpdateL1InfoTreeTopic, mainnetExitRoot, rollupExitRoot}, nil)
+	return crypto.Keccak256Hash(mainnetExitRoot.Bytes(), rollupExitRoot.Bytes())
+}
+
+// SequenceBatches queues the event of the trusted sequencer sequencing batches up to lastBatch
+func (m *MockL1) SequenceBatches(lastBatch uint64, l1InfoRoot common.Hash) {
+	m.lock.Lock()
+	m.lastBatch = lastBatch
+	lastVerified := m.lastVerified
+	m.lock.Unlock()
+	m.setRollupData(lastBatch, lastVerified)
+
+	batch := common.BigToHash(new(big.Int).SetUint64(lastBatch))
+	m.queue(m.cfg.Rollup, []common.Hash{contracts.SequenceBatchesTopic, batch}, l1InfoRoot.Bytes())
+}
+
+// VerifyBatches queues the event of the trusted aggregator verifying batches up to lastBatch
+func (m *MockL1) VerifyBatches(lastBatch uint64, stateRoot, exitRoot common.Hash, aggregator common.Address) {
+	m.lock.Lock()
+	m.lastVerified = lastBatch
+	lastSequenced := m.lastBatch
+	m.lock.Unlock()
+	m.setRollupData(lastSequenced, lastBatch)
+
+	rollupId := common.BigToHash(new(big.Int).SetUint64(m.cfg.RollupId))
+	data := make([]byte, 0, 96)
+	data = append(data, common.BigToHash(new(big.Int).SetUint64(lastBatch)).Bytes()...)
+	data = append(data, stateRoot.Bytes()...)
+	data = append(data, exitRoot.Bytes()...)
+
+	m.queue(m.cfg.RollupManager, []common.Hash{contracts.VerificationTopicEtrog, rollupId, common.BytesToHash(aggregator.Bytes())}, data)
+}
+
+// resolveNumber maps a requested block number to a mined block, nil and the negative rpc tags are the latest block as
+// everything on the mock L1 is final
+func (m *MockL1) resolveNumber(blockNumber *big.Int) (*big.Int, error) {
+	m.lock.RLock()
+	latest := m.latest
+	m.lock.RUnlock()
+
+	if blockNumber == nil || blockNumber.Sign() < 0 {
+		return new(big.Int).SetUint64(latest), nil
+	}
+	if !blockNumber.IsUint64() || blockNumber.Uint64() > latest {
+		return nil, ethereum.NotFound
+	}
+	return blockNumber, nil
+}
+
+func (m *MockL1) HeaderByNumber(ctx context.Context, blockNumber *big.Int) (*types.Header, error) {
+	number, err := m.resolveNumber(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return m.backend.HeaderByNumber(ctx, number)
+}
+
+func (m *MockL1) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(gasPrice), nil
+}
+
+func (m *MockL1) BlockByNumber(ctx context.Context, blockNumber *big.Int) (*types.Block, error) {
+	number, err := m.resolveNumber(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return m.backend.BlockByNumber(ctx, number)
+}
+
+// BlockByHash returns the mined block with the given hash
+func (m *MockL1) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	block, err := m.backend.BlockByHash(ctx, hash)
+	if err != nil || block.NumberU64() > m.LatestBlockNumber() {
+		// the simulated backend also knows the block being built
+		return nil, ethereum.NotFound
+	}
+	return block, nil
+}
+
+func (m *MockL1) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	from, to := uint64(0), m.LatestBlockNumber()
+	if query.BlockHash != nil {
+		block, err := m.BlockByHash(ctx, *query.BlockHash)
+		if err != nil {
+			return nil, err
+		}
+		from, to = block.NumberU64(), block.NumberU64()
+	} else {
+		if query.FromBlock != nil && query.FromBlock.Sign() >= 0 {
+			from = query.FromBlock.Uint64()
+		}
+		if query.ToBlock != nil && query.ToBlock.Sign() >= 0 && query.ToBlock.Uint64() < to {
+			to = query.ToBlock.Uint64()
+		}
+	}
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	result := make([]types.Log, 0)
+	for number := from; number <= to && number < uint64(len(m.logs)); number++ {
+		for _, l := range m.logs[number] {
+			if matchesQuery(l, query) {
+				result = append(result, l)
+			}
+		}
+	}
+	return result, nil
+}
+
+func matchesQuery(l types.Log, query ethereum.FilterQuery) bool {
+	if len(query.Addresses) > 0 {
+		found := false
+		for _, address := range query.Addresses {
+			if address == l.Address {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(query.Topics) > len(l.Topics) {
+		return false
+	}
+	for i, options := range query.Topics {
+		if len(options) == 0 {
+			continue
+		}
+		found := false
+		for _, topic := range options {
+			if topic == l.Topics[i] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// CallContract runs a view call on the contracts at the latest block
+func (m *MockL1) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if _, err := m.resolveNumber(blockNumber); err != nil {
+		return nil, err
+	}
+	// the simulated backend only calls on the latest state, which is all a mock L1 that never reorgs needs
+	return m.backend.CallContract(ctx, msg, nil)
+}
+
+// TransactionByHash returns a mined transaction of the mock L1
+func (m *MockL1) TransactionByHash(ctx context.Context, hash common.Hash) (types.Transaction, bool, error) {
+	txn, pending, err := m.backend.TransactionByHash(ctx, hash)
+	if err != nil {
+		return nil, false, err
+	}
+	if pending {
+		return nil, false, ethereum.NotFound
+	}
+	return txn, false, nil
+}
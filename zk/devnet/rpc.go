@@ -0,0 +1,97 @@
+package devnet
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/common/hexutility"
+	ethereum "github.com/ledgerwatch/erigon"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/filters"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/adapter/ethapi"
+)
+
+// L1API is the eth namespace of the devnet L1 RPC, enough of it for the L1 syncers of other nodes and the
+// init-from-l1 command to run against the mock L1
+type L1API struct {
+	l1 *MockL1
+}
+
+func newRpcServer(l1 *MockL1) *rpc.Server {
+	server := rpc.NewServer(16, false, true)
+	if err := server.RegisterName("eth", &L1API{l1: l1}); err != nil {
+		panic(err)
+	}
+	return server
+}
+
+// Serve serves the RPC of the mock L1 on a local port, it returns the url and the func stopping it
+func (m *MockL1) Serve() (string, func()) {
+	server := httptest.NewServer(newRpcServer(m))
+	return server.URL, server.Close
+}
+
+func (api *L1API) ChainId() hexutil.Uint64 {
+	return hexutil.Uint64(api.l1.ChainId())
+}
+
+func (api *L1API) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(api.l1.LatestBlockNumber())
+}
+
+func (api *L1API) GasPrice(ctx context.Context) (*hexutil.Big, error) {
+	price, err := api.l1.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(price), nil
+}
+
+func (api *L1API) GetBlockByNumber(ctx context.Context, number rpc.BlockNumber, fullTx bool) (map[string]interface{}, error) {
+	block, err := api.l1.BlockByNumber(ctx, big.NewInt(number.Int64()))
+	if err == ethereum.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ethapi.RPCMarshalBlock(block, true, fullTx, nil)
+}
+
+func (api *L1API) GetBlockByHash(ctx context.Context, hash common.Hash, fullTx bool) (map[string]interface{}, error) {
+	block, err := api.l1.BlockByHash(ctx, hash)
+	if err == ethereum.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ethapi.RPCMarshalBlock(block, true, fullTx, nil)
+}
+
+func (api *L1API) GetLogs(ctx context.Context, crit filters.FilterCriteria) ([]*types.Log, error) {
+	logs, err := api.l1.FilterLogs(ctx, ethereum.FilterQuery(crit))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*types.Log, len(logs))
+	for i := range logs {
+		result[i] = &logs[i]
+	}
+	return result, nil
+}
+
+func (api *L1API) Call(ctx context.Context, args ethapi.CallArgs, blockNrOrHash *rpc.BlockNumberOrHash) (hexutility.Bytes, error) {
+	var data []byte
+	if args.Input != nil {
+		data = *args.Input
+	} else if args.Data != nil {
+		data = *args.Data
+	}
+	return api.l1.CallContract(ctx, ethereum.CallMsg{To: args.To, Data: data}, nil)
+}
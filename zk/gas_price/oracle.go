@@ -0,0 +1,226 @@
+package gas_price
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/ethconfig"
+)
+
+// pollInterval is how often the oracle looks for a new L1 block, about a third of the L1 block time so no block is
+// missed
+const pollInterval = 3 * time.Second
+
+// significantDigits is how many leading digits of a suggested price are kept, the rest are zeroed so the price
+// doesn't move on every small change of the L1 price
+const significantDigits = 3
+
+// L1Reader is where the oracle reads the latest L1 block and the L1 eth_gasPrice from, syncer.L1Syncer is one
+type L1Reader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+}
+
+// Config holds what the suggested L2 gas price is calculated from besides the L1 gas price and the pool
+type Config struct {
+	Factor              float64 // applied to the median L1 gas price
+	DefaultGasPrice     uint64  // the floor of the suggested price
+	MaxGasPrice         uint64  // the ceiling of the suggested price, 0 for none
+	L1History           int     // how many L1 blocks the median L1 gas price is taken over
+	CongestionThreshold uint64  // pending transactions above which the price rises in proportion, 0 to disable
+}
+
+func ConfigFromZk(zk *ethconfig.Zk) Config {
+	return Config{
+		Factor:              zk.GasPriceFactor,
+		DefaultGasPrice:     zk.DefaultGasPrice,
+		MaxGasPrice:         zk.MaxGasPrice,
+		L1History:           zk.GasPriceL1History,
+		CongestionThreshold: zk.GasPriceCongestionThreshold,
+	}
+}
+
+type l1Sample struct {
+	number   uint64
+	gasPrice *big.Int
+}
+
+// Oracle suggests the L2 gas price for each block the sequencer seals from the L1 gas price over the latest L1 blocks
+// and the number of transactions waiting in the pool.  The L1 gas price is sampled once per L1 block from eth_gasPrice.
+// The price is stored against the block and sent to the RPC nodes with the block in the data stream, so every node
+// answers eth_gasPrice the same.
+type Oracle struct {
+	l1 L1Reader // nil for no L1, the suggested price stays at the floor
+
+	lock     sync.Mutex
+	cfg      Config
+	history  []l1Sample // oldest first
+	lastPoll time.Time
+	polling  atomic.Bool
+}
+
+func NewOracle(cfg Config, l1 L1Reader) *Oracle {
+	if cfg.L1History < 1 {
+		cfg.L1History = 1
+	}
+	return &Oracle{cfg: cfg, l1: l1}
+}
+
+func (o *Oracle) Config() Config {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return o.cfg
+}
+
+// SetConfig changes what the price is calculated from on a running sequencer, a shorter L1 history drops the oldest
+// samples straight away
+func (o *Oracle) SetConfig(cfg Config) error {
+	if cfg.Factor < 0 || cfg.L1History < 1 {
+		return fmt.Errorf("gas price factor can't be negative and the L1 history has to be at least 1, got %v and %d", cfg.Factor, cfg.L1History)
+	}
+	if cfg.MaxGasPrice > 0 && cfg.MaxGasPrice < cfg.DefaultGasPrice {
+		return fmt.Errorf("max gas price %d is below the default gas price %d", cfg.MaxGasPrice, cfg.DefaultGasPrice)
+	}
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.cfg = cfg
+	o.trimHistoryLocked()
+	return nil
+}
+
+// Poll looks for a new L1 block in the background if it hasn't looked for one within the poll interval.  It never
+// waits on the L1 so it can be called for every block the sequencer seals.
+func (o *Oracle) Poll(ctx context.Context) {
+	if o.l1 == nil {
+		return
+	}
+
+	o.lock.Lock()
+	due := time.Since(o.lastPoll) >= pollInterval
+	if due {
+		o.lastPoll = time.Now()
+	}
+	o.lock.Unlock()
+
+	if !due || !o.polling.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer o.polling.Store(false)
+		if err := o.sample(ctx); err != nil {
+			log.Warn("[gas-price] Failed to read the latest L1 block, keeping the L1 gas price history", "err", err)
+		}
+	}()
+}
+
+func (o *Oracle) sample(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, pollInterval)
+	defer cancel()
+
+	header, err := o.l1.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	number := header.Number.Uint64()
+	if o.sampled(number) {
+		return nil
+	}
+	gasPrice, err := o.l1.SuggestGasPrice(ctx)
+	if err != nil {
+		return err
+	}
+	o.AddL1Sample(number, gasPrice)
+	return nil
+}
+
+func (o *Oracle) sampled(number uint64) bool {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return len(o.history) > 0 && number <= o.history[len(o.history)-1].number
+}
+
+// AddL1Sample records the L1 gas price at an L1 block, blocks older than the latest recorded are ignored
+func (o *Oracle) AddL1Sample(number uint64, gasPrice *big.Int) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if len(o.history) > 0 && number <= o.history[len(o.history)-1].number {
+		return
+	}
+	o.history = append(o.history, l1Sample{number: number, gasPrice: new(big.Int).Set(gasPrice)})
+	o.trimHistoryLocked()
+}
+
+func (o *Oracle) trimHistoryLocked() {
+	if len(o.history) > o.cfg.L1History {
+		o.history = append(o.history[:0], o.history[len(o.history)-o.cfg.L1History:]...)
+	}
+}
+
+// L1GasPrice returns the median L1 gas price over the recorded L1 blocks, nil before any is recorded
+func (o *Oracle) L1GasPrice() *big.Int {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if len(o.history) == 0 {
+		return nil
+	}
+	prices := make([]*big.Int, len(o.history))
+	for i, s := range o.history {
+		prices[i] = s.gasPrice
+	}
+	sort.Slice(prices, func(i, j int) bool { return prices[i].Cmp(prices[j]) < 0 })
+	return new(big.Int).Set(prices[len(prices)/2])
+}
+
+// Suggest returns the L2 gas price for a block sealed with pending transactions waiting in the pool
+func (o *Oracle) Suggest(pending uint64) uint64 {
+	return Suggest(o.Config(), o.L1GasPrice(), pending)
+}
+
+// Suggest applies the factor to the L1 gas price, raises the result to the floor, scales it by how far the pending
+// transactions are over the congestion threshold and caps it, keeping the leading digits only
+func Suggest(cfg Config, l1GasPrice *big.Int, pending uint64) uint64 {
+	price := new(big.Int)
+	if l1GasPrice != nil {
+		scaled := new(big.Float).Mul(big.NewFloat(cfg.Factor), new(big.Float).SetInt(l1GasPrice))
+		scaled.Int(price)
+	}
+
+	if floor := new(big.Int).SetUint64(cfg.DefaultGasPrice); price.Cmp(floor) < 0 {
+		price = floor
+	}
+
+	// twice the threshold pending doubles the price
+	if cfg.CongestionThreshold > 0 && pending > cfg.CongestionThreshold {
+		price.Mul(price, new(big.Int).SetUint64(pending))
+		price.Div(price, new(big.Int).SetUint64(cfg.CongestionThreshold))
+	}
+
+	if ceiling := new(big.Int).SetUint64(cfg.MaxGasPrice); cfg.MaxGasPrice > 0 && price.Cmp(ceiling) > 0 {
+		price = ceiling
+	}
+	if !price.IsUint64() {
+		return Truncate(math.MaxUint64)
+	}
+	return Truncate(price.Uint64())
+}
+
+// Truncate zeroes all but the leading digits of a gas price
+func Truncate(price uint64) uint64 {
+	unit := uint64(1)
+	for p := price; p >= uint64(math.Pow10(significantDigits)); p /= 10 {
+		unit *= 10
+	}
+	return price / unit * unit
+}
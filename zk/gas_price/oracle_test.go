@@ -0,0 +1,119 @@
+package gas_price
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+type staticL1 struct {
+	header   *types.Header
+	gasPrice *big.Int
+	calls    int
+}
+
+func (s *staticL1) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return s.header, nil
+}
+
+func (s *staticL1) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	s.calls++
+	return s.gasPrice, nil
+}
+
+func TestSuggest(t *testing.T) {
+	cfg := Config{Factor: 0.5, DefaultGasPrice: 1_000_000_000, MaxGasPrice: 50_000_000_000, CongestionThreshold: 100}
+
+	tests := map[string]struct {
+		l1GasPrice *big.Int
+		pending    uint64
+		expected   uint64
+	}{
+		"no L1 price is the floor":        {nil, 0, 1_000_000_000},
+		"below the floor":                 {big.NewInt(1_000_000_000), 0, 1_000_000_000},
+		"factor applied and truncated":    {big.NewInt(12_345_678_901), 0, 6_170_000_000},
+		"congestion under threshold":      {big.NewInt(10_000_000_000), 100, 5_000_000_000},
+		"congestion scales the price":     {big.NewInt(10_000_000_000), 300, 15_000_000_000},
+		"congestion scales from floor":    {nil, 250, 2_500_000_000},
+		"capped at the max gas price":     {big.NewInt(200_000_000_000), 0, 50_000_000_000},
+		"capped after congestion scaling": {big.NewInt(60_000_000_000), 1000, 50_000_000_000},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Suggest(cfg, tt.l1GasPrice, tt.pending))
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, uint64(0), Truncate(0))
+	assert.Equal(t, uint64(999), Truncate(999))
+	assert.Equal(t, uint64(1230), Truncate(1234))
+	assert.Equal(t, uint64(987_000_000), Truncate(987_654_321))
+}
+
+func TestOracleL1History(t *testing.T) {
+	oracle := NewOracle(Config{Factor: 1, L1History: 3}, nil)
+	require.Nil(t, oracle.L1GasPrice())
+
+	oracle.AddL1Sample(1, big.NewInt(100))
+	oracle.AddL1Sample(2, big.NewInt(900))
+	oracle.AddL1Sample(3, big.NewInt(300))
+	assert.Equal(t, big.NewInt(300), oracle.L1GasPrice())
+
+	// the oldest block drops out of the history, a block seen already is ignored
+	oracle.AddL1Sample(4, big.NewInt(1000))
+	oracle.AddL1Sample(4, big.NewInt(1))
+	assert.Equal(t, big.NewInt(900), oracle.L1GasPrice())
+	assert.Equal(t, uint64(900), oracle.Suggest(0))
+}
+
+func TestOraclePoll(t *testing.T) {
+	l1 := &staticL1{header: &types.Header{Number: big.NewInt(7), BaseFee: big.NewInt(1)}, gasPrice: big.NewInt(2_000_000_000)}
+	oracle := NewOracle(Config{Factor: 1, L1History: 10}, l1)
+
+	// the L1 eth_gasPrice is sampled, not the base fee
+	require.NoError(t, oracle.sample(context.Background()))
+	assert.Equal(t, big.NewInt(2_000_000_000), oracle.L1GasPrice())
+
+	// the gas price is read once per L1 block
+	l1.gasPrice = big.NewInt(4_000_000_000)
+	require.NoError(t, oracle.sample(context.Background()))
+	assert.Equal(t, 1, l1.calls)
+	assert.Equal(t, big.NewInt(2_000_000_000), oracle.L1GasPrice())
+
+	l1.header = &types.Header{Number: big.NewInt(8)}
+	require.NoError(t, oracle.sample(context.Background()))
+	assert.Equal(t, 2, l1.calls)
+	assert.Equal(t, big.NewInt(4_000_000_000), oracle.L1GasPrice())
+}
+
+func TestOracleWithoutL1(t *testing.T) {
+	oracle := NewOracle(Config{Factor: 1, L1History: 3, DefaultGasPrice: 10}, nil)
+	oracle.Poll(context.Background())
+	require.False(t, oracle.polling.Load())
+	assert.Nil(t, oracle.L1GasPrice())
+	assert.Equal(t, uint64(10), oracle.Suggest(0))
+}
+
+func TestOracleSetConfig(t *testing.T) {
+	oracle := NewOracle(Config{Factor: 1, L1History: 3}, nil)
+	oracle.AddL1Sample(1, big.NewInt(100))
+	oracle.AddL1Sample(2, big.NewInt(900))
+	oracle.AddL1Sample(3, big.NewInt(300))
+
+	require.NoError(t, oracle.SetConfig(Config{Factor: 2, L1History: 1, DefaultGasPrice: 10}))
+	assert.Equal(t, Config{Factor: 2, L1History: 1, DefaultGasPrice: 10}, oracle.Config())
+	// only the latest sample is left
+	assert.Equal(t, big.NewInt(300), oracle.L1GasPrice())
+	assert.Equal(t, uint64(600), oracle.Suggest(0))
+
+	require.Error(t, oracle.SetConfig(Config{Factor: 1, L1History: 0}))
+	require.Error(t, oracle.SetConfig(Config{Factor: 1, L1History: 1, DefaultGasPrice: 10, MaxGasPrice: 5}))
+	assert.Equal(t, 2.0, oracle.Config().Factor, "a refused change is not applied")
+}
@@ -0,0 +1,86 @@
+package ha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+// lockRetryDelay is how often a locked lease file is tried again
+const lockRetryDelay = 10 * time.Millisecond
+
+// FileBackend keeps the lease in a json file guarded by a lock file, so sequencers sharing a host or a filesystem
+// with working locks can elect a leader without any other infrastructure
+type FileBackend struct {
+	path string
+	lock *flock.Flock
+}
+
+func NewFileBackend(path string) *FileBackend {
+	return &FileBackend{path: path, lock: flock.New(path + ".lock")}
+}
+
+func (b *FileBackend) Acquire(ctx context.Context, holder string, ttl time.Duration) (Lease, error) {
+	var lease Lease
+	err := b.update(ctx, func(current Lease) (Lease, error) {
+		lease = acquire(current, holder, ttl, time.Now())
+		return lease, nil
+	})
+	return lease, err
+}
+
+func (b *FileBackend) Release(ctx context.Context, holder string) error {
+	return b.update(ctx, func(current Lease) (Lease, error) {
+		return release(current, holder)
+	})
+}
+
+// update applies fn to the lease while holding the lock file, the new lease replaces the file in one rename so a
+// crash never leaves it half written
+func (b *FileBackend) update(ctx context.Context, fn func(Lease) (Lease, error)) error {
+	if err := os.MkdirAll(filepath.Dir(b.path), 0o755); err != nil {
+		return err
+	}
+	locked, err := b.lock.TryLockContext(ctx, lockRetryDelay)
+	if err != nil {
+		return fmt.Errorf("lock lease file: %w", err)
+	}
+	if !locked {
+		return fmt.Errorf("lock lease file %s", b.path)
+	}
+	defer b.lock.Unlock()
+
+	var current Lease
+	data, err := os.ReadFile(b.path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("read lease file: %w", err)
+	default:
+		if err := json.Unmarshal(data, &current); err != nil {
+			return fmt.Errorf("decode lease file %s: %w", b.path, err)
+		}
+	}
+
+	next, err := fn(current)
+	if err != nil {
+		return err
+	}
+	if next == current {
+		return nil
+	}
+	if data, err = json.Marshal(next); err != nil {
+		return err
+	}
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write lease file: %w", err)
+	}
+	return os.Rename(tmp, b.path)
+}
@@ -0,0 +1,37 @@
+package ha
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var sharedMemoryBackend = NewMemoryBackend()
+
+// MemoryBackend keeps the lease in process, for tests and nodes sharing a process
+type MemoryBackend struct {
+	lock  sync.Mutex
+	lease Lease
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{}
+}
+
+func (b *MemoryBackend) Acquire(_ context.Context, holder string, ttl time.Duration) (Lease, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.lease = acquire(b.lease, holder, ttl, time.Now())
+	return b.lease, nil
+}
+
+func (b *MemoryBackend) Release(_ context.Context, holder string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	lease, err := release(b.lease, holder)
+	if err != nil {
+		return err
+	}
+	b.lease = lease
+	return nil
+}
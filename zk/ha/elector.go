@@ -0,0 +1,140 @@
+package ha
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ledgerwatch/log/v3"
+)
+
+// Elector campaigns for the lease on behalf of a node and keeps renewing it once it has it.
+//
+// A leader only considers itself the leader until its lease would expire as seen from when it last asked for it,
+// less a safety margin for clock drift.  A leader that can no longer reach the backend therefore stops sequencing
+// before any standby can acquire the lease, which is what fences it out of the chain.  Leadership is only ever held
+// for a single term: once lost the elector stops campaigning, the node's chain may have been overtaken by the new
+// leader so it has to rejoin as a standby.
+type Elector struct {
+	backend Backend
+	id      string
+	ttl     time.Duration
+	margin  time.Duration
+
+	lock     sync.Mutex
+	lease    Lease
+	deadline time.Time // local time until which the node may act as the leader
+	elected  chan struct{}
+	lost     chan struct{}
+}
+
+func NewElector(backend Backend, id string, ttl time.Duration) *Elector {
+	return &Elector{
+		backend: backend,
+		id:      id,
+		ttl:     ttl,
+		margin:  ttl / 5,
+		elected: make(chan struct{}),
+		lost:    make(chan struct{}),
+	}
+}
+
+func (e *Elector) Id() string {
+	return e.id
+}
+
+// Run campaigns until ctx is done or the lease is lost, the lease is then released for a standby to take over
+// straight away
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+	for {
+		e.campaign(ctx)
+		select {
+		case <-e.lost:
+			return
+		case <-ctx.Done():
+			if e.IsLeader() {
+				releaseCtx, cancel := context.WithTimeout(context.Background(), e.ttl)
+				if err := e.backend.Release(releaseCtx, e.id); err != nil {
+					log.Warn("[ha] Failed to release the sequencer lease", "id", e.id, "err", err)
+				}
+				cancel()
+			}
+			e.lock.Lock()
+			e.deadline = time.Time{}
+			e.lock.Unlock()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Elector) campaign(ctx context.Context) {
+	started := time.Now()
+	lease, err := e.backend.Acquire(ctx, e.id, e.ttl)
+	if err != nil {
+		// the deadline is left to run out so a leader keeps sequencing through a blip in the backend
+		log.Warn("[ha] Failed to acquire the sequencer lease", "id", e.id, "err", err)
+		return
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	wasLeader := e.isElected()
+	term := e.lease.Term
+	e.lease = lease
+	if wasLeader && (lease.Holder != e.id || lease.Term != term) {
+		// the lease was taken over, or expired and was acquired again under a new term, since we last renewed it
+		log.Warn("[ha] Sequencer lease lost", "id", e.id, "holder", lease.Holder, "term", lease.Term)
+		e.deadline = time.Time{}
+		close(e.lost)
+		return
+	}
+	if lease.Holder != e.id {
+		return
+	}
+	e.deadline = started.Add(e.ttl - e.margin)
+	if !wasLeader {
+		log.Info("[ha] Sequencer lease acquired", "id", e.id, "term", lease.Term)
+		close(e.elected)
+	}
+}
+
+func (e *Elector) isElected() bool {
+	select {
+	case <-e.elected:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsLeader reports whether the node holds the lease and may produce blocks right now
+func (e *Elector) IsLeader() bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return time.Now().Before(e.deadline)
+}
+
+// Lost is closed once the node lost the lease it held
+func (e *Elector) Lost() <-chan struct{} {
+	return e.lost
+}
+
+// Lease returns the lease as last seen in the backend
+func (e *Elector) Lease() Lease {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.lease
+}
+
+// WaitForLeadership blocks until the node first acquires the lease, returning the term it acquired
+func (e *Elector) WaitForLeadership(ctx context.Context) (uint64, error) {
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-e.elected:
+		return e.Lease().Term, nil
+	}
+}
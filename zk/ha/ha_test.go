@@ -0,0 +1,157 @@
+package ha
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testBackend(t *testing.T, backend Backend) {
+	ctx := context.Background()
+	ttl := 200 * time.Millisecond
+
+	lease, err := backend.Acquire(ctx, "a", ttl)
+	require.NoError(t, err)
+	require.Equal(t, "a", lease.Holder)
+	require.Equal(t, uint64(1), lease.Term)
+
+	// held by a, so b only sees it
+	lease, err = backend.Acquire(ctx, "b", ttl)
+	require.NoError(t, err)
+	require.Equal(t, "a", lease.Holder)
+	require.ErrorIs(t, backend.Release(ctx, "b"), ErrNotHolder)
+
+	// renewing keeps the term
+	lease, err = backend.Acquire(ctx, "a", ttl)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), lease.Term)
+
+	// b takes over once it expires
+	time.Sleep(ttl)
+	lease, err = backend.Acquire(ctx, "b", ttl)
+	require.NoError(t, err)
+	require.Equal(t, "b", lease.Holder)
+	require.Equal(t, uint64(2), lease.Term)
+
+	// and a straight away once b releases it
+	require.NoError(t, backend.Release(ctx, "b"))
+	lease, err = backend.Acquire(ctx, "a", ttl)
+	require.NoError(t, err)
+	require.Equal(t, "a", lease.Holder)
+	require.Equal(t, uint64(3), lease.Term)
+}
+
+func TestMemoryBackend(t *testing.T) {
+	testBackend(t, NewMemoryBackend())
+}
+
+func TestFileBackend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lease.json")
+	testBackend(t, NewFileBackend(path))
+
+	// the lease survives the backend
+	lease, err := NewFileBackend(path).Acquire(context.Background(), "b", time.Second)
+	require.NoError(t, err)
+	require.Equal(t, "a", lease.Holder)
+}
+
+func TestNewBackend(t *testing.T) {
+	backend, err := NewBackend("file://" + filepath.Join(t.TempDir(), "lease.json"))
+	require.NoError(t, err)
+	require.IsType(t, &FileBackend{}, backend)
+
+	backend, err = NewBackend("memory://")
+	require.NoError(t, err)
+	require.IsType(t, &MemoryBackend{}, backend)
+
+	_, err = NewBackend("etcd://localhost:2379")
+	require.Error(t, err)
+}
+
+func TestElectorFailover(t *testing.T) {
+	backend := NewMemoryBackend()
+	ttl := 300 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	leaderCtx, stopLeader := context.WithCancel(ctx)
+	leader := NewElector(backend, "a", ttl)
+	leaderDone := make(chan struct{})
+	go func() {
+		leader.Run(leaderCtx)
+		close(leaderDone)
+	}()
+	term, err := leader.WaitForLeadership(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), term)
+	require.True(t, leader.IsLeader())
+
+	standby := NewElector(backend, "b", ttl)
+	go standby.Run(ctx)
+	time.Sleep(ttl)
+	require.False(t, standby.IsLeader())
+	require.Equal(t, "a", standby.Lease().Holder)
+
+	// the leader releases the lease on the way out so the standby doesn't wait for it to expire
+	stopLeader()
+	<-leaderDone
+	require.False(t, leader.IsLeader())
+	term, err = standby.WaitForLeadership(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), term)
+	require.True(t, standby.IsLeader())
+}
+
+type flakyBackend struct {
+	Backend
+	down atomic.Bool
+}
+
+func (b *flakyBackend) Acquire(ctx context.Context, holder string, ttl time.Duration) (Lease, error) {
+	if b.down.Load() {
+		return Lease{}, errors.New("unreachable")
+	}
+	return b.Backend.Acquire(ctx, holder, ttl)
+}
+
+func TestElectorFencesItself(t *testing.T) {
+	shared := NewMemoryBackend()
+	flaky := &flakyBackend{Backend: shared}
+	ttl := 300 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	leader := NewElector(flaky, "a", ttl)
+	go leader.Run(ctx)
+	_, err := leader.WaitForLeadership(ctx)
+	require.NoError(t, err)
+
+	// cut off from the backend the leader stops sequencing before its lease expires for everyone else
+	flaky.down.Store(true)
+	for leader.IsLeader() {
+		time.Sleep(5 * time.Millisecond)
+	}
+	lease, err := shared.Acquire(ctx, "b", ttl)
+	require.NoError(t, err)
+	require.Equal(t, "a", lease.Holder)
+
+	standby := NewElector(shared, "b", ttl)
+	go standby.Run(ctx)
+	term, err := standby.WaitForLeadership(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), term)
+
+	// and never comes back once it reaches the backend again
+	flaky.down.Store(false)
+	select {
+	case <-leader.Lost():
+	case <-ctx.Done():
+		t.Fatal("leader didn't notice it lost the lease")
+	}
+	require.False(t, leader.IsLeader())
+}
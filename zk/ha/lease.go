@@ -0,0 +1,73 @@
+package ha
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+var ErrNotHolder = errors.New("lease is held by another node")
+
+// Lease is the right to sequence.  Term grows every time the lease changes hands and is used to fence a sequencer
+// that lost it: a node only produces blocks under the term it acquired.
+type Lease struct {
+	Holder string    `json:"holder"`
+	Term   uint64    `json:"term"`
+	Expiry time.Time `json:"expiry"`
+}
+
+func (l Lease) heldBy(holder string, now time.Time) bool {
+	return l.Holder == holder && now.Before(l.Expiry)
+}
+
+// Backend stores the lease shared by the sequencers of a chain
+type Backend interface {
+	// Acquire takes the lease for holder, or renews it if holder already has it, as long as it is free or expired.
+	// It returns the lease as it stands afterwards whoever holds it.
+	Acquire(ctx context.Context, holder string, ttl time.Duration) (Lease, error)
+
+	// Release gives up the lease if holder has it so a standby can take over without waiting for it to expire
+	Release(ctx context.Context, holder string) error
+}
+
+// acquire is the lease transition every backend applies atomically
+func acquire(current Lease, holder string, ttl time.Duration, now time.Time) Lease {
+	if current.Holder != "" && current.Holder != holder && now.Before(current.Expiry) {
+		return current
+	}
+	next := Lease{Holder: holder, Term: current.Term, Expiry: now.Add(ttl)}
+	if current.Holder != holder || !now.Before(current.Expiry) {
+		next.Term++
+	}
+	return next
+}
+
+// release is the lease once holder gave it up, the term is kept so the next holder gets a higher one
+func release(current Lease, holder string) (Lease, error) {
+	if current.Holder != holder {
+		return current, ErrNotHolder
+	}
+	return Lease{Term: current.Term}, nil
+}
+
+// NewBackend returns the backend for a lease url, "memory://" for a process wide lease or "file:///path" for a lease
+// file shared by the nodes on a host or a shared filesystem
+func NewBackend(rawUrl string) (Backend, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, fmt.Errorf("parse lease url %s: %w", rawUrl, err)
+	}
+	switch u.Scheme {
+	case "memory":
+		return sharedMemoryBackend, nil
+	case "file":
+		if u.Path == "" {
+			return nil, fmt.Errorf("lease url %s has no path", rawUrl)
+		}
+		return NewFileBackend(u.Path), nil
+	default:
+		return nil, fmt.Errorf("unsupported lease backend %q", u.Scheme)
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"encoding/json"
 
+	"github.com/ledgerwatch/erigon/crypto"
 	dstypes "github.com/ledgerwatch/erigon/zk/datastream/types"
 	"github.com/ledgerwatch/erigon/zk/types"
 	"github.com/ledgerwatch/log/v3"
@@ -39,6 +40,13 @@ const L1_INFO_TREE_HIGHEST_BLOCK = "l1_info_tree_highest_block"        // highes
 const REUSED_L1_INFO_TREE_INDEX = "reused_l1_info_tree_index"          // block number => const 1
 const LATEST_USED_GER = "latest_used_ger"                              // batch number -> GER latest used GER
 const BATCH_BLOCKS = "batch_blocks"                                    // batch number -> block numbers (concatenated together)
+const L2_TX_HASHES = "hermez_l2TxHashes"                               // l2TxHash -> txHash
+const FORK_HISTORY = "hermez_forkHistory"                              // forkId -> ForkInterval from L1
+const L1_FORCED_BATCHES = "l1_forced_batches"                          // forced batch number -> L1ForcedBatch
+const BATCH_FORCED_BATCHES = "batch_forced_batches"                    // batch number -> forced batch number it sequenced
+const BLOCK_L2_GAS_PRICES = "block_l2_gas_prices"                      // block number -> suggested l2 gas price
+const BATCH_SEAL_REASONS = "batch_seal_reasons"                        // batch number -> why the sequencer sealed it
+const BLOCK_UNEXECUTED_TXS = "block_unexecuted_txs"                    // block number -> forced transactions kept in it unexecuted
 
 type HermezDb struct {
 	tx kv.RwTx
@@ -90,6 +98,13 @@ func CreateHermezBuckets(tx kv.RwTx) error {
 		REUSED_L1_INFO_TREE_INDEX,
 		LATEST_USED_GER,
 		BATCH_BLOCKS,
+		L2_TX_HASHES,
+		FORK_HISTORY,
+		L1_FORCED_BATCHES,
+		BATCH_FORCED_BATCHES,
+		BLOCK_L2_GAS_PRICES,
+		BATCH_SEAL_REASONS,
+		BLOCK_UNEXECUTED_TXS,
 	}
 	for _, t := range tables {
 		if err := tx.CreateBucket(t); err != nil {
@@ -351,6 +366,10 @@ func (db *HermezDbReader) getLatest(table string) (*types.L1BatchInfo, error) {
 		}
 	}
 
+	if value == nil {
+		return nil, nil
+	}
+
 	if len(value) != 96 && len(value) != 64 {
 		return nil, fmt.Errorf("invalid hash length")
 	}
@@ -906,6 +925,55 @@ func (db *HermezDb) DeleteForkIds(fromBatchNum, toBatchNum uint64) error {
 	return db.deleteFromBucketWithUintKeysRange(FORKIDS, fromBatchNum, toBatchNum)
 }
 
+func (db *HermezDb) WriteForkInterval(interval *types.ForkInterval) error {
+	return db.tx.Put(FORK_HISTORY, Uint64ToBytes(interval.ForkId), interval.Marshall())
+}
+
+// GetForkIntervals returns the fork activations seen on L1 ordered by fork id
+func (db *HermezDbReader) GetForkIntervals() ([]types.ForkInterval, error) {
+	c, err := db.tx.Cursor(FORK_HISTORY)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	var intervals []types.ForkInterval
+	var k, v []byte
+
+	for k, v, err = c.First(); k != nil; k, v, err = c.Next() {
+		if err != nil {
+			break
+		}
+		interval := types.ForkInterval{}
+		if err = interval.Unmarshall(v); err != nil {
+			return nil, err
+		}
+		intervals = append(intervals, interval)
+	}
+
+	return intervals, err
+}
+
+func (db *HermezDbReader) GetForkIdBlocks() (map[uint64]uint64, error) {
+	c, err := db.tx.Cursor(FORKID_BLOCK)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	forkBlocks := make(map[uint64]uint64)
+	var k, v []byte
+
+	for k, v, err = c.First(); k != nil; k, v, err = c.Next() {
+		if err != nil {
+			break
+		}
+		forkBlocks[BytesToUint64(k)] = BytesToUint64(v)
+	}
+
+	return forkBlocks, err
+}
+
 func (db *HermezDb) WriteEffectiveGasPricePercentage(txHash common.Hash, txPricePercentage uint8) error {
 	return db.tx.Put(TX_PRICE_PERCENTAGE, txHash.Bytes(), Uint8ToBytes(txPricePercentage))
 }
@@ -930,6 +998,33 @@ func (db *HermezDb) DeleteEffectiveGasPricePercentages(txHashes *[]common.Hash)
 	return nil
 }
 
+func (db *HermezDb) WriteL2TxHash(l2TxHash, txHash common.Hash) error {
+	return db.tx.Put(L2_TX_HASHES, l2TxHash.Bytes(), txHash.Bytes())
+}
+
+func (db *HermezDbReader) GetTxHashByL2TxHash(l2TxHash common.Hash) (common.Hash, bool, error) {
+	data, err := db.tx.GetOne(L2_TX_HASHES, l2TxHash.Bytes())
+	if err != nil {
+		return common.Hash{}, false, err
+	}
+	if len(data) == 0 {
+		return common.Hash{}, false, nil
+	}
+
+	return common.BytesToHash(data), true, nil
+}
+
+func (db *HermezDb) DeleteL2TxHashes(l2TxHashes *[]common.Hash) error {
+	for _, l2TxHash := range *l2TxHashes {
+		err := db.tx.Delete(L2_TX_HASHES, l2TxHash.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (db *HermezDb) WriteStateRoot(l2BlockNo uint64, rpcRoot common.Hash) error {
 	return db.tx.Put(STATE_ROOTS, Uint64ToBytes(l2BlockNo), rpcRoot.Bytes())
 }
@@ -1128,6 +1223,106 @@ func (db *HermezDbReader) GetL1InjectedBatch(index uint64) (*types.L1InjectedBat
 	return ib, nil
 }
 
+func (db *HermezDb) WriteL1ForcedBatch(batch *types.L1ForcedBatch) error {
+	return db.tx.Put(L1_FORCED_BATCHES, Uint64ToBytes(batch.ForcedBatchNumber), batch.Marshall())
+}
+
+func (db *HermezDbReader) GetL1ForcedBatch(forcedBatchNumber uint64) (*types.L1ForcedBatch, error) {
+	v, err := db.tx.GetOne(L1_FORCED_BATCHES, Uint64ToBytes(forcedBatchNumber))
+	if err != nil {
+		return nil, err
+	}
+	if len(v) == 0 {
+		return nil, nil
+	}
+	fb := new(types.L1ForcedBatch)
+	if err = fb.Unmarshall(v); err != nil {
+		return nil, err
+	}
+	return fb, nil
+}
+
+// GetL1ForcedBatchByTransactionsHash finds the forced batch whose transactions hash to hash, a sequence only has the
+// hash of the transactions of the forced batches it includes.  There are few forced batches so they are searched.
+func (db *HermezDbReader) GetL1ForcedBatchByTransactionsHash(hash common.Hash) (*types.L1ForcedBatch, error) {
+	c, err := db.tx.Cursor(L1_FORCED_BATCHES)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	var k, v []byte
+	for k, v, err = c.First(); k != nil; k, v, err = c.Next() {
+		if err != nil {
+			return nil, err
+		}
+		fb := new(types.L1ForcedBatch)
+		if err = fb.Unmarshall(v); err != nil {
+			return nil, err
+		}
+		if crypto.Keccak256Hash(fb.Transactions) == hash {
+			return fb, nil
+		}
+	}
+	return nil, err
+}
+
+func (db *HermezDb) WriteBatchForcedBatchNumber(batchNo, forcedBatchNumber uint64) error {
+	return db.tx.Put(BATCH_FORCED_BATCHES, Uint64ToBytes(batchNo), Uint64ToBytes(forcedBatchNumber))
+}
+
+// GetForcedBatchNumberByBatch returns the forced batch sequenced in the batch, if the batch was a forced one
+func (db *HermezDbReader) GetForcedBatchNumberByBatch(batchNo uint64) (uint64, bool, error) {
+	v, err := db.tx.GetOne(BATCH_FORCED_BATCHES, Uint64ToBytes(batchNo))
+	if err != nil {
+		return 0, false, err
+	}
+	if len(v) == 0 {
+		return 0, false, nil
+	}
+	return BytesToUint64(v), true, nil
+}
+
+// GetHighestUsedForcedBatchNumber returns the last forced batch that has been sequenced, forced batches are always
+// sequenced in order so this is the forced batch in the highest batch
+func (db *HermezDbReader) GetHighestUsedForcedBatchNumber() (uint64, error) {
+	c, err := db.tx.Cursor(BATCH_FORCED_BATCHES)
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close()
+
+	k, v, err := c.Last()
+	if err != nil {
+		return 0, err
+	}
+	if k == nil {
+		return 0, nil
+	}
+
+	return BytesToUint64(v), nil
+}
+
+// TruncateBatchForcedBatchNumbers removes the forced batch records for every batch after the given batch
+func (db *HermezDb) TruncateBatchForcedBatchNumbers(afterBatch uint64) error {
+	c, err := db.tx.RwCursor(BATCH_FORCED_BATCHES)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	for k, _, err := c.Seek(Uint64ToBytes(afterBatch + 1)); k != nil; k, _, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		if err = c.DeleteCurrent(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (db *HermezDb) WriteBlockInfoRoot(blockNumber uint64, root common.Hash) error {
 	k := Uint64ToBytes(blockNumber)
 	return db.tx.Put(BLOCK_INFO_ROOTS, k, root.Bytes())
@@ -1143,6 +1338,79 @@ func (db *HermezDbReader) GetBlockInfoRoot(blockNumber uint64) (common.Hash, err
 	return res, nil
 }
 
+func (db *HermezDb) WriteBlockL2GasPrice(blockNumber uint64, gasPrice uint64) error {
+	return db.tx.Put(BLOCK_L2_GAS_PRICES, Uint64ToBytes(blockNumber), Uint64ToBytes(gasPrice))
+}
+
+// GetBlockL2GasPrice returns the l2 gas price the sequencer suggested when it sealed the block, found is false
+// for blocks sealed by a sequencer that didn't suggest one
+func (db *HermezDbReader) GetBlockL2GasPrice(blockNumber uint64) (gasPrice uint64, found bool, err error) {
+	v, err := db.tx.GetOne(BLOCK_L2_GAS_PRICES, Uint64ToBytes(blockNumber))
+	if err != nil || len(v) == 0 {
+		return 0, false, err
+	}
+	return BytesToUint64(v), true, nil
+}
+
+func (db *HermezDb) DeleteBlockL2GasPrices(fromBlockNum, toBlockNum uint64) error {
+	return db.deleteFromBucketWithUintKeysRange(BLOCK_L2_GAS_PRICES, fromBlockNum, toBlockNum)
+}
+
+func (db *HermezDb) WriteBatchSealReason(batchNumber uint64, reason string) error {
+	return db.tx.Put(BATCH_SEAL_REASONS, Uint64ToBytes(batchNumber), []byte(reason))
+}
+
+// GetBatchSealReason returns why the sequencer sealed a batch, empty for batches it didn't seal itself or sealed
+// before it recorded why
+func (db *HermezDbReader) GetBatchSealReason(batchNumber uint64) (string, error) {
+	v, err := db.tx.GetOne(BATCH_SEAL_REASONS, Uint64ToBytes(batchNumber))
+	if err != nil {
+		return "", err
+	}
+	return string(v), nil
+}
+
+// TruncateBatchSealReasons removes the seal reasons of every batch after the given batch
+func (db *HermezDb) TruncateBatchSealReasons(afterBatch uint64) error {
+	c, err := db.tx.RwCursor(BATCH_SEAL_REASONS)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	for k, _, err := c.Seek(Uint64ToBytes(afterBatch + 1)); k != nil; k, _, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		if err = c.DeleteCurrent(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *HermezDb) WriteBlockUnexecutedTransactions(blockNumber uint64, txs []types.UnexecutedTransaction) error {
+	if len(txs) == 0 {
+		return nil
+	}
+	return db.tx.Put(BLOCK_UNEXECUTED_TXS, Uint64ToBytes(blockNumber), types.MarshallUnexecutedTransactions(txs))
+}
+
+// GetBlockUnexecutedTransactions returns the transactions of a forced batch a block carries without executing them,
+// ordered by their index in the block
+func (db *HermezDbReader) GetBlockUnexecutedTransactions(blockNumber uint64) ([]types.UnexecutedTransaction, error) {
+	v, err := db.tx.GetOne(BLOCK_UNEXECUTED_TXS, Uint64ToBytes(blockNumber))
+	if err != nil || len(v) == 0 {
+		return nil, err
+	}
+	return types.UnmarshallUnexecutedTransactions(v)
+}
+
+func (db *HermezDb) DeleteBlockUnexecutedTransactions(fromBlockNum, toBlockNum uint64) error {
+	return db.deleteFromBucketWithUintKeysRange(BLOCK_UNEXECUTED_TXS, fromBlockNum, toBlockNum)
+}
+
 func (db *HermezDb) WriteWitness(batchNumber uint64, witness []byte) error {
 	return db.tx.Put(BATCH_WITNESSES, Uint64ToBytes(batchNumber), witness)
 }
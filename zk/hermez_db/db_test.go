@@ -6,6 +6,8 @@ import (
 	"github.com/gateway-fm/cdk-erigon-lib/common"
 	"github.com/gateway-fm/cdk-erigon-lib/kv"
 	"github.com/gateway-fm/cdk-erigon-lib/kv/mdbx"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/zk/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -361,6 +363,181 @@ func TestTruncateBlockBatches(t *testing.T) {
 	}
 }
 
+func TestGetTxHashByL2TxHash(t *testing.T) {
+	tx, cleanup := GetDbTx()
+	defer cleanup()
+	db := NewHermezDb(tx)
+
+	l2TxHash := common.HexToHash("0xabc")
+	txHash := common.HexToHash("0xdef")
+
+	err := db.WriteL2TxHash(l2TxHash, txHash)
+	require.NoError(t, err, "Failed to write L2 tx hash")
+
+	fetched, found, err := db.GetTxHashByL2TxHash(l2TxHash)
+	require.NoError(t, err, "Failed to get tx hash")
+	assert.True(t, found, "Expected L2 tx hash to be found")
+	assert.Equal(t, txHash, fetched, "Fetched tx hash doesn't match expected")
+
+	err = db.DeleteL2TxHashes(&[]common.Hash{l2TxHash})
+	require.NoError(t, err, "Failed to delete L2 tx hashes")
+
+	_, found, err = db.GetTxHashByL2TxHash(l2TxHash)
+	require.NoError(t, err, "Failed to get tx hash")
+	assert.False(t, found, "Expected L2 tx hash to be deleted")
+}
+
+func TestGetForkIntervals(t *testing.T) {
+	tx, cleanup := GetDbTx()
+	defer cleanup()
+	db := NewHermezDb(tx)
+
+	expected := []types.ForkInterval{
+		{ForkId: 5, FromBatchNumber: 1, L1BlockNumber: 100, Version: "v2.0.0-RC1-fork.5"},
+		{ForkId: 7, FromBatchNumber: 1001, L1BlockNumber: 200, Version: "v3.0.0-RC1-fork.7"},
+	}
+
+	// write out of order to check the results come back sorted by fork id
+	for i := len(expected) - 1; i >= 0; i-- {
+		err := db.WriteForkInterval(&expected[i])
+		require.NoError(t, err, "Failed to write fork interval")
+	}
+
+	intervals, err := db.GetForkIntervals()
+	require.NoError(t, err, "Failed to get fork intervals")
+	assert.Equal(t, expected, intervals, "Fetched fork intervals don't match expected")
+}
+
+func TestForcedBatches(t *testing.T) {
+	tx, cleanup := GetDbTx()
+	defer cleanup()
+	db := NewHermezDb(tx)
+
+	forced := &types.L1ForcedBatch{
+		ForcedBatchNumber: 1,
+		L1BlockNumber:     100,
+		Timestamp:         1000,
+		L1ParentHash:      common.HexToHash("0x1"),
+		GlobalExitRoot:    common.HexToHash("0x2"),
+		Sequencer:         common.HexToAddress("0x3"),
+		Transactions:      []byte{0x0b, 0x01},
+	}
+	require.NoError(t, db.WriteL1ForcedBatch(forced), "Failed to write forced batch")
+
+	fetched, err := db.GetL1ForcedBatch(1)
+	require.NoError(t, err, "Failed to get forced batch")
+	assert.Equal(t, forced, fetched, "Fetched forced batch doesn't match expected")
+
+	fetched, err = db.GetL1ForcedBatch(2)
+	require.NoError(t, err, "Failed to get forced batch")
+	assert.Nil(t, fetched, "Expected no forced batch")
+
+	fetched, err = db.GetL1ForcedBatchByTransactionsHash(crypto.Keccak256Hash(forced.Transactions))
+	require.NoError(t, err, "Failed to get forced batch by transactions hash")
+	assert.Equal(t, forced, fetched, "Fetched forced batch doesn't match expected")
+
+	fetched, err = db.GetL1ForcedBatchByTransactionsHash(common.HexToHash("0x4"))
+	require.NoError(t, err, "Failed to get forced batch by transactions hash")
+	assert.Nil(t, fetched, "Expected no forced batch")
+
+	require.NoError(t, db.WriteBatchForcedBatchNumber(5, 1), "Failed to write batch forced batch number")
+	require.NoError(t, db.WriteBatchForcedBatchNumber(8, 2), "Failed to write batch forced batch number")
+
+	highest, err := db.GetHighestUsedForcedBatchNumber()
+	require.NoError(t, err, "Failed to get highest used forced batch")
+	assert.Equal(t, uint64(2), highest)
+
+	require.NoError(t, db.TruncateBatchForcedBatchNumbers(5), "Failed to truncate batch forced batch numbers")
+
+	highest, err = db.GetHighestUsedForcedBatchNumber()
+	require.NoError(t, err, "Failed to get highest used forced batch")
+	assert.Equal(t, uint64(1), highest)
+
+	forcedBatchNo, found, err := db.GetForcedBatchNumberByBatch(5)
+	require.NoError(t, err, "Failed to get forced batch number")
+	assert.True(t, found)
+	assert.Equal(t, uint64(1), forcedBatchNo)
+
+	_, found, err = db.GetForcedBatchNumberByBatch(8)
+	require.NoError(t, err, "Failed to get forced batch number")
+	assert.False(t, found)
+}
+
+func TestBlockL2GasPrices(t *testing.T) {
+	tx, cleanup := GetDbTx()
+	defer cleanup()
+	db := NewHermezDb(tx)
+
+	for i := uint64(1); i <= 10; i++ {
+		require.NoError(t, db.WriteBlockL2GasPrice(i, i*1_000_000_000), "Failed to write l2 gas price")
+	}
+
+	gasPrice, found, err := db.GetBlockL2GasPrice(4)
+	require.NoError(t, err, "Failed to get l2 gas price")
+	assert.True(t, found)
+	assert.Equal(t, uint64(4_000_000_000), gasPrice)
+
+	require.NoError(t, db.DeleteBlockL2GasPrices(6, 10), "Failed to delete l2 gas prices")
+
+	_, found, err = db.GetBlockL2GasPrice(6)
+	require.NoError(t, err, "Failed to get l2 gas price")
+	assert.False(t, found)
+
+	_, found, err = db.GetBlockL2GasPrice(5)
+	require.NoError(t, err, "Failed to get l2 gas price")
+	assert.True(t, found)
+}
+
+func TestBatchSealReasons(t *testing.T) {
+	tx, cleanup := GetDbTx()
+	defer cleanup()
+	db := NewHermezDb(tx)
+
+	require.NoError(t, db.WriteBatchSealReason(1, "injected"), "Failed to write seal reason")
+	require.NoError(t, db.WriteBatchSealReason(2, "batch-timer"), "Failed to write seal reason")
+	require.NoError(t, db.WriteBatchSealReason(3, "overflow"), "Failed to write seal reason")
+
+	reason, err := db.GetBatchSealReason(2)
+	require.NoError(t, err, "Failed to get seal reason")
+	assert.Equal(t, "batch-timer", reason)
+
+	require.NoError(t, db.TruncateBatchSealReasons(2), "Failed to truncate seal reasons")
+
+	reason, err = db.GetBatchSealReason(3)
+	require.NoError(t, err, "Failed to get seal reason")
+	assert.Empty(t, reason)
+
+	reason, err = db.GetBatchSealReason(2)
+	require.NoError(t, err, "Failed to get seal reason")
+	assert.Equal(t, "batch-timer", reason)
+}
+
+func TestBlockUnexecutedTransactions(t *testing.T) {
+	tx, cleanup := GetDbTx()
+	defer cleanup()
+	db := NewHermezDb(tx)
+
+	txs := []types.UnexecutedTransaction{
+		{Index: 0, EffectiveGasPricePercentage: 255, Encoded: []byte{1, 2, 3}},
+		{Index: 2, EffectiveGasPricePercentage: 127, Encoded: []byte{4}},
+	}
+	require.NoError(t, db.WriteBlockUnexecutedTransactions(5, txs), "Failed to write unexecuted transactions")
+	require.NoError(t, db.WriteBlockUnexecutedTransactions(6, txs[:1]), "Failed to write unexecuted transactions")
+
+	got, err := db.GetBlockUnexecutedTransactions(5)
+	require.NoError(t, err, "Failed to get unexecuted transactions")
+	assert.Equal(t, txs, got)
+
+	got, err = db.GetBlockUnexecutedTransactions(4)
+	require.NoError(t, err, "Failed to get unexecuted transactions")
+	assert.Empty(t, got)
+
+	require.NoError(t, db.DeleteBlockUnexecutedTransactions(6, 10), "Failed to delete unexecuted transactions")
+	got, err = db.GetBlockUnexecutedTransactions(6)
+	require.NoError(t, err, "Failed to get unexecuted transactions")
+	assert.Empty(t, got)
+}
+
 // Benchmarks
 
 func BenchmarkWriteSequence(b *testing.B) {
@@ -1,12 +1,14 @@
 package l1_data
 
 import (
+	"context"
 	"strings"
 	"github.com/ledgerwatch/erigon/zk/contracts"
 	"encoding/json"
 	"fmt"
 	"github.com/gateway-fm/cdk-erigon-lib/common"
 	"github.com/ledgerwatch/erigon/accounts/abi"
+	"github.com/ledgerwatch/erigon/crypto"
 )
 
 type RollupBaseEtrogBatchData struct {
@@ -16,7 +18,46 @@ type RollupBaseEtrogBatchData struct {
 	ForcedBlockHashL1    [32]byte
 }
 
-func DecodeL1BatchData(txData []byte) ([][]byte, common.Address, error) {
+// ValidiumBatchData is a batch sequenced by a validium, the L1 only has the hash of its transactions
+type ValidiumBatchData struct {
+	TransactionsHash     [32]byte
+	ForcedGlobalExitRoot [32]byte
+	ForcedTimestamp      uint64
+	ForcedBlockHashL1    [32]byte
+}
+
+// DataAvailability resolves the data of the batches a validium sequences from their hashes
+type DataAvailability interface {
+	// VerifySequence checks the data availability message of a sequence holds the signatures of the committee, as
+	// it was in the L1 block of the sequence, on the signed hash of the sequence
+	VerifySequence(ctx context.Context, l1BlockNo uint64, signedHash common.Hash, message []byte) error
+	// GetBatchData returns the data of a batch by the hash of its transactions
+	GetBatchData(ctx context.Context, hash common.Hash) ([]byte, error)
+}
+
+// ForcedBatchData resolves the data of the forced batches a validium sequences from their hashes, the committee
+// doesn't have it, it was sent to the L1 when the batch was forced
+type ForcedBatchData interface {
+	GetForcedBatchData(hash common.Hash) ([]byte, error)
+}
+
+// ValidiumSignedHash is the hash the committee signs for a sequence, the transaction hashes of its batches that
+// are not forced accumulated the way the validium contract does
+func ValidiumSignedHash(batches []ValidiumBatchData) common.Hash {
+	var accumulated common.Hash
+	for _, batch := range batches {
+		if batch.ForcedTimestamp > 0 {
+			continue
+		}
+		accumulated = crypto.Keccak256Hash(accumulated.Bytes(), batch.TransactionsHash[:])
+	}
+	return accumulated
+}
+
+// DecodeL1BatchData returns the data of the batches and the coinbase of a sequence transaction of an L1 block.  The
+// data of the batches a validium sequences comes from da once the committee signatures are checked, or from forced
+// for the forced batches, both may be nil for a rollup.
+func DecodeL1BatchData(ctx context.Context, txData []byte, l1BlockNo uint64, da DataAvailability, forced ForcedBatchData) ([][]byte, common.Address, error) {
 	// we need to know which version of the ABI to use here so lets find it
 	idAsString := fmt.Sprintf("%x", txData[:4])
 	abiMapped, found := contracts.SequenceBatchesMapping[idAsString]
@@ -41,6 +82,8 @@ func DecodeL1BatchData(txData []byte) ([][]byte, common.Address, error) {
 	}
 
 	var coinbase common.Address
+	var validium bool
+	var daMessage []byte
 
 	switch idAsString {
 	case contracts.SequenceBatchesIdv5_0:
@@ -55,10 +98,35 @@ func DecodeL1BatchData(txData []byte) ([][]byte, common.Address, error) {
 			return nil, common.Address{}, fmt.Errorf("expected position 3 in the l1 call data to be address")
 		}
 		coinbase = cb
+	case contracts.SequenceBatchesValidiumIdv5_0:
+		cb, ok := data[1].(common.Address)
+		if !ok {
+			return nil, common.Address{}, fmt.Errorf("expected position 1 in the l1 call data to be address")
+		}
+		msg, ok := data[2].([]byte)
+		if !ok {
+			return nil, common.Address{}, fmt.Errorf("expected position 2 in the l1 call data to be bytes")
+		}
+		coinbase, validium, daMessage = cb, true, msg
+	case contracts.SequenceBatchesValidiumIdv6_6:
+		cb, ok := data[3].(common.Address)
+		if !ok {
+			return nil, common.Address{}, fmt.Errorf("expected position 3 in the l1 call data to be address")
+		}
+		msg, ok := data[4].([]byte)
+		if !ok {
+			return nil, common.Address{}, fmt.Errorf("expected position 4 in the l1 call data to be bytes")
+		}
+		coinbase, validium, daMessage = cb, true, msg
 	default:
 		return nil, common.Address{}, fmt.Errorf("unknown l1 call data")
 	}
 
+	if validium {
+		batchL2Datas, err := decodeValidiumBatchData(ctx, data[0], l1BlockNo, daMessage, da, forced)
+		return batchL2Datas, coinbase, err
+	}
+
 	var sequences []RollupBaseEtrogBatchData
 
 	bytedata, err := json.Marshal(data[0])
@@ -77,3 +145,38 @@ func DecodeL1BatchData(txData []byte) ([][]byte, common.Address, error) {
 
 	return batchL2Datas, coinbase, err
 }
+
+func decodeValidiumBatchData(ctx context.Context, batchesArg interface{}, l1BlockNo uint64, daMessage []byte, da DataAvailability, forced ForcedBatchData) ([][]byte, error) {
+	if da == nil {
+		return nil, fmt.Errorf("validium sequence but no data availability committee is configured")
+	}
+
+	var sequences []ValidiumBatchData
+	bytedata, err := json.Marshal(batchesArg)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(bytedata, &sequences); err != nil {
+		return nil, err
+	}
+
+	if err = da.VerifySequence(ctx, l1BlockNo, ValidiumSignedHash(sequences), daMessage); err != nil {
+		return nil, fmt.Errorf("data availability message: %w", err)
+	}
+
+	batchL2Datas := make([][]byte, len(sequences))
+	for idx, sequence := range sequences {
+		if sequence.ForcedTimestamp > 0 {
+			if forced == nil {
+				return nil, fmt.Errorf("batch %d of the sequence is forced but forced batches can't be looked up", idx)
+			}
+			batchL2Datas[idx], err = forced.GetForcedBatchData(sequence.TransactionsHash)
+		} else {
+			batchL2Datas[idx], err = da.GetBatchData(ctx, sequence.TransactionsHash)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("data of batch %d of the sequence: %w", idx, err)
+		}
+	}
+	return batchL2Datas, nil
+}
@@ -1,9 +1,16 @@
 package l1_data
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
 	"testing"
 	"github.com/gateway-fm/cdk-erigon-lib/common"
 	zktx "github.com/ledgerwatch/erigon/zk/tx"
+	"github.com/ledgerwatch/erigon/accounts/abi"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/zk/contracts"
 )
 
 // taken from https://sepolia.etherscan.io/tx/0x44b7aacaf535bd947803c88c18e63358c8ddd44fbb24950efbb5abb50f938cef
@@ -11,7 +18,7 @@ func Test_DecodeL1BatchData(t *testing.T) {
 	testData := "0xdef57e5400000000000000000000000000000000000000000000000000000000000000800000000000000000000000000000000000000000000000000000000065f838a100000000000000000000000000000000000000000000000000000000000000010000000000000000000000007597b12b953bffe1457d89e7e4fe3da149b45d8800000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000008000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000003cc0b00000890000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000117000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000b00000003000000000000000000000000000000000000000000000000"
 	txData := common.FromHex(testData)
 
-	transactions, _, err := DecodeL1BatchData(txData)
+	transactions, _, err := DecodeL1BatchData(context.Background(), txData, 0, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -26,7 +33,7 @@ func Test_DecodeL1MultiBatchData(t *testing.T) {
 
 	txData := common.FromHex(testData)
 
-	transactions, _, err := DecodeL1BatchData(txData)
+	transactions, _, err := DecodeL1BatchData(context.Background(), txData, 0, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -42,7 +49,7 @@ func Test_Integration_L1DataForBatch33(t *testing.T) {
 
 	txData := common.FromHex(testData)
 
-	batches, _, err := DecodeL1BatchData(txData)
+	batches, _, err := DecodeL1BatchData(context.Background(), txData, 0, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -60,3 +67,147 @@ func Test_Integration_L1DataForBatch33(t *testing.T) {
 		t.Errorf("expected 3 blocks but found %v", len(blocks))
 	}
 }
+
+// dataAvailability holds the data of batches and the signed hash it expects
+type dataAvailability struct {
+	signedHash common.Hash
+	message    []byte
+	data       map[common.Hash][]byte
+}
+
+func (d *dataAvailability) VerifySequence(ctx context.Context, l1BlockNo uint64, signedHash common.Hash, message []byte) error {
+	if signedHash != d.signedHash || !bytes.Equal(message, d.message) {
+		return fmt.Errorf("not signed")
+	}
+	return nil
+}
+
+func (d *dataAvailability) GetBatchData(ctx context.Context, hash common.Hash) ([]byte, error) {
+	data, ok := d.data[hash]
+	if !ok {
+		return nil, fmt.Errorf("not found")
+	}
+	return data, nil
+}
+
+func Test_DecodeL1ValidiumBatchData(t *testing.T) {
+	type batch struct {
+		TransactionsHash     [32]byte
+		ForcedGlobalExitRoot [32]byte
+		ForcedTimestamp      uint64
+		ForcedBlockHashL1    [32]byte
+	}
+	first, second := []byte{0x0b, 0x01}, []byte{0x0b, 0x02}
+	firstHash, secondHash := crypto.Keccak256Hash(first), crypto.Keccak256Hash(second)
+	batches := []batch{{TransactionsHash: firstHash}, {TransactionsHash: secondHash}}
+	coinbase := common.HexToAddress("0x5b06837a43bdc3dd9f114558daf4b26ed49842ed")
+	message := []byte("signatures and addresses")
+
+	da := &dataAvailability{
+		signedHash: crypto.Keccak256Hash(crypto.Keccak256Hash(common.Hash{}.Bytes(), firstHash.Bytes()).Bytes(), secondHash.Bytes()),
+		message:    message,
+		data:       map[common.Hash][]byte{firstHash: first, secondHash: second},
+	}
+
+	for selector, pack := range map[string]func(method abi.Method) ([]byte, error){
+		contracts.SequenceBatchesValidiumIdv5_0: func(method abi.Method) ([]byte, error) {
+			return method.Inputs.Pack(batches, coinbase, message)
+		},
+		contracts.SequenceBatchesValidiumIdv6_6: func(method abi.Method) ([]byte, error) {
+			return method.Inputs.Pack(batches, uint64(0), uint64(0), coinbase, message)
+		},
+	} {
+		smcAbi, err := abi.JSON(strings.NewReader(contracts.SequenceBatchesMapping[selector]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		method := smcAbi.Methods["sequenceBatchesValidium"]
+		if fmt.Sprintf("%x", method.ID) != selector {
+			t.Fatalf("expected selector %s but found %x", selector, method.ID)
+		}
+		args, err := pack(method)
+		if err != nil {
+			t.Fatal(err)
+		}
+		txData := append(common.Copy(method.ID), args...)
+
+		decoded, decodedCoinbase, err := DecodeL1BatchData(context.Background(), txData, 0, da, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decodedCoinbase != coinbase {
+			t.Errorf("expected coinbase %s but found %s", coinbase, decodedCoinbase)
+		}
+		if len(decoded) != 2 || !bytes.Equal(decoded[0], first) || !bytes.Equal(decoded[1], second) {
+			t.Errorf("unexpected batch data %x", decoded)
+		}
+
+		if _, _, err = DecodeL1BatchData(context.Background(), txData, 0, nil, nil); err == nil {
+			t.Errorf("expected an error without data availability")
+		}
+	}
+}
+
+// forcedBatches holds the data of the batches forced on the L1
+type forcedBatches map[common.Hash][]byte
+
+func (f forcedBatches) GetForcedBatchData(hash common.Hash) ([]byte, error) {
+	data, ok := f[hash]
+	if !ok {
+		return nil, fmt.Errorf("not forced")
+	}
+	return data, nil
+}
+
+func Test_DecodeL1ValidiumBatchDataForced(t *testing.T) {
+	type batch struct {
+		TransactionsHash     [32]byte
+		ForcedGlobalExitRoot [32]byte
+		ForcedTimestamp      uint64
+		ForcedBlockHashL1    [32]byte
+	}
+	first, forced, last := []byte{0x0b, 0x01}, []byte{0x0b, 0x02}, []byte{0x0b, 0x03}
+	firstHash, forcedHash, lastHash := crypto.Keccak256Hash(first), crypto.Keccak256Hash(forced), crypto.Keccak256Hash(last)
+	batches := []batch{
+		{TransactionsHash: firstHash},
+		{TransactionsHash: forcedHash, ForcedGlobalExitRoot: common.HexToHash("0x01"), ForcedTimestamp: 1700000000, ForcedBlockHashL1: common.HexToHash("0x02")},
+		{TransactionsHash: lastHash},
+	}
+	coinbase := common.HexToAddress("0x5b06837a43bdc3dd9f114558daf4b26ed49842ed")
+	message := []byte("signatures and addresses")
+
+	// the committee signs and holds only the batches that are not forced
+	da := &dataAvailability{
+		signedHash: crypto.Keccak256Hash(crypto.Keccak256Hash(common.Hash{}.Bytes(), firstHash.Bytes()).Bytes(), lastHash.Bytes()),
+		message:    message,
+		data:       map[common.Hash][]byte{firstHash: first, lastHash: last},
+	}
+
+	smcAbi, err := abi.JSON(strings.NewReader(contracts.SequenceBatchesValidiumAbiv6_6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	method := smcAbi.Methods["sequenceBatchesValidium"]
+	args, err := method.Inputs.Pack(batches, uint64(0), uint64(0), coinbase, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txData := append(common.Copy(method.ID), args...)
+
+	decoded, _, err := DecodeL1BatchData(context.Background(), txData, 100, da, forcedBatches{forcedHash: forced})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 3 || !bytes.Equal(decoded[0], first) || !bytes.Equal(decoded[1], forced) || !bytes.Equal(decoded[2], last) {
+		t.Errorf("unexpected batch data %x", decoded)
+	}
+
+	// the data of a forced batch never comes from the committee
+	da.data[forcedHash] = forced
+	if _, _, err = DecodeL1BatchData(context.Background(), txData, 100, da, forcedBatches{}); err == nil {
+		t.Errorf("expected an error for a forced batch not found on the L1")
+	}
+	if _, _, err = DecodeL1BatchData(context.Background(), txData, 100, da, nil); err == nil {
+		t.Errorf("expected an error without forced batches")
+	}
+}
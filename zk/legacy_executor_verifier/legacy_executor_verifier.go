@@ -14,6 +14,7 @@ import (
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
 	"github.com/ledgerwatch/erigon/zk/datastream/server"
+	"github.com/ledgerwatch/erigon/zk/ha"
 	"github.com/ledgerwatch/erigon/zk/hermez_db"
 	"github.com/ledgerwatch/erigon/zk/legacy_executor_verifier/proto/github.com/0xPolygonHermez/zkevm-node/state/runtime/executor"
 	"github.com/ledgerwatch/erigon/zk/syncer"
@@ -40,6 +41,10 @@ type VerifierResponse struct {
 
 var ErrNoExecutorAvailable = fmt.Errorf("no executor available")
 
+// ErrLeaseLost is returned by a highly available sequencer that lost the lease its batches were sealed under, the
+// batches it has yet to stream are dropped as the sequencer that took over carries on the chain without them
+var ErrLeaseLost = fmt.Errorf("the sequencer lease the batches were sealed under was lost")
+
 type ILegacyExecutor interface {
 	Verify(*Payload, *VerifierRequest, common.Hash) (bool, error)
 	CheckOnline() bool
@@ -62,9 +67,10 @@ type LegacyExecutorVerifier struct {
 	witnessGenerator WitnessGenerator
 	l1Syncer         *syncer.L1Syncer
 	executorGrpc     executor.ExecutorServiceClient
+	leader           *ha.Elector // set on a highly available sequencer, batches are only streamed under their lease term
 
 	promises     []*Promise[*VerifierResponse]
-	addedBatches map[uint64]struct{}
+	addedBatches map[uint64]uint64 // the lease term each batch was requested under
 }
 
 func NewLegacyExecutorVerifier(
@@ -75,6 +81,7 @@ func NewLegacyExecutorVerifier(
 	witnessGenerator WitnessGenerator,
 	l1Syncer *syncer.L1Syncer,
 	stream *datastreamer.StreamServer,
+	leader *ha.Elector,
 ) *LegacyExecutorVerifier {
 	executorLocks := make([]*sync.Mutex, len(executors))
 	for i := range executorLocks {
@@ -93,8 +100,9 @@ func NewLegacyExecutorVerifier(
 		stream:           stream,
 		witnessGenerator: witnessGenerator,
 		l1Syncer:         l1Syncer,
+		leader:           leader,
 		promises:         make([]*Promise[*VerifierResponse], 0),
-		addedBatches:     make(map[uint64]struct{}),
+		addedBatches:     make(map[uint64]uint64),
 	}
 
 	return verifier
@@ -167,6 +175,9 @@ func (v *LegacyExecutorVerifier) AddRequestUnsafe(ctx context.Context, tx kv.RwT
 		ContextId:               strconv.Itoa(int(request.BatchNumber)),
 		L1InfoTreeMinTimestamps: l1InfoTreeMinTimestamps,
 	}
+	if err = setForcedBatchPayload(hermezDb, request.BatchNumber, payload); err != nil {
+		return nil, err
+	}
 
 	previousBlock, err := rawdb.ReadBlockByNumber(tx, blocks[0]-1)
 	if err != nil {
@@ -204,14 +215,43 @@ func (v *LegacyExecutorVerifier) AddRequestUnsafe(ctx context.Context, tx kv.RwT
 	})
 
 	// add batch to the list of batches we've added
-	v.addedBatches[request.BatchNumber] = struct{}{}
+	v.addedBatches[request.BatchNumber] = v.leaseTerm()
 
 	// add the promise to the list of promises
 	v.promises = append(v.promises, promise)
 	return promise, nil
 }
 
+// setForcedBatchPayload sets what the executor checks a forced batch against: the hash of the L1 block before the one
+// it was forced in, the global exit root and the timestamp it was forced with.  Other batches are left as they are.
+func setForcedBatchPayload(hermezDb *hermez_db.HermezDbReader, batchNo uint64, payload *Payload) error {
+	forcedBatchNo, forced, err := hermezDb.GetForcedBatchNumberByBatch(batchNo)
+	if err != nil {
+		return err
+	}
+	if !forced {
+		return nil
+	}
+	fb, err := hermezDb.GetL1ForcedBatch(forcedBatchNo)
+	if err != nil {
+		return err
+	}
+	if fb == nil {
+		return fmt.Errorf("forced batch %d of batch %d not found", forcedBatchNo, batchNo)
+	}
+
+	payload.ForcedBlockhashL1 = fb.L1ParentHash.Bytes()
+	payload.L1InfoRoot = fb.GlobalExitRoot.Bytes()
+	payload.TimestampLimit = fb.Timestamp
+	return nil
+}
+
 func writeBatchToStream(result *VerifierResponse, hdb *hermez_db.HermezDbReader, roTx kv.Tx, v *LegacyExecutorVerifier) error {
+	// a verifier following the stream has none of its own to write to
+	if v.stream == nil {
+		return nil
+	}
+
 	blks, err := hdb.GetL2BlockNosByBatch(result.BatchNumber)
 	if err != nil {
 		return err
@@ -223,6 +263,27 @@ func writeBatchToStream(result *VerifierResponse, hdb *hermez_db.HermezDbReader,
 	return nil
 }
 
+// leaseTerm is the term of the lease as the sequencer last saw it, 0 without high availability
+func (v *LegacyExecutorVerifier) leaseTerm() uint64 {
+	if v.leader == nil {
+		return 0
+	}
+	return v.leader.Lease().Term
+}
+
+// checkLease fences a highly available sequencer out of the stream once it no longer holds the lease the batch was
+// requested under.  A standby only acquires the lease after the sequencer stopped considering itself the leader, so
+// whatever it streamed before is in the stream the standby takes over.
+func (v *LegacyExecutorVerifier) checkLease(batch uint64) error {
+	if v.leader == nil {
+		return nil
+	}
+	if !v.leader.IsLeader() || v.leader.Lease().Term != v.addedBatches[batch] {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
 func (v *LegacyExecutorVerifier) ConsumeResultsUnsafe(tx kv.RwTx) ([]*VerifierResponse, error) {
 	hdb := hermez_db.NewHermezDbReader(tx)
 
@@ -236,6 +297,12 @@ func (v *LegacyExecutorVerifier) ConsumeResultsUnsafe(tx kv.RwTx) ([]*VerifierRe
 			log.Error("error getting verifier result", "err", err)
 		}
 		if result != nil {
+			if err = v.checkLease(result.BatchNumber); err != nil {
+				// the sequencer that took over carries on from what was streamed, nothing after it can be written
+				log.Warn("[Verifier] Dropping the batches not streamed yet", "from", result.BatchNumber, "err", err)
+				v.CancelAllRequestsUnsafe()
+				return nil, err
+			}
 			err = writeBatchToStream(result, hdb, tx, v)
 			if err != nil {
 				log.Error("error getting verifier result", "err", err)
@@ -308,6 +375,13 @@ func (v *LegacyExecutorVerifier) GetStreamBytes(request *VerifierRequest, tx kv.
 	return streamBytes, nil
 }
 
+// CancelAllRequestsUnsafe drops the requests in flight and the results not consumed yet, their batches are being
+// unwound
+func (v *LegacyExecutorVerifier) CancelAllRequestsUnsafe() {
+	v.promises = make([]*Promise[*VerifierResponse], 0)
+	v.addedBatches = make(map[uint64]uint64)
+}
+
 func (v *LegacyExecutorVerifier) HasExecutors() bool {
 	return len(v.executors) > 0
 }
@@ -0,0 +1,47 @@
+package legacy_executor_verifier
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/zk/hermez_db"
+	"github.com/ledgerwatch/erigon/zk/types"
+)
+
+func TestSetForcedBatchPayload(t *testing.T) {
+	db := memdb.NewTestDB(t)
+	tx, err := db.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+	require.NoError(t, hermez_db.CreateHermezBuckets(tx))
+
+	hermezDb := hermez_db.NewHermezDb(tx)
+	fb := &types.L1ForcedBatch{
+		ForcedBatchNumber: 1,
+		L1BlockNumber:     100,
+		Timestamp:         1700000000,
+		L1ParentHash:      common.HexToHash("0x01"),
+		GlobalExitRoot:    common.HexToHash("0x02"),
+		Transactions:      []byte{},
+	}
+	require.NoError(t, hermezDb.WriteL1ForcedBatch(fb))
+	require.NoError(t, hermezDb.WriteBatchForcedBatchNumber(5, 1))
+
+	regular := &Payload{ForcedBlockhashL1: []byte{0}, TimestampLimit: 42}
+	require.NoError(t, setForcedBatchPayload(hermezDb.HermezDbReader, 4, regular))
+	require.Equal(t, &Payload{ForcedBlockhashL1: []byte{0}, TimestampLimit: 42}, regular)
+
+	forced := &Payload{ForcedBlockhashL1: []byte{0}, TimestampLimit: 42}
+	require.NoError(t, setForcedBatchPayload(hermezDb.HermezDbReader, 5, forced))
+	require.Equal(t, fb.L1ParentHash.Bytes(), forced.ForcedBlockhashL1)
+	require.Equal(t, fb.GlobalExitRoot.Bytes(), forced.L1InfoRoot)
+	require.Equal(t, fb.Timestamp, forced.TimestampLimit)
+
+	// a forced batch the node never saw on L1 can't be verified
+	require.NoError(t, hermezDb.WriteBatchForcedBatchNumber(6, 2))
+	require.Error(t, setForcedBatchPayload(hermezDb.HermezDbReader, 6, &Payload{}))
+}
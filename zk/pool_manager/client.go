@@ -0,0 +1,250 @@
+package pool_manager
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+const (
+	// requestTimeout bounds every request to the pool manager
+	requestTimeout = 5 * time.Second
+
+	// retryInterval is how long the reports wait to be sent again after the pool manager failed to take them
+	retryInterval = time.Second
+
+	// maxQueuedReports bounds the reports waiting for the pool manager, the oldest are dropped beyond it
+	maxQueuedReports = 100_000
+)
+
+// Status is what became of a transaction the sequencer took from the pool manager
+type Status string
+
+const (
+	StatusPending      Status = "pending"      // waiting in the pool manager
+	StatusIncluded     Status = "included"     // sealed in a block
+	StatusOverflow     Status = "overflow"     // overflows the zk counters of an empty batch so can never be sequenced
+	StatusInvalidNonce Status = "invalidNonce" // the nonce was already used when the sequencer got to it
+	StatusDiscarded    Status = "discarded"    // refused by the access control lists or failed validation
+)
+
+// Report tells the pool manager what became of a transaction
+type Report struct {
+	Hash        common.Hash `json:"hash"`
+	Status      Status      `json:"status"`
+	BlockNumber uint64      `json:"blockNumber,omitempty"` // for StatusIncluded
+	Error       string      `json:"error,omitempty"`
+}
+
+// TransactionStatus is the status of a transaction as known to the pool manager
+type TransactionStatus struct {
+	Status      Status `json:"status"`
+	BlockNumber uint64 `json:"blockNumber,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Client talks to an external pool manager.  The sequencer takes the transactions to sequence from it with
+// poolmanager_pendingTransactions and reports back what became of each with poolmanager_reportTransactions, the RPC
+// nodes ask it where a transaction is with poolmanager_transactionStatus.
+type Client struct {
+	client *rpc.Client
+
+	lock  sync.Mutex
+	queue []Report
+	held  []Report // included in blocks not committed yet
+	// unsent are the transactions reported or held but not yet taken by the pool manager, which still hands them out
+	unsent  map[common.Hash]struct{}
+	sending atomic.Bool
+}
+
+func NewClient(url string) (*Client, error) {
+	client, err := rpc.DialHTTP(url)
+	if err != nil {
+		return nil, fmt.Errorf("dial the pool manager at %s: %w", url, err)
+	}
+	return &Client{client: client, unsent: make(map[common.Hash]struct{})}, nil
+}
+
+func (c *Client) call(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	if err := c.client.CallContext(ctx, result, method, args...); err != nil {
+		return fmt.Errorf("pool manager %s: %w", method, err)
+	}
+	return nil
+}
+
+// PendingTransactions returns up to limit transactions in the order the pool manager wants them sequenced, those
+// taken before are returned again until they are reported.  Those whose report hasn't reached the pool manager yet are
+// left out, tried again they would be reported twice.
+func (c *Client) PendingTransactions(ctx context.Context, limit uint64) ([]types.Transaction, error) {
+	// taken before the call, a report the pool manager takes while answering is sent after the answer was made
+	c.lock.Lock()
+	unsent := make(map[common.Hash]struct{}, len(c.unsent))
+	for hash := range c.unsent {
+		unsent[hash] = struct{}{}
+	}
+	c.lock.Unlock()
+
+	var encoded []hexutility.Bytes
+	if err := c.call(ctx, &encoded, "poolmanager_pendingTransactions", limit); err != nil {
+		return nil, err
+	}
+
+	transactions := make([]types.Transaction, 0, len(encoded))
+	for i, raw := range encoded {
+		transaction, err := types.DecodeTransaction(rlp.NewStream(bytes.NewReader(raw), uint64(len(raw))))
+		if err != nil {
+			return nil, fmt.Errorf("decode pending transaction %d of the pool manager: %w", i, err)
+		}
+		transactions = append(transactions, transaction)
+	}
+	pending := transactions[:0]
+	for _, transaction := range transactions {
+		if _, ok := unsent[transaction.Hash()]; !ok {
+			pending = append(pending, transaction)
+		}
+	}
+	return pending, nil
+}
+
+// TransactionStatus returns the status of a transaction, nil if the pool manager doesn't know it
+func (c *Client) TransactionStatus(ctx context.Context, hash common.Hash) (*TransactionStatus, error) {
+	var status *TransactionStatus
+	if err := c.call(ctx, &status, "poolmanager_transactionStatus", hash); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// Report queues the reports to be sent to the pool manager in the background so the sequencer never waits on it.
+// Reports the pool manager fails to take are sent again.
+func (c *Client) Report(reports ...Report) {
+	if len(reports) == 0 {
+		return
+	}
+	c.lock.Lock()
+	c.queue = append(c.queue, reports...)
+	for _, report := range reports {
+		c.unsent[report.Hash] = struct{}{}
+	}
+	if dropped := len(c.queue) - maxQueuedReports; dropped > 0 {
+		log.Warn("[pool-manager] Too many reports waiting for the pool manager, dropping the oldest", "dropped", dropped)
+		c.sent(c.queue[:dropped])
+		c.queue = append(c.queue[:0], c.queue[dropped:]...)
+	}
+	c.lock.Unlock()
+
+	if !c.sending.CompareAndSwap(false, true) {
+		return
+	}
+	go c.send()
+}
+
+// Hold keeps the reports of transactions included in blocks whose tx is committed by someone else, ReleaseHeld sends
+// them once it is
+func (c *Client) Hold(reports ...Report) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.held = append(c.held, reports...)
+	for _, report := range reports {
+		c.unsent[report.Hash] = struct{}{}
+	}
+}
+
+// ReleaseHeld sends the held reports whose transaction made it into the committed chain and drops the others.  A
+// dropped one was rolled back with its block, left unreported the pool manager hands it out again.
+func (c *Client) ReleaseHeld(committed func(Report) (bool, error)) error {
+	c.lock.Lock()
+	held := c.held
+	c.held = nil
+	c.lock.Unlock()
+
+	released := make([]Report, 0, len(held))
+	for i, report := range held {
+		ok, err := committed(report)
+		if err != nil {
+			c.lock.Lock()
+			c.held = append(held[i:], c.held...)
+			c.lock.Unlock()
+			c.Report(released...)
+			return err
+		}
+		if ok {
+			released = append(released, report)
+		} else {
+			c.lock.Lock()
+			delete(c.unsent, report.Hash)
+			c.lock.Unlock()
+		}
+	}
+	c.Report(released...)
+	return nil
+}
+
+// sent forgets the reports the pool manager took, or that were dropped, the lock must be held
+func (c *Client) sent(reports []Report) {
+	for _, report := range reports {
+		delete(c.unsent, report.Hash)
+	}
+}
+
+func (c *Client) send() {
+	for {
+		c.lock.Lock()
+		reports := c.queue
+		c.queue = nil
+		c.lock.Unlock()
+		if len(reports) == 0 {
+			c.sending.Store(false)
+			// a report queued after the queue was found empty but before the flag was cleared didn't start a sender
+			c.lock.Lock()
+			queued := len(c.queue) > 0
+			c.lock.Unlock()
+			if !queued || !c.sending.CompareAndSwap(false, true) {
+				return
+			}
+			continue
+		}
+
+		if err := c.call(context.Background(), nil, "poolmanager_reportTransactions", reports); err != nil {
+			log.Warn("[pool-manager] Failed to report transactions, retrying", "count", len(reports), "err", err)
+			c.lock.Lock()
+			c.queue = append(reports, c.queue...)
+			c.lock.Unlock()
+			time.Sleep(retryInterval)
+			continue
+		}
+		c.lock.Lock()
+		c.sent(reports)
+		c.lock.Unlock()
+	}
+}
+
+// Flush waits for the queued reports to be sent, for tests
+func (c *Client) Flush(ctx context.Context) error {
+	for {
+		c.lock.Lock()
+		empty := len(c.queue) == 0
+		c.lock.Unlock()
+		if empty && !c.sending.Load() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package pool_manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+func TestClient(t *testing.T) {
+	mock := NewMock()
+	url, stop, err := mock.Serve()
+	require.NoError(t, err)
+	defer stop()
+
+	client, err := NewClient(url)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	var txs []types.Transaction
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		txs = append(txs, types.NewTransaction(nonce, common.HexToAddress("0x01"), uint256.NewInt(1), 21000, uint256.NewInt(1), nil))
+	}
+	require.NoError(t, mock.Add(txs...))
+
+	pending, err := client.PendingTransactions(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	require.Equal(t, txs[0].Hash(), pending[0].Hash())
+	require.Equal(t, txs[1].Hash(), pending[1].Hash())
+
+	client.Report(
+		Report{Hash: txs[0].Hash(), Status: StatusIncluded, BlockNumber: 5},
+		Report{Hash: txs[1].Hash(), Status: StatusInvalidNonce, Error: "nonce too low"},
+	)
+	flushCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	require.NoError(t, client.Flush(flushCtx))
+	require.Len(t, mock.Reports(), 2)
+
+	// reported transactions are no longer handed out
+	pending, err = client.PendingTransactions(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, txs[2].Hash(), pending[0].Hash())
+
+	status, err := client.TransactionStatus(ctx, txs[0].Hash())
+	require.NoError(t, err)
+	require.Equal(t, &TransactionStatus{Status: StatusIncluded, BlockNumber: 5}, status)
+
+	status, err = client.TransactionStatus(ctx, txs[2].Hash())
+	require.NoError(t, err)
+	require.Equal(t, StatusPending, status.Status)
+
+	status, err = client.TransactionStatus(ctx, common.HexToHash("0x01"))
+	require.NoError(t, err)
+	require.Nil(t, status)
+}
+
+func TestClientReleaseHeld(t *testing.T) {
+	mock := NewMock()
+	url, stop, err := mock.Serve()
+	require.NoError(t, err)
+	defer stop()
+
+	client, err := NewClient(url)
+	require.NoError(t, err)
+
+	committed := Report{Hash: common.HexToHash("0x01"), Status: StatusIncluded, BlockNumber: 5}
+	rolledBack := Report{Hash: common.HexToHash("0x02"), Status: StatusIncluded, BlockNumber: 6}
+	client.Hold(committed, rolledBack)
+
+	require.NoError(t, client.ReleaseHeld(func(report Report) (bool, error) {
+		return report.Hash == committed.Hash, nil
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, client.Flush(ctx))
+	require.Equal(t, []Report{committed}, mock.Reports())
+
+	// the dropped report isn't held any more
+	require.NoError(t, client.ReleaseHeld(func(report Report) (bool, error) {
+		return true, nil
+	}))
+	require.NoError(t, client.Flush(ctx))
+	require.Len(t, mock.Reports(), 1)
+}
+
+func TestClientUnsentNotPending(t *testing.T) {
+	mock := NewMock()
+	url, stop, err := mock.Serve()
+	require.NoError(t, err)
+	defer stop()
+
+	client, err := NewClient(url)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	var txs []types.Transaction
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		txs = append(txs, types.NewTransaction(nonce, common.HexToAddress("0x01"), uint256.NewInt(1), 21000, uint256.NewInt(1), nil))
+	}
+	require.NoError(t, mock.Add(txs...))
+
+	// the pool manager still has the held transactions but they aren't tried again
+	client.Hold(
+		Report{Hash: txs[0].Hash(), Status: StatusIncluded, BlockNumber: 5},
+		Report{Hash: txs[1].Hash(), Status: StatusIncluded, BlockNumber: 6},
+	)
+	pending, err := client.PendingTransactions(ctx, 2)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	// the one rolled back with its block is handed out again
+	require.NoError(t, client.ReleaseHeld(func(report Report) (bool, error) {
+		return report.Hash == txs[0].Hash(), nil
+	}))
+	flushCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	require.NoError(t, client.Flush(flushCtx))
+	pending, err = client.PendingTransactions(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, txs[1].Hash(), pending[0].Hash())
+}
@@ -0,0 +1,120 @@
+package pool_manager
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/common/hexutility"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// Mock is an in memory pool manager serving the API the Client expects, and eth_sendRawTransaction for the RPC nodes
+// forwarding to it, for tests.  Pending transactions are handed
+// out in the order they were added until they are reported.
+type Mock struct {
+	lock    sync.Mutex
+	pending []hexutility.Bytes
+	hashes  []common.Hash
+	status  map[common.Hash]*TransactionStatus
+	reports []Report
+}
+
+func NewMock() *Mock {
+	return &Mock{status: make(map[common.Hash]*TransactionStatus)}
+}
+
+// Serve starts serving the mock over http, returning its url and a function to stop it
+func (m *Mock) Serve() (string, func(), error) {
+	srv := rpc.NewServer(0, false, false)
+	if err := srv.RegisterName("poolmanager", &mockApi{m}); err != nil {
+		return "", nil, err
+	}
+	if err := srv.RegisterName("eth", &mockEthApi{m}); err != nil {
+		return "", nil, err
+	}
+	httpSrv := httptest.NewServer(srv)
+	return httpSrv.URL, func() {
+		httpSrv.Close()
+		srv.Stop()
+	}, nil
+}
+
+// Add queues transactions to be sequenced
+func (m *Mock) Add(txs ...types.Transaction) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, txn := range txs {
+		var buf bytes.Buffer
+		if err := txn.MarshalBinary(&buf); err != nil {
+			return err
+		}
+		m.pending = append(m.pending, buf.Bytes())
+		m.hashes = append(m.hashes, txn.Hash())
+		m.status[txn.Hash()] = &TransactionStatus{Status: StatusPending}
+	}
+	return nil
+}
+
+// Reports returns the reports received so far
+func (m *Mock) Reports() []Report {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return append([]Report(nil), m.reports...)
+}
+
+type mockApi struct {
+	m *Mock
+}
+
+func (api *mockApi) PendingTransactions(limit uint64) ([]hexutility.Bytes, error) {
+	api.m.lock.Lock()
+	defer api.m.lock.Unlock()
+
+	if limit > uint64(len(api.m.pending)) {
+		limit = uint64(len(api.m.pending))
+	}
+	return append([]hexutility.Bytes{}, api.m.pending[:limit]...), nil
+}
+
+func (api *mockApi) ReportTransactions(reports []Report) error {
+	api.m.lock.Lock()
+	defer api.m.lock.Unlock()
+
+	api.m.reports = append(api.m.reports, reports...)
+	for _, report := range reports {
+		api.m.status[report.Hash] = &TransactionStatus{Status: report.Status, BlockNumber: report.BlockNumber, Error: report.Error}
+		for i, hash := range api.m.hashes {
+			if hash == report.Hash {
+				api.m.pending = append(api.m.pending[:i], api.m.pending[i+1:]...)
+				api.m.hashes = append(api.m.hashes[:i], api.m.hashes[i+1:]...)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (api *mockApi) TransactionStatus(hash common.Hash) (*TransactionStatus, error) {
+	api.m.lock.Lock()
+	defer api.m.lock.Unlock()
+	return api.m.status[hash], nil
+}
+
+// mockEthApi takes the transactions RPC nodes forward to the pool manager
+type mockEthApi struct {
+	m *Mock
+}
+
+func (api *mockEthApi) SendRawTransaction(encodedTx hexutility.Bytes) (common.Hash, error) {
+	txn, err := types.DecodeTransaction(rlp.NewStream(bytes.NewReader(encodedTx), uint64(len(encodedTx))))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return txn.Hash(), api.m.Add(txn)
+}
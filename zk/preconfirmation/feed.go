@@ -0,0 +1,83 @@
+package preconfirmation
+
+import (
+	"sync"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+)
+
+// maxKept is how many of the latest preconfirmations a feed answers for, by then the blocks are written and the
+// receipts tell the rest
+const maxKept = 10_000
+
+// subscriberBuffer is how many preconfirmations a subscriber may fall behind before it misses some
+const subscriberBuffer = 1024
+
+// Feed keeps the latest preconfirmations a node gave or relayed and hands each new one to the subscribers
+type Feed struct {
+	lock   sync.Mutex
+	byHash map[common.Hash]*Preconfirmation
+	order  []common.Hash // oldest first
+
+	subs    map[uint64]chan *Preconfirmation
+	nextSub uint64
+}
+
+func NewFeed() *Feed {
+	return &Feed{
+		byHash: make(map[common.Hash]*Preconfirmation),
+		subs:   make(map[uint64]chan *Preconfirmation),
+	}
+}
+
+// Add keeps a preconfirmation and hands it to the subscribers.  One the feed has already is ignored, a block executed
+// again after an overflow or a relay reconnecting gives the same preconfirmation twice.
+func (f *Feed) Add(p *Preconfirmation) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if known, ok := f.byHash[p.TxHash]; ok {
+		if known.same(p) {
+			return
+		}
+	} else {
+		f.order = append(f.order, p.TxHash)
+	}
+	f.byHash[p.TxHash] = p
+
+	for len(f.order) > maxKept {
+		delete(f.byHash, f.order[0])
+		f.order = f.order[1:]
+	}
+
+	for _, ch := range f.subs {
+		select {
+		case ch <- p:
+		default:
+			// a subscriber too slow to keep up misses preconfirmations rather than holding up the sequencer
+		}
+	}
+}
+
+// Get returns the preconfirmation of a transaction if the feed still has it
+func (f *Feed) Get(txHash common.Hash) (*Preconfirmation, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	p, ok := f.byHash[txHash]
+	return p, ok
+}
+
+// Subscribe returns a channel of the preconfirmations added from now on and the function that ends the subscription
+func (f *Feed) Subscribe() (<-chan *Preconfirmation, func()) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	id := f.nextSub
+	f.nextSub++
+	ch := make(chan *Preconfirmation, subscriberBuffer)
+	f.subs[id] = ch
+	return ch, func() {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		delete(f.subs, id)
+	}
+}
@@ -0,0 +1,103 @@
+package preconfirmation
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/common/hexutility"
+	"github.com/gateway-fm/cdk-erigon-lib/common/length"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/crypto"
+)
+
+// Preconfirmation is the sequencer's word that a transaction is in the block it is sealing, at an index and with a
+// receipt status.  It is given as the transaction is executed, before the block is written, and signed so it can be
+// held against the sequencer if the block turns out otherwise.  It is a promise only: an unwind of the block, the
+// executors failing its batch or another sequencer taking over before the block reaches it all break it.
+type Preconfirmation struct {
+	TxHash      common.Hash      `json:"transactionHash"`
+	BlockNumber hexutil.Uint64   `json:"blockNumber"`
+	Index       hexutil.Uint64   `json:"transactionIndex"`
+	Status      hexutil.Uint64   `json:"status"`
+	Signature   hexutility.Bytes `json:"signature"`
+}
+
+// SigningHash is what the sequencer signs: keccak256 of the chain id, the transaction hash, the block number, the
+// index and the status, the numbers as 8 byte big endian
+func (p *Preconfirmation) SigningHash(chainId uint64) common.Hash {
+	var data [8 + length.Hash + 8 + 8 + 8]byte
+	binary.BigEndian.PutUint64(data[0:], chainId)
+	copy(data[8:], p.TxHash[:])
+	binary.BigEndian.PutUint64(data[8+length.Hash:], uint64(p.BlockNumber))
+	binary.BigEndian.PutUint64(data[16+length.Hash:], uint64(p.Index))
+	binary.BigEndian.PutUint64(data[24+length.Hash:], uint64(p.Status))
+	return crypto.Keccak256Hash(data[:])
+}
+
+// Sign sets the signature of the preconfirmation, 65 bytes of r, s and a recovery id of 0 or 1
+func (p *Preconfirmation) Sign(chainId uint64, key *ecdsa.PrivateKey) error {
+	hash := p.SigningHash(chainId)
+	sig, err := crypto.Sign(hash[:], key)
+	if err != nil {
+		return err
+	}
+	p.Signature = sig
+	return nil
+}
+
+// Signer recovers the address that signed the preconfirmation, it is the sequencer's if the preconfirmation is genuine
+func (p *Preconfirmation) Signer(chainId uint64) (common.Address, error) {
+	if len(p.Signature) != crypto.SignatureLength {
+		return common.Address{}, errors.New("preconfirmation is not signed")
+	}
+	hash := p.SigningHash(chainId)
+	pub, err := crypto.SigToPub(hash[:], p.Signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+func (p *Preconfirmation) same(other *Preconfirmation) bool {
+	return p.TxHash == other.TxHash && p.BlockNumber == other.BlockNumber && p.Index == other.Index &&
+		p.Status == other.Status && bytes.Equal(p.Signature, other.Signature)
+}
+
+// Sequencer signs the preconfirmations of the transactions the sequencer executes and adds them to the feed of the
+// node
+type Sequencer struct {
+	key     *ecdsa.PrivateKey
+	chainId uint64
+	feed    *Feed
+}
+
+// NewSequencer returns nil, which gives no preconfirmations, without a key to sign with or a feed to hand them out on
+func NewSequencer(key *ecdsa.PrivateKey, chainId uint64, feed *Feed) *Sequencer {
+	if key == nil || feed == nil {
+		return nil
+	}
+	return &Sequencer{key: key, chainId: chainId, feed: feed}
+}
+
+// Confirm signs the preconfirmation of a transaction executed at index of a block and hands it out, a nil Sequencer
+// gives none
+func (s *Sequencer) Confirm(txHash common.Hash, blockNumber, index, status uint64) error {
+	if s == nil {
+		return nil
+	}
+	p := Preconfirmation{
+		TxHash:      txHash,
+		BlockNumber: hexutil.Uint64(blockNumber),
+		Index:       hexutil.Uint64(index),
+		Status:      hexutil.Uint64(status),
+	}
+	if err := p.Sign(s.chainId, s.key); err != nil {
+		return err
+	}
+	s.feed.Add(&p)
+	return nil
+}
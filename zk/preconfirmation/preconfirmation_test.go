@@ -0,0 +1,129 @@
+package preconfirmation
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+const testChainId = 1001
+
+func TestSignedPreconfirmation(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	feed := NewFeed()
+	txHash := common.HexToHash("0x01")
+
+	require.NoError(t, NewSequencer(key, testChainId, feed).Confirm(txHash, 12, 3, 1))
+	p, ok := feed.Get(txHash)
+	require.True(t, ok)
+	require.Equal(t, uint64(12), uint64(p.BlockNumber))
+	require.Equal(t, uint64(3), uint64(p.Index))
+	require.Equal(t, uint64(1), uint64(p.Status))
+
+	signer, err := p.Signer(testChainId)
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(key.PublicKey), signer)
+
+	// the signature covers the chain and every field
+	signer, err = p.Signer(testChainId + 1)
+	require.NoError(t, err)
+	require.NotEqual(t, crypto.PubkeyToAddress(key.PublicKey), signer)
+	forged := *p
+	forged.Status = 0
+	signer, err = forged.Signer(testChainId)
+	require.NoError(t, err)
+	require.NotEqual(t, crypto.PubkeyToAddress(key.PublicKey), signer)
+
+	var none *Sequencer
+	require.NoError(t, none.Confirm(txHash, 13, 0, 1))
+	require.Nil(t, NewSequencer(nil, testChainId, feed))
+}
+
+func TestFeed(t *testing.T) {
+	feed := NewFeed()
+	ch, unsubscribe := feed.Subscribe()
+
+	first := &Preconfirmation{TxHash: common.HexToHash("0x01"), BlockNumber: 1, Status: 1, Signature: []byte{1}}
+	feed.Add(first)
+	again := *first
+	feed.Add(&again)
+	require.Equal(t, first, <-ch)
+	require.Empty(t, ch, "the same preconfirmation is handed out once")
+
+	// a different one for the same transaction replaces it
+	moved := &Preconfirmation{TxHash: first.TxHash, BlockNumber: 2, Status: 1, Signature: []byte{2}}
+	feed.Add(moved)
+	require.Equal(t, moved, <-ch)
+	got, ok := feed.Get(first.TxHash)
+	require.True(t, ok)
+	require.Equal(t, moved, got)
+
+	unsubscribe()
+	for i := 0; i < maxKept; i++ {
+		feed.Add(&Preconfirmation{TxHash: common.BigToHash(big.NewInt(int64(i + 2)))})
+	}
+	_, ok = feed.Get(first.TxHash)
+	require.False(t, ok, "the oldest preconfirmation is dropped")
+	require.Len(t, feed.order, maxKept)
+}
+
+type testService struct {
+	feed *Feed
+}
+
+func (s *testService) Preconfirmations(ctx context.Context) (*rpc.Subscription, error) {
+	return Serve(ctx, s.feed)
+}
+
+func TestRelay(t *testing.T) {
+	upstream, downstream := NewFeed(), NewFeed()
+	server := rpc.NewServer(16, false, false)
+	require.NoError(t, server.RegisterName("zkevm", &testService{feed: upstream}))
+	defer server.Stop()
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- relayFrom(ctx, client, testChainId, crypto.PubkeyToAddress(key.PublicKey), downstream)
+	}()
+	require.Eventually(t, func() bool {
+		upstream.lock.Lock()
+		defer upstream.lock.Unlock()
+		return len(upstream.subs) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// a preconfirmation signed by any other key is dropped
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	forged := common.HexToHash("0x02")
+	require.NoError(t, NewSequencer(other, testChainId, upstream).Confirm(forged, 12, 0, 1))
+
+	txHash := common.HexToHash("0x01")
+	require.NoError(t, NewSequencer(key, testChainId, upstream).Confirm(txHash, 12, 0, 1))
+	require.Eventually(t, func() bool {
+		_, ok := downstream.Get(txHash)
+		return ok
+	}, 5*time.Second, 10*time.Millisecond)
+
+	relayed, _ := downstream.Get(txHash)
+	signer, err := relayed.Signer(testChainId)
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(key.PublicKey), signer)
+	_, ok := downstream.Get(forged)
+	require.False(t, ok)
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+}
@@ -0,0 +1,93 @@
+package preconfirmation
+
+import (
+	"context"
+	"time"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// relayRetry is how long a relay waits before it connects again after losing its upstream
+const relayRetry = 5 * time.Second
+
+// Serve streams the preconfirmations added to the feed to the subscriber of an RPC subscription
+func Serve(ctx context.Context, feed *Feed) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	ch, unsubscribe := feed.Subscribe()
+	go func() {
+		defer unsubscribe()
+		for {
+			select {
+			case p := <-ch:
+				if err := notifier.Notify(rpcSub.ID, p); err != nil {
+					log.Warn("error while notifying subscription", "err", err)
+					return
+				}
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// Relay adds the preconfirmations of the sequencer, or of an RPC node relaying them, to the feed of an RPC node until
+// the context is done.  It subscribes to zkevm_subscribe("preconfirmations") at the websocket url and connects again
+// whenever the connection is lost, the preconfirmations given in between are missed.  Only those signed for the chain
+// by the sequencer's address are relayed.
+func Relay(ctx context.Context, url string, chainId uint64, sequencer common.Address, feed *Feed) {
+	for {
+		err := relay(ctx, url, chainId, sequencer, feed)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warn("Relaying preconfirmations stopped, connecting again", "url", url, "err", err, "in", relayRetry)
+		select {
+		case <-time.After(relayRetry):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func relay(ctx context.Context, url string, chainId uint64, sequencer common.Address, feed *Feed) error {
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return relayFrom(ctx, client, chainId, sequencer, feed)
+}
+
+func relayFrom(ctx context.Context, client *rpc.Client, chainId uint64, sequencer common.Address, feed *Feed) error {
+	ch := make(chan *Preconfirmation, subscriberBuffer)
+	sub, err := client.Subscribe(ctx, "zkevm", ch, "preconfirmations")
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+	log.Info("Relaying preconfirmations")
+
+	for {
+		select {
+		case p := <-ch:
+			if signer, err := p.Signer(chainId); err != nil || signer != sequencer {
+				log.Warn("Dropping a preconfirmation not signed by the sequencer", "tx", p.TxHash, "signer", signer, "err", err)
+				continue
+			}
+			feed.Add(p)
+		case err := <-sub.Err():
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
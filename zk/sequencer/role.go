@@ -0,0 +1,90 @@
+package sequencer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Role is what a node does in the chain
+type Role string
+
+const (
+	RoleSequencer Role = "sequencer" // seals the blocks of the chain
+	RoleRpc       Role = "rpc"       // follows the sequencer's data stream and serves users, forwarding their transactions
+	RoleArchive   Role = "archive"   // follows a data stream, or an archive of one, keeping and serving the whole history read only
+	RoleVerifier  Role = "verifier"  // follows the data stream checking its batches with the executors, serving no users
+)
+
+var Roles = []Role{RoleSequencer, RoleRpc, RoleArchive, RoleVerifier}
+
+// verifierNamespaces are the only rpc namespaces a verifier serves, enough to inspect what it checked
+var verifierNamespaces = map[string]struct{}{
+	"eth":   {},
+	"net":   {},
+	"web3":  {},
+	"zkevm": {},
+	"debug": {},
+	"admin": {},
+}
+
+func ParseRole(s string) (Role, error) {
+	for _, r := range Roles {
+		if string(r) == s {
+			return r, nil
+		}
+	}
+	names := make([]string, len(Roles))
+	for i, r := range Roles {
+		names[i] = string(r)
+	}
+	return "", fmt.Errorf("unknown node role %q, expected one of %s", s, strings.Join(names, ", "))
+}
+
+// ResolveRole resolves the role of a node from the zkevm.role flag, falling back to the deprecated CDK_ERIGON_SEQUENCER
+// when the flag is empty.  Both have to agree when both are set so a stray environment variable can't turn a node into
+// a sequencer.
+func ResolveRole(flag string, standby bool) (Role, error) {
+	envSequencer := os.Getenv(SEQUENCER_ENV_KEY) == "1"
+	if standby && envSequencer {
+		return "", fmt.Errorf("a standby sequencer is promoted by the leader election, it must not be started with %s=1", SEQUENCER_ENV_KEY)
+	}
+
+	if flag == "" {
+		if envSequencer || standby {
+			return RoleSequencer, nil
+		}
+		return RoleRpc, nil
+	}
+
+	role, err := ParseRole(flag)
+	if err != nil {
+		return "", err
+	}
+	if envSequencer && role != RoleSequencer {
+		return "", fmt.Errorf("%s=1 conflicts with --zkevm.role=%s, unset it", SEQUENCER_ENV_KEY, role)
+	}
+	if standby && role != RoleSequencer {
+		return "", fmt.Errorf("only a sequencer can be a standby, the lease is set on a node with --zkevm.role=%s", role)
+	}
+	return role, nil
+}
+
+// AcceptsTransactions is true for the roles that take transactions from users, a node that doesn't refuses them
+// instead of forwarding them on and runs no pool
+func (r Role) AcceptsTransactions() bool {
+	return r == RoleSequencer || r == RoleRpc
+}
+
+// ServesNamespace reports whether the role serves an rpc namespace enabled with http.api
+func (r Role) ServesNamespace(namespace string) bool {
+	switch r {
+	case RoleArchive:
+		return namespace != "txpool"
+	case RoleVerifier:
+		_, ok := verifierNamespaces[namespace]
+		return ok
+	default:
+		return true
+	}
+}
@@ -0,0 +1,100 @@
+package sequencer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func resetRole(t *testing.T) {
+	t.Cleanup(func() {
+		roleSet.Store(false)
+		sequencing.Store(false)
+	})
+}
+
+func TestParseRole(t *testing.T) {
+	for _, r := range Roles {
+		parsed, err := ParseRole(string(r))
+		require.NoError(t, err)
+		require.Equal(t, r, parsed)
+	}
+
+	_, err := ParseRole("Sequencer")
+	require.ErrorContains(t, err, "unknown node role")
+	_, err = ParseRole("")
+	require.Error(t, err)
+}
+
+func TestServesNamespace(t *testing.T) {
+	require.True(t, RoleRpc.ServesNamespace("txpool"))
+	require.True(t, RoleSequencer.ServesNamespace("ots"))
+	require.False(t, RoleArchive.ServesNamespace("txpool"))
+	require.True(t, RoleArchive.ServesNamespace("trace"))
+	require.True(t, RoleVerifier.ServesNamespace("zkevm"))
+	require.False(t, RoleVerifier.ServesNamespace("trace"))
+	require.False(t, RoleVerifier.ServesNamespace("txpool"))
+}
+
+func TestResolveRole(t *testing.T) {
+	t.Setenv(SEQUENCER_ENV_KEY, "")
+	for flag, expected := range map[string]Role{"": RoleRpc, "archive": RoleArchive, "sequencer": RoleSequencer} {
+		role, err := ResolveRole(flag, false)
+		require.NoError(t, err)
+		require.Equal(t, expected, role)
+	}
+	role, err := ResolveRole("", true)
+	require.NoError(t, err)
+	require.Equal(t, RoleSequencer, role)
+	_, err = ResolveRole("rpc", true)
+	require.ErrorContains(t, err, "standby")
+	_, err = ResolveRole("sequencer ", false)
+	require.ErrorContains(t, err, "unknown node role")
+
+	// the environment variable only agrees with the sequencer role
+	t.Setenv(SEQUENCER_ENV_KEY, "1")
+	role, err = ResolveRole("", false)
+	require.NoError(t, err)
+	require.Equal(t, RoleSequencer, role)
+	_, err = ResolveRole("verifier", false)
+	require.ErrorContains(t, err, "conflicts")
+	_, err = ResolveRole("", true)
+	require.ErrorContains(t, err, "standby")
+}
+
+func TestRoleFromEnv(t *testing.T) {
+	resetRole(t)
+
+	t.Setenv(SEQUENCER_ENV_KEY, "")
+	require.Equal(t, RoleRpc, NodeRole())
+	require.False(t, IsSequencer())
+
+	t.Setenv(SEQUENCER_ENV_KEY, "1")
+	require.Equal(t, RoleSequencer, NodeRole())
+	require.True(t, IsSequencer())
+}
+
+func TestSetRole(t *testing.T) {
+	resetRole(t)
+
+	// the role wins over the environment once set
+	t.Setenv(SEQUENCER_ENV_KEY, "1")
+	SetRole(RoleRpc, false)
+	require.Equal(t, RoleRpc, NodeRole())
+	require.False(t, IsSequencer())
+
+	SetRole(RoleSequencer, false)
+	require.True(t, IsSequencer())
+}
+
+func TestPromoteStandby(t *testing.T) {
+	resetRole(t)
+
+	SetRole(RoleSequencer, true)
+	require.Equal(t, RoleSequencer, NodeRole())
+	require.False(t, IsSequencer())
+
+	Promote()
+	require.Equal(t, RoleSequencer, NodeRole())
+	require.True(t, IsSequencer())
+}
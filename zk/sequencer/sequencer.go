@@ -1,14 +1,52 @@
 package sequencer
 
-import "os"
+import (
+	"os"
+	"sync/atomic"
+)
 
 const (
-	// Env variable to enable sequencer
+	// Env variable to enable sequencer, superseded by the zkevm.role flag
 	SEQUENCER_ENV_KEY = "CDK_ERIGON_SEQUENCER"
 )
 
+var (
+	roleSet    atomic.Bool
+	role       atomic.Value // Role
+	sequencing atomic.Bool  // the node seals blocks, a standby sequencer only once promoted
+)
+
+// SetRole sets the role of the node on start up.  A standby sequencer follows the sequencer until it is promoted.
+func SetRole(r Role, standby bool) {
+	role.Store(r)
+	sequencing.Store(r == RoleSequencer && !standby)
+	roleSet.Store(true)
+}
+
+// NodeRole returns the role the node was started with, read from the environment if it was never set
+func NodeRole() Role {
+	if roleSet.Load() {
+		return role.Load().(Role)
+	}
+	if os.Getenv(SEQUENCER_ENV_KEY) == "1" {
+		return RoleSequencer
+	}
+	return RoleRpc
+}
+
+// IsSequencer reports whether the node seals the blocks of the chain
 func IsSequencer() bool {
-	// TODO: SEQ: make a commmand-line flag for that and replace the env variable
-	// read from the environment
+	if roleSet.Load() {
+		return sequencing.Load()
+	}
 	return os.Getenv(SEQUENCER_ENV_KEY) == "1"
 }
+
+// Promote turns a standby into the sequencer for the rest of the process
+func Promote() {
+	if !roleSet.Load() {
+		role.Store(RoleSequencer)
+	}
+	sequencing.Store(true)
+	roleSet.Store(true)
+}
@@ -0,0 +1,149 @@
+package sequencer_control
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ledgerwatch/erigon/eth/ethconfig"
+)
+
+// pausedWait is how long a paused sequencer waits for an unwind request before the stage loop carries on
+const pausedWait = time.Second
+
+var (
+	ErrNotPaused = errors.New("the sequencer has to be paused first")
+	ErrNoControl = errors.New("the sequencer can't be controlled")
+)
+
+// Timers are the seal times of the sequencer, a change applies from the next batch
+type Timers struct {
+	BlockSealTime         time.Duration
+	BatchSealTime         time.Duration
+	NonEmptyBatchSealTime time.Duration
+}
+
+func TimersFromZk(zk *ethconfig.Zk) Timers {
+	return Timers{
+		BlockSealTime:         zk.SequencerBlockSealTime,
+		BatchSealTime:         zk.SequencerBatchSealTime,
+		NonEmptyBatchSealTime: zk.SequencerNonEmptyBatchSealTime,
+	}
+}
+
+func (t Timers) validate() error {
+	if t.BlockSealTime <= 0 || t.BatchSealTime <= 0 || t.NonEmptyBatchSealTime <= 0 {
+		return fmt.Errorf("seal times have to be above zero, got block %s, batch %s, non-empty batch %s", t.BlockSealTime, t.BatchSealTime, t.NonEmptyBatchSealTime)
+	}
+	return nil
+}
+
+// UnwindRequest asks a paused sequencer to unwind the chain to the end of a batch
+type UnwindRequest struct {
+	Batch uint64
+	reply chan unwindReply
+}
+
+type unwindReply struct {
+	block uint64
+	err   error
+}
+
+// Reply tells the requester the block the chain is unwound to or why it can't be
+func (r UnwindRequest) Reply(block uint64, err error) {
+	r.reply <- unwindReply{block: block, err: err}
+}
+
+// Control holds what an operator changes on a running sequencer: whether it seals blocks, its seal times and the
+// unwinds it was asked for.  The sequencing stage reads it at the start of every batch.  A nil Control is a sequencer
+// that is never paused, has no seal times of its own and takes no unwinds.
+type Control struct {
+	paused atomic.Bool
+
+	lock   sync.Mutex
+	timers Timers
+
+	unwinds chan UnwindRequest
+}
+
+func NewControl(timers Timers) *Control {
+	return &Control{
+		timers:  timers,
+		unwinds: make(chan UnwindRequest),
+	}
+}
+
+// Pause stops the sequencer once the open batch is sealed, the batch is sealed after its current block
+func (c *Control) Pause() {
+	if c != nil {
+		c.paused.Store(true)
+	}
+}
+
+func (c *Control) Resume() {
+	if c != nil {
+		c.paused.Store(false)
+	}
+}
+
+func (c *Control) Paused() bool {
+	return c != nil && c.paused.Load()
+}
+
+// Timers returns the seal times, zero for a nil Control
+func (c *Control) Timers() Timers {
+	if c == nil {
+		return Timers{}
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.timers
+}
+
+func (c *Control) SetTimers(timers Timers) error {
+	if c == nil {
+		return ErrNoControl
+	}
+	if err := timers.validate(); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.timers = timers
+	return nil
+}
+
+// RequestUnwind asks the paused sequencer to unwind the chain to the last block of batch, it returns that block once
+// the stage loop has taken the request, the unwind itself runs before the stages do anything else
+func (c *Control) RequestUnwind(ctx context.Context, batch uint64) (uint64, error) {
+	if !c.Paused() {
+		return 0, ErrNotPaused
+	}
+	req := UnwindRequest{Batch: batch, reply: make(chan unwindReply, 1)}
+	select {
+	case c.unwinds <- req:
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+	reply := <-req.reply
+	return reply.block, reply.err
+}
+
+// WaitWhilePaused waits a little for an unwind request while the sequencer is paused, ok is false if none came in
+func (c *Control) WaitWhilePaused(ctx context.Context) (req UnwindRequest, ok bool) {
+	if c == nil {
+		return UnwindRequest{}, false
+	}
+	timer := time.NewTimer(pausedWait)
+	defer timer.Stop()
+	select {
+	case req = <-c.unwinds:
+		return req, true
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return UnwindRequest{}, false
+}
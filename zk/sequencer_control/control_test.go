@@ -0,0 +1,71 @@
+package sequencer_control
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testTimers = Timers{BlockSealTime: time.Second, BatchSealTime: 10 * time.Second, NonEmptyBatchSealTime: 5 * time.Second}
+
+func TestTimers(t *testing.T) {
+	c := NewControl(testTimers)
+	require.Equal(t, testTimers, c.Timers())
+
+	changed := Timers{BlockSealTime: 500 * time.Millisecond, BatchSealTime: time.Minute, NonEmptyBatchSealTime: time.Second}
+	require.NoError(t, c.SetTimers(changed))
+	require.Equal(t, changed, c.Timers())
+
+	require.Error(t, c.SetTimers(Timers{BlockSealTime: time.Second, BatchSealTime: time.Second}))
+	require.Equal(t, changed, c.Timers(), "a refused change is not applied")
+}
+
+func TestRequestUnwind(t *testing.T) {
+	c := NewControl(testTimers)
+	ctx := context.Background()
+
+	_, err := c.RequestUnwind(ctx, 3)
+	require.ErrorIs(t, err, ErrNotPaused)
+
+	c.Pause()
+	_, ok := c.WaitWhilePaused(ctx)
+	require.False(t, ok)
+
+	go func() {
+		req, ok := c.WaitWhilePaused(ctx)
+		if !ok {
+			return
+		}
+		if req.Batch == 3 {
+			req.Reply(42, nil)
+		} else {
+			req.Reply(0, errors.New("unknown batch"))
+		}
+	}()
+	block, err := c.RequestUnwind(ctx, 3)
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), block)
+
+	// nobody takes the request while the sequencer is busy
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = c.RequestUnwind(ctx, 3)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestNilControl(t *testing.T) {
+	var c *Control
+	c.Pause()
+	require.False(t, c.Paused())
+	c.Resume()
+	require.Equal(t, Timers{}, c.Timers())
+	require.ErrorIs(t, c.SetTimers(testTimers), ErrNoControl)
+
+	_, err := c.RequestUnwind(context.Background(), 3)
+	require.ErrorIs(t, err, ErrNotPaused)
+	_, ok := c.WaitWhilePaused(context.Background())
+	require.False(t, ok)
+}
@@ -16,10 +16,12 @@ import (
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/erigon/zk"
 	"github.com/ledgerwatch/erigon/zk/datastream/types"
+	zktypes "github.com/ledgerwatch/erigon/zk/types"
 	"github.com/ledgerwatch/erigon/zk/erigon_db"
 	"github.com/ledgerwatch/erigon/zk/hermez_db"
 	"github.com/ledgerwatch/erigon/zk/sequencer"
 	txtype "github.com/ledgerwatch/erigon/zk/tx"
+	zkUtils "github.com/ledgerwatch/erigon/zk/utils"
 
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
@@ -28,11 +30,8 @@ import (
 )
 
 const (
-	preForkId7BlockGasLimit = 30_000_000
-	forkId7BlockGasLimit    = 18446744073709551615 // 0xffffffffffffffff
-	forkId8BlockGasLimit    = 1125899906842624     // 0x4000000000000
-	HIGHEST_KNOWN_FORK      = 9
-	newBlockTimeout         = 500
+	HIGHEST_KNOWN_FORK = 9
+	newBlockTimeout    = 500
 )
 
 type ErigonDb interface {
@@ -46,6 +45,8 @@ type HermezDb interface {
 	WriteBlockBatch(l2BlockNumber uint64, batchNumber uint64) error
 	WriteEffectiveGasPricePercentage(txHash common.Hash, effectiveGasPricePercentage uint8) error
 	DeleteEffectiveGasPricePercentages(txHashes *[]common.Hash) error
+	WriteL2TxHash(l2TxHash, txHash common.Hash) error
+	DeleteL2TxHashes(l2TxHashes *[]common.Hash) error
 
 	WriteStateRoot(l2BlockNumber uint64, rpcRoot common.Hash) error
 
@@ -71,6 +72,9 @@ type HermezDb interface {
 	WriteIntermediateTxStateRoot(l2BlockNumber uint64, txHash common.Hash, rpcRoot common.Hash) error
 	WriteBlockL1InfoTreeIndex(blockNumber uint64, l1Index uint64) error
 	WriteLatestUsedGer(batchNo uint64, ger common.Hash) error
+	WriteBlockL2GasPrice(blockNumber uint64, gasPrice uint64) error
+	WriteBlockUnexecutedTransactions(blockNumber uint64, txs []zktypes.UnexecutedTransaction) error
+	DeleteBlockUnexecutedTransactions(fromBlockNum, toBlockNum uint64) error
 }
 
 type DatastreamClient interface {
@@ -501,16 +505,29 @@ func UnwindBatchesStage(u *stagedsync.UnwindState, tx kv.RwTx, cfg BatchesCfg, c
 		return fmt.Errorf("get body transactions error: %v", err)
 	}
 	transactionHashes := make([]common.Hash, 0, len(*transactions))
+	l2TxHashes := make([]common.Hash, 0, len(*transactions))
 	for _, tx := range *transactions {
 		transactionHashes = append(transactionHashes, tx.Hash())
+
+		l2TxHash, err := txtype.ComputeL2TxHashForTransaction(tx)
+		if err != nil {
+			return fmt.Errorf("compute l2 tx hash error: %v", err)
+		}
+		l2TxHashes = append(l2TxHashes, l2TxHash)
 	}
 
 	if err := hermezDb.DeleteEffectiveGasPricePercentages(&transactionHashes); err != nil {
 		return fmt.Errorf("delete effective gas price percentages error: %v", err)
 	}
+	if err := hermezDb.DeleteL2TxHashes(&l2TxHashes); err != nil {
+		return fmt.Errorf("delete l2 tx hashes error: %v", err)
+	}
 	if err := hermezDb.DeleteStateRoots(fromBlock, toBlock); err != nil {
 		return fmt.Errorf("delete state roots error: %v", err)
 	}
+	if err := hermezDb.DeleteBlockUnexecutedTransactions(fromBlock, toBlock); err != nil {
+		return fmt.Errorf("delete block unexecuted transactions error: %v", err)
+	}
 	if err := hermezDb.DeleteIntermediateTxStateRoots(fromBlock, toBlock); err != nil {
 		return fmt.Errorf("delete intermediate tx state roots error: %v", err)
 	}
@@ -568,6 +585,10 @@ func UnwindBatchesStage(u *stagedsync.UnwindState, tx kv.RwTx, cfg BatchesCfg, c
 	if err = hermezDb.DeleteReusedL1InfoTreeIndexes(fromBlock, toBlock); err != nil {
 		return fmt.Errorf("write reused l1 info tree index error: %w", err)
 	}
+
+	if err = hermezDb.DeleteBlockL2GasPrices(fromBlock, toBlock); err != nil {
+		return fmt.Errorf("delete block l2 gas prices error: %w", err)
+	}
 	///////////////////////////////////////////////////////
 
 	log.Info(fmt.Sprintf("[%s] Deleted headers, bodies, forkIds and blockBatches.", logPrefix))
@@ -699,17 +720,7 @@ func PruneBatchesStage(s *stagedsync.PruneState, tx kv.RwTx, cfg BatchesCfg, ctx
 }
 
 func getGasLimit(forkId uint64) uint64 {
-	if forkId >= 8 {
-		return forkId8BlockGasLimit
-	}
-
-	// [hack] the rpc returns forkid8 value, but forkid7 is used in execution
-	if forkId == 7 {
-		return forkId8BlockGasLimit
-		// return forkId7BlockGasLimit
-	}
-
-	return preForkId7BlockGasLimit
+	return zkUtils.GetBlockGasLimitForFork(forkId)
 }
 
 // writeL2Block writes L2Block to ErigonDb and HermezDb
@@ -717,7 +728,14 @@ func getGasLimit(forkId uint64) uint64 {
 func writeL2Block(eriDb ErigonDb, hermezDb HermezDb, l2Block *types.FullL2Block, highestL1InfoTreeIndex uint64) error {
 	bn := new(big.Int).SetUint64(l2Block.L2BlockNumber)
 	txs := make([]ethTypes.Transaction, 0, len(l2Block.L2Txs))
-	for _, transaction := range l2Block.L2Txs {
+	var unexecuted []zktypes.UnexecutedTransaction
+	for i, transaction := range l2Block.L2Txs {
+		// the transactions of a forced batch the executor skipped are kept aside for the data stream
+		if !transaction.IsValid {
+			unexecuted = append(unexecuted, zktypes.UnexecutedTransaction{Index: uint64(i), EffectiveGasPricePercentage: transaction.EffectiveGasPricePercentage, Encoded: transaction.Encoded})
+			continue
+		}
+
 		ltx, _, err := txtype.DecodeTx(transaction.Encoded, transaction.EffectiveGasPricePercentage, l2Block.ForkId)
 		if err != nil {
 			return fmt.Errorf("decode tx error: %v", err)
@@ -728,6 +746,14 @@ func writeL2Block(eriDb ErigonDb, hermezDb HermezDb, l2Block *types.FullL2Block,
 			return fmt.Errorf("write effective gas price percentage error: %v", err)
 		}
 
+		l2TxHash, err := txtype.ComputeL2TxHashForTransaction(ltx)
+		if err != nil {
+			return fmt.Errorf("compute l2 tx hash error: %v", err)
+		}
+		if err := hermezDb.WriteL2TxHash(l2TxHash, ltx.Hash()); err != nil {
+			return fmt.Errorf("write l2 tx hash error: %v", err)
+		}
+
 		if err := hermezDb.WriteStateRoot(l2Block.L2BlockNumber, transaction.IntermediateStateRoot); err != nil {
 			return fmt.Errorf("write rpc root error: %v", err)
 		}
@@ -736,6 +762,9 @@ func writeL2Block(eriDb ErigonDb, hermezDb HermezDb, l2Block *types.FullL2Block,
 			return fmt.Errorf("write rpc root error: %v", err)
 		}
 	}
+	if err := hermezDb.WriteBlockUnexecutedTransactions(l2Block.L2BlockNumber, unexecuted); err != nil {
+		return fmt.Errorf("write block unexecuted transactions error: %v", err)
+	}
 	txCollection := ethTypes.Transactions(txs)
 	txHash := ethTypes.DeriveSha(txCollection)
 
@@ -835,5 +864,12 @@ func writeL2Block(eriDb ErigonDb, hermezDb HermezDb, l2Block *types.FullL2Block,
 		return fmt.Errorf("write block batch error: %v", err)
 	}
 
+	// a sequencer that doesn't suggest an l2 gas price leaves the rpc node to work one out itself
+	if l2Block.L2GasPrice != 0 {
+		if err := hermezDb.WriteBlockL2GasPrice(l2Block.L2BlockNumber, l2Block.L2GasPrice); err != nil {
+			return fmt.Errorf("write block l2 gas price error: %v", err)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,214 @@
+package stages
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/eth/stagedsync"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/zk/hermez_db"
+	"github.com/ledgerwatch/erigon/zk/legacy_executor_verifier"
+	"github.com/ledgerwatch/log/v3"
+)
+
+type FollowerExecutorVerifyCfg struct {
+	db       kv.RwDB
+	verifier *legacy_executor_verifier.LegacyExecutorVerifier
+}
+
+// StageFollowerExecutorVerifyCfg is the stage of a verifier node checking the batches it follows with the executors,
+// the stage is disabled without a verifier
+func StageFollowerExecutorVerifyCfg(
+	db kv.RwDB,
+	verifier *legacy_executor_verifier.LegacyExecutorVerifier,
+) FollowerExecutorVerifyCfg {
+	return FollowerExecutorVerifyCfg{
+		db:       db,
+		verifier: verifier,
+	}
+}
+
+// SpawnFollowerExecutorVerifyStage sends the batches executed from the data stream to the executors and moves the
+// progress on over the ones they found valid.  It stops at the first invalid batch and keeps logging it, a verifier
+// serves what it checked so it never goes past a batch the executors disagree with.  The progress is the last block of
+// the last valid batch so the unwinds of the blocks below it reach the stage.
+func SpawnFollowerExecutorVerifyStage(
+	s *stagedsync.StageState,
+	u stagedsync.Unwinder,
+	tx kv.RwTx,
+	ctx context.Context,
+	cfg FollowerExecutorVerifyCfg,
+	initialCycle bool,
+	quiet bool,
+) error {
+	logPrefix := s.LogPrefix()
+
+	var err error
+	freshTx := tx == nil
+	if freshTx {
+		tx, err = cfg.db.BeginRw(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+	}
+
+	hermezDb := hermez_db.NewHermezDb(tx)
+
+	progress, err := hermezDb.GetBatchNoByL2Block(s.BlockNumber)
+	if err != nil {
+		return err
+	}
+
+	executeProgress, err := stages.GetStageProgress(tx, stages.Execution)
+	if err != nil {
+		return err
+	}
+	latestBatch, err := hermezDb.GetBatchNoByL2Block(executeProgress)
+	if err != nil {
+		return err
+	}
+	// the latest batch could still have blocks to come from the stream, only the ones before it are closed
+	var closedBatch uint64
+	if latestBatch > 0 {
+		closedBatch = latestBatch - 1
+	}
+
+	responses, err := cfg.verifier.ConsumeResultsUnsafe(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, response := range responses {
+		if response == nil {
+			return fmt.Errorf("verifier failed (but not due to verification)")
+		}
+		if response.BatchNumber != progress+1 {
+			break
+		}
+		if !response.Valid {
+			log.Error(fmt.Sprintf("[%s] Batch failed verification, not moving the progress past it", logPrefix), "batch", response.BatchNumber)
+			break
+		}
+
+		if err = hermezDb.WriteWitness(response.BatchNumber, response.Witness); err != nil {
+			log.Warn(fmt.Sprintf("[%s] Failed to write witness", logPrefix), "batch", response.BatchNumber, "err", err)
+		}
+		if err = saveFollowerExecutorVerifyProgress(tx, hermezDb, response.BatchNumber); err != nil {
+			return err
+		}
+		progress = response.BatchNumber
+	}
+
+	for batch := progress + 1; batch <= closedBatch; batch++ {
+		// the injected batch has nothing for the executors to check
+		if batch == injectedBatchNumber {
+			if err = saveFollowerExecutorVerifyProgress(tx, hermezDb, batch); err != nil {
+				return err
+			}
+			progress = batch
+			continue
+		}
+		if cfg.verifier.IsRequestAddedUnsafe(batch) {
+			continue
+		}
+
+		highestBlock, err := hermezDb.GetHighestBlockInBatch(batch)
+		if err != nil {
+			return err
+		}
+		block, err := rawdb.ReadBlockByNumber(tx, highestBlock)
+		if err != nil {
+			return err
+		}
+		if block == nil {
+			return fmt.Errorf("block %d of batch %d not found", highestBlock, batch)
+		}
+		forkId, err := hermezDb.GetForkId(batch)
+		if err != nil {
+			return err
+		}
+
+		// a follower has no counters of its own to compare the executor's with
+		if _, err = cfg.verifier.AddRequestUnsafe(ctx, tx, &legacy_executor_verifier.VerifierRequest{BatchNumber: batch, ForkId: forkId, StateRoot: block.Root()}); err != nil {
+			log.Error(fmt.Sprintf("[%s] Failed to add request to verifier", logPrefix), "batch", batch, "err", err)
+		}
+	}
+
+	if freshTx {
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UnwindFollowerExecutorVerifyStage drops the requests in flight, they were for the blocks being unwound, and moves
+// the progress back to the last batch left whole
+func UnwindFollowerExecutorVerifyStage(
+	u *stagedsync.UnwindState,
+	s *stagedsync.StageState,
+	tx kv.RwTx,
+	ctx context.Context,
+	cfg FollowerExecutorVerifyCfg,
+	initialCycle bool,
+) error {
+	var err error
+	useExternalTx := tx != nil
+	if !useExternalTx {
+		tx, err = cfg.db.BeginRw(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+	}
+
+	cfg.verifier.CancelAllRequestsUnsafe()
+
+	// the batch of the unwind point loses blocks, the one before it is the last the executors checked whole
+	hermezDb := hermez_db.NewHermezDbReader(tx)
+	unwindBatch, err := hermezDb.GetBatchNoByL2Block(u.UnwindPoint)
+	if err != nil {
+		return err
+	}
+	var progress uint64
+	if unwindBatch > 0 {
+		if progress, err = hermezDb.GetHighestBlockInBatch(unwindBatch - 1); err != nil {
+			return err
+		}
+	}
+	if progress > u.UnwindPoint {
+		progress = u.UnwindPoint
+	}
+	if err = stages.SaveStageProgress(tx, stages.FollowerExecutorVerify, progress); err != nil {
+		return err
+	}
+
+	if !useExternalTx {
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func saveFollowerExecutorVerifyProgress(tx kv.RwTx, hermezDb *hermez_db.HermezDb, batch uint64) error {
+	highestBlock, err := hermezDb.GetHighestBlockInBatch(batch)
+	if err != nil {
+		return err
+	}
+	return stages.SaveStageProgress(tx, stages.FollowerExecutorVerify, highestBlock)
+}
+
+func PruneFollowerExecutorVerifyStage(
+	s *stagedsync.PruneState,
+	tx kv.RwTx,
+	cfg FollowerExecutorVerifyCfg,
+	ctx context.Context,
+	initialCycle bool,
+) error {
+	return nil
+}
@@ -242,6 +242,13 @@ func UnwindZkIntermediateHashesStage(u *stagedsync.UnwindState, s *stagedsync.St
 	return nil
 }
 
+// RegenerateSmtRoot builds the SMT of the whole plain state in the tx from scratch, the way the stage does when it
+// regenerates the tree, and returns its root
+func RegenerateSmtRoot(logPrefix string, tx kv.RwTx) (common.Hash, error) {
+	eridb := db2.NewEriDb(tx)
+	return regenerateIntermediateHashes(logPrefix, tx, eridb, smt.NewSMT(eridb))
+}
+
 func regenerateIntermediateHashes(logPrefix string, db kv.RwTx, eridb *db2.EriDb, smtIn *smt.SMT) (common.Hash, error) {
 	log.Info(fmt.Sprintf("[%s] Regeneration trie hashes started", logPrefix))
 	defer log.Info(fmt.Sprintf("[%s] Regeneration ended", logPrefix))
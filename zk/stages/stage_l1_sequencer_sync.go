@@ -1,8 +1,10 @@
 package stages
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"math/big"
 
 	"github.com/gateway-fm/cdk-erigon-lib/common"
 	"github.com/gateway-fm/cdk-erigon-lib/kv"
@@ -57,8 +59,9 @@ func SpawnL1SequencerSyncStage(
 	if err != nil {
 		return err
 	}
-	if progress > 0 {
-		// if we have progress then we can assume that we have the single injected batch already so can just return here
+	if progress > 0 && cfg.syncer.IsSyncStarted() && !cfg.syncer.IsDownloading() && cfg.syncer.GetLastCheckedL1Block() <= progress {
+		// we have the injected batch and every forced batch up to our progress, and the syncer hasn't checked any
+		// new L1 blocks since, so there is nothing to do
 		return nil
 	}
 	if progress == 0 {
@@ -83,6 +86,7 @@ Loop:
 				return err
 			}
 
+			injected := false
 			for _, l := range logs {
 				header := headersMap[l.BlockNumber]
 				switch l.Topics[0] {
@@ -90,13 +94,29 @@ Loop:
 					if err := HandleInitialSequenceBatches(cfg.syncer, hermezDb, l, header); err != nil {
 						return err
 					}
-					// we only ever handle a single injected batch as a sequencer currently so we can just
-					// exit early here
-					break Loop
+					injected = true
+				case contracts.ForceBatchTopic:
+					if err := HandleForceBatch(cfg.syncer, hermezDb, l, header); err != nil {
+						return err
+					}
+				case contracts.UpdateZkEVMVersionTopic:
+					interval, err := parseForkIntervalLog(&l)
+					if err != nil {
+						return fmt.Errorf("failed to parse fork update, %w", err)
+					}
+					log.Info(fmt.Sprintf("[%s] Fork update found on L1", logPrefix), "forkId", interval.ForkId, "fromBatch", interval.FromBatchNumber, "version", interval.Version)
+					if err := hermezDb.WriteForkInterval(interval); err != nil {
+						return fmt.Errorf("failed to write fork interval, %w", err)
+					}
 				default:
 					log.Warn("received unexpected topic from l1 sequencer sync stage", "topic", l.Topics[0])
 				}
 			}
+			if injected {
+				// the sequencer can start as soon as it has the injected batch, the forced batches after it are
+				// picked up in the next cycles as the syncer keeps running
+				break Loop
+			}
 		case progMsg := <-progressChan:
 			log.Info(fmt.Sprintf("[%s] %s", logPrefix, progMsg))
 		default:
@@ -106,8 +126,6 @@ Loop:
 		}
 	}
 
-	cfg.syncer.Stop()
-
 	progress = cfg.syncer.GetLastCheckedL1Block()
 	if progress >= cfg.zkCfg.L1FirstBlock {
 		// do not save progress if progress less than L1FirstBlock
@@ -181,12 +199,11 @@ func HandleL1InfoTreeUpdate(
 }
 
 const (
-	injectedBatchLogTrailingBytes        = 24
-	injectedBatchLogTransactionStartByte = 128
-	injectedBatchLastGerStartByte        = 31
-	injectedBatchLastGerEndByte          = 64
-	injectedBatchSequencerStartByte      = 76
-	injectedBatchSequencerEndByte        = 96
+	injectedBatchLogTxOffsetPos     = 0
+	injectedBatchLastGerStartByte   = 31
+	injectedBatchLastGerEndByte     = 64
+	injectedBatchSequencerStartByte = 76
+	injectedBatchSequencerEndByte   = 96
 )
 
 func HandleInitialSequenceBatches(
@@ -204,11 +221,12 @@ func HandleInitialSequenceBatches(
 		}
 	}
 
-	// the log appears to have some trailing 24 bytes of all 0s in it.  Not sure why but we can't handle the
-	// TX without trimming these off
-	trailingCutoff := len(l.Data) - injectedBatchLogTrailingBytes
-
-	txData := l.Data[injectedBatchLogTransactionStartByte:trailingCutoff]
+	// the transactions are abi encoded bytes so the data is padded to 32 bytes after them, the length tells us
+	// where they end
+	txData, err := decodeAbiBytes(l.Data, injectedBatchLogTxOffsetPos)
+	if err != nil {
+		return err
+	}
 
 	ib := &types.L1InjectedBatch{
 		L1BlockNumber:      l.BlockNumber,
@@ -227,6 +245,99 @@ func HandleInitialSequenceBatches(
 	return nil
 }
 
+const (
+	forceBatchLogGerEndByte         = 32
+	forceBatchLogSequencerStartByte = 44
+	forceBatchLogSequencerEndByte   = 64
+	forceBatchLogTxOffsetPos        = 64
+	forceBatchCallDataSelectorBytes = 4
+)
+
+// HandleForceBatch stores a batch forced on L1 so the sequencer can include it.  When the batch is forced directly
+// from an EOA the event does not carry the transactions, they have to be read from the forceBatch call data instead.
+// The call data is only read when the transaction calls forceBatch on the rollup itself, a batch whose transactions
+// can't be found is stored empty.
+func HandleForceBatch(
+	syncer IL1Syncer,
+	db *hermez_db.HermezDb,
+	l ethTypes.Log,
+	header *ethTypes.Header,
+) error {
+	var err error
+
+	if len(l.Topics) < 2 || len(l.Data) < forceBatchLogTxOffsetPos+32 {
+		log.Warn("Received malformed force batch log", "txHash", l.TxHash)
+		return nil
+	}
+
+	if header == nil {
+		header, err = syncer.GetHeader(l.BlockNumber)
+		if err != nil {
+			return err
+		}
+	}
+
+	txData, err := decodeAbiBytes(l.Data, forceBatchLogTxOffsetPos)
+	if err != nil {
+		return err
+	}
+
+	if len(txData) == 0 {
+		tx, _, err := syncer.GetTransaction(l.TxHash)
+		if err != nil {
+			return err
+		}
+		if txData, err = forceBatchCallData(tx, l.Address); err != nil {
+			log.Warn("Could not read the transactions of a forced batch, storing it empty", "txHash", l.TxHash, "err", err)
+			txData = nil
+		}
+	}
+
+	fb := &types.L1ForcedBatch{
+		ForcedBatchNumber: new(big.Int).SetBytes(l.Topics[1].Bytes()).Uint64(),
+		L1BlockNumber:     l.BlockNumber,
+		Timestamp:         header.Time,
+		L1ParentHash:      header.ParentHash,
+		GlobalExitRoot:    common.BytesToHash(l.Data[:forceBatchLogGerEndByte]),
+		Sequencer:         common.BytesToAddress(l.Data[forceBatchLogSequencerStartByte:forceBatchLogSequencerEndByte]),
+		Transactions:      txData,
+	}
+
+	log.Info("Found forced batch on L1", "forcedBatch", fb.ForcedBatchNumber, "l1Block", fb.L1BlockNumber)
+
+	return db.WriteL1ForcedBatch(fb)
+}
+
+// forceBatchCallData reads the transactions of a batch forced by an EOA from its call to forceBatch(bytes transactions,
+// uint256 polAmount) on the rollup
+func forceBatchCallData(tx ethTypes.Transaction, rollup common.Address) ([]byte, error) {
+	if to := tx.GetTo(); to == nil || *to != rollup {
+		return nil, fmt.Errorf("forced through another contract")
+	}
+	callData := tx.GetData()
+	if len(callData) < forceBatchCallDataSelectorBytes || !bytes.Equal(callData[:forceBatchCallDataSelectorBytes], contracts.ForceBatchMethodId) {
+		return nil, fmt.Errorf("not a forceBatch call")
+	}
+	return decodeAbiBytes(callData[forceBatchCallDataSelectorBytes:], 0)
+}
+
+// decodeAbiBytes reads a dynamic bytes argument from abi encoded data given the position of its offset word
+func decodeAbiBytes(data []byte, offsetPos int) ([]byte, error) {
+	if len(data) < offsetPos+32 {
+		return nil, fmt.Errorf("abi data too short to read bytes offset")
+	}
+	offset := new(big.Int).SetBytes(data[offsetPos : offsetPos+32])
+	if !offset.IsUint64() || offset.Uint64()+32 > uint64(len(data)) {
+		return nil, fmt.Errorf("abi bytes offset out of range")
+	}
+	start := offset.Uint64() + 32
+	length := new(big.Int).SetBytes(data[offset.Uint64():start])
+	if !length.IsUint64() || start+length.Uint64() > uint64(len(data)) {
+		return nil, fmt.Errorf("abi bytes length out of range")
+	}
+	return data[start : start+length.Uint64()], nil
+}
+
 func UnwindL1SequencerSyncStage(u *stagedsync.UnwindState, tx kv.RwTx, cfg L1SequencerSyncCfg, ctx context.Context) error {
 	return nil
 }
@@ -0,0 +1,202 @@
+package stages
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/kv/memdb"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/accounts/abi"
+	libcommon "github.com/ledgerwatch/erigon/common"
+	ethTypes "github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/zk/contracts"
+	"github.com/ledgerwatch/erigon/zk/hermez_db"
+)
+
+func TestHandleInitialSequenceBatches(t *testing.T) {
+	rollupAbi, err := abi.JSON(strings.NewReader(contracts.SequenceBatchesAbiv6_6))
+	require.NoError(t, err)
+	event := rollupAbi.Events["InitialSequenceBatches"]
+
+	ger := common.HexToHash("0xad3228b676f7d3cd4284a5443f17f1962b36e491b30a40b2405849e597ba5fb5")
+	sequencer := common.HexToAddress("0x5b06837a43bdc3dd9f114558daf4b26ed49842ed")
+
+	tests := []struct {
+		name  string
+		txLen int
+	}{
+		// the injected transaction the rollup contracts generate, 24 bytes short of a multiple of 32, the data used
+		// to be read by trimming 24 bytes of padding off the end of the log
+		{"rollup contract transaction", 232},
+		// a transaction that needs no padding lost its last 24 bytes to that trimming
+		{"transaction without padding", 256},
+		{"empty transaction", 0},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := memdb.NewTestDB(t)
+			tx, err := db.BeginRw(context.Background())
+			require.NoError(t, err)
+			defer tx.Rollback()
+			require.NoError(t, hermez_db.CreateHermezBuckets(tx))
+			hermezDb := hermez_db.NewHermezDb(tx)
+
+			txData := bytes.Repeat([]byte{byte(i + 1)}, tt.txLen)
+			data, err := event.Inputs.Pack(txData, ger, sequencer)
+			require.NoError(t, err)
+			if tt.txLen%32 == 8 {
+				require.Equal(t, txData, data[128:len(data)-24])
+			}
+
+			header := &ethTypes.Header{Number: common.Big1, Time: 1700000000}
+			l := ethTypes.Log{BlockNumber: 1, Topics: []common.Hash{contracts.InitialSequenceBatchesTopic}, Data: data}
+			require.NoError(t, HandleInitialSequenceBatches(nil, hermezDb, l, header))
+
+			ib, err := hermezDb.GetL1InjectedBatch(0)
+			require.NoError(t, err)
+			require.Equal(t, txData, ib.Transaction)
+			require.Equal(t, ger, ib.LastGlobalExitRoot)
+			require.Equal(t, sequencer, ib.Sequencer)
+			require.Equal(t, header.Hash(), ib.L1BlockHash)
+		})
+	}
+
+	// a log too short for its own length is rejected rather than read past its end
+	data, err := event.Inputs.Pack(make([]byte, 64), ger, sequencer)
+	require.NoError(t, err)
+	l := ethTypes.Log{BlockNumber: 1, Data: data[:len(data)-32]}
+	require.Error(t, HandleInitialSequenceBatches(nil, nil, l, &ethTypes.Header{}))
+}
+
+// word is a 32 byte abi word holding v
+func word(v uint64) []byte {
+	return libcommon.LeftPadBytes(new(big.Int).SetUint64(v).Bytes(), 32)
+}
+
+func TestDecodeAbiBytes(t *testing.T) {
+	payload := bytes.Repeat([]byte{0xab}, 40)
+	valid := append(append(append(word(0xff), word(64)...), word(uint64(len(payload)))...), libcommon.RightPadBytes(payload, 64)...)
+
+	tests := []struct {
+		name      string
+		data      []byte
+		offsetPos int
+		want      []byte
+		wantErr   bool
+	}{
+		{"valid", valid, 32, payload, false},
+		{"empty bytes", append(word(32), word(0)...), 0, []byte{}, false},
+		{"too short for the offset word", valid[:40], 32, nil, true},
+		{"offset past the end", append(word(96), word(0)...), 0, nil, true},
+		{"offset leaves no room for the length", append(word(40), word(0)...), 0, nil, true},
+		{"offset wider than 64 bits", append(append(bytes.Repeat([]byte{0xff}, 24), word(0)[24:]...), word(0)...), 0, nil, true},
+		{"length past the end", append(word(32), word(1)...), 0, nil, true},
+		{"length wider than 64 bits", append(word(32), bytes.Repeat([]byte{0xff}, 32)...), 0, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeAbiBytes(tt.data, tt.offsetPos)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// forceBatchSyncer serves the transaction that emitted a ForceBatch log
+type forceBatchSyncer struct {
+	IL1Syncer
+	tx ethTypes.Transaction
+}
+
+func (s *forceBatchSyncer) GetTransaction(common.Hash) (ethTypes.Transaction, bool, error) {
+	return s.tx, false, nil
+}
+
+func TestHandleForceBatch(t *testing.T) {
+	bytesType, err := abi.NewType("bytes", "", nil)
+	require.NoError(t, err)
+	uint256Type, err := abi.NewType("uint256", "", nil)
+	require.NoError(t, err)
+	addressType, err := abi.NewType("address", "", nil)
+	require.NoError(t, err)
+	bytes32Type, err := abi.NewType("bytes32", "", nil)
+	require.NoError(t, err)
+	// ForceBatch(uint64 indexed forceBatchNum, bytes32 lastGlobalExitRoot, address sequencer, bytes transactions)
+	eventData := abi.Arguments{{Type: bytes32Type}, {Type: addressType}, {Type: bytesType}}
+	// forceBatch(bytes transactions, uint256 polAmount)
+	callArgs := abi.Arguments{{Type: bytesType}, {Type: uint256Type}}
+
+	rollup := common.HexToAddress("0x519e42c24163192dca44cd3fbdcebf6be9130987")
+	other := common.HexToAddress("0x1234")
+	ger := common.HexToHash("0xad3228b676f7d3cd4284a5443f17f1962b36e491b30a40b2405849e597ba5fb5")
+	sequencer := common.HexToAddress("0x5b06837a43bdc3dd9f114558daf4b26ed49842ed")
+	txData := bytes.Repeat([]byte{0x42}, 70)
+
+	forceBatchCall := func(txs []byte) []byte {
+		args, err := callArgs.Pack(txs, big.NewInt(1))
+		require.NoError(t, err)
+		return append(libcommon.CopyBytes(contracts.ForceBatchMethodId), args...)
+	}
+	call := func(to common.Address, data []byte) ethTypes.Transaction {
+		return ethTypes.NewTransaction(0, to, uint256.NewInt(0), 100000, uint256.NewInt(1), data)
+	}
+
+	tests := []struct {
+		name    string
+		logTxs  []byte
+		tx      ethTypes.Transaction
+		wantTxs []byte
+	}{
+		{"transactions in the event", txData, nil, txData},
+		{"forced by an eoa", nil, call(rollup, forceBatchCall(txData)), txData},
+		{"forced through another contract", nil, call(other, forceBatchCall(txData)), nil},
+		{"contract creation", nil, ethTypes.NewContractCreation(0, uint256.NewInt(0), 100000, uint256.NewInt(1), forceBatchCall(txData)), nil},
+		{"not a forceBatch call", nil, call(rollup, append([]byte{1, 2, 3, 4}, forceBatchCall(txData)[4:]...)), nil},
+		{"call data too short", nil, call(rollup, contracts.ForceBatchMethodId[:2]), nil},
+		{"malformed call data", nil, call(rollup, forceBatchCall(txData)[:100]), nil},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := memdb.NewTestDB(t)
+			tx, err := db.BeginRw(context.Background())
+			require.NoError(t, err)
+			defer tx.Rollback()
+			require.NoError(t, hermez_db.CreateHermezBuckets(tx))
+			hermezDb := hermez_db.NewHermezDb(tx)
+
+			data, err := eventData.Pack(ger, sequencer, tt.logTxs)
+			require.NoError(t, err)
+			forcedBatchNumber := uint64(i + 1)
+			l := ethTypes.Log{
+				Address:     rollup,
+				BlockNumber: 10,
+				Topics:      []common.Hash{contracts.ForceBatchTopic, common.BytesToHash(word(forcedBatchNumber))},
+				Data:        data,
+			}
+			header := &ethTypes.Header{Number: big.NewInt(10), Time: 1700000000, ParentHash: common.HexToHash("0x01")}
+			require.NoError(t, HandleForceBatch(&forceBatchSyncer{tx: tt.tx}, hermezDb, l, header))
+
+			fb, err := hermezDb.GetL1ForcedBatch(forcedBatchNumber)
+			require.NoError(t, err)
+			require.NotNil(t, fb)
+			require.Equal(t, len(tt.wantTxs), len(fb.Transactions))
+			if len(tt.wantTxs) > 0 {
+				require.Equal(t, tt.wantTxs, fb.Transactions)
+			}
+			require.Equal(t, ger, fb.GlobalExitRoot)
+			require.Equal(t, sequencer, fb.Sequencer)
+			require.Equal(t, header.Time, fb.Timestamp)
+			require.Equal(t, header.ParentHash, fb.L1ParentHash)
+		})
+	}
+}
@@ -36,6 +36,7 @@ type IL1Syncer interface {
 	L1QueryHeaders(logs []ethTypes.Log) (map[uint64]*ethTypes.Header, error)
 	GetBlock(number uint64) (*ethTypes.Block, error)
 	GetHeader(number uint64) (*ethTypes.Header, error)
+	GetTransaction(hash common.Hash) (ethTypes.Transaction, bool, error)
 	Run(lastCheckedBlock uint64)
 	Stop()
 }
@@ -149,6 +150,15 @@ Loop:
 						return fmt.Errorf("failed to write verification for block %d, %w", info.L1BlockNo, err)
 					}
 					newVerificationsCount++
+				case logForkUpdate:
+					interval, err := parseForkIntervalLog(&l)
+					if err != nil {
+						return fmt.Errorf("failed to parse fork update, %w", err)
+					}
+					log.Info(fmt.Sprintf("[%s] Fork update found on L1", logPrefix), "forkId", interval.ForkId, "fromBatch", interval.FromBatchNumber, "version", interval.Version)
+					if err := hermezDb.WriteForkInterval(interval); err != nil {
+						return fmt.Errorf("failed to write fork interval, %w", err)
+					}
 				case logIncompatible:
 					continue
 				default:
@@ -207,13 +217,14 @@ var (
 	logSequence         BatchLogType = 1
 	logVerify           BatchLogType = 2
 	logL1InfoTreeUpdate BatchLogType = 4
+	logForkUpdate       BatchLogType = 5
 
 	logIncompatible BatchLogType = 100
 )
 
 func parseLogType(l1RollupId uint64, log *ethTypes.Log) (l1BatchInfo types.L1BatchInfo, batchLogType BatchLogType) {
 	bigRollupId := new(big.Int).SetUint64(l1RollupId)
-	isRollupIdMatching := log.Topics[1] == common.BigToHash(bigRollupId)
+	isRollupIdMatching := len(log.Topics) > 1 && log.Topics[1] == common.BigToHash(bigRollupId)
 
 	var batchNum uint64
 	var stateRoot, l1InfoRoot common.Hash
@@ -240,6 +251,8 @@ func parseLogType(l1RollupId uint64, log *ethTypes.Log) (l1BatchInfo types.L1Bat
 		}
 	case contracts.UpdateL1InfoTreeTopic:
 		batchLogType = logL1InfoTreeUpdate
+	case contracts.UpdateZkEVMVersionTopic:
+		batchLogType = logForkUpdate
 	default:
 		batchLogType = logUnknown
 		batchNum = 0
@@ -254,6 +267,30 @@ func parseLogType(l1RollupId uint64, log *ethTypes.Log) (l1BatchInfo types.L1Bat
 	}, batchLogType
 }
 
+// parseForkIntervalLog decodes an UpdateZkEVMVersion(uint64 numBatch, uint64 forkID, string version) event.  numBatch
+// is the last batch verified before the upgrade so the new fork applies from the batch after it.
+func parseForkIntervalLog(log *ethTypes.Log) (*types.ForkInterval, error) {
+	if len(log.Data) < 128 {
+		return nil, fmt.Errorf("fork update log data too short: %d", len(log.Data))
+	}
+
+	versionOffset := new(big.Int).SetBytes(log.Data[64:96]).Uint64()
+	if versionOffset+32 > uint64(len(log.Data)) {
+		return nil, fmt.Errorf("fork update version offset out of range: %d", versionOffset)
+	}
+	versionLength := new(big.Int).SetBytes(log.Data[versionOffset : versionOffset+32]).Uint64()
+	if versionOffset+32+versionLength > uint64(len(log.Data)) {
+		return nil, fmt.Errorf("fork update version length out of range: %d", versionLength)
+	}
+
+	return &types.ForkInterval{
+		FromBatchNumber: new(big.Int).SetBytes(log.Data[:32]).Uint64() + 1,
+		ForkId:          new(big.Int).SetBytes(log.Data[32:64]).Uint64(),
+		L1BlockNumber:   log.BlockNumber,
+		Version:         string(log.Data[versionOffset+32 : versionOffset+32+versionLength]),
+	}, nil
+}
+
 func UnwindL1SyncerStage(u *stagedsync.UnwindState, tx kv.RwTx, cfg L1SyncerCfg, ctx context.Context) (err error) {
 	useExternalTx := tx != nil
 	if !useExternalTx {
@@ -268,7 +305,8 @@ func UnwindL1SyncerStage(u *stagedsync.UnwindState, tx kv.RwTx, cfg L1SyncerCfg,
 	/*
 		1. unwind sequences table
 		2. unwind verifications table
-		3. update l1verifications batchno and l1syncer stage progress
+		3. unwind fork history table
+		4. update l1verifications batchno and l1syncer stage progress
 	*/
 
 	err = tx.ClearBucket(hermez_db.L1SEQUENCES)
@@ -279,6 +317,10 @@ func UnwindL1SyncerStage(u *stagedsync.UnwindState, tx kv.RwTx, cfg L1SyncerCfg,
 	if err != nil {
 		return err
 	}
+	err = tx.ClearBucket(hermez_db.FORK_HISTORY)
+	if err != nil {
+		return err
+	}
 
 	// the below are very inefficient due to key layout
 	//hermezDb := hermez_db.NewHermezDb(tx)
@@ -397,6 +439,19 @@ func verifyAgainstLocalBlocks(tx kv.RwTx, hermezDb *hermez_db.HermezDb, logPrefi
 		}
 	}
 
+	// the last batch seen in the stream can still get blocks, its last block is only known once the next batch starts
+	batchToCheck, err := hermezDb.GetBatchNoByL2Block(blockToCheck)
+	if err != nil {
+		return err
+	}
+	highestSeenBatchNo, err := stages.GetStageProgress(tx, stages.HighestSeenBatchNumber)
+	if err != nil {
+		return fmt.Errorf("failed to get highest seen batch no, %w", err)
+	}
+	if batchToCheck >= highestSeenBatchNo {
+		return nil
+	}
+
 	// already checked
 	highestChecked, err := stages.GetStageProgress(tx, stages.VerificationsStateRootCheck)
 	if err != nil {
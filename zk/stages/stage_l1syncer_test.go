@@ -0,0 +1,44 @@
+package stages
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/zk/hermez_db"
+)
+
+func TestVerifyAgainstLocalBlocksOpenBatch(t *testing.T) {
+	db := memdb.NewTestDB(t)
+	tx, err := db.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+	require.NoError(t, hermez_db.CreateHermezBuckets(tx))
+	hermezDb := hermez_db.NewHermezDb(tx)
+
+	// blocks 1 and 2 of batch 1 have been synced, the batch was closed on L1 with a block the node hasn't got yet
+	for blockNo := uint64(1); blockNo <= 2; blockNo++ {
+		header := &types.Header{Number: big.NewInt(int64(blockNo)), Root: common.Hash{byte(blockNo)}}
+		rawdb.WriteHeader(tx, header)
+		require.NoError(t, rawdb.WriteCanonicalHash(tx, header.Hash(), blockNo))
+		require.NoError(t, rawdb.WriteBody(tx, header.Hash(), blockNo, &types.Body{}))
+		require.NoError(t, hermezDb.WriteBlockBatch(blockNo, 1))
+	}
+	require.NoError(t, hermezDb.WriteVerification(10, 1, common.Hash{}, common.Hash{3}))
+	require.NoError(t, stages.SaveStageProgress(tx, stages.IntermediateHashes, 2))
+
+	// the node is still in batch 1 so its last block isn't known
+	require.NoError(t, stages.SaveStageProgress(tx, stages.HighestSeenBatchNumber, 1))
+	require.NoError(t, verifyAgainstLocalBlocks(tx, hermezDb, "test"))
+
+	// once the next batch has started block 2 is the last block of batch 1 and has to match the verification
+	require.NoError(t, stages.SaveStageProgress(tx, stages.HighestSeenBatchNumber, 2))
+	require.ErrorIs(t, verifyAgainstLocalBlocks(tx, hermezDb, "test"), ErrStateRootMismatch)
+}
@@ -2,6 +2,7 @@ package stages
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -19,7 +20,9 @@ import (
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
 	"github.com/ledgerwatch/erigon/eth/stagedsync"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/zk/batch_sealing"
 	"github.com/ledgerwatch/erigon/zk/datastream/server"
+	"github.com/ledgerwatch/erigon/zk/pool_manager"
 	zktx "github.com/ledgerwatch/erigon/zk/tx"
 	"github.com/ledgerwatch/erigon/zk/utils"
 )
@@ -38,6 +41,10 @@ func SpawnSequencingStage(
 	log.Info(fmt.Sprintf("[%s] Starting sequencing stage", logPrefix))
 	defer log.Info(fmt.Sprintf("[%s] Finished sequencing stage", logPrefix))
 
+	if err = cfg.checkLeader(); err != nil {
+		return err
+	}
+
 	freshTx := tx == nil
 	if freshTx {
 		tx, err = cfg.db.BeginRw(ctx)
@@ -47,6 +54,10 @@ func SpawnSequencingStage(
 		defer tx.Rollback()
 	}
 
+	if err = releaseIncluded(cfg, tx); err != nil {
+		return err
+	}
+
 	sdb := newStageDb(tx)
 
 	executionAt, err := s.ExecutionAt(tx)
@@ -59,6 +70,19 @@ func SpawnSequencingStage(
 		return err
 	}
 
+	// a paused sequencer seals nothing, it only takes the unwinds asked of it
+	if cfg.control.Paused() {
+		if req, ok := cfg.control.WaitWhilePaused(ctx); ok {
+			block, err := unwindTargetOfBatch(cfg, sdb.hermezDb, lastBatch, req.Batch)
+			if err == nil {
+				log.Info(fmt.Sprintf("[%s] Unwinding to batch %d on request", logPrefix, req.Batch), "block", block)
+				u.UnwindTo(block, common.Hash{})
+			}
+			req.Reply(block, err)
+		}
+		return nil
+	}
+
 	forkId, err := prepareForkId(cfg, lastBatch, executionAt, sdb.hermezDb)
 	if err != nil {
 		return err
@@ -87,6 +111,9 @@ func SpawnSequencingStage(
 		if err != nil {
 			return err
 		}
+		if err = sdb.hermezDb.WriteBatchSealReason(injectedBatchNumber, string(batch_sealing.ReasonInjected)); err != nil {
+			return err
+		}
 
 		srv := server.NewDataStreamServer(cfg.stream, cfg.chainConfig.ChainID.Uint64(), server.StandardOperationMode)
 		if err = server.WriteBlocksToStream(tx, sdb.hermezDb.HermezDbReader, srv, cfg.stream, 1, 1, logPrefix); err != nil {
@@ -98,6 +125,7 @@ func SpawnSequencingStage(
 				return err
 			}
 		}
+		batch_sealing.Sealed(batch_sealing.ReasonInjected)
 
 		return nil
 	}
@@ -106,6 +134,49 @@ func SpawnSequencingStage(
 		return err
 	}
 
+	// forced batches take priority over the pool and are sequenced as a batch of their own
+	if !l1Recovery {
+		forced, err := getNextForcedBatch(sdb)
+		if err != nil {
+			return err
+		}
+		if forced != nil {
+			thisBatch := lastBatch + 1
+			log.Info(fmt.Sprintf("[%s] Starting forced batch %d...", logPrefix, thisBatch), "forcedBatch", forced.ForcedBatchNumber)
+
+			lastBlock, included, err := processForcedBatch(ctx, cfg, s, sdb, forkId, executionAt, thisBatch, forced)
+			if err != nil {
+				return err
+			}
+			if err = sdb.hermezDb.WriteBatchSealReason(thisBatch, string(batch_sealing.ReasonForced)); err != nil {
+				return err
+			}
+
+			if err = cfg.checkLeader(); err != nil {
+				return err
+			}
+
+			if !cfg.zk.HasExecutors() {
+				srv := server.NewDataStreamServer(cfg.stream, cfg.chainConfig.ChainID.Uint64(), server.StandardOperationMode)
+				if err = server.WriteBlocksToStream(tx, sdb.hermezDb.HermezDbReader, srv, cfg.stream, executionAt+1, lastBlock, logPrefix); err != nil {
+					return err
+				}
+			}
+
+			log.Info(fmt.Sprintf("[%s] Finish forced batch %d...", logPrefix, thisBatch))
+
+			if freshTx {
+				if err = tx.Commit(); err != nil {
+					return err
+				}
+			}
+			reportIncluded(cfg, freshTx, included)
+			batch_sealing.Sealed(batch_sealing.ReasonForced)
+
+			return nil
+		}
+	}
+
 	var header *types.Header
 	var parentBlock *types.Block
 
@@ -119,9 +190,11 @@ func SpawnSequencingStage(
 	var blockTransactions []types.Transaction
 	var l1EffectiveGases, effectiveGases []uint8
 
-	batchTicker := time.NewTicker(cfg.zk.SequencerBatchSealTime)
+	// the seal times may be changed while the sequencer runs, they apply from the next batch
+	timers := cfg.control.Timers()
+	batchTicker := time.NewTicker(timers.BatchSealTime)
 	defer batchTicker.Stop()
-	nonEmptyBatchTimer := time.NewTicker(cfg.zk.SequencerNonEmptyBatchSealTime)
+	nonEmptyBatchTimer := time.NewTicker(timers.NonEmptyBatchSealTime)
 	defer nonEmptyBatchTimer.Stop()
 
 	hasAnyTransactionsInThisBatch := false
@@ -130,10 +203,20 @@ func SpawnSequencingStage(
 	runLoopBlocks := true
 	lastStartedBn := executionAt - 1
 	yielded := mapset.NewSet[[32]byte]()
+	var included []pool_manager.Report // told to the pool manager once the batch is done
 	coinbase := cfg.zk.AddressSequencer
 	workRemaining := true
 	decodedBlocksSize := uint64(0)
 
+	// why the batch is sealed, set wherever the block loop is left
+	var sealReason batch_sealing.Reason
+	// an admin request is only served once the batch comes from the pool, a recovered batch ends where it did on the L1
+	sealRequested := cfg.sealer.Requested()
+	if l1Recovery {
+		sealReason = batch_sealing.ReasonL1Recovery
+		sealRequested = nil
+	}
+
 	if l1Recovery {
 		if cfg.zk.L1SyncStopBatch > 0 && thisBatch > cfg.zk.L1SyncStopBatch {
 			log.Info(fmt.Sprintf("[%s] L1 recovery has completed!", logPrefix), "batch", thisBatch)
@@ -173,6 +256,10 @@ func SpawnSequencingStage(
 
 		log.Info(fmt.Sprintf("[%s] Starting block %d...", logPrefix, blockNumber+1))
 
+		if err = cfg.checkLeader(); err != nil {
+			return err
+		}
+
 		reRunBlockAfterOverflow := blockNumber == lastStartedBn
 		lastStartedBn = blockNumber
 
@@ -204,6 +291,7 @@ func SpawnSequencingStage(
 			return err
 		}
 		if !l1Recovery && overflowOnNewBlock {
+			sealReason = batch_sealing.ReasonOverflow
 			break
 		}
 
@@ -229,6 +317,7 @@ func SpawnSequencingStage(
 			&parentRoot,
 			l1TreeUpdate,
 			shouldWriteGerToContract,
+			false,
 		); err != nil {
 			return err
 		}
@@ -243,7 +332,7 @@ func SpawnSequencingStage(
 			// avoid a leak
 			logTicker := time.NewTicker(10 * time.Second)
 			defer logTicker.Stop()
-			blockTicker := time.NewTicker(cfg.zk.SequencerBlockSealTime)
+			blockTicker := time.NewTicker(timers.BlockSealTime)
 			defer blockTicker.Stop()
 			overflow := false
 
@@ -261,14 +350,21 @@ func SpawnSequencingStage(
 					}
 				case <-batchTicker.C:
 					if !l1Recovery {
+						sealReason = batch_sealing.ReasonBatchTimer
 						runLoopBlocks = false
 						break LOOP_TRANSACTIONS
 					}
 				case <-nonEmptyBatchTimer.C:
 					if !l1Recovery && hasAnyTransactionsInThisBatch {
+						sealReason = batch_sealing.ReasonNonEmptyBatchTimer
 						runLoopBlocks = false
 						break LOOP_TRANSACTIONS
 					}
+				case <-sealRequested:
+					log.Info(fmt.Sprintf("[%s] Sealing batch %d on request", logPrefix, thisBatch))
+					sealReason = batch_sealing.ReasonAdmin
+					runLoopBlocks = false
+					break LOOP_TRANSACTIONS
 				default:
 					if !l1Recovery {
 						cfg.txPool.LockFlusher()
@@ -287,12 +383,35 @@ func SpawnSequencingStage(
 							effectiveGas = l1EffectiveGases[i]
 						} else {
 							effectiveGas = DeriveEffectiveGasPrice(cfg, transaction)
-							effectiveGases = append(effectiveGases, effectiveGas)
 						}
-						effectiveGases = append(effectiveGases, effectiveGas)
 
-						receipt, overflow, err = attemptAddTransaction(cfg, sdb, ibs, batchCounters, &blockContext, header, transaction, effectiveGas, l1Recovery, forkId)
+						receipt, overflow, err = attemptAddTransaction(cfg, sdb, ibs, batchCounters, &blockContext, header, transaction, uint64(len(addedTransactions)), effectiveGas, l1Recovery, !l1Recovery, forkId)
 						if err != nil {
+							var refused *aclRefusedError
+							if errors.As(err, &refused) {
+								log.Info(fmt.Sprintf("[%s] discarding transaction %s: %s", logPrefix, transaction.Hash(), refused.reason))
+								cfg.txPool.DiscardFromPending(transaction.Hash(), refused.reason)
+								if cfg.poolManager != nil {
+									cfg.poolManager.Report(pool_manager.Report{Hash: transaction.Hash(), Status: pool_manager.StatusDiscarded, Error: refused.Error()})
+								}
+								err = nil
+								continue
+							}
+							var ahead *nonceTooHighError
+							if errors.As(err, &ahead) {
+								// left unreported the pool manager hands it out again, by then the nonces before it may be in
+								log.Debug(fmt.Sprintf("[%s] skipping transaction %s of the pool manager: %v", logPrefix, transaction.Hash(), ahead))
+								err = nil
+								continue
+							}
+							var rejected *rejectedError
+							if errors.As(err, &rejected) {
+								log.Info(fmt.Sprintf("[%s] rejecting transaction %s of the pool manager: %v", logPrefix, transaction.Hash(), rejected))
+								cfg.poolManager.Report(pool_manager.Report{Hash: transaction.Hash(), Status: rejected.status, Error: rejected.err.Error()})
+								err = nil
+								continue
+							}
+
 							// if we are in recovery just log the error as a warning.  If the data is on the L1 then we should consider it as confirmed.
 							// The executor/prover would simply skip a TX with an invalid nonce for example so we don't need to worry about that here.
 							if l1Recovery {
@@ -315,6 +434,9 @@ func SpawnSequencingStage(
 							*/
 							if len(addedTransactions) == 0 {
 								cfg.txPool.MarkForDiscardFromPendingBest(transaction.Hash())
+								if cfg.poolManager != nil {
+									cfg.poolManager.Report(pool_manager.Report{Hash: transaction.Hash(), Status: pool_manager.StatusOverflow})
+								}
 								log.Trace(fmt.Sprintf("single transaction %s overflow counters", transaction.Hash()))
 							} else {
 								txSize := len(blockTransactions)
@@ -328,9 +450,24 @@ func SpawnSequencingStage(
 
 						addedTransactions = append(addedTransactions, transaction)
 						addedReceipts = append(addedReceipts, receipt)
+						effectiveGases = append(effectiveGases, effectiveGas)
 
 						hasAnyTransactionsInThisBatch = true
-						nonEmptyBatchTimer.Reset(cfg.zk.SequencerNonEmptyBatchSealTime)
+						nonEmptyBatchTimer.Reset(timers.NonEmptyBatchSealTime)
+
+						// the rest of the transactions are taken again by the next batch
+						if !l1Recovery && cfg.sealer.WatchesUsage() {
+							usage, err := batchUsage(batchCounters)
+							if err != nil {
+								return err
+							}
+							if reason := cfg.sealer.Check(usage); reason != "" {
+								log.Info(fmt.Sprintf("[%s] Sealing batch %d", logPrefix, thisBatch), "reason", reason, "counters", usage.CounterPercentage, "l2DataSize", usage.L2DataSize)
+								sealReason = reason
+								runLoopBlocks = false
+								break LOOP_TRANSACTIONS
+							}
+						}
 					}
 
 					if l1Recovery {
@@ -351,7 +488,7 @@ func SpawnSequencingStage(
 		} else {
 			for idx, transaction := range addedTransactions {
 				effectiveGas := effectiveGases[idx]
-				receipt, innerOverflow, err := attemptAddTransaction(cfg, sdb, ibs, batchCounters, &blockContext, header, transaction, effectiveGas, false, forkId)
+				receipt, innerOverflow, err := attemptAddTransaction(cfg, sdb, ibs, batchCounters, &blockContext, header, transaction, uint64(idx), effectiveGas, false, false, forkId)
 				if err != nil {
 					return err
 				}
@@ -361,6 +498,7 @@ func SpawnSequencingStage(
 				}
 				addedReceipts[idx] = receipt
 			}
+			sealReason = batch_sealing.ReasonOverflow
 			runLoopBlocks = false // close the batch because there are no counters left
 		}
 
@@ -371,8 +509,27 @@ func SpawnSequencingStage(
 		if err = doFinishBlockAndUpdateState(ctx, cfg, s, sdb, ibs, header, parentBlock, forkId, thisBatch, ger, l1BlockHash, addedTransactions, addedReceipts, effectiveGases, infoTreeIndexProgress); err != nil {
 			return err
 		}
+		if !l1Recovery {
+			for _, transaction := range addedTransactions {
+				included = append(included, pool_manager.Report{Hash: transaction.Hash(), Status: pool_manager.StatusIncluded, BlockNumber: thisBlockNumber})
+			}
+		}
 
 		log.Info(fmt.Sprintf("[%s] Finish block %d with %d transactions...", logPrefix, thisBlockNumber, len(addedTransactions)))
+
+		// calculateNextL1TreeUpdateToUse only hands out an index once, so a block with one used a new update
+		if runLoopBlocks && !l1Recovery {
+			if reason := cfg.sealer.CheckBlock(l1TreeUpdateIndex > 0); reason != "" {
+				log.Info(fmt.Sprintf("[%s] Sealing batch %d", logPrefix, thisBatch), "reason", reason, "l1InfoTreeIndex", l1TreeUpdateIndex)
+				sealReason = reason
+				runLoopBlocks = false
+			}
+		}
+		if runLoopBlocks && !l1Recovery && cfg.control.Paused() {
+			log.Info(fmt.Sprintf("[%s] Sealing batch %d to pause", logPrefix, thisBatch))
+			sealReason = batch_sealing.ReasonAdmin
+			runLoopBlocks = false
+		}
 	}
 
 	counters, err := batchCounters.CombineCollectors()
@@ -385,6 +542,14 @@ func SpawnSequencingStage(
 	if err != nil {
 		return err
 	}
+	if err = sdb.hermezDb.WriteBatchSealReason(thisBatch, string(sealReason)); err != nil {
+		return err
+	}
+
+	// the last chance to leave the batch unwritten if the lease ran out while it was being sealed
+	if err = cfg.checkLeader(); err != nil {
+		return err
+	}
 
 	// if we do not have an executors in the zk config then we can populate the stream immediately with the latest
 	// batch information
@@ -395,13 +560,15 @@ func SpawnSequencingStage(
 		}
 	}
 
-	log.Info(fmt.Sprintf("[%s] Finish batch %d...", logPrefix, thisBatch))
+	log.Info(fmt.Sprintf("[%s] Finish batch %d...", logPrefix, thisBatch), "sealReason", sealReason)
 
 	if freshTx {
 		if err = tx.Commit(); err != nil {
 			return err
 		}
 	}
+	reportIncluded(cfg, freshTx, included)
+	batch_sealing.Sealed(sealReason)
 
 	return nil
 }
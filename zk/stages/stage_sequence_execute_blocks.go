@@ -18,6 +18,7 @@ import (
 	"github.com/ledgerwatch/erigon/smt/pkg/blockinfo"
 	"github.com/ledgerwatch/erigon/zk/erigon_db"
 	"github.com/ledgerwatch/erigon/zk/hermez_db"
+	zktx "github.com/ledgerwatch/erigon/zk/tx"
 	zktypes "github.com/ledgerwatch/erigon/zk/types"
 	"github.com/ledgerwatch/secp256k1"
 )
@@ -32,13 +33,14 @@ func handleStateForNewBlockStarting(
 	stateRoot *common.Hash,
 	l1info *zktypes.L1InfoTreeUpdate,
 	shouldWriteGerToContract bool,
+	forcedFromL1 bool,
 ) error {
 	ibs.PreExecuteStateSet(chainConfig, blockNumber, timestamp, stateRoot)
 
 	// handle writing to the ger manager contract but only if the index is above 0
-	// block 1 is a special case as it's the injected batch, so we always need to check the GER/L1 block hash
+	// injected and forced batches are a special case, so we always need to check the GER/L1 block hash
 	// as these will be force-fed from the event from L1
-	if l1info != nil && l1info.Index > 0 || blockNumber == 1 {
+	if l1info != nil && (l1info.Index > 0 || forcedFromL1) {
 		// store it so we can retrieve for the data stream
 		if err := hermezDb.WriteBlockGlobalExitRoot(blockNumber, l1info.GER); err != nil {
 			return err
@@ -47,8 +49,9 @@ func handleStateForNewBlockStarting(
 			return err
 		}
 
-		// in the case of a re-used l1 info tree index we don't want to write the ger to the contract
-		if shouldWriteGerToContract {
+		// in the case of a re-used l1 info tree index we don't want to write the ger to the contract, nor an empty
+		// ger a batch can be forced with
+		if shouldWriteGerToContract && l1info.GER != (common.Hash{}) {
 			// first check if this ger has already been written
 			l1BlockHash := ibs.ReadGerManagerL1BlockHash(l1info.GER)
 			if l1BlockHash == (common.Hash{}) {
@@ -111,6 +114,14 @@ func finaliseBlock(
 			Receipt:           receipts[i],
 			Signer:            &from,
 		})
+
+		l2TxHash, err := zktx.ComputeL2TxHashForTransaction(tx)
+		if err != nil {
+			return err
+		}
+		if err = sdb.hermezDb.WriteL2TxHash(l2TxHash, tx.Hash()); err != nil {
+			return err
+		}
 	}
 	if err := postBlockStateHandling(cfg, ibs, sdb.hermezDb, newHeader, ger, l1BlockHash, parentBlock.Root(), txInfos); err != nil {
 		return err
@@ -231,7 +242,9 @@ func addSenders(
 	finalHeader *types.Header,
 ) error {
 	signer := types.MakeSigner(cfg.chainConfig, newNum.Uint64())
-	cryptoContext := secp256k1.ContextForThread(1)
+	// there is a context per cpu so only the first one exists on every machine, recovering a sender only reads the
+	// context so sharing it with the senders stage is safe
+	cryptoContext := secp256k1.ContextForThread(0)
 	senders := make([]common.Address, 0, len(finalTransactions))
 	for _, transaction := range finalTransactions {
 		from, err := signer.SenderWithContext(cryptoContext, transaction)
@@ -0,0 +1,268 @@
+package stages
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/eth/stagedsync"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	db2 "github.com/ledgerwatch/erigon/smt/pkg/db"
+	"github.com/ledgerwatch/erigon/zk/hermez_db"
+	"github.com/ledgerwatch/erigon/zk/pool_manager"
+	zktx "github.com/ledgerwatch/erigon/zk/tx"
+	zktypes "github.com/ledgerwatch/erigon/zk/types"
+)
+
+// getNextForcedBatch returns the next forced batch to sequence once it has reached finality on L1, or nil if there
+// is nothing to force yet
+func getNextForcedBatch(sdb *stageDb) (*zktypes.L1ForcedBatch, error) {
+	lastForced, err := sdb.hermezDb.GetHighestUsedForcedBatchNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	forced, err := sdb.hermezDb.GetL1ForcedBatch(lastForced + 1)
+	if err != nil {
+		return nil, err
+	}
+	if forced == nil || !hasReachedL1Finality(forced.Timestamp) {
+		return nil, nil
+	}
+
+	return forced, nil
+}
+
+// errForcedBatchOverflow is returned when a forced batch runs out of counters, the ROM takes such a batch as invalid
+var errForcedBatchOverflow = errors.New("forced batch overflowed the counters")
+
+// processForcedBatch sequences a forced batch as a batch of its own, block for block and transaction for transaction
+// as it was forced so the batch data rebuilt from the data stream is the data L1 committed to.  Transactions the
+// executor skips, because they fail before execution, stay in their block unexecuted.  A batch that can't be decoded
+// or runs out of counters is invalid to the ROM and none of its transactions are executed; as that is only known once
+// it is executed it is tried on a copy of the state first.  It returns the last block of the batch and the
+// transactions it included, for the pool manager.
+func processForcedBatch(
+	ctx context.Context,
+	cfg SequenceBlockCfg,
+	s *stagedsync.StageState,
+	sdb *stageDb,
+	forkId uint64,
+	executionAt uint64,
+	thisBatch uint64,
+	forced *zktypes.L1ForcedBatch,
+) (uint64, []pool_manager.Report, error) {
+	logPrefix := s.LogPrefix()
+
+	decodedBlocks, err := zktx.DecodeBatchL2Blocks(forced.Transactions, forkId)
+	if err != nil {
+		log.Warn(fmt.Sprintf("[%s] could not decode forced batch, sequencing it as an invalid batch", logPrefix), "forcedBatch", forced.ForcedBatchNumber, "err", err)
+		return executeForcedBatch(ctx, cfg, s, sdb, forkId, executionAt, thisBatch, forced, []zktx.DecodedBatchL2Data{{}}, false)
+	}
+	if len(decodedBlocks) == 0 {
+		decodedBlocks = []zktx.DecodedBatchL2Data{{}}
+	}
+
+	_, _, err = tryForcedBatch(ctx, cfg, s, sdb, forkId, executionAt, thisBatch, forced, decodedBlocks)
+	valid := true
+	if errors.Is(err, errForcedBatchOverflow) {
+		log.Warn(fmt.Sprintf("[%s] forced batch overflowed the counters, sequencing it as an invalid batch", logPrefix), "forcedBatch", forced.ForcedBatchNumber)
+		valid = false
+	} else if err != nil {
+		return 0, nil, err
+	}
+
+	return executeForcedBatch(ctx, cfg, s, sdb, forkId, executionAt, thisBatch, forced, decodedBlocks, valid)
+}
+
+// tryForcedBatch executes a forced batch on an in memory copy of the state, which is dropped afterwards
+func tryForcedBatch(
+	ctx context.Context,
+	cfg SequenceBlockCfg,
+	s *stagedsync.StageState,
+	sdb *stageDb,
+	forkId uint64,
+	executionAt uint64,
+	thisBatch uint64,
+	forced *zktypes.L1ForcedBatch,
+	decodedBlocks []zktx.DecodedBatchL2Data,
+) (uint64, []pool_manager.Report, error) {
+	trial := memdb.NewMemoryBatch(sdb.tx, cfg.dirs.Tmp)
+	defer trial.Close()
+	// the zk tables are not chain data tables, the copy only has them once they are created
+	if err := hermez_db.CreateHermezBuckets(trial); err != nil {
+		return 0, nil, err
+	}
+	if err := db2.CreateEriDbBuckets(trial); err != nil {
+		return 0, nil, err
+	}
+
+	trialCfg := cfg
+	trialCfg.accumulator = nil
+	return executeForcedBatch(ctx, trialCfg, s, newStageDb(trial), forkId, executionAt, thisBatch, forced, decodedBlocks, true)
+}
+
+// forcedBlockTime is the timestamp of a block of a forced batch.  The ROM holds the blocks of a forced batch to the
+// timestamp it was forced at: the first block takes it, or its parent's when the chain is already past it, and the
+// others follow with the deltas of the batch data, which must not take them past it.
+func forcedBlockTime(forced *zktypes.L1ForcedBatch, first bool, parentTime, deltaTimestamp, limit uint64) (uint64, error) {
+	if first {
+		if forced.Timestamp > parentTime {
+			return forced.Timestamp, nil
+		}
+		return parentTime, nil
+	}
+	blockTime := parentTime + deltaTimestamp
+	if blockTime > limit {
+		return 0, fmt.Errorf("forced batch %d can't be sequenced as forced, a block at %d is past its timestamp limit %d", forced.ForcedBatchNumber, blockTime, limit)
+	}
+	return blockTime, nil
+}
+
+// executeForcedBatch writes the blocks of a forced batch, executing their transactions when the batch is valid
+func executeForcedBatch(
+	ctx context.Context,
+	cfg SequenceBlockCfg,
+	s *stagedsync.StageState,
+	sdb *stageDb,
+	forkId uint64,
+	executionAt uint64,
+	thisBatch uint64,
+	forced *zktypes.L1ForcedBatch,
+	decodedBlocks []zktx.DecodedBatchL2Data,
+	valid bool,
+) (uint64, []pool_manager.Report, error) {
+	logPrefix := s.LogPrefix()
+
+	infoTreeIndexProgress, err := stages.GetStageProgress(sdb.tx, stages.HighestUsedL1InfoIndex)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	fakeL1TreeUpdate := &zktypes.L1InfoTreeUpdate{
+		GER:        forced.GlobalExitRoot,
+		ParentHash: forced.L1ParentHash,
+		Timestamp:  forced.Timestamp,
+	}
+
+	getHeader := func(hash common.Hash, number uint64) *types.Header { return rawdb.ReadHeader(sdb.tx, hash, number) }
+	batchCounters := vm.NewBatchCounterCollector(sdb.smt.GetDepth(), uint16(forkId), cfg.zk.ShouldCountersBeUnlimited(false))
+	var included []pool_manager.Report
+	var timestampLimit uint64
+
+	blockNumber := executionAt
+	for b, decodedBlock := range decodedBlocks {
+		header, parentBlock, err := prepareHeader(sdb.tx, blockNumber, uint64(decodedBlock.DeltaTimestamp), forkId, cfg.zk.AddressSequencer)
+		if err != nil {
+			return 0, nil, err
+		}
+		if header.Time, err = forcedBlockTime(forced, b == 0, parentBlock.Time(), uint64(decodedBlock.DeltaTimestamp), timestampLimit); err != nil {
+			return 0, nil, err
+		}
+		if b == 0 {
+			timestampLimit = header.Time
+		}
+
+		if _, err = batchCounters.StartNewBlock(); err != nil {
+			return 0, nil, err
+		}
+
+		ibs := state.New(sdb.stateReader)
+		getHashFn := core.GetHashFn(header, getHeader)
+		blockContext := core.NewEVMBlockContext(header, getHashFn, cfg.engine, &cfg.zk.AddressSequencer, parentBlock.ExcessDataGas())
+
+		parentRoot := parentBlock.Root()
+		if err = handleStateForNewBlockStarting(
+			cfg.chainConfig,
+			sdb.hermezDb,
+			ibs,
+			header.Number.Uint64(),
+			thisBatch,
+			header.Time,
+			&parentRoot,
+			fakeL1TreeUpdate,
+			true,
+			true,
+		); err != nil {
+			return 0, nil, err
+		}
+
+		addedTransactions := []types.Transaction{}
+		addedReceipts := []*types.Receipt{}
+		effectiveGases := []uint8{}
+		var unexecuted []zktypes.UnexecutedTransaction
+
+		for i, transaction := range decodedBlock.Transactions {
+			effectiveGas := DeriveEffectiveGasPrice(cfg, transaction)
+			if i < len(decodedBlock.EffectiveGasPricePercentages) {
+				effectiveGas = decodedBlock.EffectiveGasPricePercentages[i]
+			}
+
+			if valid {
+				receipt, overflow, err := attemptAddTransaction(cfg, sdb, ibs, batchCounters, &blockContext, header, transaction, uint64(len(addedTransactions)), effectiveGas, false, false, forkId)
+				var notExecuted *notExecutedError
+				if errors.As(err, &notExecuted) {
+					log.Warn(fmt.Sprintf("[%s] forced transaction not executed: %v", logPrefix, err), "forcedBatch", forced.ForcedBatchNumber, "tx-hash", transaction.Hash())
+				} else if err != nil {
+					return 0, nil, err
+				} else if overflow {
+					return 0, nil, errForcedBatchOverflow
+				} else {
+					addedTransactions = append(addedTransactions, transaction)
+					addedReceipts = append(addedReceipts, receipt)
+					effectiveGases = append(effectiveGases, effectiveGas)
+					continue
+				}
+			}
+
+			var encoded bytes.Buffer
+			if err = transaction.EncodeRLP(&encoded); err != nil {
+				return 0, nil, err
+			}
+			unexecuted = append(unexecuted, zktypes.UnexecutedTransaction{Index: uint64(i), EffectiveGasPricePercentage: effectiveGas, Encoded: encoded.Bytes()})
+		}
+
+		thisBlockNumber := header.Number.Uint64()
+		if err = sdb.hermezDb.WriteBlockL1InfoTreeIndex(thisBlockNumber, 0); err != nil {
+			return 0, nil, err
+		}
+		if err = sdb.hermezDb.WriteBlockUnexecutedTransactions(thisBlockNumber, unexecuted); err != nil {
+			return 0, nil, err
+		}
+
+		if err = doFinishBlockAndUpdateState(ctx, cfg, s, sdb, ibs, header, parentBlock, forkId, thisBatch, forced.GlobalExitRoot, forced.L1ParentHash, addedTransactions, addedReceipts, effectiveGases, infoTreeIndexProgress); err != nil {
+			return 0, nil, err
+		}
+
+		for _, transaction := range addedTransactions {
+			included = append(included, pool_manager.Report{Hash: transaction.Hash(), Status: pool_manager.StatusIncluded, BlockNumber: thisBlockNumber})
+		}
+
+		log.Info(fmt.Sprintf("[%s] Finish forced block %d with %d transactions...", logPrefix, thisBlockNumber, len(addedTransactions)), "unexecuted", len(unexecuted))
+		blockNumber = thisBlockNumber
+	}
+
+	counters, err := batchCounters.CombineCollectors()
+	if err != nil {
+		return 0, nil, err
+	}
+	if err = sdb.hermezDb.WriteBatchCounters(thisBatch, counters.UsedAsMap()); err != nil {
+		return 0, nil, err
+	}
+
+	if err = sdb.hermezDb.WriteBatchForcedBatchNumber(thisBatch, forced.ForcedBatchNumber); err != nil {
+		return 0, nil, err
+	}
+
+	return blockNumber, included, nil
+}
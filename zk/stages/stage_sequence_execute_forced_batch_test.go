@@ -0,0 +1,99 @@
+package stages
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gateway-fm/cdk-erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/zk/hermez_db"
+	zktypes "github.com/ledgerwatch/erigon/zk/types"
+)
+
+func TestGetNextForcedBatch(t *testing.T) {
+	final := uint64(time.Now().Add(-time.Hour).Unix())
+	recent := uint64(time.Now().Unix())
+
+	tests := []struct {
+		name    string
+		forced  []zktypes.L1ForcedBatch
+		used    uint64 // the forced batch the chain sequenced last, 0 for none
+		wantNil bool
+		want    uint64
+	}{
+		{name: "none forced", wantNil: true},
+		{name: "first forced batch", forced: []zktypes.L1ForcedBatch{{ForcedBatchNumber: 1, Timestamp: final}}, want: 1},
+		{name: "not final on L1 yet", forced: []zktypes.L1ForcedBatch{{ForcedBatchNumber: 1, Timestamp: recent}}, wantNil: true},
+		{
+			name:   "the one after the last sequenced",
+			forced: []zktypes.L1ForcedBatch{{ForcedBatchNumber: 1, Timestamp: final}, {ForcedBatchNumber: 2, Timestamp: final}},
+			used:   1,
+			want:   2,
+		},
+		{name: "all sequenced", forced: []zktypes.L1ForcedBatch{{ForcedBatchNumber: 1, Timestamp: final}}, used: 1, wantNil: true},
+		{
+			// forced batches are sequenced in order, a later one that is final does not jump one that is not
+			name:    "in order",
+			forced:  []zktypes.L1ForcedBatch{{ForcedBatchNumber: 1, Timestamp: recent}, {ForcedBatchNumber: 2, Timestamp: final}},
+			wantNil: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := memdb.NewTestDB(t)
+			tx, err := db.BeginRw(context.Background())
+			require.NoError(t, err)
+			defer tx.Rollback()
+			require.NoError(t, hermez_db.CreateHermezBuckets(tx))
+			sdb := newStageDb(tx)
+
+			for i := range tt.forced {
+				require.NoError(t, sdb.hermezDb.WriteL1ForcedBatch(&tt.forced[i]))
+			}
+			if tt.used > 0 {
+				require.NoError(t, sdb.hermezDb.WriteBatchForcedBatchNumber(10, tt.used))
+			}
+
+			forced, err := getNextForcedBatch(sdb)
+			require.NoError(t, err)
+			if tt.wantNil {
+				require.Nil(t, forced)
+				return
+			}
+			require.NotNil(t, forced)
+			require.Equal(t, tt.want, forced.ForcedBatchNumber)
+		})
+	}
+}
+
+func TestForcedBlockTime(t *testing.T) {
+	forced := &zktypes.L1ForcedBatch{ForcedBatchNumber: 1, Timestamp: 1000}
+
+	tests := []struct {
+		name       string
+		first      bool
+		parentTime uint64
+		delta      uint64
+		limit      uint64
+		want       uint64
+		wantErr    bool
+	}{
+		{name: "first block takes the forced timestamp", first: true, parentTime: 900, delta: 50, want: 1000},
+		{name: "first block after a later parent", first: true, parentTime: 1100, delta: 50, want: 1100},
+		{name: "later block within the limit", parentTime: 990, delta: 10, limit: 1000, want: 1000},
+		{name: "later block past the limit", parentTime: 1000, delta: 1, limit: 1000, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := forcedBlockTime(forced, tt.first, tt.parentTime, tt.delta, tt.limit)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -57,6 +57,7 @@ func processInjectedInitialBatch(
 		&parentRoot,
 		fakeL1TreeUpdate,
 		true,
+		true,
 	); err != nil {
 		return err
 	}
@@ -97,7 +98,7 @@ func handleInjectedBatch(
 
 	// process the tx and we can ignore the counters as an overflow at this stage means no network anyway
 	effectiveGas := DeriveEffectiveGasPrice(cfg, decodedBlocks[0].Transactions[0])
-	receipt, _, err := attemptAddTransaction(cfg, sdb, ibs, batchCounters, blockContext, header, decodedBlocks[0].Transactions[0], effectiveGas, false, forkId)
+	receipt, _, err := attemptAddTransaction(cfg, sdb, ibs, batchCounters, blockContext, header, decodedBlocks[0].Transactions[0], 0, effectiveGas, false, false, forkId)
 	if err != nil {
 		return nil, nil, 0, err
 	}
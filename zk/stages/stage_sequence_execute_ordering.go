@@ -0,0 +1,127 @@
+package stages
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/zk/txpool"
+)
+
+const (
+	// TxOrderingPrice is the pool's own order, by price then nonce
+	TxOrderingPrice = "price"
+	// TxOrderingFifo takes the transactions in the order the pool received them
+	TxOrderingFifo = "fifo"
+	// TxOrderingPriorityFee takes the transactions by priority fee, the ones received first for the same fee
+	TxOrderingPriorityFee = "priority-fee"
+)
+
+// TxOrderingPolicy decides the order the sequencer takes the pending transactions of the pool in.  Whatever the order,
+// the pool keeps the transactions of a sender in nonce order.
+type TxOrderingPolicy interface {
+	// Order returns the candidates in the order to try them in, candidates left out wait for a later yield
+	Order(candidates []*txpool.PendingTx) []*txpool.PendingTx
+}
+
+// NewTxOrderingPolicy builds the ordering of the config, nil for the pool's own order.  Priority senders go first and
+// the cap on the transactions of a sender applies to each yield, so the other senders get their share of every block.
+func NewTxOrderingPolicy(cfg *ethconfig.Zk) (TxOrderingPolicy, error) {
+	var policy TxOrderingPolicy
+	switch cfg.SequencerTxOrdering {
+	case "", TxOrderingPrice:
+	case TxOrderingFifo:
+		policy = fifoOrdering{}
+	case TxOrderingPriorityFee:
+		policy = priorityFeeOrdering{}
+	default:
+		return nil, fmt.Errorf("unknown transaction ordering %q, expected one of %s, %s, %s", cfg.SequencerTxOrdering,
+			TxOrderingPrice, TxOrderingFifo, TxOrderingPriorityFee)
+	}
+
+	if len(cfg.SequencerPrioritySenders) > 0 {
+		senders := make(map[common.Address]struct{}, len(cfg.SequencerPrioritySenders))
+		for _, sender := range cfg.SequencerPrioritySenders {
+			senders[sender] = struct{}{}
+		}
+		policy = prioritySendersOrdering{senders: senders, next: policy}
+	}
+	if cfg.SequencerMaxTxsPerSender > 0 {
+		policy = senderCapOrdering{max: cfg.SequencerMaxTxsPerSender, next: policy}
+	}
+	return policy, nil
+}
+
+// txOrder adapts a policy to the pool, nil keeps the pool's own order
+func txOrder(policy TxOrderingPolicy) txpool.TxOrder {
+	if policy == nil {
+		return nil
+	}
+	return policy.Order
+}
+
+// orderWith orders by policy, candidates come in the pool's own order so a nil policy keeps it
+func orderWith(policy TxOrderingPolicy, candidates []*txpool.PendingTx) []*txpool.PendingTx {
+	if policy == nil {
+		return candidates
+	}
+	return policy.Order(candidates)
+}
+
+type fifoOrdering struct{}
+
+func (fifoOrdering) Order(candidates []*txpool.PendingTx) []*txpool.PendingTx {
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Arrival < candidates[j].Arrival })
+	return candidates
+}
+
+type priorityFeeOrdering struct{}
+
+func (priorityFeeOrdering) Order(candidates []*txpool.PendingTx) []*txpool.PendingTx {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if c := candidates[i].Tip.Cmp(&candidates[j].Tip); c != 0 {
+			return c > 0
+		}
+		return candidates[i].Arrival < candidates[j].Arrival
+	})
+	return candidates
+}
+
+// prioritySendersOrdering puts the transactions of the allow-listed senders ahead of the others, both in the order of
+// the next policy
+type prioritySendersOrdering struct {
+	senders map[common.Address]struct{}
+	next    TxOrderingPolicy
+}
+
+func (o prioritySendersOrdering) Order(candidates []*txpool.PendingTx) []*txpool.PendingTx {
+	candidates = orderWith(o.next, candidates)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		_, iPriority := o.senders[candidates[i].Sender]
+		_, jPriority := o.senders[candidates[j].Sender]
+		return iPriority && !jPriority
+	})
+	return candidates
+}
+
+// senderCapOrdering yields at most max transactions of a sender at once, in the order of the next policy
+type senderCapOrdering struct {
+	max  uint64
+	next TxOrderingPolicy
+}
+
+func (o senderCapOrdering) Order(candidates []*txpool.PendingTx) []*txpool.PendingTx {
+	candidates = orderWith(o.next, candidates)
+	counts := make(map[common.Address]uint64)
+	result := candidates[:0]
+	for _, c := range candidates {
+		if counts[c.Sender] >= o.max {
+			continue
+		}
+		counts[c.Sender]++
+		result = append(result, c)
+	}
+	return result
+}
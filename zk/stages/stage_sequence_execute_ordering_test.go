@@ -0,0 +1,67 @@
+package stages
+
+import (
+	"testing"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/zk/txpool"
+)
+
+func TestTxOrderingPolicy(t *testing.T) {
+	alice := common.HexToAddress("0xa")
+	bob := common.HexToAddress("0xb")
+	carol := common.HexToAddress("0xc")
+
+	// in the pool's own order
+	candidates := func() []*txpool.PendingTx {
+		pending := func(sender common.Address, arrival, tip uint64) *txpool.PendingTx {
+			return &txpool.PendingTx{Sender: sender, Arrival: arrival, Tip: *uint256.NewInt(tip)}
+		}
+		return []*txpool.PendingTx{
+			pending(alice, 4, 30),
+			pending(alice, 5, 30),
+			pending(alice, 6, 30),
+			pending(bob, 2, 20),
+			pending(carol, 3, 20),
+			pending(carol, 1, 10),
+		}
+	}
+	arrivals := func(ordered []*txpool.PendingTx) []uint64 {
+		result := make([]uint64, len(ordered))
+		for i, c := range ordered {
+			result[i] = c.Arrival
+		}
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		cfg      ethconfig.Zk
+		expected []uint64
+	}{
+		{"price", ethconfig.Zk{}, []uint64{4, 5, 6, 2, 3, 1}},
+		{"fifo", ethconfig.Zk{SequencerTxOrdering: TxOrderingFifo}, []uint64{1, 2, 3, 4, 5, 6}},
+		{"priority fee", ethconfig.Zk{SequencerTxOrdering: TxOrderingPriorityFee}, []uint64{4, 5, 6, 2, 3, 1}},
+		{"priority senders", ethconfig.Zk{SequencerTxOrdering: TxOrderingFifo, SequencerPrioritySenders: []common.Address{carol}}, []uint64{1, 3, 2, 4, 5, 6}},
+		{"sender cap", ethconfig.Zk{SequencerMaxTxsPerSender: 1}, []uint64{4, 2, 3}},
+		{"priority senders capped", ethconfig.Zk{SequencerTxOrdering: TxOrderingFifo, SequencerPrioritySenders: []common.Address{alice}, SequencerMaxTxsPerSender: 2}, []uint64{4, 5, 1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewTxOrderingPolicy(&tt.cfg)
+			require.NoError(t, err)
+			ordered := candidates()
+			if policy != nil {
+				ordered = policy.Order(ordered)
+			}
+			require.Equal(t, tt.expected, arrivals(ordered))
+		})
+	}
+
+	_, err := NewTxOrderingPolicy(&ethconfig.Zk{SequencerTxOrdering: "lifo"})
+	require.ErrorContains(t, err, "unknown transaction ordering")
+}
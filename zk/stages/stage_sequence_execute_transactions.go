@@ -2,11 +2,13 @@ package stages
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/gateway-fm/cdk-erigon-lib/common"
 	"github.com/gateway-fm/cdk-erigon-lib/common/length"
 	"github.com/gateway-fm/cdk-erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
 
 	"bytes"
 	"io"
@@ -20,12 +22,18 @@ import (
 	"github.com/ledgerwatch/erigon/core/vm/evmtypes"
 	"github.com/ledgerwatch/erigon/rlp"
 	"github.com/ledgerwatch/erigon/zk/hermez_db"
+	"github.com/ledgerwatch/erigon/zk/pool_manager"
 	zktx "github.com/ledgerwatch/erigon/zk/tx"
+	"github.com/ledgerwatch/erigon/zk/txpool"
 	"errors"
 	"github.com/ledgerwatch/erigon/zk/constants"
 )
 
 func getNextPoolTransactions(cfg SequenceBlockCfg, executionAt, forkId uint64, alreadyYielded mapset.Set[[32]byte]) ([]types.Transaction, error) {
+	if cfg.poolManager != nil {
+		return getNextPoolManagerTransactions(cfg, executionAt, alreadyYielded), nil
+	}
+
 	var transactions []types.Transaction
 	var err error
 	var count int
@@ -40,7 +48,7 @@ LOOP:
 		}
 		if err := cfg.txPoolDb.View(context.Background(), func(poolTx kv.Tx) error {
 			slots := types2.TxsRlp{}
-			_, count, err = cfg.txPool.YieldBest(yieldSize, &slots, poolTx, executionAt, getGasLimit(forkId), alreadyYielded)
+			_, count, err = cfg.txPool.YieldBestOrdered(yieldSize, &slots, poolTx, executionAt, getGasLimit(forkId), alreadyYielded, txOrder(cfg.txOrdering))
 			if err != nil {
 				return err
 			}
@@ -65,6 +73,40 @@ LOOP:
 	return transactions, err
 }
 
+// getNextPoolManagerTransactions takes the transactions the pool manager has pending that haven't been tried in this
+// batch.  The pool manager being unreachable doesn't stop the sequencer, it only seals empty blocks until it's back.
+func getNextPoolManagerTransactions(cfg SequenceBlockCfg, executionAt uint64, alreadyYielded mapset.Set[[32]byte]) []types.Transaction {
+	// the transactions tried in this batch are pending until the batch is done and they're reported
+	pending, err := cfg.poolManager.PendingTransactions(context.Background(), yieldSize+uint64(alreadyYielded.Cardinality()))
+	if err != nil {
+		log.Warn("Failed to get the pending transactions of the pool manager", "err", err)
+	}
+
+	signer := types.MakeSigner(cfg.chainConfig, executionAt+1)
+	transactions := make([]types.Transaction, 0, len(pending))
+	for _, transaction := range pending {
+		hash := transaction.Hash()
+		if alreadyYielded.Contains(hash) {
+			continue
+		}
+		alreadyYielded.Add(hash)
+
+		sender, err := transaction.Sender(*signer)
+		if err != nil {
+			cfg.poolManager.Report(pool_manager.Report{Hash: hash, Status: pool_manager.StatusDiscarded, Error: err.Error()})
+			continue
+		}
+		transaction.SetSender(sender)
+		transactions = append(transactions, transaction)
+	}
+
+	if len(transactions) == 0 {
+		// the pool manager is across the network, don't ask it again straight away
+		time.Sleep(poolManagerIdleWait)
+	}
+	return transactions
+}
+
 func getNextL1BatchData(batchNumber uint64, forkId uint64, hermezDb *hermez_db.HermezDb) ([]zktx.DecodedBatchL2Data, common.Address, bool, error) {
 	// we expect that the batch we're going to load in next should be in the db already because of the l1 block sync
 	// stage, if it is not there we need to panic as we're in a bad state
@@ -133,6 +175,81 @@ func extractTransactionsFromSlot(slot types2.TxsRlp) ([]types.Transaction, error
 	return transactions, nil
 }
 
+// aclRefusedError is returned for a transaction of the pool the access control lists refuse, an operator banned or
+// whose sender can't be recovered, the lists may have changed since the pool accepted it
+type aclRefusedError struct {
+	reason txpool.DiscardReason
+}
+
+func (e *aclRefusedError) Error() string {
+	return fmt.Sprintf("refused by the acl: %s", e.reason)
+}
+
+// rejectedError is returned for a transaction of the pool manager that can't go in the block, the pool manager is
+// told why
+type rejectedError struct {
+	status pool_manager.Status
+	err    error
+}
+
+func (e *rejectedError) Error() string {
+	return fmt.Sprintf("%s: %v", e.status, e.err)
+}
+
+func (e *rejectedError) Unwrap() error {
+	return e.err
+}
+
+// nonceTooHighError is returned for a transaction of the pool manager ahead of its sender's nonce, it isn't reported
+// so the pool manager keeps it and hands it out again
+type nonceTooHighError struct {
+	err error
+}
+
+func (e *nonceTooHighError) Error() string {
+	return e.err.Error()
+}
+
+func (e *nonceTooHighError) Unwrap() error {
+	return e.err
+}
+
+// notExecutedError is returned for a transaction that fails the checks before it is executed, the executor skips
+// such a transaction and leaves the state as it was
+type notExecutedError struct {
+	err error
+}
+
+func (e *notExecutedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *notExecutedError) Unwrap() error {
+	return e.err
+}
+
+// checkPoolManagerTransaction checks a transaction of the pool manager, which sees nothing of the state, can be
+// applied before it uses up any counters
+func checkPoolManagerTransaction(ibs *state.IntraBlockState, sender common.Address, transaction types.Transaction) error {
+	if nonce := ibs.GetNonce(sender); transaction.GetNonce() < nonce {
+		return &rejectedError{
+			status: pool_manager.StatusInvalidNonce,
+			err:    fmt.Errorf("%w: address %v, tx: %d state: %d", core.ErrNonceTooLow, sender.Hex(), transaction.GetNonce(), nonce),
+		}
+	} else if transaction.GetNonce() > nonce {
+		return &nonceTooHighError{
+			err: fmt.Errorf("%w: address %v, tx: %d state: %d", core.ErrNonceTooHigh, sender.Hex(), transaction.GetNonce(), nonce),
+		}
+	}
+	if balance := ibs.GetBalance(sender); balance.Lt(transaction.Cost()) {
+		return &rejectedError{
+			status: pool_manager.StatusDiscarded,
+			err:    fmt.Errorf("%w: address %v have %v want %v", core.ErrInsufficientFunds, sender.Hex(), balance, transaction.Cost()),
+		}
+	}
+	return nil
+}
+
 func attemptAddTransaction(
 	cfg SequenceBlockCfg,
 	sdb *stageDb,
@@ -141,10 +258,36 @@ func attemptAddTransaction(
 	blockContext *evmtypes.BlockContext,
 	header *types.Header,
 	transaction types.Transaction,
+	index uint64,
 	effectiveGasPrice uint8,
 	l1Recovery bool,
+	fromPool bool,
 	forkId uint64,
 ) (*types.Receipt, bool, error) {
+	// only the transactions of the pool are checked against the lists, reported to the pool manager and preconfirmed,
+	// those of the l1 have to go in whatever the lists say
+	if fromPool {
+		if cfg.txPool.ACL().IsBanned(transaction.Hash()) {
+			return nil, false, &aclRefusedError{reason: txpool.TxBanned}
+		}
+		sender, ok := transaction.GetSender()
+		if !ok {
+			// the pool recovers the senders of its transactions, this is only a safety net
+			var err error
+			if sender, err = transaction.Sender(*types.MakeSigner(cfg.chainConfig, header.Number.Uint64())); err != nil {
+				return nil, false, &aclRefusedError{reason: txpool.InvalidSender}
+			}
+		}
+		if reason := cfg.txPool.ACL().Check(sender, transaction.GetTo()); reason != txpool.Success {
+			return nil, false, &aclRefusedError{reason: reason}
+		}
+		if cfg.poolManager != nil {
+			if err := checkPoolManagerTransaction(ibs, sender, transaction); err != nil {
+				return nil, false, err
+			}
+		}
+	}
+
 	txCounters := vm.NewTransactionCounter(transaction, sdb.smt.GetDepth(), cfg.zk.ShouldCountersBeUnlimited(l1Recovery))
 	overflow, err := batchCounters.AddNewTransactionCounters(txCounters)
 	if err != nil {
@@ -163,6 +306,7 @@ func attemptAddTransaction(
 
 	ibs.Prepare(transaction.Hash(), common.Hash{}, 0)
 	evm := vm.NewZkEVM(*blockContext, evmtypes.TxContext{}, ibs, cfg.chainConfig, *cfg.zkVmConfig)
+	snapshot := ibs.Snapshot()
 
 	receipt, execResult, err := core.ApplyTransaction_zkevm(
 		cfg.chainConfig,
@@ -178,7 +322,12 @@ func attemptAddTransaction(
 	)
 
 	if err != nil {
-		return nil, false, err
+		// the checks before execution may have charged the sender already
+		ibs.RevertToSnapshot(snapshot)
+		if fromPool && cfg.poolManager != nil {
+			return nil, false, &rejectedError{status: pool_manager.StatusDiscarded, err: err}
+		}
+		return nil, false, &notExecutedError{err: err}
 	}
 
 	if forkId <= uint64(constants.ForkID7Etrog) && errors.Is(execResult.Err, vm.ErrUnsupportedPrecompile) {
@@ -198,6 +347,18 @@ func attemptAddTransaction(
 
 	// now that we have executed we can check again for an overflow
 	overflow, err = batchCounters.CheckForOverflow()
+	if err != nil || overflow {
+		return receipt, overflow, err
+	}
+
+	// the transaction is in the block, the pool's are preconfirmed as they're first executed and a block executed
+	// again after an overflow keeps them at the same index.  The block is not written yet, an unwind or another
+	// sequencer taking over before it is can break the preconfirmation.
+	if fromPool {
+		if err = cfg.preconfirmations.Confirm(transaction.Hash(), header.Number.Uint64(), index, receipt.Status); err != nil {
+			return nil, false, err
+		}
+	}
 
-	return receipt, overflow, err
+	return receipt, false, nil
 }
@@ -3,6 +3,7 @@ package stages
 import (
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 
 	"github.com/gateway-fm/cdk-erigon-lib/common"
@@ -19,8 +20,12 @@ import (
 	"github.com/ledgerwatch/erigon/core/state"
 	"github.com/ledgerwatch/erigon/core/types/accounts"
 	"github.com/ledgerwatch/erigon/eth/stagedsync"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/erigon/turbo/shards"
+	"github.com/ledgerwatch/erigon/zk/datastream/server"
+	"github.com/ledgerwatch/erigon/zk/erigon_db"
 	"github.com/ledgerwatch/erigon/zk/hermez_db"
+	zktx "github.com/ledgerwatch/erigon/zk/tx"
 )
 
 func UnwindSequenceExecutionStage(u *stagedsync.UnwindState, s *stagedsync.StageState, tx kv.RwTx, ctx context.Context, cfg SequenceBlockCfg, initialCycle bool) (err error) {
@@ -41,6 +46,12 @@ func UnwindSequenceExecutionStage(u *stagedsync.UnwindState, s *stagedsync.Stage
 	if err = unwindExecutionStage(u, s, tx, ctx, cfg, initialCycle); err != nil {
 		return err
 	}
+	if err = unwindL2TxHashes(tx, u.UnwindPoint+1, s.BlockNumber); err != nil {
+		return err
+	}
+	if err = unwindSequencedBlocks(u, s, tx, cfg); err != nil {
+		return err
+	}
 	if err = u.Done(tx); err != nil {
 		return err
 	}
@@ -193,10 +204,190 @@ func unwindExecutionStage(u *stagedsync.UnwindState, s *stagedsync.StageState, t
 	if err = hermezDb.TruncateLatestUsedGers(fromBatch); err != nil {
 		return fmt.Errorf("truncate latest used gers error: %v", err)
 	}
+	if err = hermezDb.DeleteBlockL2GasPrices(u.UnwindPoint+1, s.BlockNumber); err != nil {
+		return fmt.Errorf("delete block l2 gas prices error: %v", err)
+	}
+	if err = hermezDb.DeleteBlockUnexecutedTransactions(u.UnwindPoint+1, s.BlockNumber); err != nil {
+		return fmt.Errorf("delete block unexecuted transactions error: %v", err)
+	}
+	// a forced batch is a single batch of its own, only the batches after the unwind point are gone
+	if err = hermezDb.TruncateBatchForcedBatchNumbers(fromBatch); err != nil {
+		return fmt.Errorf("truncate batch forced batch numbers error: %v", err)
+	}
+	if err = hermezDb.TruncateBatchSealReasons(fromBatch); err != nil {
+		return fmt.Errorf("truncate batch seal reasons error: %v", err)
+	}
+
+	return nil
+}
+
+// unwindL2TxHashes removes the l2 tx hashes of the transactions in the unwound blocks
+func unwindL2TxHashes(tx kv.RwTx, fromBlock, toBlock uint64) error {
+	transactions, err := erigon_db.NewErigonDb(tx).GetBodyTransactions(fromBlock, toBlock)
+	if err != nil {
+		return fmt.Errorf("get body transactions error: %v", err)
+	}
+	l2TxHashes := make([]common.Hash, 0, len(*transactions))
+	for _, transaction := range *transactions {
+		l2TxHash, err := zktx.ComputeL2TxHashForTransaction(transaction)
+		if err != nil {
+			return fmt.Errorf("compute l2 tx hash error: %v", err)
+		}
+		l2TxHashes = append(l2TxHashes, l2TxHash)
+	}
+	if err = hermez_db.NewHermezDb(tx).DeleteL2TxHashes(&l2TxHashes); err != nil {
+		return fmt.Errorf("delete l2 tx hashes error: %v", err)
+	}
+	return nil
+}
+
+// unwindSequencedBlocks removes the blocks after the unwind point and what the sequencer stored with them, so it
+// carries on sealing from the unwind point as if they were never sealed
+func unwindSequencedBlocks(u *stagedsync.UnwindState, s *stagedsync.StageState, tx kv.RwTx, cfg SequenceBlockCfg) error {
+	fromBlock, toBlock := u.UnwindPoint+1, s.BlockNumber
+	eriDb := erigon_db.NewErigonDb(tx)
+	hermezDb := hermez_db.NewHermezDb(tx)
+
+	unwindBatch, err := hermezDb.GetBatchNoByL2Block(u.UnwindPoint)
+	if err != nil {
+		return fmt.Errorf("get batch no by l2 block error: %v", err)
+	}
+	toBatch, err := hermezDb.GetBatchNoByL2Block(toBlock)
+	if err != nil {
+		return fmt.Errorf("get toBatch no by l2 block error: %v", err)
+	}
+
+	transactions, err := eriDb.GetBodyTransactions(fromBlock, toBlock)
+	if err != nil {
+		return fmt.Errorf("get body transactions error: %v", err)
+	}
+	transactionHashes := make([]common.Hash, 0, len(*transactions))
+	for _, transaction := range *transactions {
+		transactionHashes = append(transactionHashes, transaction.Hash())
+	}
+	if err = hermezDb.DeleteEffectiveGasPricePercentages(&transactionHashes); err != nil {
+		return fmt.Errorf("delete effective gas price percentages error: %v", err)
+	}
+
+	// the sequencer uses the l1 info tree updates in order, the first one a removed block used is the next to use
+	for blockNo := fromBlock; blockNo <= toBlock; blockNo++ {
+		index, err := hermezDb.GetBlockL1InfoTreeIndex(blockNo)
+		if err != nil {
+			return fmt.Errorf("get block l1 info tree index error: %v", err)
+		}
+		if index > 0 {
+			if err = stages.SaveStageProgress(tx, stages.HighestUsedL1InfoIndex, index-1); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	if err = eriDb.DeleteHeaders(u.UnwindPoint); err != nil {
+		return fmt.Errorf("delete headers error: %v", err)
+	}
+	if err = eriDb.DeleteBodies(u.UnwindPoint); err != nil {
+		return fmt.Errorf("delete bodies error: %v", err)
+	}
+	headHash, err := rawdb.ReadCanonicalHash(tx, u.UnwindPoint)
+	if err != nil {
+		return fmt.Errorf("read canonical hash of unwind point: %w", err)
+	}
+	if err = rawdb.WriteHeadHeaderHash(tx, headHash); err != nil {
+		return err
+	}
+	if err = hermezDb.DeleteBlockBatches(fromBlock, toBlock); err != nil {
+		return fmt.Errorf("delete block batches error: %v", err)
+	}
+	if unwindBatch < toBatch {
+		if err = hermezDb.DeleteForkIds(unwindBatch+1, toBatch); err != nil {
+			return fmt.Errorf("delete fork ids error: %v", err)
+		}
+	}
+	if err = hermezDb.DeleteBlockGlobalExitRoots(fromBlock, toBlock); err != nil {
+		return fmt.Errorf("delete block global exit roots error: %v", err)
+	}
+	if err = hermezDb.DeleteBlockL1BlockHashes(fromBlock, toBlock); err != nil {
+		return fmt.Errorf("delete block l1 block hashes error: %v", err)
+	}
+	if err = hermezDb.DeleteBlockL1InfoTreeIndexes(fromBlock, toBlock); err != nil {
+		return fmt.Errorf("delete block l1 info tree indexes error: %v", err)
+	}
+	if err = hermezDb.DeleteReusedL1InfoTreeIndexes(fromBlock, toBlock); err != nil {
+		return fmt.Errorf("delete reused l1 info tree indexes error: %v", err)
+	}
+
+	if err = stages.SaveStageProgress(tx, stages.Headers, u.UnwindPoint); err != nil {
+		return err
+	}
+	if err = stages.SaveStageProgress(tx, stages.HighestSeenBatchNumber, unwindBatch); err != nil {
+		return err
+	}
+	verifiedBatch, err := stages.GetStageProgress(tx, stages.SequenceExecutorVerify)
+	if err != nil {
+		return err
+	}
+	if verifiedBatch > unwindBatch {
+		if err = stages.SaveStageProgress(tx, stages.SequenceExecutorVerify, unwindBatch); err != nil {
+			return err
+		}
+	}
+
+	// the rpc nodes unwind when the stream hands them a block they have already
+	if cfg.stream != nil {
+		srv := server.NewDataStreamServer(cfg.stream, cfg.chainConfig.ChainID.Uint64(), server.StandardOperationMode)
+		highestStreamed, err := srv.GetHighestBlockNumber()
+		if err != nil {
+			return err
+		}
+		if highestStreamed > u.UnwindPoint {
+			if err = srv.UnwindToBlock(fromBlock); err != nil {
+				return fmt.Errorf("unwind data stream error: %v", err)
+			}
+		}
+	}
+	streamed, err := stages.GetStageProgress(tx, stages.DataStream)
+	if err != nil {
+		return err
+	}
+	if streamed > u.UnwindPoint {
+		if err = stages.SaveStageProgress(tx, stages.DataStream, u.UnwindPoint); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
+// unwindTargetOfBatch returns the last block of the batch an operator asked to unwind to.  Batches the L1 has are
+// kept, as far as the node knows of them, and a recovering sequencer follows the L1 so it doesn't unwind at all.
+func unwindTargetOfBatch(cfg SequenceBlockCfg, hermezDb *hermez_db.HermezDb, lastBatch, batch uint64) (uint64, error) {
+	if cfg.zk.L1SyncStartBlock > 0 {
+		return 0, errors.New("a sequencer recovering from the L1 can't unwind")
+	}
+	if batch < injectedBatchNumber {
+		return 0, fmt.Errorf("can't unwind past the injected batch %d", injectedBatchNumber)
+	}
+	if batch >= lastBatch {
+		return 0, fmt.Errorf("batch %d is not before the latest batch %d", batch, lastBatch)
+	}
+	sequenced, err := hermezDb.GetLatestSequence()
+	if err != nil {
+		return 0, err
+	}
+	if sequenced != nil && batch < sequenced.BatchNo {
+		return 0, fmt.Errorf("batch %d is before batch %d which is sequenced on the L1", batch, sequenced.BatchNo)
+	}
+	block, err := hermezDb.GetHighestBlockInBatch(batch)
+	if err != nil {
+		return 0, err
+	}
+	if block == 0 {
+		return 0, fmt.Errorf("batch %d has no blocks", batch)
+	}
+	return block, nil
+}
+
 func recoverCodeHashPlain(acc *accounts.Account, db kv.Tx, key []byte) {
 	var address common.Address
 	copy(address[:], key)
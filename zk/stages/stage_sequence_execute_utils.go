@@ -30,7 +30,13 @@ import (
 	smtNs "github.com/ledgerwatch/erigon/smt/pkg/smt"
 	"github.com/ledgerwatch/erigon/turbo/services"
 	"github.com/ledgerwatch/erigon/turbo/shards"
+	"github.com/ledgerwatch/erigon/zk/batch_sealing"
+	"github.com/ledgerwatch/erigon/zk/gas_price"
+	"github.com/ledgerwatch/erigon/zk/ha"
 	"github.com/ledgerwatch/erigon/zk/hermez_db"
+	"github.com/ledgerwatch/erigon/zk/pool_manager"
+	"github.com/ledgerwatch/erigon/zk/preconfirmation"
+	"github.com/ledgerwatch/erigon/zk/sequencer_control"
 	zktx "github.com/ledgerwatch/erigon/zk/tx"
 	"github.com/ledgerwatch/erigon/zk/txpool"
 	zktypes "github.com/ledgerwatch/erigon/zk/types"
@@ -45,11 +51,16 @@ const (
 	transactionGasLimit = 30000000
 
 	yieldSize = 100 // arbitrary number defining how many transactions to yield from the pool at once
+
+	poolManagerIdleWait = 50 * time.Millisecond // how long to wait before asking the pool manager again after it had nothing new
 )
 
 var (
 	noop            = state.NewNoopWriter()
 	blockDifficulty = new(big.Int).SetUint64(0)
+
+	// errNotLeader stops a highly available sequencer that no longer holds the lease from writing anything
+	errNotLeader = errors.New("the sequencer lease is not held")
 )
 
 type HasChangeSetWriter interface {
@@ -79,8 +90,16 @@ type SequenceBlockCfg struct {
 	stream    *datastreamer.StreamServer
 	zk        *ethconfig.Zk
 
-	txPool   *txpool.TxPool
-	txPoolDb kv.RwDB
+	txPool      *txpool.TxPool
+	txPoolDb    kv.RwDB
+	txOrdering  TxOrderingPolicy
+	gasPrice    *gas_price.Oracle
+	poolManager *pool_manager.Client // takes the place of the txpool when set
+	leader      *ha.Elector          // set on a highly available sequencer, blocks are only sealed while it holds the lease
+	sealer      *batch_sealing.Sealer
+	control     *sequencer_control.Control // pauses the sequencer, its seal times and unwinds
+
+	preconfirmations *preconfirmation.Sequencer // signs a preconfirmation of each transaction as it is executed, nil gives none
 }
 
 func StageSequenceBlocksCfg(
@@ -106,7 +125,23 @@ func StageSequenceBlocksCfg(
 
 	txPool *txpool.TxPool,
 	txPoolDb kv.RwDB,
+	gasPrice *gas_price.Oracle,
+	poolManager *pool_manager.Client,
+	leader *ha.Elector,
+	sealer *batch_sealing.Sealer,
+	control *sequencer_control.Control,
+	preconfirmations *preconfirmation.Sequencer,
 ) SequenceBlockCfg {
+	txOrdering, err := NewTxOrderingPolicy(zk)
+	if err != nil {
+		// the flags are checked on start up, only a config built in code gets here
+		panic(err)
+	}
+	// a sequencer without an admin api runs on the seal times of its flags
+	if control == nil {
+		control = sequencer_control.NewControl(sequencer_control.TimersFromZk(zk))
+	}
+
 	return SequenceBlockCfg{
 		db:            db,
 		prune:         pm,
@@ -128,9 +163,53 @@ func StageSequenceBlocksCfg(
 		zk:            zk,
 		txPool:        txPool,
 		txPoolDb:      txPoolDb,
+		txOrdering:    txOrdering,
+		gasPrice:      gasPrice,
+		poolManager:   poolManager,
+		leader:        leader,
+		sealer:        sealer,
+		control:       control,
+
+		preconfirmations: preconfirmations,
 	}
 }
 
+// checkLeader fences a highly available sequencer out of the chain once another one may have taken over
+func (cfg *SequenceBlockCfg) checkLeader() error {
+	if cfg.leader != nil && !cfg.leader.IsLeader() {
+		return errNotLeader
+	}
+	return nil
+}
+
+// reportIncluded tells the pool manager of the transactions the stage included.  Only a tx the stage committed itself
+// is known to stick, the reports of an external one are held until the next run sees what was committed.
+func reportIncluded(cfg SequenceBlockCfg, freshTx bool, included []pool_manager.Report) {
+	if cfg.poolManager == nil {
+		return
+	}
+	if freshTx {
+		cfg.poolManager.Report(included...)
+	} else {
+		cfg.poolManager.Hold(included...)
+	}
+}
+
+// releaseIncluded sends the held reports of the transactions the last run included, those whose block didn't make it
+// are left for the pool manager to hand out again
+func releaseIncluded(cfg SequenceBlockCfg, tx kv.Tx) error {
+	if cfg.poolManager == nil {
+		return nil
+	}
+	return cfg.poolManager.ReleaseHeld(func(report pool_manager.Report) (bool, error) {
+		block, err := rawdb.ReadBlockByNumber(tx, report.BlockNumber)
+		if err != nil || block == nil {
+			return false, err
+		}
+		return block.Transaction(report.Hash) != nil, nil
+	})
+}
+
 type stageDb struct {
 	tx          kv.RwTx
 	hermezDb    *hermez_db.HermezDb
@@ -265,16 +344,20 @@ func calculateNextL1TreeUpdateToUse(lastInfoIndex uint64, hermezDb *hermez_db.He
 	}
 
 	// check that we have reached finality on the l1 info tree event before using it
-	// todo: [zkevm] think of a better way to handle finality
-	now := time.Now()
-	target := now.Add(-(12 * time.Minute))
-	if l1Info != nil && l1Info.Timestamp < uint64(target.Unix()) {
+	if l1Info != nil && hasReachedL1Finality(l1Info.Timestamp) {
 		nextL1Index = l1Info.Index
 	}
 
 	return nextL1Index, l1Info, nil
 }
 
+// hasReachedL1Finality reports whether an L1 event with the given timestamp can be considered final
+// todo: [zkevm] think of a better way to handle finality
+func hasReachedL1Finality(timestamp uint64) bool {
+	target := time.Now().Add(-(12 * time.Minute))
+	return timestamp < uint64(target.Unix())
+}
+
 func updateSequencerProgress(tx kv.RwTx, newHeight uint64, newBatch uint64, l1InfoIndex uint64) error {
 	// now update stages that will be used later on in stageloop.go and other stages. As we're the sequencer
 	// we won't have headers stage for example as we're already writing them here
@@ -284,6 +367,10 @@ func updateSequencerProgress(tx kv.RwTx, newHeight uint64, newBatch uint64, l1In
 	if err := stages.SaveStageProgress(tx, stages.Headers, newHeight); err != nil {
 		return err
 	}
+	// the block is hashed as it is sealed, the stage only unwinds the tree
+	if err := stages.SaveStageProgress(tx, stages.IntermediateHashes, newHeight); err != nil {
+		return err
+	}
 	if err := stages.SaveStageProgress(tx, stages.HighestSeenBatchNumber, newBatch); err != nil {
 		return err
 	}
@@ -317,6 +404,13 @@ func doFinishBlockAndUpdateState(
 		return err
 	}
 
+	// the price goes out with the block in the data stream so every rpc node suggests the same
+	cfg.gasPrice.Poll(ctx)
+	pending, _, _ := cfg.txPool.CountContent()
+	if err := sdb.hermezDb.WriteBlockL2GasPrice(thisBlockNumber, cfg.gasPrice.Suggest(uint64(pending))); err != nil {
+		return err
+	}
+
 	if err := updateSequencerProgress(sdb.tx, thisBlockNumber, thisBatch, l1InfoIndex); err != nil {
 		return err
 	}
@@ -331,3 +425,17 @@ func doFinishBlockAndUpdateState(
 
 	return nil
 }
+
+// batchUsage works out what the open batch used so far for the seal policies
+func batchUsage(batchCounters *vm.BatchCounterCollector) (batch_sealing.Usage, error) {
+	counters, err := batchCounters.CombineCollectors()
+	if err != nil {
+		return batch_sealing.Usage{}, err
+	}
+	_, percentage := counters.HighestUsagePercentage()
+	size, err := batchCounters.L2DataSize()
+	if err != nil {
+		return batch_sealing.Usage{}, err
+	}
+	return batch_sealing.Usage{CounterPercentage: percentage, L2DataSize: uint64(size)}, nil
+}
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/gateway-fm/cdk-erigon-lib/common"
 	"github.com/gateway-fm/cdk-erigon-lib/kv"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
 	"github.com/ledgerwatch/erigon/eth/stagedsync"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/zk/datacommittee"
 	"github.com/ledgerwatch/erigon/zk/hermez_db"
 	"github.com/ledgerwatch/erigon/zk/l1_data"
 	"github.com/ledgerwatch/erigon/zk/syncer"
@@ -21,13 +23,21 @@ type SequencerL1BlockSyncCfg struct {
 	db     kv.RwDB
 	zkCfg  *ethconfig.Zk
 	syncer *syncer.L1Syncer
+
+	// dataAvailability resolves the batches of a validium, nil for a rollup
+	dataAvailability l1_data.DataAvailability
 }
 
 func StageSequencerL1BlockSyncCfg(db kv.RwDB, zkCfg *ethconfig.Zk, syncer *syncer.L1Syncer) SequencerL1BlockSyncCfg {
+	var dataAvailability l1_data.DataAvailability
+	if zkCfg.AddressDataCommittee != (common.Address{}) {
+		dataAvailability = datacommittee.NewClient(syncer, zkCfg.AddressDataCommittee)
+	}
 	return SequencerL1BlockSyncCfg{
-		db:     db,
-		zkCfg:  zkCfg,
-		syncer: syncer,
+		db:               db,
+		zkCfg:            zkCfg,
+		syncer:           syncer,
+		dataAvailability: dataAvailability,
 	}
 }
 
@@ -151,7 +161,7 @@ LOOP:
 				lastBatchSequenced := l.Topics[1].Big().Uint64()
 				latestBatch = lastBatchSequenced
 
-				batches, coinbase, err := l1_data.DecodeL1BatchData(transaction.GetData())
+				batches, coinbase, err := l1_data.DecodeL1BatchData(ctx, transaction.GetData(), l.BlockNumber, cfg.dataAvailability, forcedBatchData{hermezDb})
 				if err != nil {
 					return err
 				}
@@ -218,6 +228,23 @@ LOOP:
 	return nil
 }
 
+// forcedBatchData looks up the data of the forced batches of a validium sequence among the forced batches the L1
+// sequencer sync stage found on the L1
+type forcedBatchData struct {
+	hermezDb *hermez_db.HermezDb
+}
+
+func (f forcedBatchData) GetForcedBatchData(hash common.Hash) ([]byte, error) {
+	fb, err := f.hermezDb.GetL1ForcedBatchByTransactionsHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	if fb == nil {
+		return nil, fmt.Errorf("no forced batch with the transactions hash %s has been found on the L1", hash)
+	}
+	return fb.Transactions, nil
+}
+
 func debugLogProgress(batch []byte, cfg SequencerL1BlockSyncCfg, totalBlocks int, logPrefix string, b uint64) {
 	decoded, err := zktx.DecodeBatchL2Blocks(batch, cfg.zkCfg.SequencerInitialForkId)
 	if err != nil {
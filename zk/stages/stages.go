@@ -124,6 +124,15 @@ func SequencerZkStages(
 				return stages.PruneHashStateStage(p, tx, hashState, ctx)
 			},
 		},
+		{
+			// the sequencer hashes every block as it seals it so there is only the tree to unwind
+			ID:          stages2.IntermediateHashes,
+			Description: "Unwind the intermediate hashes of sealed blocks",
+			Disabled:    false,
+			Unwind: func(firstCycle bool, u *stages.UnwindState, s *stages.StageState, tx kv.RwTx) error {
+				return UnwindZkIntermediateHashesStage(u, s, tx, zkInterHashesCfg, ctx)
+			},
+		},
 		{
 			ID:                  stages2.CallTraces,
 			Description:         "Generate call traces index",
@@ -222,6 +231,7 @@ func DefaultZkStages(
 	exec stages.ExecuteBlockCfg,
 	hashState stages.HashStateCfg,
 	zkInterHashesCfg ZkInterHashesCfg,
+	followerExecutorVerifyCfg FollowerExecutorVerifyCfg,
 	history stages.HistoryCfg,
 	logIndex stages.LogIndexCfg,
 	callTraces stages.CallTracesCfg,
@@ -440,6 +450,20 @@ func DefaultZkStages(
 				return nil
 			},
 		},
+		{
+			ID:          stages2.FollowerExecutorVerify,
+			Description: "Verifier, check the followed batches with the executors",
+			Disabled:    followerExecutorVerifyCfg.verifier == nil,
+			Forward: func(firstCycle bool, badBlockUnwind bool, s *stages.StageState, u stages.Unwinder, tx kv.RwTx, quiet bool) error {
+				return SpawnFollowerExecutorVerifyStage(s, u, tx, ctx, followerExecutorVerifyCfg, firstCycle, quiet)
+			},
+			Unwind: func(firstCycle bool, u *stages.UnwindState, s *stages.StageState, tx kv.RwTx) error {
+				return UnwindFollowerExecutorVerifyStage(u, s, tx, ctx, followerExecutorVerifyCfg, firstCycle)
+			},
+			Prune: func(firstCycle bool, p *stages.PruneState, tx kv.RwTx) error {
+				return PruneFollowerExecutorVerifyStage(p, tx, followerExecutorVerifyCfg, ctx, firstCycle)
+			},
+		},
 		{
 			ID:          stages2.Finish,
 			Description: "Final: update current block for the RPC API",
@@ -468,23 +492,25 @@ var AllStagesZk = []stages2.SyncStage{
 	stages2.LogIndex,
 	stages2.CallTraces,
 	stages2.TxLookup,
+	stages2.FollowerExecutorVerify,
 	stages2.Finish,
 }
 
 var ZkSequencerUnwindOrder = stages.UnwindOrder{
 	stages2.IntermediateHashes, // need to unwind SMT before we remove history
+	stages2.Finish,
+	stages2.TxLookup,
+	stages2.LogIndex,
 	stages2.HashState,
-	stages2.Execution,
-	stages2.CallTraces,
-	stages2.AccountHistoryIndex,
 	stages2.StorageHistoryIndex,
-	stages2.LogIndex,
-	stages2.TxLookup,
-	stages2.Finish,
+	stages2.AccountHistoryIndex,
+	stages2.CallTraces,
+	stages2.Execution, // removes the changesets, receipts and blocks the stages above unwind from
 }
 
 var ZkUnwindOrder = stages.UnwindOrder{
 	stages2.Finish,
+	stages2.FollowerExecutorVerify,
 	stages2.TxLookup,
 	stages2.LogIndex,
 	stages2.HashState,
@@ -0,0 +1,233 @@
+package stateless_executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	libcommon "github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/chain"
+	"github.com/ledgerwatch/erigon/consensus"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/core/vm/evmtypes"
+	"github.com/ledgerwatch/erigon/smt/pkg/blockinfo"
+	"github.com/ledgerwatch/erigon/smt/pkg/smt"
+	"github.com/ledgerwatch/erigon/turbo/trie"
+	"github.com/ledgerwatch/erigon/zk/constants"
+	dstypes "github.com/ledgerwatch/erigon/zk/datastream/types"
+	"github.com/ledgerwatch/erigon/zk/legacy_executor_verifier"
+	zktx "github.com/ledgerwatch/erigon/zk/tx"
+	zkUtils "github.com/ledgerwatch/erigon/zk/utils"
+)
+
+const logPrefix = "stateless_executor"
+
+// Executor re-executes a batch using only the witness and data stream from the executor payload.  It satisfies
+// legacy_executor_verifier.ILegacyExecutor so it can be used in place of a remote executor.
+type Executor struct {
+	chainConfig *chain.Config
+	engine      consensus.Engine
+}
+
+var _ legacy_executor_verifier.ILegacyExecutor = (*Executor)(nil)
+
+func NewExecutor(chainConfig *chain.Config, engine consensus.Engine) *Executor {
+	return &Executor{
+		chainConfig: chainConfig,
+		engine:      engine,
+	}
+}
+
+// CheckOnline always reports true as the executor runs in process
+func (e *Executor) CheckOnline() bool {
+	return true
+}
+
+func (e *Executor) Verify(p *legacy_executor_verifier.Payload, request *legacy_executor_verifier.VerifierRequest, oldStateRoot libcommon.Hash) (bool, error) {
+	log.Info(fmt.Sprintf("[%s] Verifying batch", logPrefix), "batch", request.BatchNumber, "ourRoot", request.StateRoot, "oldRoot", oldStateRoot)
+
+	newRoot, err := e.ExecuteBatch(p, oldStateRoot)
+	if err != nil {
+		return false, err
+	}
+
+	if newRoot != request.StateRoot {
+		return false, fmt.Errorf("erigon state root mismatch: expected %s, got %s", request.StateRoot, newRoot)
+	}
+
+	log.Info(fmt.Sprintf("[%s] Batch verified", logPrefix), "batch", request.BatchNumber, "root", newRoot)
+
+	return true, nil
+}
+
+// ExecuteBatch runs the blocks in the payload data stream against the state held in the payload witness and
+// returns the resulting state root
+func (e *Executor) ExecuteBatch(p *legacy_executor_verifier.Payload, oldStateRoot libcommon.Hash) (libcommon.Hash, error) {
+	witness, err := trie.NewWitnessFromReader(bytes.NewReader(p.Witness), false)
+	if err != nil {
+		return libcommon.Hash{}, fmt.Errorf("failed to decode witness: %w", err)
+	}
+
+	smtTrie, err := smt.BuildSMTfromWitness(witness)
+	if err != nil {
+		return libcommon.Hash{}, fmt.Errorf("failed to build smt from witness: %w", err)
+	}
+
+	witnessRoot := libcommon.BigToHash(smtTrie.LastRoot())
+	if witnessRoot != oldStateRoot {
+		return libcommon.Hash{}, fmt.Errorf("witness root mismatch: expected %s, got %s", oldStateRoot, witnessRoot)
+	}
+
+	ws, err := newWitnessState(witness)
+	if err != nil {
+		return libcommon.Hash{}, err
+	}
+
+	batch, err := DecodeStreamBytes(p.DataStream)
+	if err != nil {
+		return libcommon.Hash{}, err
+	}
+
+	// before etrog every transaction changes the root stored in the system contract, which needs the
+	// intermediate roots that only the syncer keeps, so only etrog onwards can be re-executed statelessly
+	if batch.ForkId < uint64(constants.ForkID7Etrog) {
+		return libcommon.Hash{}, fmt.Errorf("stateless execution is not supported for fork id %d", batch.ForkId)
+	}
+
+	sequencer := libcommon.HexToAddress(p.Coinbase)
+	prevRoot := oldStateRoot
+	for i, block := range batch.Blocks {
+		// the ger updates of the stream come before its first block, as the syncer writes them with that block
+		var gerUpdates []dstypes.GerUpdate
+		if i == 0 {
+			gerUpdates = append(gerUpdates, batch.GerUpdates...)
+		}
+		if prevRoot, err = e.executeBlock(smtTrie, ws, block, gerUpdates, sequencer, prevRoot); err != nil {
+			return libcommon.Hash{}, fmt.Errorf("block %d: %w", block.L2BlockNumber, err)
+		}
+
+		if block.StateRoot != (libcommon.Hash{}) && block.StateRoot != prevRoot {
+			log.Warn(fmt.Sprintf("[%s] Block root mismatch", logPrefix), "block", block.L2BlockNumber, "stream", block.StateRoot, "executed", prevRoot)
+		}
+	}
+
+	return prevRoot, nil
+}
+
+func (e *Executor) executeBlock(
+	smtTrie *smt.SMT,
+	ws *witnessState,
+	block *dstypes.FullL2Block,
+	gerUpdates []dstypes.GerUpdate,
+	sequencer libcommon.Address,
+	prevRoot libcommon.Hash,
+) (libcommon.Hash, error) {
+	blockNumber := block.L2BlockNumber
+	gasLimit := zkUtils.GetBlockGasLimitForFork(block.ForkId)
+
+	coinbase := block.Coinbase
+	if coinbase == (libcommon.Address{}) {
+		coinbase = sequencer
+	}
+
+	header := &types.Header{
+		Number:     new(big.Int).SetUint64(blockNumber),
+		Time:       uint64(block.Timestamp),
+		Coinbase:   coinbase,
+		GasLimit:   gasLimit,
+		Difficulty: new(big.Int),
+	}
+
+	ibs := state.New(ws)
+
+	ger := block.GlobalExitRoot
+	l1BlockHash := block.L1BlockHash
+	ibs.SyncerPreExecuteStateSet(e.chainConfig, blockNumber, header.Time, &prevRoot, &ger, &l1BlockHash, &gerUpdates, false)
+
+	// block hashes are served from the system contract storage by the zkevm interpreter
+	getHashFn := func(n uint64) libcommon.Hash { return libcommon.Hash{} }
+	blockContext := core.NewEVMBlockContext(header, getHashFn, e.engine, &coinbase, nil)
+
+	gasPool := new(core.GasPool).AddGas(gasLimit)
+	signer := types.MakeSigner(e.chainConfig, blockNumber)
+
+	receipts := make(types.Receipts, 0, len(block.L2Txs))
+	transactions := make(types.Transactions, 0, len(block.L2Txs))
+	txInfos := make([]blockinfo.ExecutedTxInfo, 0, len(block.L2Txs))
+	for i, l2Tx := range block.L2Txs {
+		// the transactions of a forced batch the executor skipped are in the stream but not the block
+		if !l2Tx.IsValid {
+			continue
+		}
+		transaction, effectiveGas, err := zktx.DecodeTx(l2Tx.Encoded, l2Tx.EffectiveGasPricePercentage, block.ForkId)
+		if err != nil {
+			return libcommon.Hash{}, fmt.Errorf("failed to decode tx %d: %w", i, err)
+		}
+
+		ibs.Prepare(transaction.Hash(), libcommon.Hash{}, len(transactions))
+		evm := vm.NewZkEVM(blockContext, evmtypes.TxContext{}, ibs, e.chainConfig, vm.NewZkConfig(vm.Config{}, nil))
+
+		receipt, _, err := core.ApplyTransaction_zkevm(
+			e.chainConfig,
+			e.engine,
+			evm,
+			gasPool,
+			ibs,
+			state.NewNoopWriter(),
+			header,
+			transaction,
+			&header.GasUsed,
+			effectiveGas,
+		)
+		if err != nil {
+			return libcommon.Hash{}, fmt.Errorf("failed to apply tx %s: %w", transaction.Hash(), err)
+		}
+
+		sender, err := transaction.Sender(*signer)
+		if err != nil {
+			return libcommon.Hash{}, err
+		}
+
+		receipts = append(receipts, receipt)
+		transactions = append(transactions, transaction)
+		txInfos = append(txInfos, blockinfo.ExecutedTxInfo{
+			Tx:                transaction,
+			EffectiveGasPrice: effectiveGas,
+			Receipt:           receipt,
+			Signer:            &sender,
+		})
+	}
+
+	blockInfoRoot, err := blockinfo.BuildBlockInfoTree(
+		&header.Coinbase,
+		blockNumber,
+		header.Time,
+		header.GasLimit,
+		header.GasUsed,
+		ger,
+		l1BlockHash,
+		prevRoot,
+		&txInfos,
+	)
+	if err != nil {
+		return libcommon.Hash{}, err
+	}
+	ibs.PostExecuteStateSet(e.chainConfig, blockNumber, blockInfoRoot)
+
+	collector := newChangeCollector(ws)
+	if _, _, _, err = core.FinalizeBlockExecution(e.engine, ws, header, transactions, nil, collector, e.chainConfig, ibs, receipts, nil, nil, false, nil); err != nil {
+		return libcommon.Hash{}, err
+	}
+
+	if _, _, err = smtTrie.SetStorage(context.Background(), logPrefix, collector.accChanges, collector.codeChanges, collector.storageChanges); err != nil {
+		return libcommon.Hash{}, err
+	}
+
+	return libcommon.BigToHash(smtTrie.LastRoot()), nil
+}
@@ -0,0 +1,217 @@
+package stateless_executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	libcommon "github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/holiman/uint256"
+	"github.com/iden3/go-iden3-crypto/keccak256"
+
+	"github.com/ledgerwatch/erigon/chain"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/consensus/ethash"
+	"github.com/ledgerwatch/erigon/consensus/serenity"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/smt/pkg/blockinfo"
+	"github.com/ledgerwatch/erigon/smt/pkg/db"
+	"github.com/ledgerwatch/erigon/smt/pkg/smt"
+	"github.com/ledgerwatch/erigon/turbo/trie"
+	"github.com/ledgerwatch/erigon/zk/datastream/proto/github.com/0xPolygonHermez/zkevm-node/state/datastream"
+	"github.com/ledgerwatch/erigon/zk/datastream/types"
+	"github.com/ledgerwatch/erigon/zk/legacy_executor_verifier"
+	zkUtils "github.com/ledgerwatch/erigon/zk/utils"
+)
+
+type streamEntry interface {
+	Marshal() ([]byte, error)
+	Type() types.EntryType
+}
+
+func encodeEntries(t *testing.T, entries ...streamEntry) []byte {
+	var result []byte
+	for _, entry := range entries {
+		data, err := entry.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf := []byte{2}
+		buf = binary.BigEndian.AppendUint32(buf, types.FileEntryMinSize+uint32(len(data)))
+		buf = binary.BigEndian.AppendUint32(buf, uint32(entry.Type()))
+		buf = binary.BigEndian.AppendUint64(buf, 0)
+		result = append(result, append(buf, data...)...)
+	}
+	return result
+}
+
+func emptyBlockStream(t *testing.T, batchNo, blockNo, timestamp uint64) []byte {
+	return encodeEntries(t,
+		&types.BookmarkProto{BookMark: &datastream.BookMark{Type: datastream.BookmarkType_BOOKMARK_TYPE_BATCH, Value: batchNo}},
+		&types.BatchStartProto{BatchStart: &datastream.BatchStart{Number: batchNo, ForkId: 8, ChainId: 1}},
+		&types.BookmarkProto{BookMark: &datastream.BookMark{Type: datastream.BookmarkType_BOOKMARK_TYPE_L2_BLOCK, Value: blockNo}},
+		&types.L2BlockProto{L2Block: &datastream.L2Block{Number: blockNo, BatchNumber: batchNo, Timestamp: timestamp}},
+	)
+}
+
+func testChainConfig() *chain.Config {
+	return &chain.Config{
+		ChainID:                 big.NewInt(1),
+		ForkID4Block:            big.NewInt(0),
+		ForkID5DragonfruitBlock: big.NewInt(0),
+		ForkID6IncaBerryBlock:   big.NewInt(0),
+		ForkID7EtrogBlock:       big.NewInt(0),
+		ForkID88ElderberryBlock: big.NewInt(0),
+	}
+}
+
+func witnessBytes(t *testing.T, smtTrie *smt.SMT) []byte {
+	witness, err := smt.BuildWitness(smtTrie, &trie.AlwaysTrueRetainDecider{}, context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err = witness.WriteInto(&buf, false); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeStreamBytes(t *testing.T) {
+	data := encodeEntries(t,
+		&types.BatchStartProto{BatchStart: &datastream.BatchStart{Number: 3, ForkId: 8, ChainId: 1}},
+		&types.L2BlockProto{L2Block: &datastream.L2Block{Number: 10, BatchNumber: 3, Timestamp: 100}},
+		&types.TxProto{Transaction: &datastream.Transaction{L2BlockNumber: 10, Encoded: []byte{0x01}, EffectiveGasPricePercentage: 255}},
+		&types.L2BlockProto{L2Block: &datastream.L2Block{Number: 11, BatchNumber: 3, Timestamp: 101}},
+	)
+
+	batch, err := DecodeStreamBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if batch.BatchNumber != 3 || batch.ForkId != 8 || batch.ChainId != 1 {
+		t.Fatalf("unexpected batch start: %+v", batch)
+	}
+	if len(batch.Blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %d", len(batch.Blocks))
+	}
+	if len(batch.Blocks[0].L2Txs) != 1 || len(batch.Blocks[1].L2Txs) != 0 {
+		t.Fatalf("transactions assigned to the wrong blocks")
+	}
+	if batch.Blocks[0].L2Txs[0].EffectiveGasPricePercentage != 255 {
+		t.Fatalf("unexpected effective gas price percentage")
+	}
+	if batch.Blocks[1].ForkId != 8 {
+		t.Fatalf("expected fork id to be carried into the blocks")
+	}
+
+	if _, err = DecodeStreamBytes(data[:len(data)-1]); err == nil {
+		t.Fatal("expected an error for a truncated stream")
+	}
+	if _, err = DecodeStreamBytes(nil); err != ErrNoBlocksInStream {
+		t.Fatalf("expected ErrNoBlocksInStream, got %v", err)
+	}
+}
+
+func TestExecuteEmptyBlock(t *testing.T) {
+	const (
+		batchNo   = 2
+		blockNo   = 5
+		timestamp = 1000
+	)
+
+	account := libcommon.HexToAddress("0x1000000000000000000000000000000000000001")
+	preState := smt.NewSMT(db.NewMemDb())
+	if _, err := preState.SetAccountState(account.String(), big.NewInt(1000), big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+	oldRoot := libcommon.BigToHash(preState.LastRoot())
+
+	payload := &legacy_executor_verifier.Payload{
+		Witness:    witnessBytes(t, preState),
+		DataStream: emptyBlockStream(t, batchNo, blockNo, timestamp),
+	}
+
+	// the only changes for an empty block are the system contract writes
+	blockInfoRoot, err := blockinfo.BuildBlockInfoTree(&libcommon.Address{}, blockNo, timestamp, zkUtils.GetBlockGasLimitForFork(8), 0, libcommon.Hash{}, libcommon.Hash{}, oldRoot, &[]blockinfo.ExecutedTxInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prevBlockKey := keccak256.Hash(common.LeftPadBytes(uint256.NewInt(blockNo-1).Bytes(), 32), common.LeftPadBytes(state.STATE_ROOT_STORAGE_POS.Bytes(), 32))
+	storage := map[string]string{
+		state.LAST_BLOCK_STORAGE_POS.String():        big.NewInt(blockNo).String(),
+		state.TIMESTAMP_STORAGE_POS.String():         big.NewInt(timestamp).String(),
+		libcommon.BytesToHash(prevBlockKey).String(): oldRoot.Big().String(),
+		state.BLOCK_INFO_ROOT_STORAGE_POS.String():   blockInfoRoot.Big().String(),
+	}
+	if _, err = preState.SetContractStorage(state.ADDRESS_SCALABLE_L2.String(), storage, nil); err != nil {
+		t.Fatal(err)
+	}
+	expectedRoot := libcommon.BigToHash(preState.LastRoot())
+
+	executor := NewExecutor(testChainConfig(), serenity.New(ethash.NewFaker()))
+
+	newRoot, err := executor.ExecuteBatch(payload, oldRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newRoot != expectedRoot {
+		t.Fatalf("unexpected root: expected %s, got %s", expectedRoot, newRoot)
+	}
+
+	ok, err := executor.Verify(payload, &legacy_executor_verifier.VerifierRequest{BatchNumber: batchNo, StateRoot: expectedRoot}, oldRoot)
+	if err != nil || !ok {
+		t.Fatalf("expected batch to verify, got %v, %v", ok, err)
+	}
+
+	ok, err = executor.Verify(payload, &legacy_executor_verifier.VerifierRequest{BatchNumber: batchNo, StateRoot: oldRoot}, oldRoot)
+	if err == nil || ok {
+		t.Fatal("expected verification to fail for the wrong state root")
+	}
+}
+
+func TestExecuteWitnessRootMismatch(t *testing.T) {
+	preState := smt.NewSMT(db.NewMemDb())
+	if _, err := preState.SetAccountState("0x1000000000000000000000000000000000000001", big.NewInt(1000), big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := &legacy_executor_verifier.Payload{
+		Witness:    witnessBytes(t, preState),
+		DataStream: emptyBlockStream(t, 1, 1, 1),
+	}
+
+	executor := NewExecutor(testChainConfig(), serenity.New(ethash.NewFaker()))
+	if _, err := executor.ExecuteBatch(payload, libcommon.HexToHash("0x1234")); err == nil {
+		t.Fatal("expected an error when the witness does not match the old state root")
+	}
+}
+
+func TestWitnessStateBalances(t *testing.T) {
+	account := libcommon.HexToAddress("0x1000000000000000000000000000000000000001")
+	preState := smt.NewSMT(db.NewMemDb())
+	if _, err := preState.SetAccountState(account.String(), big.NewInt(1000), big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	witness, err := trie.NewWitnessFromReader(bytes.NewReader(witnessBytes(t, preState)), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws, err := newWitnessState(witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the transactions of a batch read the accounts through the intra block state
+	ibs := state.New(ws)
+	if balance := ibs.GetBalance(account); balance.Cmp(uint256.NewInt(1000)) != 0 {
+		t.Fatalf("expected a balance of 1000, got %s", balance)
+	}
+	if nonce := ibs.GetNonce(account); nonce != 1 {
+		t.Fatalf("expected a nonce of 1, got %d", nonce)
+	}
+}
@@ -0,0 +1,183 @@
+package stateless_executor
+
+import (
+	"fmt"
+	"math/big"
+
+	libcommon "github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/common/hexutility"
+	"github.com/holiman/uint256"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/smt/pkg/utils"
+	"github.com/ledgerwatch/erigon/turbo/trie"
+)
+
+// witnessState is a state reader backed only by the leaves held in a witness.  Anything not present in the
+// witness reads as empty, which matches the SMT where zero values are never stored.
+type witnessState struct {
+	accounts map[libcommon.Address]*accounts.Account
+	code     map[libcommon.Address][]byte
+	storage  map[libcommon.Address]map[libcommon.Hash][]byte
+}
+
+var _ state.StateReader = (*witnessState)(nil)
+
+func newWitnessState(w *trie.Witness) (*witnessState, error) {
+	ws := &witnessState{
+		accounts: make(map[libcommon.Address]*accounts.Account),
+		code:     make(map[libcommon.Address][]byte),
+		storage:  make(map[libcommon.Address]map[libcommon.Hash][]byte),
+	}
+
+	// the code operator always comes directly before the SC_CODE leaf it belongs to
+	var pendingCode []byte
+	for _, operator := range w.Operators {
+		switch op := operator.(type) {
+		case *trie.OperatorCode:
+			pendingCode = op.Code
+		case *trie.OperatorSMTLeafValue:
+			address := libcommon.BytesToAddress(op.Address)
+			value := new(big.Int).SetBytes(op.Value)
+
+			switch int(op.NodeType) {
+			case utils.KEY_BALANCE:
+				ws.account(address).Balance.SetFromBig(value)
+			case utils.KEY_NONCE:
+				ws.account(address).Nonce = value.Uint64()
+			case utils.SC_CODE:
+				if pendingCode == nil {
+					return nil, fmt.Errorf("missing bytecode in witness for contract %s", address)
+				}
+				acc := ws.account(address)
+				acc.CodeHash = crypto.Keccak256Hash(pendingCode)
+				acc.Incarnation = state.FirstContractIncarnation
+				ws.code[address] = pendingCode
+				pendingCode = nil
+			case utils.SC_LENGTH:
+				ws.account(address)
+			case utils.SC_STORAGE:
+				ws.account(address)
+				if ws.storage[address] == nil {
+					ws.storage[address] = make(map[libcommon.Hash][]byte)
+				}
+				ws.storage[address][libcommon.BytesToHash(op.StorageKey)] = value.Bytes()
+			default:
+				return nil, fmt.Errorf("unknown smt leaf type %d", op.NodeType)
+			}
+		}
+	}
+
+	return ws, nil
+}
+
+func (ws *witnessState) account(address libcommon.Address) *accounts.Account {
+	if acc, ok := ws.accounts[address]; ok {
+		return acc
+	}
+	// an account that isn't initialised is read as a new one, with its balance dropped
+	acc := accounts.NewAccount()
+	acc.Initialised = true
+	ws.accounts[address] = &acc
+	return &acc
+}
+
+func (ws *witnessState) ReadAccountData(address libcommon.Address) (*accounts.Account, error) {
+	acc, ok := ws.accounts[address]
+	if !ok {
+		return nil, nil
+	}
+	cpy := acc.SelfCopy()
+	return cpy, nil
+}
+
+func (ws *witnessState) ReadAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash) ([]byte, error) {
+	return ws.storage[address][*key], nil
+}
+
+func (ws *witnessState) ReadAccountCode(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash) ([]byte, error) {
+	return ws.code[address], nil
+}
+
+func (ws *witnessState) ReadAccountCodeSize(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash) (int, error) {
+	return len(ws.code[address]), nil
+}
+
+func (ws *witnessState) ReadAccountIncarnation(address libcommon.Address) (uint64, error) {
+	if acc, ok := ws.accounts[address]; ok {
+		return acc.Incarnation, nil
+	}
+	return 0, nil
+}
+
+// changeCollector gathers the state changes of a block in the shape expected by SMT.SetStorage and also
+// applies them to the underlying witness state so the next block in the batch reads the updated values
+type changeCollector struct {
+	ws             *witnessState
+	accChanges     map[libcommon.Address]*accounts.Account
+	codeChanges    map[libcommon.Address]string
+	storageChanges map[libcommon.Address]map[string]string
+}
+
+var _ state.WriterWithChangeSets = (*changeCollector)(nil)
+
+func newChangeCollector(ws *witnessState) *changeCollector {
+	return &changeCollector{
+		ws:             ws,
+		accChanges:     make(map[libcommon.Address]*accounts.Account),
+		codeChanges:    make(map[libcommon.Address]string),
+		storageChanges: make(map[libcommon.Address]map[string]string),
+	}
+}
+
+func (c *changeCollector) UpdateAccountData(address libcommon.Address, original, account *accounts.Account) error {
+	c.accChanges[address] = account.SelfCopy()
+	c.ws.accounts[address] = account.SelfCopy()
+	return nil
+}
+
+func (c *changeCollector) UpdateAccountCode(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash, code []byte) error {
+	c.codeChanges[address] = hexutility.Encode(code)
+	c.ws.code[address] = common.CopyBytes(code)
+	return nil
+}
+
+func (c *changeCollector) DeleteAccount(address libcommon.Address, original *accounts.Account) error {
+	c.accChanges[address] = nil
+	delete(c.ws.accounts, address)
+	delete(c.ws.code, address)
+	delete(c.ws.storage, address)
+	return nil
+}
+
+func (c *changeCollector) WriteAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash, original, value *uint256.Int) error {
+	if *original == *value {
+		return nil
+	}
+
+	if c.storageChanges[address] == nil {
+		c.storageChanges[address] = make(map[string]string)
+	}
+	c.storageChanges[address][fmt.Sprintf("0x%032x", *key)] = fmt.Sprintf("0x%032x", libcommon.Hash(value.Bytes32()))
+
+	if c.ws.storage[address] == nil {
+		c.ws.storage[address] = make(map[libcommon.Hash][]byte)
+	}
+	c.ws.storage[address][*key] = value.Bytes()
+	return nil
+}
+
+func (c *changeCollector) CreateContract(address libcommon.Address) error {
+	return nil
+}
+
+func (c *changeCollector) WriteChangeSets() error {
+	return nil
+}
+
+func (c *changeCollector) WriteHistory() error {
+	return nil
+}
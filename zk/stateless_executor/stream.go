@@ -0,0 +1,88 @@
+package stateless_executor
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/zk/datastream/types"
+)
+
+var ErrNoBlocksInStream = errors.New("no l2 blocks found in the data stream")
+
+// StreamBatch holds the decoded contents of a data stream payload as sent to the executor
+type StreamBatch struct {
+	BatchNumber uint64
+	ForkId      uint64
+	ChainId     uint64
+	Blocks      []*types.FullL2Block
+	GerUpdates  []types.GerUpdate
+}
+
+// DecodeStreamBytes decodes the data stream bytes built for the executor (see
+// DataStreamServer.CreateAndBuildStreamEntryBytesProto) back into blocks and their transactions
+func DecodeStreamBytes(data []byte) (*StreamBatch, error) {
+	batch := &StreamBatch{}
+
+	var currentBlock *types.FullL2Block
+	for offset := 0; offset < len(data); {
+		if len(data)-offset < int(types.FileEntryMinSize) {
+			return nil, fmt.Errorf("truncated data stream entry at offset %d", offset)
+		}
+
+		entryLength := int(binary.BigEndian.Uint32(data[offset+1 : offset+5]))
+		if entryLength < int(types.FileEntryMinSize) || offset+entryLength > len(data) {
+			return nil, fmt.Errorf("invalid data stream entry length %d at offset %d", entryLength, offset)
+		}
+
+		entry, err := types.DecodeFileEntry(data[offset : offset+entryLength])
+		if err != nil {
+			return nil, err
+		}
+		offset += entryLength
+
+		switch {
+		case entry.IsBookmark(), entry.IsBatchEnd():
+			// bookmarks only help with navigating the stream and the previous batch end carries nothing we need
+		case entry.IsBatchStart():
+			batchStart, err := types.UnmarshalBatchStart(entry.Data)
+			if err != nil {
+				return nil, err
+			}
+			batch.BatchNumber = batchStart.Number
+			batch.ForkId = batchStart.ForkId
+			batch.ChainId = batchStart.ChainId
+		case entry.IsUpdateGer():
+			gerUpdate, err := types.DecodeGerUpdateProto(entry.Data)
+			if err != nil {
+				return nil, err
+			}
+			batch.GerUpdates = append(batch.GerUpdates, *gerUpdate)
+		case entry.IsL2Block():
+			currentBlock, err = types.UnmarshalL2Block(entry.Data)
+			if err != nil {
+				return nil, err
+			}
+			currentBlock.ForkId = batch.ForkId
+			currentBlock.ChainId = batch.ChainId
+			batch.Blocks = append(batch.Blocks, currentBlock)
+		case entry.IsL2Tx():
+			if currentBlock == nil {
+				return nil, fmt.Errorf("transaction found before any l2 block in the data stream")
+			}
+			tx, err := types.UnmarshalTx(entry.Data)
+			if err != nil {
+				return nil, err
+			}
+			currentBlock.L2Txs = append(currentBlock.L2Txs, *tx)
+		default:
+			return nil, fmt.Errorf("unexpected data stream entry type %d", entry.EntryType)
+		}
+	}
+
+	if len(batch.Blocks) == 0 {
+		return nil, ErrNoBlocksInStream
+	}
+
+	return batch, nil
+}
@@ -34,6 +34,7 @@ type IEtherman interface {
 	FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]ethTypes.Log, error)
 	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
 	TransactionByHash(ctx context.Context, hash common.Hash) (ethTypes.Transaction, bool, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
 }
 
 type fetchJob struct {
@@ -186,6 +187,21 @@ func (s *L1Syncer) GetTransaction(hash common.Hash) (ethTypes.Transaction, bool,
 	return em.TransactionByHash(context.Background(), hash)
 }
 
+func (s *L1Syncer) HeaderByNumber(ctx context.Context, blockNumber *big.Int) (*ethTypes.Header, error) {
+	em := s.getNextEtherman()
+	return em.HeaderByNumber(ctx, blockNumber)
+}
+
+func (s *L1Syncer) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	em := s.getNextEtherman()
+	return em.SuggestGasPrice(ctx)
+}
+
+func (s *L1Syncer) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	em := s.getNextEtherman()
+	return em.CallContract(ctx, msg, blockNumber)
+}
+
 func (s *L1Syncer) GetOldAccInputHash(ctx context.Context, addr *common.Address, rollupId, batchNum uint64) (common.Hash, error) {
 	loopCount := 0
 	for {
@@ -0,0 +1,94 @@
+package e2e
+
+import (
+	"testing"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/zk/txpool"
+)
+
+func TestACL(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	h := New(t, DefaultConfig())
+	acl := h.ACL()
+	require.NotNil(t, acl)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	other := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	h.SendTransactions(t, h.Transfer(t, other, uint256.NewInt(1_000_000_000_000_000_000)))
+	h.SealBatch(t)
+
+	sign := func(tx types.Transaction) types.Transaction {
+		signed, err := types.SignTx(tx, *types.LatestSignerForChainID(h.Sequencer.ChainConfig.ChainID), key)
+		require.NoError(t, err)
+		return signed
+	}
+	gasPrice := uint256.NewInt(10_000_000_000)
+
+	// only the harness account may send
+	require.NoError(t, acl.Add(h.ctx, txpool.ACLSendAllowlist, h.Address()))
+	require.NoError(t, acl.SetEnabled(h.ctx, txpool.ACLSendAllowlist, true))
+	refused := h.RefusedTransactions(t, sign(types.NewTransaction(0, to, uint256.NewInt(1), 21000, gasPrice, nil)))
+	require.Equal(t, []string{txpool.SenderNotAllowed.String()}, refused)
+	require.NoError(t, acl.SetEnabled(h.ctx, txpool.ACLSendAllowlist, false))
+
+	// nobody but the harness account may deploy
+	require.NoError(t, acl.SetEnabled(h.ctx, txpool.ACLDeployAllowlist, true))
+	refused = h.RefusedTransactions(t, sign(types.NewContractCreation(0, uint256.NewInt(0), 100000, gasPrice, []byte{0x00})))
+	require.Equal(t, []string{txpool.DeployNotAllowed.String()}, refused)
+
+	// a recipient blocked once the transaction is in the pool is refused by the sequencer
+	pooled := sign(types.NewTransaction(0, to, uint256.NewInt(1), 21000, gasPrice, nil))
+	h.SendTransactions(t, pooled)
+	require.NoError(t, acl.Add(h.ctx, txpool.ACLBlocklist, to))
+	require.NoError(t, acl.SetEnabled(h.ctx, txpool.ACLBlocklist, true))
+	refused = h.RefusedTransactions(t, sign(types.NewTransaction(1, to, uint256.NewInt(1), 21000, gasPrice, nil)))
+	require.Equal(t, []string{txpool.RecipientBlocked.String()}, refused)
+
+	from := h.Progress(t, h.Sequencer, stages.Execution)
+	h.SendTransactions(t, h.Transfer(t, other, uint256.NewInt(1)))
+	h.SealBatch(t)
+
+	included := includedSince(t, h, from)
+	require.Len(t, included, 1)
+	require.NotContains(t, included, pooled.Hash())
+
+	// the refused transaction is gone from the pool, it is not sequenced once the recipient is no longer blocked and
+	// the pool remembers it as discarded
+	require.NoError(t, acl.SetEnabled(h.ctx, txpool.ACLBlocklist, false))
+	from = h.Progress(t, h.Sequencer, stages.Execution)
+	h.SendTransactions(t, h.Transfer(t, other, uint256.NewInt(1)))
+	h.SealBatch(t)
+	require.NotContains(t, includedSince(t, h, from), pooled.Hash())
+	require.Equal(t, []string{txpool.AlreadyKnown.String()}, h.RefusedTransactions(t, pooled))
+}
+
+// includedSince returns the transactions of the sequencer's blocks after a block
+func includedSince(t *testing.T, h *Harness, from uint64) []common.Hash {
+	var included []common.Hash
+	require.NoError(t, h.Sequencer.DB.View(h.ctx, func(tx kv.Tx) error {
+		to := h.Progress(t, h.Sequencer, stages.Execution)
+		for number := from + 1; number <= to; number++ {
+			block, err := rawdb.ReadBlockByNumber(tx, number)
+			require.NoError(t, err)
+			for _, txn := range block.Transactions() {
+				included = append(included, txn.Hash())
+			}
+		}
+		return nil
+	}))
+	return included
+}
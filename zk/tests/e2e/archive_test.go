@@ -0,0 +1,79 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/zk/datastream/archive"
+	"github.com/ledgerwatch/erigon/zk/hermez_db"
+)
+
+func TestDataStreamArchive(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	h := New(t, DefaultConfig())
+	to := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	for batch := 0; batch < 3; batch++ {
+		h.SendTransactions(t, h.Transfer(t, to, uint256.NewInt(1000)))
+		h.SealBatch(t)
+	}
+
+	srv := h.DataStream()
+	closed, err := srv.GetHighestClosedBatch()
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, closed, uint64(3))
+
+	dir := t.TempDir()
+
+	// only whole batches are exported
+	incomplete := filepath.Join(dir, "incomplete.dsa")
+	_, err = srv.ExportArchive(incomplete, 0, closed+1, archive.CompressionNone)
+	require.ErrorContains(t, err, "not complete")
+	require.NoFileExists(t, incomplete)
+
+	path := filepath.Join(dir, "stream.dsa")
+	header, err := srv.ExportArchive(path, 0, closed, archive.CompressionGzip)
+	require.NoError(t, err)
+	require.Equal(t, h.Sequencer.ChainConfig.ChainID.Uint64(), header.ChainId)
+	require.Equal(t, closed, header.ToBatch)
+
+	// a tampered archive is refused before anything is read from it
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[len(data)-1] ^= 0xff
+	tampered := filepath.Join(dir, "tampered.dsa")
+	require.NoError(t, os.WriteFile(tampered, data, 0600))
+	_, err = archive.Open(tampered)
+	require.ErrorIs(t, err, archive.ErrChecksumMismatch)
+
+	// an rpc node bootstrapped from the archive ends with the last block of the archive
+	n := h.NewArchiveRpc(t, path)
+	h.SyncArchiveRpc(t, n)
+
+	head := h.Progress(t, n, stages.Execution)
+	require.NotZero(t, head)
+	require.NoError(t, n.DB.View(h.ctx, func(tx kv.Tx) error {
+		hermezDb := hermez_db.NewHermezDbReader(tx)
+		batch, err := hermezDb.GetBatchNoByL2Block(head)
+		require.NoError(t, err)
+		require.Equal(t, closed, batch)
+		next, err := hermezDb.GetBatchNoByL2Block(head + 1)
+		require.NoError(t, err)
+		require.Zero(t, next)
+		return nil
+	}))
+
+	sequencerHeader := h.header(t, h.Sequencer, head)
+	archiveHeader := h.header(t, n, head)
+	require.Equal(t, sequencerHeader.Root, archiveHeader.Root)
+	require.Equal(t, sequencerHeader.Hash(), archiveHeader.Hash())
+}
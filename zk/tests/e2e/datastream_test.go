@@ -0,0 +1,118 @@
+package e2e
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/ledgerwatch/erigon/zk/datastream/proto/github.com/0xPolygonHermez/zkevm-node/state/datastream"
+	"github.com/ledgerwatch/erigon/zk/datastream/server"
+	"github.com/ledgerwatch/erigon/zk/datastream/types"
+)
+
+func TestDataStreamCheckAndRepair(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	h := New(t, DefaultConfig())
+	to := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	for batch := 0; batch < 3; batch++ {
+		for i := 0; i < 2; i++ {
+			h.SendTransactions(t, h.Transfer(t, to, uint256.NewInt(1000)))
+		}
+		h.SealBatch(t)
+	}
+
+	srv := h.DataStream()
+	stream := h.Sequencer.stream
+	check := func() *server.StreamCheckResult {
+		var result *server.StreamCheckResult
+		require.NoError(t, h.Sequencer.DB.View(context.Background(), func(tx kv.Tx) error {
+			var err error
+			result, err = srv.Check(tx, "test")
+			return err
+		}))
+		return result
+	}
+	repair := func(from uint64) {
+		require.NoError(t, h.Sequencer.DB.Update(context.Background(), func(tx kv.RwTx) error {
+			_, err := srv.Repair(tx, from, "test")
+			return err
+		}))
+	}
+
+	result := check()
+	require.Nil(t, result.Divergence)
+	require.GreaterOrEqual(t, result.Transactions, uint64(6))
+	require.Equal(t, result.TotalEntries, stream.GetHeader().TotalEntries)
+	highest, err := srv.GetHighestBlockNumber()
+	require.NoError(t, err)
+	require.Equal(t, highest, result.HighestBlock)
+	entries := result.TotalEntries
+
+	// a block whose state root no longer matches the db
+	var blockEntry uint64
+	var blockNum uint64
+	for i := entries - 1; i > 0; i-- {
+		entry, err := stream.GetEntry(i)
+		require.NoError(t, err)
+		if types.EntryType(entry.Type) != types.EntryTypeL2Block {
+			continue
+		}
+		l2Block := &datastream.L2Block{}
+		require.NoError(t, proto.Unmarshal(entry.Data, l2Block))
+		if l2Block.Number == highest-1 {
+			l2Block.StateRoot[0] ^= 0xff
+			data, err := proto.Marshal(l2Block)
+			require.NoError(t, err)
+			require.NoError(t, stream.UpdateEntryData(i, entry.Type, data))
+			blockEntry, blockNum = i, l2Block.Number
+			break
+		}
+	}
+	require.NotZero(t, blockEntry)
+
+	result = check()
+	require.NotNil(t, result.Divergence)
+	require.Equal(t, blockEntry, result.Divergence.EntryNum)
+	require.Contains(t, result.Divergence.Reason, "state root")
+	require.LessOrEqual(t, result.Divergence.BlockNum, blockNum)
+
+	repair(result.Divergence.BlockNum)
+	result = check()
+	require.Nil(t, result.Divergence)
+	require.Equal(t, highest, result.HighestBlock)
+	require.Equal(t, entries, result.TotalEntries)
+
+	// a write that never finished, the stream ends with a bookmark
+	require.NoError(t, stream.TruncateFile(entries-1))
+	for {
+		entry, err := stream.GetEntry(stream.GetHeader().TotalEntries - 1)
+		require.NoError(t, err)
+		if types.EntryType(entry.Type) == types.BookmarkEntryType {
+			break
+		}
+		require.NoError(t, stream.TruncateFile(stream.GetHeader().TotalEntries-1))
+	}
+	result = check()
+	require.NotNil(t, result.Divergence)
+	require.Contains(t, result.Divergence.Reason, "ends with a bookmark")
+
+	repair(result.Divergence.BlockNum)
+	result = check()
+	require.Nil(t, result.Divergence)
+	require.Equal(t, highest, result.HighestBlock)
+	require.Equal(t, entries, result.TotalEntries)
+
+	// the whole stream
+	repair(0)
+	result = check()
+	require.Nil(t, result.Divergence)
+	require.Equal(t, entries, result.TotalEntries)
+}
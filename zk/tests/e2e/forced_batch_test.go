@@ -0,0 +1,156 @@
+package e2e
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"testing"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/zk/datastream/proto/github.com/0xPolygonHermez/zkevm-node/state/datastream"
+	dstypes "github.com/ledgerwatch/erigon/zk/datastream/types"
+	"github.com/ledgerwatch/erigon/zk/hermez_db"
+	zktx "github.com/ledgerwatch/erigon/zk/tx"
+)
+
+// keccakLoopInitCode is contract creation code hashing 100000 bytes of memory, more keccak counters than a batch has
+var keccakLoopInitCode = common.FromHex("0x620186a06000205000")
+
+func TestForcedBatch(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	to := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	unfunded, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		// txs builds the transactions of the forced batch, the funded account is at nonce
+		txs func(h *Harness, nonce uint64) []types.Transaction
+		// executed is which of them the forced block executes
+		executed []bool
+		// nonces is how many nonces of the funded account the executed transactions took
+		nonces uint64
+	}{
+		{
+			name: "valid transactions",
+			txs: func(h *Harness, nonce uint64) []types.Transaction {
+				return []types.Transaction{
+					h.sign(t, h.key, types.NewTransaction(nonce, to, uint256.NewInt(1000), 21000, uint256.NewInt(1_000_000_000), nil)),
+					h.sign(t, h.key, types.NewTransaction(nonce+1, to, uint256.NewInt(1000), 21000, uint256.NewInt(1_000_000_000), nil)),
+				}
+			},
+			executed: []bool{true, true},
+			nonces:   2,
+		},
+		{
+			// a transaction that fails before execution is skipped by the executor but stays in the batch data
+			name: "invalid transaction",
+			txs: func(h *Harness, nonce uint64) []types.Transaction {
+				return []types.Transaction{
+					h.sign(t, h.key, types.NewTransaction(nonce, to, uint256.NewInt(1000), 21000, uint256.NewInt(1_000_000_000), nil)),
+					h.sign(t, unfunded, types.NewTransaction(0, to, uint256.NewInt(1000), 21000, uint256.NewInt(1_000_000_000), nil)),
+					h.sign(t, h.key, types.NewTransaction(nonce+10, to, uint256.NewInt(1000), 21000, uint256.NewInt(1_000_000_000), nil)),
+					h.sign(t, h.key, types.NewTransaction(nonce+1, to, uint256.NewInt(1000), 21000, uint256.NewInt(1_000_000_000), nil)),
+				}
+			},
+			executed: []bool{true, false, false, true},
+			nonces:   2,
+		},
+		{
+			// a batch that runs out of counters is invalid to the ROM, none of its transactions are executed
+			name: "counters overflow",
+			txs: func(h *Harness, nonce uint64) []types.Transaction {
+				return []types.Transaction{
+					h.sign(t, h.key, types.NewTransaction(nonce, to, uint256.NewInt(1000), 21000, uint256.NewInt(1_000_000_000), nil)),
+					h.sign(t, h.key, types.NewContractCreation(nonce+1, uint256.NewInt(0), 1_000_000, uint256.NewInt(1_000_000_000), keccakLoopInitCode)),
+				}
+			},
+			executed: []bool{false, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := New(t, DefaultConfig())
+			// a regular batch first so the forced one does not follow the injected batch
+			h.SendTransactions(t, h.Transfer(t, to, uint256.NewInt(1)))
+			h.SealBatch(t)
+
+			txs := tt.txs(h, h.nonce)
+			batchL2Data, err := zktx.GenerateBlockBatchL2Data(uint16(h.cfg.L1.ForkId), 0, 0, txs)
+			require.NoError(t, err)
+			h.L1.ForceBatch(1, batchL2Data, common.Hash{}, h.Address())
+			h.L1.Mine()
+
+			from := h.Progress(t, h.Sequencer, stages.Execution)
+			batch := h.SealBatch(t)
+			require.Equal(t, from+1, h.Progress(t, h.Sequencer, stages.Execution), "the forced batch is a single block")
+			require.NoError(t, h.Sequencer.DB.View(h.ctx, func(tx kv.Tx) error {
+				forced, ok, err := hermez_db.NewHermezDbReader(tx).GetForcedBatchNumberByBatch(batch)
+				require.True(t, ok, "batch %d is not the forced batch", batch)
+				require.Equal(t, uint64(1), forced)
+				return err
+			}))
+
+			var executed []common.Hash
+			for i, txn := range txs {
+				if tt.executed[i] {
+					executed = append(executed, txn.Hash())
+				}
+			}
+			require.Equal(t, executed, includedSince(t, h, from))
+
+			// the stream holds the batch data as it was forced, transaction for transaction
+			streamed := streamedTransactions(t, h, from+1)
+			require.Len(t, streamed, len(txs))
+			for i, txn := range txs {
+				var encoded bytes.Buffer
+				require.NoError(t, txn.EncodeRLP(&encoded))
+				require.Equal(t, encoded.Bytes(), streamed[i].Encoded, "transaction %d", i)
+				require.Equal(t, tt.executed[i], streamed[i].IsValid, "transaction %d", i)
+			}
+
+			// the next batch is a regular one again
+			h.nonce += tt.nonces
+			h.SendTransactions(t, h.Transfer(t, to, uint256.NewInt(1)))
+			h.SealBatch(t)
+			h.SyncRpc(t)
+			h.RequireSameState(t)
+		})
+	}
+}
+
+// sign signs a transaction for the harness chain
+func (h *Harness) sign(t *testing.T, key *ecdsa.PrivateKey, tx types.Transaction) types.Transaction {
+	signed, err := types.SignTx(tx, *types.LatestSignerForChainID(h.Sequencer.ChainConfig.ChainID), key)
+	require.NoError(t, err)
+	return signed
+}
+
+// streamedTransactions returns the transaction entries the sequencer streamed for a block, in order
+func streamedTransactions(t *testing.T, h *Harness, blockNumber uint64) []*datastream.Transaction {
+	stream := h.Sequencer.stream
+	var txs []*datastream.Transaction
+	for i := uint64(0); i < stream.GetHeader().TotalEntries; i++ {
+		entry, err := stream.GetEntry(i)
+		require.NoError(t, err)
+		if dstypes.EntryType(entry.Type) != dstypes.EntryTypeL2Tx {
+			continue
+		}
+		transaction := &datastream.Transaction{}
+		require.NoError(t, proto.Unmarshal(entry.Data, transaction))
+		if transaction.L2BlockNumber == blockNumber {
+			txs = append(txs, transaction)
+		}
+	}
+	return txs
+}
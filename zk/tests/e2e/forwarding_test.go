@@ -0,0 +1,107 @@
+package e2e
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/zk/pool_manager"
+)
+
+func TestRpcForwarding(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	h := New(t, DefaultConfig())
+	h.ForwardTransactions(t)
+	h.SyncRpc(t)
+	to := common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	// the RPC node refuses what its state already rules out, without forwarding it
+	broke, err := types.SignTx(types.NewTransaction(0, to, new(uint256.Int).Lsh(uint256.NewInt(1), 200), 21000, uint256.NewInt(1_000_000_000), nil), *types.LatestSignerForChainID(h.Rpc.ChainConfig.ChainID), h.key)
+	require.NoError(t, err)
+	_, err = h.RpcSendTransaction(t, broke)
+	require.ErrorContains(t, err, "insufficient funds")
+	otherChain, err := types.SignTx(types.NewTransaction(0, to, uint256.NewInt(1), 21000, uint256.NewInt(1_000_000_000), nil), *types.LatestSignerForChainID(big.NewInt(1)), h.key)
+	require.NoError(t, err)
+	_, err = h.RpcSendTransaction(t, otherChain)
+	require.ErrorContains(t, err, "invalid chain id")
+	require.Empty(t, h.ForwardedTransactions())
+	require.Equal(t, uint64(0), h.RpcNonce(t, rpc.PendingBlockNumber))
+
+	// a transaction it takes is forwarded to the sequencer and pending on the RPC node until it is mined
+	transfer := h.Transfer(t, to, uint256.NewInt(1))
+	hash, err := h.RpcSendTransaction(t, transfer)
+	require.NoError(t, err)
+	require.Equal(t, transfer.Hash(), hash)
+	require.Equal(t, uint64(1), h.RpcNonce(t, rpc.PendingBlockNumber))
+	require.Equal(t, uint64(0), h.RpcNonce(t, rpc.LatestBlockNumber))
+	require.Len(t, h.ForwardedTransactions()[h.Address()], 1)
+	pending, err := h.Rpc.EthApi.GetTransactionByHash(h.ctx, hash)
+	require.NoError(t, err)
+	require.NotNil(t, pending)
+	require.Nil(t, pending.BlockNumber)
+
+	from := h.Progress(t, h.Sequencer, stages.Execution)
+	h.SealBatch(t)
+	require.Equal(t, []common.Hash{transfer.Hash()}, includedSince(t, h, from))
+	// the RPC node stays a block behind, the next batch hands it the block of the transfer
+	h.SealBatch(t)
+	h.SyncRpc(t)
+
+	// the new head of the mined transfer stops the RPC node tracking it
+	require.Eventually(t, func() bool {
+		return len(h.ForwardedTransactions()) == 0
+	}, 5*time.Second, 10*time.Millisecond, "the mined transfer is still tracked")
+	_, err = h.RpcSendTransaction(t, transfer)
+	require.ErrorContains(t, err, "nonce too low")
+	require.Equal(t, uint64(1), h.RpcNonce(t, rpc.PendingBlockNumber))
+}
+
+func TestRpcForwardingToPoolManager(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	mock := pool_manager.NewMock()
+	url, stop, err := mock.Serve()
+	require.NoError(t, err)
+	defer stop()
+
+	cfg := DefaultConfig()
+	cfg.PoolManagerUrl = url
+	h := New(t, cfg)
+	h.ForwardTransactions(t)
+	h.SyncRpc(t)
+	to := common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	// the sequencer only sees the transfer once it takes it from the pool manager, the pending nonce of the RPC node
+	// carries on from the transactions it forwarded until then
+	transfer := h.Transfer(t, to, uint256.NewInt(1))
+	_, err = h.RpcSendTransaction(t, transfer)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), h.RpcNonce(t, rpc.PendingBlockNumber))
+	next := h.Transfer(t, to, uint256.NewInt(1))
+	_, err = h.RpcSendTransaction(t, next)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), h.RpcNonce(t, rpc.PendingBlockNumber))
+
+	from := h.Progress(t, h.Sequencer, stages.Execution)
+	h.SealBatch(t)
+	require.Equal(t, []common.Hash{transfer.Hash(), next.Hash()}, includedSince(t, h, from))
+	h.SealBatch(t)
+	h.SyncRpc(t)
+
+	require.Eventually(t, func() bool {
+		return len(h.ForwardedTransactions()) == 0
+	}, 5*time.Second, 10*time.Millisecond, "the mined transfers are still tracked")
+	require.Equal(t, uint64(2), h.RpcNonce(t, rpc.PendingBlockNumber))
+}
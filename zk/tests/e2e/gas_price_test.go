@@ -0,0 +1,59 @@
+package e2e
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+func TestSuggestedGasPrice(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	cfg := DefaultConfig()
+	cfg.DefaultGasPrice = 1_234_567_890
+	h := New(t, cfg)
+
+	h.SendTransactions(t, h.Transfer(t, common.HexToAddress("0x1000000000000000000000000000000000000001"), uint256.NewInt(1)))
+	h.SealBatch(t)
+	h.SealBatch(t)
+	h.SyncRpc(t)
+
+	// the mock L1 gas price is below the floor so the price is the floor, with the leading digits kept.  The RPC node
+	// has the price of each block from the data stream.
+	expected := (*hexutil.Big)(big.NewInt(1_230_000_000))
+	for _, n := range []*Node{h.Sequencer, h.Rpc} {
+		price, err := n.EthApi.GasPrice(h.ctx)
+		require.NoError(t, err)
+		require.Equal(t, expected, price)
+
+		tip, err := n.EthApi.MaxPriorityFeePerGas(h.ctx)
+		require.NoError(t, err)
+		require.Equal(t, expected, tip)
+
+		head := h.Progress(t, n, stages.Execution)
+		require.Greater(t, head, uint64(2))
+
+		history, err := n.EthApi.FeeHistory(h.ctx, 2, rpc.LatestBlockNumber, []float64{25, 75})
+		require.NoError(t, err)
+		require.Equal(t, (*hexutil.Big)(new(big.Int).SetUint64(head-1)), history.OldestBlock)
+		require.Len(t, history.GasUsedRatio, 2)
+		require.Len(t, history.BaseFee, 3)
+		require.Equal(t, [][]*hexutil.Big{{expected, expected}, {expected, expected}}, history.Reward)
+
+		// the genesis has no suggested price so the history starts after it
+		history, err = n.EthApi.FeeHistory(h.ctx, 1024, rpc.LatestBlockNumber, nil)
+		require.NoError(t, err)
+		require.Equal(t, (*hexutil.Big)(big.NewInt(1)), history.OldestBlock)
+		require.Len(t, history.GasUsedRatio, int(head))
+		require.Nil(t, history.Reward)
+	}
+}
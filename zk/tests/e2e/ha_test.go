@@ -0,0 +1,94 @@
+package e2e
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/zk/ha"
+	"github.com/ledgerwatch/erigon/zk/legacy_executor_verifier"
+)
+
+func TestSequencerFailover(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	cfg := DefaultConfig()
+	cfg.Lease = ha.NewMemoryBackend()
+	h := New(t, cfg)
+	to := common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	h.SendTransactions(t, h.Transfer(t, to, uint256.NewInt(1)))
+	h.SealBatch(t)
+	h.SyncRpc(t)
+	h.RequireSameState(t)
+
+	old := h.Failover(t)
+
+	// the old sequencer no longer holds the lease so it refuses to seal anything
+	before := h.Progress(t, old, stages.Execution)
+	require.ErrorContains(t, old.tryCycle(h.ctx, t), "lease")
+	require.Equal(t, before, h.Progress(t, old, stages.Execution))
+
+	// the promoted sequencer carries on the chain it followed and a new RPC node follows it from genesis
+	from := h.Progress(t, h.Sequencer, stages.Execution)
+	transfer := h.Transfer(t, to, uint256.NewInt(1))
+	h.SendTransactions(t, transfer)
+	h.SealBatch(t)
+	require.Equal(t, []common.Hash{transfer.Hash()}, includedSince(t, h, from))
+
+	h.SyncRpc(t)
+	head := h.RequireSameState(t)
+	require.Greater(t, head, from)
+}
+
+func TestSequencerFailoverWithExecutors(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	cfg := DefaultConfig()
+	cfg.Lease = ha.NewMemoryBackend()
+	cfg.Executors = true
+	h := New(t, cfg)
+	to := common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	h.SendTransactions(t, h.Transfer(t, to, uint256.NewInt(1)))
+	h.SealBatch(t)
+	h.StreamVerified(t)
+	h.SyncRpc(t)
+	h.RequireSameState(t)
+
+	// the next batch is sealed but the sequencer loses the lease before it is verified, so it never streams it
+	lost := h.Transfer(t, to, uint256.NewInt(1))
+	h.SendTransactions(t, lost)
+	h.SealBatch(t)
+	old := h.Failover(t)
+
+	streamed := h.Progress(t, old, stages.DataStream)
+	require.Eventually(t, func() bool {
+		err := old.tryStreamVerified(h.ctx)
+		if err != nil {
+			require.ErrorIs(t, err, legacy_executor_verifier.ErrLeaseLost)
+		}
+		return err != nil
+	}, 10*time.Second, 10*time.Millisecond, "old sequencer did not drop the batch it didn't stream")
+	require.Equal(t, streamed, h.Progress(t, old, stages.DataStream))
+
+	// the promoted sequencer carries on from what was streamed, the transaction of the dropped batch is sent again
+	from := h.Progress(t, h.Sequencer, stages.Execution)
+	transfer := h.Transfer(t, to, uint256.NewInt(1))
+	h.SendTransactions(t, lost, transfer)
+	h.SealBatch(t)
+	require.Equal(t, []common.Hash{lost.Hash(), transfer.Hash()}, includedSince(t, h, from))
+
+	h.StreamVerified(t)
+	h.SyncRpc(t)
+	head := h.RequireSameState(t)
+	require.Greater(t, head, from)
+}
@@ -0,0 +1,450 @@
+// Package e2e runs a sequencer and an RPC node against each other in process so the staged sync of both can be
+// tested end to end.  The sequencer runs the SequencerZkStages list and serves its data stream over loopback, the RPC
+// node runs the DefaultZkStages list reading that stream, and both read the same mock L1.  Every step is driven by the
+// test so a scenario is deterministic: each SealBatch call runs a single sequencer cycle, each SyncRpc call runs RPC
+// cycles until the RPC node has caught up with what the sequencer has streamed.
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	txpool_proto "github.com/gateway-fm/cdk-erigon-lib/gointerfaces/txpool"
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/commands"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/zk/batch_sealing"
+	"github.com/ledgerwatch/erigon/zk/datastream/client"
+	"github.com/ledgerwatch/erigon/zk/datastream/server"
+	"github.com/ledgerwatch/erigon/zk/devnet"
+	"github.com/ledgerwatch/erigon/zk/ha"
+	"github.com/ledgerwatch/erigon/zk/hermez_db"
+	"github.com/ledgerwatch/erigon/zk/sequencer"
+	zkStages "github.com/ledgerwatch/erigon/zk/stages"
+	"github.com/ledgerwatch/erigon/zk/txpool"
+)
+
+// FundedKey is the key of an account funded in the genesis of the harness chain
+const FundedKey = "45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8"
+
+// maxRpcCycles bounds the cycles SyncRpc runs before it gives up on the RPC node catching up
+const maxRpcCycles = 20
+
+// leaseTTL is the sequencer lease of a harness with a lease backend
+const leaseTTL = time.Second
+
+// forwardedTxsTTL is how long the RPC node tracks the transactions it forwards, see ForwardTransactions
+const forwardedTxsTTL = time.Minute
+
+type Config struct {
+	L1                    devnet.Config
+	BlockSealTime         time.Duration
+	BatchSealTime         time.Duration
+	NonEmptyBatchSealTime time.Duration
+	TxOrdering            string
+	DefaultGasPrice       uint64
+	PoolManagerUrl        string     // the sequencer takes its transactions from the pool manager here when set
+	Lease                 ha.Backend // the sequencer only seals while it holds the lease here when set, see Failover
+	Executors             bool       // the sequencer verifies its batches with the stateless executor, see StreamVerified
+	Sealing               batch_sealing.Config
+	PreconfirmationKey    *ecdsa.PrivateKey // the sequencer signs preconfirmations with it when set
+}
+
+// DefaultConfig is the hermez-dev chain with seal times short enough for a cycle to take well under a second
+func DefaultConfig() Config {
+	return Config{
+		L1: devnet.Config{
+			L1ChainId:     devnet.DefaultL1ChainId,
+			L2ChainId:     999999,
+			RollupId:      1,
+			ForkId:        8,
+			RollupManager: devnet.RollupManagerAddress,
+			Rollup:        devnet.RollupAddress,
+			GerManager:    devnet.GerManagerAddress,
+			Sequencer:     devnet.SequencerAddress,
+		},
+		BlockSealTime:         100 * time.Millisecond,
+		BatchSealTime:         time.Second,
+		NonEmptyBatchSealTime: 300 * time.Millisecond,
+	}
+}
+
+// Harness is a sequencer and an RPC node syncing from it, sharing a mock L1
+type Harness struct {
+	L1        *devnet.MockL1
+	Sequencer *Node
+	Rpc       *Node
+
+	ctx   context.Context
+	cfg   Config
+	key   *ecdsa.PrivateKey
+	nonce uint64
+
+	stopLeader context.CancelFunc // stops the sequencer campaigning for the lease
+	failovers  int
+}
+
+// New starts a harness and runs the first sequencer cycle, which sequences the injected batch
+func New(t *testing.T, cfg Config) *Harness {
+	d, err := devnet.New(cfg.L1)
+	require.NoError(t, err)
+	t.Cleanup(d.L1().Close)
+
+	key, err := crypto.HexToECDSA(FundedKey)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &Harness{
+		L1:  d.L1(),
+		ctx: ctx,
+		cfg: cfg,
+		key: key,
+	}
+
+	h.Sequencer = newSequencer(ctx, t, cfg, h.L1, h.campaign(t))
+	// registered after the pool cleanup so it runs first and lets the pool loop exit
+	t.Cleanup(func() {
+		cancel()
+		h.Sequencer.stop()
+		if h.Rpc != nil {
+			h.Rpc.stop()
+		}
+	})
+	h.Rpc = newRpcNode(ctx, t, cfg, h.L1, h.Sequencer.streamAddr)
+
+	h.SealBatch(t)
+
+	return h
+}
+
+// campaign starts a new sequencer campaigning for the lease and waits for it to win it, nil without a lease backend
+func (h *Harness) campaign(t *testing.T) *ha.Elector {
+	if h.cfg.Lease == nil {
+		return nil
+	}
+	leader := ha.NewElector(h.cfg.Lease, fmt.Sprintf("sequencer-%d", h.failovers), leaseTTL)
+	ctx, cancel := context.WithCancel(h.ctx)
+	h.stopLeader = cancel
+	go leader.Run(ctx)
+	_, err := leader.WaitForLeadership(ctx)
+	require.NoError(t, err)
+	return leader
+}
+
+// Failover stops the sequencer campaigning, which gives up its lease and fences it out of the chain, and promotes the
+// RPC node in its place as a standby that won the lease does: it takes in what the old sequencer streamed and carries
+// on the chain it synced.  A new RPC node follows the promoted sequencer from genesis.  The old sequencer is returned.
+func (h *Harness) Failover(t *testing.T) *Node {
+	require.NotNil(t, h.cfg.Lease, "failover needs a lease backend")
+	old := h.Sequencer
+	h.stopLeader()
+	require.Eventually(t, func() bool { return !old.leader.IsLeader() }, 5*time.Second, 5*time.Millisecond, "sequencer kept the lease")
+
+	h.failovers++
+	leader := h.campaign(t)
+	h.SyncRpc(t)
+	old.stop()
+
+	ctx, cancel := context.WithCancel(h.ctx)
+	h.Rpc.promote(ctx, t, h.L1, leader)
+	// registered after the pool cleanup of the promoted node so it runs first and lets the pool loop exit
+	t.Cleanup(cancel)
+	h.Sequencer = h.Rpc
+	h.Rpc = newRpcNode(h.ctx, t, h.cfg, h.L1, h.Sequencer.streamAddr)
+	return old
+}
+
+// Address is the address of the funded account Transfer sends from
+func (h *Harness) Address() common.Address {
+	return crypto.PubkeyToAddress(h.key.PublicKey)
+}
+
+// Transfer signs a transfer from the funded account, it is not sent
+func (h *Harness) Transfer(t *testing.T, to common.Address, amount *uint256.Int) types.Transaction {
+	tx := types.NewTransaction(h.nonce, to, amount, 21000, uint256.NewInt(1_000_000_000), nil)
+	signed, err := types.SignTx(tx, *types.LatestSignerForChainID(h.Sequencer.ChainConfig.ChainID), h.key)
+	require.NoError(t, err)
+	h.nonce++
+	return signed
+}
+
+// SendTransactions adds transactions to the sequencer's pool
+func (h *Harness) SendTransactions(t *testing.T, txs ...types.Transaction) {
+	h.Sequencer.addTransactions(h.ctx, t, txs...)
+}
+
+// RefusedTransactions adds transactions to the sequencer's pool and returns why the pool refused each of them, an
+// empty string for those it took
+func (h *Harness) RefusedTransactions(t *testing.T, txs ...types.Transaction) []string {
+	reply := h.Sequencer.tryAddTransactions(h.ctx, t, txs...)
+	refused := make([]string, len(txs))
+	for i, imported := range reply.Imported {
+		if imported != txpool_proto.ImportResult_SUCCESS {
+			refused[i] = reply.Errors[i]
+		}
+	}
+	return refused
+}
+
+// ForwardTransactions makes the RPC node forward the transactions it is sent to the sequencer, served over http, or to
+// the pool manager when the config has one.  It checks them against its state first and tracks them until mined.
+func (h *Harness) ForwardTransactions(t *testing.T) {
+	h.Rpc.forwardTo(h.ctx, h.Sequencer.serveRpc(t))
+}
+
+// RpcSendTransaction sends a transaction to the RPC node as eth_sendRawTransaction does
+func (h *Harness) RpcSendTransaction(t *testing.T, txn types.Transaction) (common.Hash, error) {
+	var buf bytes.Buffer
+	require.NoError(t, txn.MarshalBinary(&buf))
+	t.Setenv(sequencer.SEQUENCER_ENV_KEY, "")
+	return h.Rpc.EthApi.SendRawTransaction(h.ctx, buf.Bytes())
+}
+
+// RpcNonce is the nonce of the funded account at a block of the RPC node as eth_getTransactionCount returns it
+func (h *Harness) RpcNonce(t *testing.T, block rpc.BlockNumber) uint64 {
+	t.Setenv(sequencer.SEQUENCER_ENV_KEY, "")
+	nonce, err := h.Rpc.EthApi.GetTransactionCount(h.ctx, h.Address(), &rpc.BlockNumberOrHash{BlockNumber: &block})
+	require.NoError(t, err)
+	return uint64(*nonce)
+}
+
+// ForwardedTransactions are the transactions the RPC node tracks as forwarded and not mined yet
+func (h *Harness) ForwardedTransactions() map[common.Address][]types.Transaction {
+	return h.Rpc.forwardedTxs.Content()
+}
+
+// ACL is the access control lists of the sequencer's pool
+func (h *Harness) ACL() *txpool.ACL {
+	return h.Sequencer.pool.ACL()
+}
+
+// SealBatch runs one sequencer cycle, sequencing the pending transactions into blocks until the batch is sealed, and
+// returns the number of the sealed batch
+func (h *Harness) SealBatch(t *testing.T) uint64 {
+	h.Sequencer.waitForL1(t, h.L1.LatestBlockNumber())
+	h.Sequencer.runCycle(h.ctx, t)
+	return h.sealedBatch(t)
+}
+
+// RequestSeal asks the sequencer to seal the batch of the next SealBatch after its first block, as
+// zkevmadmin_closeBatch does
+func (h *Harness) RequestSeal() {
+	h.Sequencer.sealer.Request()
+}
+
+// Admin is the zkevmadmin api of the sequencer
+func (h *Harness) Admin() *commands.ZkEvmAdminAPIImpl {
+	n := h.Sequencer
+	return commands.NewZkEvmAdminAPI(n.pool.ACL(), n.sealer, n.gasPrice, n.control)
+}
+
+// UnwindToBatch asks the paused sequencer to unwind to the end of a batch as zkevmadmin_unwindToBatch does and returns
+// the block it unwound to.  A sequencer cycle takes the request and the chain is unwound at the start of the next one,
+// both are run here.
+func (h *Harness) UnwindToBatch(t *testing.T, batch uint64) (uint64, error) {
+	type reply struct {
+		block hexutil.Uint64
+		err   error
+	}
+	replies := make(chan reply, 1)
+	ctx, cancel := context.WithCancel(h.ctx)
+	defer cancel()
+	t.Setenv(sequencer.SEQUENCER_ENV_KEY, "1")
+	go func() {
+		block, err := h.Admin().UnwindToBatch(ctx, hexutil.Uint64(batch))
+		replies <- reply{block: block, err: err}
+	}()
+	h.Sequencer.runCycle(h.ctx, t)
+	// a request the cycle didn't take is given up on
+	cancel()
+	r := <-replies
+	if r.err == nil {
+		h.Sequencer.runCycle(h.ctx, t)
+	}
+	return uint64(r.block), r.err
+}
+
+// SealReason is why the sequencer sealed a batch
+func (h *Harness) SealReason(t *testing.T, batch uint64) batch_sealing.Reason {
+	var reason string
+	require.NoError(t, h.Sequencer.DB.View(h.ctx, func(tx kv.Tx) error {
+		var err error
+		reason, err = hermez_db.NewHermezDbReader(tx).GetBatchSealReason(batch)
+		return err
+	}))
+	return batch_sealing.Reason(reason)
+}
+
+// SequenceBatches mines an L1 block sequencing every batch the sequencer has sealed
+func (h *Harness) SequenceBatches(t *testing.T) {
+	h.L1.SequenceBatches(h.sealedBatch(t), common.Hash{})
+	h.L1.Mine()
+}
+
+// VerifyBatches mines an L1 block verifying every batch the sequencer has sealed with the state root the sequencer
+// has for it
+func (h *Harness) VerifyBatches(t *testing.T) {
+	batch := h.sealedBatch(t)
+	var stateRoot common.Hash
+	require.NoError(t, h.Sequencer.DB.View(h.ctx, func(tx kv.Tx) error {
+		blockNo, err := hermez_db.NewHermezDbReader(tx).GetHighestBlockInBatch(batch)
+		if err != nil {
+			return err
+		}
+		header := rawdb.ReadHeaderByNumber(tx, blockNo)
+		require.NotNil(t, header, "no header for block %d", blockNo)
+		stateRoot = header.Root
+		return nil
+	}))
+	h.L1.VerifyBatches(batch, stateRoot, common.Hash{}, devnet.SequencerAddress)
+	h.L1.Mine()
+}
+
+// StreamVerified runs the executor verification stage of a sequencer with executors until every batch it sealed is
+// verified and written to its data stream.  The executor answers in the background so the stage is run on its own
+// until it has, a whole cycle would seal more blocks.
+func (h *Harness) StreamVerified(t *testing.T) {
+	batch := h.sealedBatch(t)
+	require.Eventually(t, func() bool {
+		require.NoError(t, h.Sequencer.tryStreamVerified(h.ctx))
+		return h.Progress(t, h.Sequencer, stages.SequenceExecutorVerify) >= batch
+	}, 10*time.Second, 10*time.Millisecond, "sequencer did not stream batch %d", batch)
+}
+
+// SyncRpc runs RPC node cycles until it has executed every block of the sequencer the data stream has handed over.
+// A block is only complete in the stream once the entry after it is written, and the last block of a batch is only
+// followed by the first block of the next one, so the RPC node stays a block behind the sequencer's head.  The batches
+// stage waits for a new block in the stream, so it is only run while there is one: L1 events mined after the last
+// sealed batch are picked up by the RPC node with the next batch.
+func (h *Harness) SyncRpc(t *testing.T) {
+	h.SyncFollower(t, h.Rpc)
+}
+
+// SyncFollower runs cycles of a node following the sequencer's data stream as SyncRpc does for the RPC node
+func (h *Harness) SyncFollower(t *testing.T, n *Node) {
+	streamed, err := h.DataStream().GetHighestBlockNumber()
+	require.NoError(t, err)
+	target := streamed - 1
+	for i := 0; i < maxRpcCycles; i++ {
+		if h.Progress(t, n, stages.Execution) >= target {
+			return
+		}
+		n.waitForL1(t, h.L1.LatestBlockNumber())
+		n.runCycle(h.ctx, t)
+	}
+	require.Failf(t, "node did not catch up", "node at block %d, expected block %d", h.Progress(t, n, stages.Execution), target)
+}
+
+// RequireSameState checks the RPC node has the block the sequencer has at the RPC node's head, with the same state
+// root, and returns the block number
+func (h *Harness) RequireSameState(t *testing.T) uint64 {
+	head := h.Progress(t, h.Rpc, stages.Execution)
+	require.LessOrEqual(t, head, h.Progress(t, h.Sequencer, stages.Execution), "rpc node is ahead of the sequencer")
+
+	sequencerHeader := h.header(t, h.Sequencer, head)
+	rpcHeader := h.header(t, h.Rpc, head)
+	require.Equal(t, sequencerHeader.Root, rpcHeader.Root, "state root of block %d differs", head)
+	require.Equal(t, sequencerHeader.Hash(), rpcHeader.Hash(), "hash of block %d differs", head)
+	return head
+}
+
+// Progress reads the progress of a stage of a node
+func (h *Harness) Progress(t *testing.T, n *Node, stage stages.SyncStage) uint64 {
+	var progress uint64
+	require.NoError(t, n.DB.View(h.ctx, func(tx kv.Tx) error {
+		var err error
+		progress, err = stages.GetStageProgress(tx, stage)
+		return err
+	}))
+	return progress
+}
+
+func (h *Harness) header(t *testing.T, n *Node, number uint64) *types.Header {
+	var header *types.Header
+	require.NoError(t, n.DB.View(h.ctx, func(tx kv.Tx) error {
+		header = rawdb.ReadHeaderByNumber(tx, number)
+		return nil
+	}))
+	require.NotNil(t, header, "no header for block %d", number)
+	return header
+}
+
+// sealedBatch is the highest batch the sequencer has sealed.  The batch of the last block is only sealed if the
+// cycle ended with it.
+func (h *Harness) sealedBatch(t *testing.T) uint64 {
+	return h.Progress(t, h.Sequencer, stages.HighestSeenBatchNumber)
+}
+
+// NewArchiveRpc creates another RPC node reading a datastream archive instead of the sequencer's stream
+func (h *Harness) NewArchiveRpc(t *testing.T, path string) *Node {
+	n := newRpcNode(h.ctx, t, h.cfg, h.L1, client.FileScheme+path)
+	t.Cleanup(n.stop)
+	return n
+}
+
+// NewVerifier creates a verifier following the sequencer's data stream, checking the batches it follows with the
+// stateless executor
+func (h *Harness) NewVerifier(t *testing.T) *Node {
+	n := newVerifierNode(h.ctx, t, h.cfg, h.L1, h.Sequencer.streamAddr)
+	t.Cleanup(n.stop)
+	return n
+}
+
+// VerifyFollowed runs the executor verification stage of a verifier until the batches up to batch are checked and
+// returns the batch the verifier stopped at.  The executors answer in the background so the stage is run on its own
+// until they have, a whole cycle would wait on the data stream for a new block.
+func (h *Harness) VerifyFollowed(t *testing.T, n *Node, batch uint64) uint64 {
+	var verified uint64
+	require.Eventually(t, func() bool {
+		require.NoError(t, n.DB.Update(h.ctx, func(tx kv.RwTx) error {
+			s, err := n.sync.StageState(stages.FollowerExecutorVerify, tx, n.DB)
+			if err != nil {
+				return err
+			}
+			if err = zkStages.SpawnFollowerExecutorVerifyStage(s, nil, tx, h.ctx, n.verify, false, true); err != nil {
+				return err
+			}
+			progress, err := stages.GetStageProgress(tx, stages.FollowerExecutorVerify)
+			if err != nil {
+				return err
+			}
+			verified, err = hermez_db.NewHermezDbReader(tx).GetBatchNoByL2Block(progress)
+			return err
+		}))
+		return verified >= batch
+	}, 10*time.Second, 10*time.Millisecond, "verifier did not check batch %d", batch)
+	return verified
+}
+
+// SyncArchiveRpc runs cycles of an RPC node reading an archive until it has executed every block of the archive.  The
+// batches stage reads the whole archive in the first cycle, it is not run again once the node executed all of it as
+// there is no new block to wait for.
+func (h *Harness) SyncArchiveRpc(t *testing.T, n *Node) {
+	for i := 0; i < maxRpcCycles; i++ {
+		n.waitForL1(t, h.L1.LatestBlockNumber())
+		n.runCycle(h.ctx, t)
+		if h.Progress(t, n, stages.Execution) >= h.Progress(t, n, stages.Batches) {
+			return
+		}
+	}
+	require.Failf(t, "rpc node did not execute the archive", "rpc node at block %d, archive read to block %d",
+		h.Progress(t, n, stages.Execution), h.Progress(t, n, stages.Batches))
+}
+
+// DataStream is the data stream the sequencer writes and serves
+func (h *Harness) DataStream() *server.DataStreamServer {
+	return server.NewDataStreamServer(h.Sequencer.stream, h.Sequencer.ChainConfig.ChainID.Uint64(), server.StandardOperationMode)
+}
@@ -0,0 +1,67 @@
+package e2e
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/zk/hermez_db"
+)
+
+func TestSequenceAndSync(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	h := New(t, DefaultConfig())
+	require.Equal(t, common.HexToAddress("0xa94f5374Fce5edBC8E2a8697C15331677e6EbF0B"), h.Address())
+
+	to := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	for i := 0; i < 5; i++ {
+		h.SendTransactions(t, h.Transfer(t, to, uint256.NewInt(1000)))
+	}
+	batch := h.SealBatch(t)
+
+	h.SequenceBatches(t)
+	h.SyncRpc(t)
+	h.RequireSameState(t)
+
+	require.NoError(t, h.Rpc.DB.View(context.Background(), func(tx kv.Tx) error {
+		balance, err := state.NewPlainStateReader(tx).ReadAccountData(to)
+		require.NoError(t, err)
+		require.NotNil(t, balance)
+		require.Equal(t, uint256.NewInt(5000), &balance.Balance)
+
+		sequence, err := hermez_db.NewHermezDbReader(tx).GetSequenceByBatchNo(batch)
+		require.NoError(t, err)
+		require.NotNil(t, sequence, "rpc node has no sequence for batch %d", batch)
+		return nil
+	}))
+
+	// the rpc node reads the verification when it syncs the next batch
+	h.VerifyBatches(t)
+	h.SealBatch(t)
+	h.SyncRpc(t)
+	h.RequireSameState(t)
+
+	require.GreaterOrEqual(t, h.Progress(t, h.Rpc, stages.L1VerificationsBatchNo), batch)
+	require.NoError(t, h.Rpc.DB.View(context.Background(), func(tx kv.Tx) error {
+		verification, err := hermez_db.NewHermezDbReader(tx).GetVerificationByBatchNo(batch)
+		require.NoError(t, err)
+		require.NotNil(t, verification, "rpc node has no verification for batch %d", batch)
+		return nil
+	}))
+
+	// the verified state root is only checked once the rpc node has seen the batch closed and a new L1 block
+	h.SequenceBatches(t)
+	h.SealBatch(t)
+	h.SyncRpc(t)
+	h.RequireSameState(t)
+	require.NotZero(t, h.Progress(t, h.Rpc, stages.VerificationsStateRootCheck))
+}
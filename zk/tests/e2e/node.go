@@ -0,0 +1,514 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/0xPolygonHermez/zkevm-data-streamer/datastreamer"
+	dslog "github.com/0xPolygonHermez/zkevm-data-streamer/log"
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/common/datadir"
+	txpool_proto "github.com/gateway-fm/cdk-erigon-lib/gointerfaces/txpool"
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+	"github.com/gateway-fm/cdk-erigon-lib/kv/kvcache"
+	"github.com/gateway-fm/cdk-erigon-lib/kv/memdb"
+	"github.com/gateway-fm/cdk-erigon-lib/txpool/txpoolcfg"
+	types2 "github.com/gateway-fm/cdk-erigon-lib/types"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/chain"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/commands"
+	"github.com/ledgerwatch/erigon/consensus"
+	"github.com/ledgerwatch/erigon/consensus/ethash/ethashcfg"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/eth/ethconsensusconfig"
+	"github.com/ledgerwatch/erigon/eth/stagedsync"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/rpc/rpccfg"
+	smtdb "github.com/ledgerwatch/erigon/smt/pkg/db"
+	"github.com/ledgerwatch/erigon/turbo/shards"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	stages2 "github.com/ledgerwatch/erigon/turbo/stages"
+	"github.com/ledgerwatch/erigon/zk/batch_sealing"
+	"github.com/ledgerwatch/erigon/zk/contracts"
+	"github.com/ledgerwatch/erigon/zk/datastream/client"
+	"github.com/ledgerwatch/erigon/zk/devnet"
+	"github.com/ledgerwatch/erigon/zk/gas_price"
+	"github.com/ledgerwatch/erigon/zk/ha"
+	"github.com/ledgerwatch/erigon/zk/hermez_db"
+	"github.com/ledgerwatch/erigon/zk/legacy_executor_verifier"
+	"github.com/ledgerwatch/erigon/zk/pool_manager"
+	"github.com/ledgerwatch/erigon/zk/preconfirmation"
+	"github.com/ledgerwatch/erigon/zk/sequencer"
+	"github.com/ledgerwatch/erigon/zk/sequencer_control"
+	zkStages "github.com/ledgerwatch/erigon/zk/stages"
+	"github.com/ledgerwatch/erigon/zk/stateless_executor"
+	"github.com/ledgerwatch/erigon/zk/syncer"
+	"github.com/ledgerwatch/erigon/zk/txpool"
+	"github.com/ledgerwatch/erigon/zk/txpool/txpooluitl"
+	"github.com/ledgerwatch/erigon/zk/witness"
+)
+
+// Node is a node of the harness, its chain db and the stage list it runs against it
+type Node struct {
+	DB          kv.RwDB
+	ChainConfig *chain.Config
+	Zk          *ethconfig.Zk
+	EthApi      *commands.APIImpl
+
+	isSequencer   bool
+	setup         *nodeSetup
+	sync          *stagedsync.Sync
+	notifications *shards.Notifications
+	syncers       []*syncer.L1Syncer
+	initialCycle  bool
+
+	// sequencer only
+	leader       *ha.Elector
+	sealer       *batch_sealing.Sealer
+	control      *sequencer_control.Control
+	gasPrice     *gas_price.Oracle
+	stream       *datastreamer.StreamServer
+	streamAddr   string
+	streamVerify zkStages.SequencerExecutorVerifyCfg
+	pool         *txpool.TxPool
+	poolServer   *txpool.GrpcServer
+	stateChanges *stateChanges
+
+	// Preconfirmations are those the sequencer gave
+	Preconfirmations *preconfirmation.Feed
+
+	// rpc node only
+	streamClient *client.StreamClient
+	forwardedTxs *commands.ForwardedTxs
+
+	// verifier only
+	verify zkStages.FollowerExecutorVerifyCfg
+}
+
+// nodeSetup is what both stage lists are built from
+type nodeSetup struct {
+	ethCfg      ethconfig.Config
+	engine      consensus.Engine
+	snapshots   *snapshotsync.RoSnapshots
+	blockReader *snapshotsync.BlockReaderWithSnapshots
+}
+
+func zkConfig(cfg Config) *ethconfig.Zk {
+	return &ethconfig.Zk{
+		L2ChainId:                              cfg.L1.L2ChainId,
+		L1ChainId:                              cfg.L1.L1ChainId,
+		AddressSequencer:                       cfg.L1.Sequencer,
+		AddressAdmin:                           devnet.AdminAddress,
+		AddressRollup:                          cfg.L1.RollupManager,
+		AddressZkevm:                           cfg.L1.Rollup,
+		AddressGerManager:                      cfg.L1.GerManager,
+		L1RollupId:                             cfg.L1.RollupId,
+		L1BlockRange:                           20000,
+		L1QueryDelay:                           10,
+		L1HighestBlockType:                     "latest",
+		L1FirstBlock:                           1,
+		DatastreamVersion:                      2,
+		SequencerInitialForkId:                 cfg.L1.ForkId,
+		SequencerBlockSealTime:                 cfg.BlockSealTime,
+		SequencerBatchSealTime:                 cfg.BatchSealTime,
+		SequencerNonEmptyBatchSealTime:         cfg.NonEmptyBatchSealTime,
+		SequencerSealCounterThreshold:          cfg.Sealing.CounterThreshold,
+		SequencerSealL1DataBudget:              cfg.Sealing.L1DataBudget,
+		SequencerSealOnL1InfoTree:              cfg.Sealing.OnL1InfoTreeUpdate,
+		SequencerTxOrdering:                    cfg.TxOrdering,
+		SequencerPoolManager:                   cfg.PoolManagerUrl != "",
+		PoolManagerUrl:                         cfg.PoolManagerUrl,
+		EffectiveGasPriceForEthTransfer:        255,
+		EffectiveGasPriceForErc20Transfer:      255,
+		EffectiveGasPriceForContractInvocation: 255,
+		EffectiveGasPriceForContractDeployment: 255,
+		RebuildTreeAfter:                       10000,
+		DefaultGasPrice:                        cfg.DefaultGasPrice,
+		GasPriceFactor:                         1,
+		GasPriceL1History:                      10,
+		SequencerPreconfirmationKey:            cfg.PreconfirmationKey,
+		ExecutorStateless:                      cfg.Executors,
+	}
+}
+
+// newNode creates an empty chain db with the genesis of the harness chain committed
+func newNode(t *testing.T, cfg Config, isSequencer bool) *Node {
+	dirs := datadir.New(t.TempDir())
+
+	genesis := core.HermezLocalDevnetGenesisBlock()
+	// the stages move the fork blocks of the chain config on so every node needs a copy of its own
+	chainConfig := *genesis.Config
+	chainConfig.ChainID = new(big.Int).SetUint64(cfg.L1.L2ChainId)
+	genesis.Config = &chainConfig
+
+	db := memdb.NewTestDB(t)
+	require.NoError(t, db.Update(context.Background(), func(tx kv.RwTx) error {
+		if err := hermez_db.CreateHermezBuckets(tx); err != nil {
+			return err
+		}
+		return smtdb.CreateEriDbBuckets(tx)
+	}))
+	_, _, err := core.CommitGenesisBlock(db, genesis, dirs.Tmp)
+	require.NoError(t, err)
+
+	ethCfg := ethconfig.Defaults
+	ethCfg.Dirs = dirs
+	ethCfg.Genesis = genesis
+	ethCfg.Zk = zkConfig(cfg)
+
+	snapshots := snapshotsync.NewRoSnapshots(ethCfg.Snapshot, dirs.Snap)
+	setup := &nodeSetup{
+		ethCfg:      ethCfg,
+		engine:      ethconsensusconfig.CreateConsensusEngine(&chainConfig, &ethashcfg.Config{PowMode: ethashcfg.ModeFake}, nil, true, "", "", true, dirs.DataDir, snapshots, false, db),
+		snapshots:   snapshots,
+		blockReader: snapshotsync.NewBlockReaderWithSnapshots(snapshots, ethCfg.TransactionsV3),
+	}
+
+	base := commands.NewBaseApi(nil, kvcache.NewDummy(), setup.blockReader, nil, false, rpccfg.DefaultEvmCallTimeout, setup.engine, dirs)
+
+	return &Node{
+		DB:            db,
+		ChainConfig:   &chainConfig,
+		Zk:            ethCfg.Zk,
+		EthApi:        commands.NewEthAPI(base, db, nil, nil, nil, 0, 0, &setup.ethCfg),
+		isSequencer:   isSequencer,
+		setup:         setup,
+		notifications: &shards.Notifications{Events: shards.NewEvents(), Accumulator: shards.NewAccumulator()},
+		initialCycle:  true,
+	}
+}
+
+func newL1Syncer(l1 syncer.IEtherman, zkCfg *ethconfig.Zk, addresses []common.Address, topics [][]common.Hash) *syncer.L1Syncer {
+	return syncer.NewL1Syncer([]syncer.IEtherman{l1}, addresses, topics, zkCfg.L1BlockRange, zkCfg.L1QueryDelay, zkCfg.L1HighestBlockType)
+}
+
+// newSequencer creates a sequencer with a pool and a data stream served on a free local port, wired up the way the
+// backend wires a sequencer, with the stateless executor when the config has executors.  It only seals blocks while
+// leader holds the lease when set.
+func newSequencer(ctx context.Context, t *testing.T, cfg Config, l1 syncer.IEtherman, leader *ha.Elector) *Node {
+	n := newNode(t, cfg, true)
+	n.leader = leader
+	n.startSequencing(ctx, t, l1)
+	return n
+}
+
+// promote turns an RPC node into the sequencer on the chain it synced, the way the backend promotes a standby
+// sequencer that won the lease
+func (n *Node) promote(ctx context.Context, t *testing.T, l1 syncer.IEtherman, leader *ha.Elector) {
+	n.stop()
+	n.syncers = nil
+	n.streamClient = nil
+	n.isSequencer = true
+	n.initialCycle = true
+	n.leader = leader
+	n.startSequencing(ctx, t, l1)
+}
+
+// startSequencing creates the pool, the data stream and the sequencer stages of the node
+func (n *Node) startSequencing(ctx context.Context, t *testing.T, l1 syncer.IEtherman) {
+	setup := n.setup
+	zkCfg := n.Zk
+	dirs := setup.ethCfg.Dirs
+
+	port := freePort(t)
+	var err error
+	n.stream, err = datastreamer.NewServer(port, uint8(zkCfg.DatastreamVersion), 1, datastreamer.StreamType(1), filepath.Join(dirs.DataDir, "data-stream"), &dslog.Config{Environment: "production", Level: "warn"})
+	require.NoError(t, err)
+	require.NoError(t, n.stream.Start())
+	n.streamAddr = fmt.Sprintf("127.0.0.1:%d", port)
+
+	// the sequencer has no data stream stage so the genesis goes into the stream the way the backend warms it up
+	t.Setenv(sequencer.SEQUENCER_ENV_KEY, "1")
+	require.NoError(t, n.DB.Update(ctx, func(tx kv.RwTx) error {
+		_, err := zkStages.CatchupDatastream("stream-catchup", tx, n.stream, n.ChainConfig.ChainID.Uint64(), zkCfg.DatastreamVersion)
+		return err
+	}))
+
+	n.sealer = batch_sealing.NewSealer(batch_sealing.ConfigFromZk(zkCfg))
+	n.control = sequencer_control.NewControl(sequencer_control.TimersFromZk(zkCfg))
+	n.Preconfirmations = preconfirmation.NewFeed()
+	n.stateChanges = newStateChanges()
+	n.notifications.StateChangesConsumer = n.stateChanges
+
+	poolCfg := txpoolcfg.DefaultConfig
+	poolCfg.DBDir = dirs.TxPool
+	newTxs := make(chan types2.Announcements, 1024)
+	poolDb, pool, fetch, send, poolServer, err := txpooluitl.AllComponents(ctx, poolCfg, &setup.ethCfg, kvcache.NewDummy(), newTxs, n.DB, nil, n.stateChanges)
+	require.NoError(t, err)
+	fetch.SetWaitGroup(n.stateChanges.wg)
+	fetch.ConnectCore()
+	poolDone := make(chan struct{})
+	go func() {
+		defer close(poolDone)
+		txpool.MainLoop(ctx, poolDb, n.DB, pool, newTxs, send, poolServer.NewSlotsStreams, func() {})
+	}()
+	t.Cleanup(func() {
+		<-poolDone
+		poolDb.Close()
+	})
+	n.pool = pool
+	n.poolServer = poolServer
+
+	// a promoted node carries on a chain the pool has not seen, as the backend does it tells the pool where it is
+	var executionProgress uint64
+	require.NoError(t, n.DB.View(ctx, func(tx kv.Tx) error {
+		executionProgress, err = stages.GetStageProgress(tx, stages.Execution)
+		return err
+	}))
+	pool.ForceUpdateLatestBlock(executionProgress)
+
+	l1Syncer := newL1Syncer(l1, zkCfg, []common.Address{zkCfg.AddressAdmin, zkCfg.AddressZkevm}, [][]common.Hash{{contracts.InitialSequenceBatchesTopic, contracts.ForceBatchTopic, contracts.UpdateZkEVMVersionTopic}})
+	l1InfoTreeSyncer := newL1Syncer(l1, zkCfg, []common.Address{zkCfg.AddressGerManager}, [][]common.Hash{{contracts.UpdateL1InfoTreeTopic}})
+	l1BlockSyncer := newL1Syncer(l1, zkCfg, []common.Address{zkCfg.AddressZkevm}, [][]common.Hash{{contracts.SequenceBatchesTopic}})
+	n.syncers = []*syncer.L1Syncer{l1Syncer, l1InfoTreeSyncer, l1BlockSyncer}
+
+	n.gasPrice = gas_price.NewOracle(gas_price.ConfigFromZk(zkCfg), l1Syncer)
+
+	ethCfg := setup.ethCfg
+	var executors []legacy_executor_verifier.ILegacyExecutor
+	var witnessGenerator legacy_executor_verifier.WitnessGenerator
+	if zkCfg.HasExecutors() {
+		witnessGenerator = witness.NewGenerator(dirs, ethCfg.HistoryV3, nil, setup.blockReader, n.ChainConfig, setup.engine)
+		executors = append(executors, stateless_executor.NewExecutor(n.ChainConfig, setup.engine))
+	}
+	verifier := legacy_executor_verifier.NewLegacyExecutorVerifier(*zkCfg, executors, n.ChainConfig, n.DB, witnessGenerator, l1Syncer, n.stream, n.leader)
+	n.streamVerify = zkStages.StageSequencerExecutorVerifyCfg(n.DB, verifier)
+
+	var poolManager *pool_manager.Client
+	if zkCfg.SequencerPoolManager {
+		poolManager, err = pool_manager.NewClient(zkCfg.PoolManagerUrl)
+		require.NoError(t, err)
+	}
+
+	preconfirmations := preconfirmation.NewSequencer(zkCfg.SequencerPreconfirmationKey, n.ChainConfig.ChainID.Uint64(), n.Preconfirmations)
+
+	n.sync = stagedsync.New(zkStages.SequencerZkStages(ctx,
+		stagedsync.StageCumulativeIndexCfg(n.DB),
+		zkStages.StageL1SequencerSyncCfg(n.DB, zkCfg, l1Syncer),
+		zkStages.StageL1InfoTreeCfg(n.DB, zkCfg, l1InfoTreeSyncer),
+		zkStages.StageSequencerL1BlockSyncCfg(n.DB, zkCfg, l1BlockSyncer),
+		zkStages.StageDataStreamCatchupCfg(n.stream, n.DB, n.ChainConfig.ChainID.Uint64(), zkCfg.DatastreamVersion),
+		zkStages.StageSequenceBlocksCfg(
+			n.DB,
+			ethCfg.Prune,
+			ethCfg.BatchSize,
+			nil,
+			n.ChainConfig,
+			setup.engine,
+			&vm.ZkConfig{},
+			n.notifications.Accumulator,
+			ethCfg.StateStream,
+			/*stateStream=*/ false,
+			ethCfg.HistoryV3,
+			dirs,
+			setup.blockReader,
+			ethCfg.Genesis,
+			ethCfg.Sync,
+			nil,
+			n.stream,
+			zkCfg,
+			pool,
+			poolDb,
+			n.gasPrice,
+			poolManager,
+			n.leader,
+			n.sealer,
+			n.control,
+			preconfirmations,
+		),
+		stagedsync.StageHashStateCfg(n.DB, dirs, ethCfg.HistoryV3, nil),
+		zkStages.StageZkInterHashesCfg(n.DB, true, true, false, dirs.Tmp, setup.blockReader, nil, ethCfg.HistoryV3, nil, zkCfg),
+		n.streamVerify,
+		stagedsync.StageHistoryCfg(n.DB, ethCfg.Prune, dirs.Tmp),
+		stagedsync.StageLogIndexCfg(n.DB, ethCfg.Prune, dirs.Tmp),
+		stagedsync.StageCallTracesCfg(n.DB, ethCfg.Prune, 0, dirs.Tmp),
+		stagedsync.StageTxLookupCfg(n.DB, ethCfg.Prune, dirs.Tmp, setup.snapshots, n.ChainConfig.Bor),
+		stagedsync.StageFinishCfg(n.DB, dirs.Tmp, nil),
+		false,
+	), zkStages.ZkSequencerUnwindOrder, nil)
+}
+
+// newRpcNode creates an RPC node reading the sequencer's data stream from streamAddr
+func newRpcNode(ctx context.Context, t *testing.T, cfg Config, l1 syncer.IEtherman, streamAddr string) *Node {
+	return newFollower(ctx, t, cfg, l1, streamAddr, false)
+}
+
+// newVerifierNode creates a verifier reading the sequencer's data stream from streamAddr and checking the batches it
+// follows with the stateless executor, the way the backend wires the verifier role
+func newVerifierNode(ctx context.Context, t *testing.T, cfg Config, l1 syncer.IEtherman, streamAddr string) *Node {
+	return newFollower(ctx, t, cfg, l1, streamAddr, true)
+}
+
+// newFollower creates a node following the data stream at streamAddr, with the executor verification of the batches
+// it follows when verify is set
+func newFollower(ctx context.Context, t *testing.T, cfg Config, l1 syncer.IEtherman, streamAddr string, verify bool) *Node {
+	n := newNode(t, cfg, false)
+	setup := n.setup
+	zkCfg := n.Zk
+	zkCfg.L2DataStreamerUrl = streamAddr
+	dirs := setup.ethCfg.Dirs
+
+	n.streamClient = client.NewClient(ctx, streamAddr, zkCfg.DatastreamVersion, 0)
+	require.NoError(t, n.streamClient.Start())
+
+	l1Syncer := newL1Syncer(l1, zkCfg, []common.Address{zkCfg.AddressRollup, zkCfg.AddressAdmin, zkCfg.AddressZkevm}, [][]common.Hash{{
+		contracts.SequencedBatchTopicPreEtrog,
+		contracts.SequencedBatchTopicEtrog,
+		contracts.VerificationTopicPreEtrog,
+		contracts.VerificationTopicEtrog,
+		contracts.UpdateZkEVMVersionTopic,
+	}})
+	l1InfoTreeSyncer := newL1Syncer(l1, zkCfg, []common.Address{zkCfg.AddressGerManager}, [][]common.Hash{{contracts.UpdateL1InfoTreeTopic}})
+	n.syncers = []*syncer.L1Syncer{l1Syncer, l1InfoTreeSyncer}
+
+	ethCfg := setup.ethCfg
+	var verifier *legacy_executor_verifier.LegacyExecutorVerifier
+	if verify {
+		witnessGenerator := witness.NewGenerator(dirs, ethCfg.HistoryV3, nil, setup.blockReader, n.ChainConfig, setup.engine)
+		executors := []legacy_executor_verifier.ILegacyExecutor{stateless_executor.NewExecutor(n.ChainConfig, setup.engine)}
+		verifier = legacy_executor_verifier.NewLegacyExecutorVerifier(*zkCfg, executors, n.ChainConfig, n.DB, witnessGenerator, l1Syncer, nil, nil)
+	}
+	n.verify = zkStages.StageFollowerExecutorVerifyCfg(n.DB, verifier)
+
+	blockRetire := snapshotsync.NewBlockRetire(1, dirs.Tmp, setup.snapshots, n.DB, nil, n.notifications.Events)
+	n.sync = stagedsync.New(zkStages.DefaultZkStages(ctx,
+		zkStages.StageL1SyncerCfg(n.DB, l1Syncer, zkCfg),
+		zkStages.StageL1InfoTreeCfg(n.DB, zkCfg, l1InfoTreeSyncer),
+		zkStages.StageBatchesCfg(n.DB, n.streamClient, zkCfg),
+		zkStages.StageDataStreamCatchupCfg(nil, n.DB, n.ChainConfig.ChainID.Uint64(), zkCfg.DatastreamVersion),
+		stagedsync.StageCumulativeIndexCfg(n.DB),
+		stagedsync.StageBlockHashesCfg(n.DB, dirs.Tmp, n.ChainConfig),
+		stagedsync.StageSendersCfg(n.DB, n.ChainConfig, false, dirs.Tmp, ethCfg.Prune, blockRetire, nil),
+		stagedsync.StageExecuteBlocksCfg(
+			n.DB,
+			ethCfg.Prune,
+			ethCfg.BatchSize,
+			nil,
+			n.ChainConfig,
+			setup.engine,
+			&vm.Config{},
+			n.notifications.Accumulator,
+			ethCfg.StateStream,
+			/*stateStream=*/ false,
+			ethCfg.HistoryV3,
+			dirs,
+			setup.blockReader,
+			nil,
+			ethCfg.Genesis,
+			ethCfg.Sync,
+			nil,
+			zkCfg,
+		),
+		stagedsync.StageHashStateCfg(n.DB, dirs, ethCfg.HistoryV3, nil),
+		zkStages.StageZkInterHashesCfg(n.DB, true, true, false, dirs.Tmp, setup.blockReader, nil, ethCfg.HistoryV3, nil, zkCfg),
+		n.verify,
+		stagedsync.StageHistoryCfg(n.DB, ethCfg.Prune, dirs.Tmp),
+		stagedsync.StageLogIndexCfg(n.DB, ethCfg.Prune, dirs.Tmp),
+		stagedsync.StageCallTracesCfg(n.DB, ethCfg.Prune, 0, dirs.Tmp),
+		stagedsync.StageTxLookupCfg(n.DB, ethCfg.Prune, dirs.Tmp, setup.snapshots, n.ChainConfig.Bor),
+		stagedsync.StageFinishCfg(n.DB, dirs.Tmp, nil),
+		false,
+	), zkStages.ZkUnwindOrder, nil)
+
+	return n
+}
+
+// runCycle runs one cycle of the stage loop the way the backend does.  Whether a node sequences is still read from
+// the environment by the stages so it is set for the cycle.
+func (n *Node) runCycle(ctx context.Context, t *testing.T) {
+	require.NoError(t, n.tryCycle(ctx, t))
+}
+
+// tryCycle runs one cycle of the stage loop and returns its error
+func (n *Node) tryCycle(ctx context.Context, t *testing.T) error {
+	if n.isSequencer {
+		t.Setenv(sequencer.SEQUENCER_ENV_KEY, "1")
+	} else {
+		t.Setenv(sequencer.SEQUENCER_ENV_KEY, "")
+	}
+
+	_, err := stages2.StageLoopStep(ctx, n.ChainConfig, n.DB, n.sync, n.notifications, n.initialCycle, func(context.Context, uint64, uint64, common.Hash, *uint256.Int) {})
+	if err != nil {
+		return err
+	}
+	n.initialCycle = false
+
+	// the pool has to know about the new blocks before anything else is sent to it
+	if n.stateChanges != nil {
+		n.stateChanges.wg.Wait()
+	}
+	return nil
+}
+
+// tryStreamVerified runs the executor verification stage of a sequencer on its own and returns its error
+func (n *Node) tryStreamVerified(ctx context.Context) error {
+	return n.DB.Update(ctx, func(tx kv.RwTx) error {
+		s, err := n.sync.StageState(stages.SequenceExecutorVerify, tx, n.DB)
+		if err != nil {
+			return err
+		}
+		return zkStages.SpawnSequencerExecutorVerifyStage(s, nil, tx, ctx, n.streamVerify, false, true)
+	})
+}
+
+// waitForL1 waits until every running L1 syncer of the node has seen the L1 up to head, so a cycle started after
+// it reads all of the L1 logs up to head
+func (n *Node) waitForL1(t *testing.T, head uint64) {
+	for _, s := range n.syncers {
+		if !s.IsSyncStarted() {
+			// the stage starts it and reads the L1 up to the latest block before it carries on
+			continue
+		}
+		require.Eventually(t, func() bool {
+			return s.IsDownloading() || s.GetLastCheckedL1Block() >= head
+		}, 10*time.Second, 5*time.Millisecond, "L1 syncer did not reach L1 block %d", head)
+	}
+}
+
+// addTransactions adds the transactions to the sequencer's pool the way eth_sendRawTransaction does
+func (n *Node) addTransactions(ctx context.Context, t *testing.T, txs ...types.Transaction) {
+	reply := n.tryAddTransactions(ctx, t, txs...)
+	for i, imported := range reply.Imported {
+		require.Equal(t, txpool_proto.ImportResult_SUCCESS, imported, "transaction %s was not added to the pool: %s", txs[i].Hash(), reply.Errors[i])
+	}
+}
+
+// tryAddTransactions adds the transactions to the sequencer's pool and returns the pool's reply, whether it took them
+// or not
+func (n *Node) tryAddTransactions(ctx context.Context, t *testing.T, txs ...types.Transaction) *txpool_proto.AddReply {
+	rlps, err := types.MarshalTransactionsBinary(txs)
+	require.NoError(t, err)
+
+	reply, err := n.poolServer.Add(ctx, &txpool_proto.AddRequest{RlpTxs: rlps})
+	require.NoError(t, err)
+	return reply
+}
+
+func (n *Node) stop() {
+	for _, s := range n.syncers {
+		if s.IsSyncStarted() {
+			// a syncer stuck handing over logs nobody reads would block the stop
+			go s.Stop()
+		}
+	}
+	if n.streamClient != nil {
+		n.streamClient.Stop()
+	}
+}
+
+func freePort(t *testing.T) uint16 {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	return uint16(listener.Addr().(*net.TCPAddr).Port)
+}
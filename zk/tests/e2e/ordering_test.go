@@ -0,0 +1,76 @@
+package e2e
+
+import (
+	"testing"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	zkStages "github.com/ledgerwatch/erigon/zk/stages"
+)
+
+func TestSequencerTxOrdering(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	tests := []struct {
+		ordering string
+		expected []string
+	}{
+		// the pricier transactions first, those of a sender by nonce
+		{zkStages.TxOrderingPrice, []string{"nonce0", "nonce1", "cheap"}},
+		// in the order they were received, the slots of a sender still taken by nonce
+		{zkStages.TxOrderingFifo, []string{"nonce0", "cheap", "nonce1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ordering, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.TxOrdering = tt.ordering
+			h := New(t, cfg)
+
+			key, err := crypto.GenerateKey()
+			require.NoError(t, err)
+			to := common.HexToAddress("0x1000000000000000000000000000000000000001")
+			h.SendTransactions(t, h.Transfer(t, crypto.PubkeyToAddress(key.PublicKey), uint256.NewInt(1_000_000_000_000_000_000)))
+			h.SealBatch(t)
+			from := h.Progress(t, h.Sequencer, stages.Execution)
+
+			sign := func(nonce uint64) types.Transaction {
+				tx := types.NewTransaction(nonce, to, uint256.NewInt(1), 21000, uint256.NewInt(10_000_000_000), nil)
+				signed, err := types.SignTx(tx, *types.LatestSignerForChainID(h.Sequencer.ChainConfig.ChainID), key)
+				require.NoError(t, err)
+				return signed
+			}
+			nonce1, nonce0 := sign(1), sign(0)
+			cheap := h.Transfer(t, to, uint256.NewInt(1))
+			names := map[common.Hash]string{nonce0.Hash(): "nonce0", nonce1.Hash(): "nonce1", cheap.Hash(): "cheap"}
+
+			// nonce 1 waits in the pool for nonce 0, which is received last
+			h.SendTransactions(t, nonce1)
+			h.SendTransactions(t, cheap, nonce0)
+			h.SealBatch(t)
+
+			var order []string
+			require.NoError(t, h.Sequencer.DB.View(h.ctx, func(tx kv.Tx) error {
+				to := h.Progress(t, h.Sequencer, stages.Execution)
+				for number := from + 1; number <= to; number++ {
+					block, err := rawdb.ReadBlockByNumber(tx, number)
+					require.NoError(t, err)
+					for _, txn := range block.Transactions() {
+						order = append(order, names[txn.Hash()])
+					}
+				}
+				return nil
+			}))
+			require.Equal(t, tt.expected, order)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package e2e
+
+import (
+	"crypto/ecdsa"
+	"testing"
+	"time"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/zk/pool_manager"
+)
+
+func TestPoolManager(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	mock := pool_manager.NewMock()
+	url, stop, err := mock.Serve()
+	require.NoError(t, err)
+	defer stop()
+
+	cfg := DefaultConfig()
+	cfg.PoolManagerUrl = url
+	h := New(t, cfg)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	to := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	sign := func(tx types.Transaction, key *ecdsa.PrivateKey) types.Transaction {
+		signed, err := types.SignTx(tx, *types.LatestSignerForChainID(h.Sequencer.ChainConfig.ChainID), key)
+		require.NoError(t, err)
+		return signed
+	}
+
+	included := h.Transfer(t, to, uint256.NewInt(1))
+	tooLow := sign(types.NewTransaction(included.GetNonce(), to, uint256.NewInt(2), 21000, uint256.NewInt(1_000_000_000), nil), h.key)
+	tooHigh := sign(types.NewTransaction(h.nonce+5, to, uint256.NewInt(1), 21000, uint256.NewInt(1_000_000_000), nil), h.key)
+	unfunded := sign(types.NewTransaction(0, to, uint256.NewInt(1), 21000, uint256.NewInt(1_000_000_000), nil), key)
+	require.NoError(t, mock.Add(tooHigh, unfunded, included, tooLow))
+
+	from := h.Progress(t, h.Sequencer, stages.Execution)
+	h.SealBatch(t)
+	require.Equal(t, []common.Hash{included.Hash()}, includedSince(t, h, from))
+
+	// the cycle's tx is committed after the stage, its included transactions are reported by the next one
+	h.SealBatch(t)
+
+	var reports map[common.Hash]pool_manager.Report
+	require.Eventually(t, func() bool {
+		reports = make(map[common.Hash]pool_manager.Report)
+		for _, report := range mock.Reports() {
+			reports[report.Hash] = report
+		}
+		return len(reports) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, pool_manager.StatusIncluded, reports[included.Hash()].Status)
+	require.Equal(t, from+1, reports[included.Hash()].BlockNumber)
+	require.Equal(t, pool_manager.StatusInvalidNonce, reports[tooLow.Hash()].Status)
+	require.Contains(t, reports[tooLow.Hash()].Error, "nonce too low")
+	// a nonce ahead of the state is left for the pool manager to hand out again
+	require.NotContains(t, reports, tooHigh.Hash())
+	require.Equal(t, pool_manager.StatusDiscarded, reports[unfunded.Hash()].Status)
+	require.Contains(t, reports[unfunded.Hash()].Error, "insufficient funds")
+}
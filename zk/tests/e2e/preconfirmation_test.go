@@ -0,0 +1,75 @@
+package e2e
+
+import (
+	"testing"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/commands"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+)
+
+func TestPreconfirmations(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	cfg := DefaultConfig()
+	cfg.PreconfirmationKey = key
+	h := New(t, cfg)
+	n := h.Sequencer
+	zkevm := commands.NewZkEvmAPI(n.EthApi, n.DB, 0, &n.setup.ethCfg, nil, n.Preconfirmations)
+
+	to := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	first, second := h.Transfer(t, to, uint256.NewInt(1)), h.Transfer(t, to, uint256.NewInt(1))
+	unknown, err := zkevm.GetPreconfirmation(h.ctx, first.Hash())
+	require.NoError(t, err)
+	require.Nil(t, unknown)
+
+	from := h.Progress(t, n, stages.Execution)
+	h.SendTransactions(t, first, second)
+	h.SealBatch(t)
+	require.Equal(t, []common.Hash{first.Hash(), second.Hash()}, includedSince(t, h, from))
+
+	// each preconfirmation is signed by the sequencer's key and agrees with the block the transaction went into
+	require.NoError(t, n.DB.View(h.ctx, func(tx kv.Tx) error {
+		for _, hash := range []common.Hash{first.Hash(), second.Hash()} {
+			p, err := zkevm.GetPreconfirmation(h.ctx, hash)
+			require.NoError(t, err)
+			require.NotNil(t, p)
+			signer, err := p.Signer(n.ChainConfig.ChainID.Uint64())
+			require.NoError(t, err)
+			require.Equal(t, crypto.PubkeyToAddress(key.PublicKey), signer)
+
+			block, err := rawdb.ReadBlockByNumber(tx, uint64(p.BlockNumber))
+			require.NoError(t, err)
+			require.Less(t, uint64(p.Index), uint64(block.Transactions().Len()))
+			require.Equal(t, hash, block.Transactions()[p.Index].Hash())
+			require.Equal(t, uint64(1), uint64(p.Status))
+		}
+		return nil
+	}))
+
+	// a preconfirmation is a promise only, it outlives the block it was given for being unwound
+	kept := h.SealBatch(t)
+	third := h.Transfer(t, to, uint256.NewInt(1))
+	h.SendTransactions(t, third)
+	h.SealBatch(t)
+	p, err := zkevm.GetPreconfirmation(h.ctx, third.Hash())
+	require.NoError(t, err)
+	require.NotNil(t, p)
+	require.NoError(t, h.Admin().Pause(h.ctx))
+	_, err = h.UnwindToBatch(t, kept)
+	require.NoError(t, err)
+	require.Less(t, h.Progress(t, n, stages.Execution), uint64(p.BlockNumber))
+	broken, err := zkevm.GetPreconfirmation(h.ctx, third.Hash())
+	require.NoError(t, err)
+	require.Equal(t, p, broken)
+}
@@ -0,0 +1,78 @@
+package e2e
+
+import (
+	"testing"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/zk/batch_sealing"
+)
+
+func TestSealReasons(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	h := New(t, DefaultConfig())
+	to := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	require.Equal(t, batch_sealing.ReasonInjected, h.SealReason(t, 1))
+
+	h.SendTransactions(t, h.Transfer(t, to, uint256.NewInt(1)))
+	batch := h.SealBatch(t)
+	require.Equal(t, batch_sealing.ReasonNonEmptyBatchTimer, h.SealReason(t, batch))
+
+	// an empty batch runs until the batch timer unless it is asked to seal
+	batch = h.SealBatch(t)
+	require.Equal(t, batch_sealing.ReasonBatchTimer, h.SealReason(t, batch))
+
+	from := h.Progress(t, h.Sequencer, stages.Execution)
+	h.RequestSeal()
+	batch = h.SealBatch(t)
+	require.Equal(t, batch_sealing.ReasonAdmin, h.SealReason(t, batch))
+	require.Equal(t, from+1, h.Progress(t, h.Sequencer, stages.Execution), "the batch went on past its first block")
+
+	h.SyncRpc(t)
+	h.RequireSameState(t)
+}
+
+func TestSealOnL1DataBudget(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	cfg := DefaultConfig()
+	// a transfer takes a little over a hundred bytes of batch L2 data
+	cfg.Sealing.L1DataBudget = 300
+	h := New(t, cfg)
+	to := common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	var sent []common.Hash
+	var transfers []types.Transaction
+	for i := 0; i < 5; i++ {
+		transfer := h.Transfer(t, to, uint256.NewInt(1))
+		transfers = append(transfers, transfer)
+		sent = append(sent, transfer.Hash())
+	}
+	h.SendTransactions(t, transfers...)
+
+	from := h.Progress(t, h.Sequencer, stages.Execution)
+	batch := h.SealBatch(t)
+	require.Equal(t, batch_sealing.ReasonL1DataBudget, h.SealReason(t, batch))
+	included := includedSince(t, h, from)
+	require.NotEmpty(t, included)
+	require.Less(t, len(included), len(sent))
+
+	// the rest are left for the next batches in the order they were sent
+	for i := 0; i < len(sent) && len(included) < len(sent); i++ {
+		h.SealBatch(t)
+		included = includedSince(t, h, from)
+	}
+	require.Equal(t, sent, included)
+
+	h.SyncRpc(t)
+	h.RequireSameState(t)
+}
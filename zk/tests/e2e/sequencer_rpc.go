@@ -0,0 +1,111 @@
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/common/hexutility"
+	"github.com/gateway-fm/cdk-erigon-lib/gointerfaces"
+	"github.com/gateway-fm/cdk-erigon-lib/gointerfaces/remote"
+	txpool_proto "github.com/gateway-fm/cdk-erigon-lib/gointerfaces/txpool"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/commands"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+)
+
+// sequencerRpc serves the part of the sequencer's eth api an RPC node forwards to.  The nodes of the harness share the
+// process, and with it whether the node sequences, so the sequencer's APIImpl can't answer while an RPC node calls
+// it: the requests are answered from the sequencer's pool and state the way it does.
+type sequencerRpc struct {
+	n *Node
+}
+
+// serveRpc starts serving the sequencer's rpc over http and returns its url
+func (n *Node) serveRpc(t *testing.T) string {
+	srv := rpc.NewServer(0, false, false)
+	require.NoError(t, srv.RegisterName("eth", &sequencerRpc{n}))
+	httpSrv := httptest.NewServer(srv)
+	t.Cleanup(func() {
+		httpSrv.Close()
+		srv.Stop()
+	})
+	return httpSrv.URL
+}
+
+func (api *sequencerRpc) SendRawTransaction(ctx context.Context, encodedTx hexutility.Bytes) (common.Hash, error) {
+	reply, err := api.n.poolServer.Add(ctx, &txpool_proto.AddRequest{RlpTxs: [][]byte{encodedTx}})
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if reply.Imported[0] != txpool_proto.ImportResult_SUCCESS {
+		return common.Hash{}, fmt.Errorf("%s: %s", txpool_proto.ImportResult_name[int32(reply.Imported[0])], reply.Errors[0])
+	}
+	txn, err := types.DecodeTransaction(rlp.NewStream(bytes.NewReader(encodedTx), uint64(len(encodedTx))))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return txn.Hash(), nil
+}
+
+func (api *sequencerRpc) GetTransactionCount(ctx context.Context, address common.Address, blockNrOrHash *rpc.BlockNumberOrHash) (*hexutil.Uint64, error) {
+	if blockNrOrHash != nil && blockNrOrHash.BlockNumber != nil && *blockNrOrHash.BlockNumber == rpc.PendingBlockNumber {
+		reply, err := api.n.poolServer.Nonce(ctx, &txpool_proto.NonceRequest{Address: gointerfaces.ConvertAddressToH160(address)})
+		if err != nil {
+			return nil, err
+		}
+		if reply.Found {
+			reply.Nonce++
+			return (*hexutil.Uint64)(&reply.Nonce), nil
+		}
+	}
+	tx, err := api.n.DB.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	acc, err := state.NewPlainStateReader(tx).ReadAccountData(address)
+	if err != nil || acc == nil {
+		return new(hexutil.Uint64), err
+	}
+	return (*hexutil.Uint64)(&acc.Nonce), nil
+}
+
+// forwardTo makes an RPC node forward the transactions it is sent to url, checking them against its state first and
+// tracking them until the new heads of the node show them mined, as the rpc daemon does with a forwarded txs ttl set
+func (n *Node) forwardTo(ctx context.Context, url string) {
+	n.forwardedTxs = commands.NewForwardedTxs(forwardedTxsTTL)
+	n.forwardedTxs.PruneMined(n.DB, n.newHeads(ctx))
+	n.EthApi.SetL2RpcUrl(url)
+	n.EthApi.SetForwardedTxs(n.forwardedTxs)
+}
+
+// newHeads returns filters notified of the headers the node's cycles add, as the rpc daemon's filters are through the
+// backend's events
+func (n *Node) newHeads(ctx context.Context) *rpchelper.Filters {
+	filters := rpchelper.New(ctx, nil, nil, nil, func() {})
+	headers, unsubscribe := n.notifications.Events.AddHeaderSubscription()
+	go func() {
+		defer unsubscribe()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case rlps := <-headers:
+				for _, header := range rlps {
+					filters.OnNewEvent(&remote.SubscribeReply{Type: remote.Event_HEADER, Data: header})
+				}
+			}
+		}
+	}()
+	return filters
+}
@@ -0,0 +1,48 @@
+package e2e
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gateway-fm/cdk-erigon-lib/gointerfaces/remote"
+	"google.golang.org/grpc"
+)
+
+// stateChanges hands the state changes of the sequencer cycles straight to the pool fetcher.  Unlike the kv server
+// pub/sub nothing is dropped before the fetcher subscribes, and the wait group lets a cycle wait until the pool has
+// seen every block it made.
+type stateChanges struct {
+	ch chan *remote.StateChangeBatch
+	wg *sync.WaitGroup
+}
+
+func newStateChanges() *stateChanges {
+	return &stateChanges{
+		ch: make(chan *remote.StateChangeBatch, 1024),
+		wg: &sync.WaitGroup{},
+	}
+}
+
+func (s *stateChanges) SendStateChanges(ctx context.Context, sc *remote.StateChangeBatch) {
+	s.wg.Add(1)
+	s.ch <- sc
+}
+
+func (s *stateChanges) StateChanges(ctx context.Context, in *remote.StateChangeRequest, opts ...grpc.CallOption) (remote.KV_StateChangesClient, error) {
+	return &stateChangesStream{ctx: ctx, ch: s.ch}, nil
+}
+
+type stateChangesStream struct {
+	grpc.ClientStream
+	ctx context.Context
+	ch  chan *remote.StateChangeBatch
+}
+
+func (s *stateChangesStream) Recv() (*remote.StateChangeBatch, error) {
+	select {
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	case sc := <-s.ch:
+		return sc, nil
+	}
+}
@@ -0,0 +1,43 @@
+package e2e
+
+import (
+	"testing"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/zk/hermez_db"
+)
+
+func TestVerifierChecksFollowedBatches(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	h := New(t, DefaultConfig())
+	verifier := h.NewVerifier(t)
+
+	to := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	for batch := 0; batch < 3; batch++ {
+		h.SendTransactions(t, h.Transfer(t, to, uint256.NewInt(1000)), h.Transfer(t, to, uint256.NewInt(1000)))
+		h.SealBatch(t)
+	}
+	h.SyncFollower(t, verifier)
+
+	// the verifier stays a block behind the sequencer's head, the batch of its head could still be open
+	sealed := h.sealedBatch(t)
+	verified := h.VerifyFollowed(t, verifier, sealed-1)
+	require.Equal(t, sealed-1, verified)
+
+	// the witnesses the executor checked are kept and nothing is written to a stream, the verifier has none
+	require.NoError(t, verifier.DB.View(h.ctx, func(tx kv.Tx) error {
+		witness, err := hermez_db.NewHermezDbReader(tx).GetWitness(verified)
+		require.NoError(t, err)
+		require.NotEmpty(t, witness)
+		return nil
+	}))
+	require.Zero(t, h.Progress(t, verifier, stages.DataStream))
+}
@@ -0,0 +1,172 @@
+package e2e
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/commands"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/zk/batch_sealing"
+	"github.com/ledgerwatch/erigon/zk/txpool"
+)
+
+func TestAdminPause(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	h := New(t, DefaultConfig())
+	admin := h.Admin()
+	to := common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	require.NoError(t, admin.Pause(h.ctx))
+	status, err := admin.Status(h.ctx)
+	require.NoError(t, err)
+	require.True(t, status.Paused)
+
+	// a paused sequencer doesn't open a batch
+	from := h.Progress(t, h.Sequencer, stages.Execution)
+	transfer := h.Transfer(t, to, uint256.NewInt(1))
+	h.SendTransactions(t, transfer)
+	h.SealBatch(t)
+	require.Equal(t, from, h.Progress(t, h.Sequencer, stages.Execution))
+
+	require.NoError(t, admin.Resume(h.ctx))
+	h.SealBatch(t)
+	require.Equal(t, []common.Hash{transfer.Hash()}, includedSince(t, h, from))
+
+	h.SyncRpc(t)
+	h.RequireSameState(t)
+}
+
+func TestAdminSealSettings(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	h := New(t, DefaultConfig())
+	admin := h.Admin()
+
+	require.Error(t, admin.SetSealTimers(h.ctx, commands.SealTimers{BlockSealTime: "100ms", BatchSealTime: "soon", NonEmptyBatchSealTime: "300ms"}))
+	require.Error(t, admin.SetSealTimers(h.ctx, commands.SealTimers{BlockSealTime: "100ms", BatchSealTime: "0s", NonEmptyBatchSealTime: "300ms"}))
+	timers := commands.SealTimers{BlockSealTime: "50ms", BatchSealTime: "200ms", NonEmptyBatchSealTime: "100ms"}
+	require.NoError(t, admin.SetSealTimers(h.ctx, timers))
+	status, err := admin.Status(h.ctx)
+	require.NoError(t, err)
+	require.Equal(t, timers, status.Timers)
+
+	gasPrice, err := admin.GasPriceConfig(h.ctx)
+	require.NoError(t, err)
+	gasPrice.DefaultGasPrice = 1_234_567_890
+	require.NoError(t, admin.SetGasPriceConfig(h.ctx, *gasPrice))
+	gasPrice.MaxGasPrice = 1
+	require.Error(t, admin.SetGasPriceConfig(h.ctx, *gasPrice))
+
+	// the empty batch runs until the shorter batch timer, about four blocks of the shorter block timer
+	from := h.Progress(t, h.Sequencer, stages.Execution)
+	batch := h.SealBatch(t)
+	require.Equal(t, batch_sealing.ReasonBatchTimer, h.SealReason(t, batch))
+	require.LessOrEqual(t, h.Progress(t, h.Sequencer, stages.Execution)-from, uint64(6))
+
+	price, err := h.Sequencer.EthApi.GasPrice(h.ctx)
+	require.NoError(t, err)
+	require.Equal(t, (*hexutil.Big)(big.NewInt(1_230_000_000)), price)
+
+	require.NoError(t, admin.CloseBatch(h.ctx))
+	batch = h.SealBatch(t)
+	require.Equal(t, batch_sealing.ReasonAdmin, h.SealReason(t, batch))
+
+	require.NoError(t, commands.NewAdminSealAPI(admin).SealBatch(h.ctx))
+	batch = h.SealBatch(t)
+	require.Equal(t, batch_sealing.ReasonAdmin, h.SealReason(t, batch))
+}
+
+func TestAdminBanTransaction(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	h := New(t, DefaultConfig())
+	admin := h.Admin()
+	to := common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	// a banned transaction is refused by the pool until it is unbanned
+	transfer := h.Transfer(t, to, uint256.NewInt(1))
+	require.NoError(t, admin.BanTransaction(h.ctx, []common.Hash{transfer.Hash()}))
+	banned, err := admin.BannedTransactions(h.ctx)
+	require.NoError(t, err)
+	require.Equal(t, []common.Hash{transfer.Hash()}, banned)
+	require.Equal(t, []string{txpool.TxBanned.String()}, h.RefusedTransactions(t, transfer))
+
+	require.NoError(t, admin.UnbanTransaction(h.ctx, []common.Hash{transfer.Hash()}))
+	banned, err = admin.BannedTransactions(h.ctx)
+	require.NoError(t, err)
+	require.Empty(t, banned)
+	from := h.Progress(t, h.Sequencer, stages.Execution)
+	h.SendTransactions(t, transfer)
+	h.SealBatch(t)
+	require.Equal(t, []common.Hash{transfer.Hash()}, includedSince(t, h, from))
+
+	// one banned once it is in the pool is dropped by the sequencer
+	pooled := h.Transfer(t, to, uint256.NewInt(1))
+	h.SendTransactions(t, pooled)
+	require.NoError(t, admin.BanTransaction(h.ctx, []common.Hash{pooled.Hash()}))
+	from = h.Progress(t, h.Sequencer, stages.Execution)
+	h.SealBatch(t)
+	require.Empty(t, includedSince(t, h, from))
+
+	h.SyncRpc(t)
+	h.RequireSameState(t)
+}
+
+func TestAdminUnwindToBatch(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	h := New(t, DefaultConfig())
+	admin := h.Admin()
+	to := common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	h.SendTransactions(t, h.Transfer(t, to, uint256.NewInt(1)))
+	kept := h.SealBatch(t)
+	keptHead := h.Progress(t, h.Sequencer, stages.Execution)
+
+	h.SendTransactions(t, h.Transfer(t, to, uint256.NewInt(1)))
+	h.SealBatch(t)
+	latest := h.SealBatch(t)
+	head := h.Progress(t, h.Sequencer, stages.Execution)
+
+	// only a paused sequencer unwinds, and never past the injected batch
+	_, err := admin.UnwindToBatch(h.ctx, hexutil.Uint64(kept))
+	require.Error(t, err)
+	require.NoError(t, admin.Pause(h.ctx))
+	_, err = h.UnwindToBatch(t, 0)
+	require.Error(t, err)
+	_, err = h.UnwindToBatch(t, latest)
+	require.Error(t, err)
+	require.Equal(t, head, h.Progress(t, h.Sequencer, stages.Execution))
+
+	block, err := h.UnwindToBatch(t, kept)
+	require.NoError(t, err)
+	require.Equal(t, keptHead, block)
+	require.Equal(t, keptHead, h.Progress(t, h.Sequencer, stages.Execution))
+	require.Equal(t, kept, h.sealedBatch(t))
+	streamed, err := h.DataStream().GetHighestBlockNumber()
+	require.NoError(t, err)
+	require.Equal(t, keptHead, streamed)
+
+	// the sequencer carries on from the batch it unwound to
+	require.NoError(t, admin.Resume(h.ctx))
+	require.Equal(t, kept+1, h.SealBatch(t))
+	require.Greater(t, h.Progress(t, h.Sequencer, stages.Execution), keptHead)
+	h.SealBatch(t)
+
+	h.SyncRpc(t)
+	h.RequireSameState(t)
+}
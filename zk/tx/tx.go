@@ -493,6 +493,31 @@ func ComputeL2TxHash(
 	return common.HexToHash(hashed), nil
 }
 
+// ComputeL2TxHashForTransaction computes the L2 tx hash of a signed transaction, recovering the sender from the
+// signature if it hasn't already been cached on the transaction
+func ComputeL2TxHashForTransaction(tx types.Transaction) (common.Hash, error) {
+	sender, ok := tx.GetSender()
+	if !ok {
+		signer := types.LatestSignerForChainID(tx.GetChainID().ToBig())
+		var err error
+		sender, err = tx.Sender(*signer)
+		if err != nil {
+			return common.Hash{}, err
+		}
+	}
+
+	return ComputeL2TxHash(
+		tx.GetChainID().ToBig(),
+		tx.GetValue(),
+		tx.GetPrice(),
+		tx.GetNonce(),
+		tx.GetGas(),
+		tx.GetTo(),
+		&sender,
+		tx.GetData(),
+	)
+}
+
 func formatL2TxHashParam(param interface{}, paramLength int) (string, error) {
 	var paramStr string
 
@@ -0,0 +1,337 @@
+package txpool
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	"github.com/gateway-fm/cdk-erigon-lib/kv"
+	"github.com/gateway-fm/cdk-erigon-lib/types"
+
+	eritypes "github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+/*
+the access control lists of a permissioned chain, who may send transactions and deploy contracts, and the
+transactions an operator banned.  They are kept in the pool db and in memory, the pool checks every transaction it
+receives against them and the sequencer checks them again when it adds a transaction to a block, so a change also
+applies to the transactions already in the pool.
+*/
+
+const ACL_MODES = "acl_modes"           // list -> enforced
+const ACL_ADDRESSES = "acl_addresses"   // list + address -> empty
+const ACL_BANNED_TXS = "acl_banned_txs" // tx hash -> empty
+
+// ACLList is one of the access control lists, each list is only enforced once its mode is enabled
+type ACLList uint8
+
+const (
+	// ACLDeployAllowlist only lets the senders on the list deploy contracts
+	ACLDeployAllowlist ACLList = iota + 1
+	// ACLSendAllowlist only lets the senders on the list send transactions
+	ACLSendAllowlist
+	// ACLBlocklist refuses the transactions from and to the addresses on the list
+	ACLBlocklist
+)
+
+var ACLLists = []ACLList{ACLDeployAllowlist, ACLSendAllowlist, ACLBlocklist}
+
+func (l ACLList) String() string {
+	switch l {
+	case ACLDeployAllowlist:
+		return "deploy-allowlist"
+	case ACLSendAllowlist:
+		return "send-allowlist"
+	case ACLBlocklist:
+		return "blocklist"
+	default:
+		return fmt.Sprintf("unknown acl list %d", uint8(l))
+	}
+}
+
+func ParseACLList(name string) (ACLList, error) {
+	for _, l := range ACLLists {
+		if l.String() == name {
+			return l, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown acl list %q, expected one of %s, %s, %s", name, ACLDeployAllowlist, ACLSendAllowlist, ACLBlocklist)
+}
+
+// ACLListContent is a list and whether it is enforced
+type ACLListContent struct {
+	List      string           `json:"list"`
+	Enabled   bool             `json:"enabled"`
+	Addresses []common.Address `json:"addresses"`
+}
+
+type ACL struct {
+	db   kv.RwDB
+	lock sync.RWMutex
+
+	enabled   map[ACLList]bool
+	addresses map[ACLList]map[common.Address]struct{}
+	banned    map[common.Hash]struct{}
+}
+
+// NewACL creates the tables of the lists in the db if needed and loads them
+func NewACL(ctx context.Context, db kv.RwDB) (*ACL, error) {
+	a := &ACL{
+		db:        db,
+		enabled:   make(map[ACLList]bool),
+		addresses: make(map[ACLList]map[common.Address]struct{}),
+		banned:    make(map[common.Hash]struct{}),
+	}
+	for _, l := range ACLLists {
+		a.addresses[l] = make(map[common.Address]struct{})
+	}
+
+	if err := db.Update(ctx, func(tx kv.RwTx) error {
+		for _, table := range []string{ACL_MODES, ACL_ADDRESSES, ACL_BANNED_TXS} {
+			if err := tx.CreateBucket(table); err != nil {
+				return err
+			}
+		}
+		if err := tx.ForEach(ACL_MODES, nil, func(k, v []byte) error {
+			a.enabled[ACLList(k[0])] = len(v) > 0 && v[0] == 1
+			return nil
+		}); err != nil {
+			return err
+		}
+		if err := tx.ForEach(ACL_ADDRESSES, nil, func(k, _ []byte) error {
+			if list, ok := a.addresses[ACLList(k[0])]; ok {
+				list[common.BytesToAddress(k[1:])] = struct{}{}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		return tx.ForEach(ACL_BANNED_TXS, nil, func(k, _ []byte) error {
+			a.banned[common.BytesToHash(k)] = struct{}{}
+			return nil
+		})
+	}); err != nil {
+		return nil, fmt.Errorf("load acl: %w", err)
+	}
+	return a, nil
+}
+
+// Check returns the reason to refuse a transaction of the sender to the recipient, nil for a deployment, or Success.
+// A nil ACL allows everything.
+func (a *ACL) Check(sender common.Address, to *common.Address) DiscardReason {
+	if a == nil {
+		return Success
+	}
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	if a.enabled[ACLBlocklist] {
+		if _, ok := a.addresses[ACLBlocklist][sender]; ok {
+			return SenderBlocked
+		}
+		if to != nil {
+			if _, ok := a.addresses[ACLBlocklist][*to]; ok {
+				return RecipientBlocked
+			}
+		}
+	}
+	if a.enabled[ACLSendAllowlist] {
+		if _, ok := a.addresses[ACLSendAllowlist][sender]; !ok {
+			return SenderNotAllowed
+		}
+	}
+	if to == nil && a.enabled[ACLDeployAllowlist] {
+		if _, ok := a.addresses[ACLDeployAllowlist][sender]; !ok {
+			return DeployNotAllowed
+		}
+	}
+	return Success
+}
+
+// checksRecipient tells whether Check needs the recipient of a call, only the blocklist has recipients in it
+func (a *ACL) checksRecipient() bool {
+	if a == nil {
+		return false
+	}
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	return a.enabled[ACLBlocklist] && len(a.addresses[ACLBlocklist]) > 0
+}
+
+// SetEnabled enforces a list or stops enforcing it
+func (a *ACL) SetEnabled(ctx context.Context, list ACLList, enabled bool) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	v := []byte{0}
+	if enabled {
+		v[0] = 1
+	}
+	if err := a.db.Update(ctx, func(tx kv.RwTx) error {
+		return tx.Put(ACL_MODES, []byte{byte(list)}, v)
+	}); err != nil {
+		return err
+	}
+	a.enabled[list] = enabled
+	return nil
+}
+
+// Add adds addresses to a list
+func (a *ACL) Add(ctx context.Context, list ACLList, addresses ...common.Address) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if err := a.db.Update(ctx, func(tx kv.RwTx) error {
+		for _, address := range addresses {
+			if err := tx.Put(ACL_ADDRESSES, aclKey(list, address), []byte{}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, address := range addresses {
+		a.addresses[list][address] = struct{}{}
+	}
+	return nil
+}
+
+// Remove removes addresses from a list
+func (a *ACL) Remove(ctx context.Context, list ACLList, addresses ...common.Address) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if err := a.db.Update(ctx, func(tx kv.RwTx) error {
+		for _, address := range addresses {
+			if err := tx.Delete(ACL_ADDRESSES, aclKey(list, address)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, address := range addresses {
+		delete(a.addresses[list], address)
+	}
+	return nil
+}
+
+// Lists returns the content of every list, the addresses sorted
+func (a *ACL) Lists() []ACLListContent {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	result := make([]ACLListContent, 0, len(ACLLists))
+	for _, l := range ACLLists {
+		content := ACLListContent{List: l.String(), Enabled: a.enabled[l], Addresses: make([]common.Address, 0, len(a.addresses[l]))}
+		for address := range a.addresses[l] {
+			content.Addresses = append(content.Addresses, address)
+		}
+		sort.Slice(content.Addresses, func(i, j int) bool {
+			return bytes.Compare(content.Addresses[i][:], content.Addresses[j][:]) < 0
+		})
+		result = append(result, content)
+	}
+	return result
+}
+
+// IsBanned tells whether an operator banned the transaction, a nil ACL bans nothing
+func (a *ACL) IsBanned(txHash common.Hash) bool {
+	if a == nil {
+		return false
+	}
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	_, ok := a.banned[txHash]
+	return ok
+}
+
+// Ban refuses the transactions from now on, those already in the pool are dropped by the sequencer when it comes to
+// them
+func (a *ACL) Ban(ctx context.Context, txHashes ...common.Hash) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if err := a.db.Update(ctx, func(tx kv.RwTx) error {
+		for _, txHash := range txHashes {
+			if err := tx.Put(ACL_BANNED_TXS, txHash[:], []byte{}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, txHash := range txHashes {
+		a.banned[txHash] = struct{}{}
+	}
+	return nil
+}
+
+func (a *ACL) Unban(ctx context.Context, txHashes ...common.Hash) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if err := a.db.Update(ctx, func(tx kv.RwTx) error {
+		for _, txHash := range txHashes {
+			if err := tx.Delete(ACL_BANNED_TXS, txHash[:]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, txHash := range txHashes {
+		delete(a.banned, txHash)
+	}
+	return nil
+}
+
+// Banned returns the banned transactions sorted
+func (a *ACL) Banned() []common.Hash {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	result := make([]common.Hash, 0, len(a.banned))
+	for txHash := range a.banned {
+		result = append(result, txHash)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return bytes.Compare(result[i][:], result[j][:]) < 0
+	})
+	return result
+}
+
+func aclKey(list ACLList, address common.Address) []byte {
+	return append([]byte{byte(list)}, address[:]...)
+}
+
+// checkACLLocked checks a transaction the pool received, its recipient is only decoded when the blocklist needs it
+func (p *TxPool) checkACLLocked(txn *types.TxSlot) DiscardReason {
+	if p.acl == nil {
+		return Success
+	}
+	if p.acl.IsBanned(txn.IDHash) {
+		return TxBanned
+	}
+	sender := p.senders.senderID2Addr[txn.SenderID]
+	if txn.Creation {
+		return p.acl.Check(sender, nil)
+	}
+
+	to := &common.Address{}
+	if p.acl.checksRecipient() && len(txn.Rlp) > 0 {
+		// the pool has parsed it already, should it fail here the sequencer still checks the recipient
+		if decoded, err := eritypes.DecodeTransaction(rlp.NewStream(bytes.NewReader(txn.Rlp), uint64(len(txn.Rlp)))); err == nil {
+			to = decoded.GetTo()
+		}
+	}
+	return p.acl.Check(sender, to)
+}
@@ -141,6 +141,11 @@ const (
 	InitCodeTooLarge    DiscardReason = 22 // EIP-3860 - transaction init code is too large
 	UnsupportedTx       DiscardReason = 23 // unsupported transaction type
 	OverflowZkCounters  DiscardReason = 24 // unsupported transaction type
+	SenderNotAllowed    DiscardReason = 25 // the send allowlist is enforced and does not have the sender
+	DeployNotAllowed    DiscardReason = 26 // the deploy allowlist is enforced and does not have the sender
+	SenderBlocked       DiscardReason = 27 // the sender is on the blocklist
+	RecipientBlocked    DiscardReason = 28 // the recipient is on the blocklist
+	TxBanned            DiscardReason = 29 // an operator banned the transaction
 )
 
 func (r DiscardReason) String() string {
@@ -195,6 +200,16 @@ func (r DiscardReason) String() string {
 		return "unsupported transaction type"
 	case OverflowZkCounters:
 		return "overflow zk-counters"
+	case SenderNotAllowed:
+		return "sender not allowed to send transactions"
+	case DeployNotAllowed:
+		return "sender not allowed to deploy contracts"
+	case SenderBlocked:
+		return "sender is blocked"
+	case RecipientBlocked:
+		return "recipient is blocked"
+	case TxBanned:
+		return "transaction is banned"
 	default:
 		panic(fmt.Sprintf("discard reason: %d", r))
 	}
@@ -210,6 +225,7 @@ type metaTx struct {
 	bestIndex                         int
 	worstIndex                        int
 	timestamp                         uint64 // when it was added to pool
+	arrival                           uint64 // order the pool received the transactions in
 	subPool                           SubPoolMarker
 	currentSubPool                    SubPoolType
 	alreadyYielded                    bool
@@ -217,7 +233,7 @@ type metaTx struct {
 }
 
 func newMetaTx(slot *types.TxSlot, isLocal bool, timestmap uint64) *metaTx {
-	mt := &metaTx{Tx: slot, worstIndex: -1, bestIndex: -1, timestamp: timestmap}
+	mt := &metaTx{Tx: slot, worstIndex: -1, bestIndex: -1, timestamp: timestmap, arrival: arrivals.Add(1)}
 	if isLocal {
 		mt.subPool = IsLocal
 	}
@@ -303,6 +319,7 @@ type TxPool struct {
 	shanghaiTime            *big.Int
 	isPostShanghai          atomic.Bool
 	allowFreeTransactions   bool
+	acl                     *ACL
 
 	// we cannot be in a flushing state whilst getting transactions from the pool, so we have this mutex which is
 	// exposed publicly so anything wanting to get "best" transactions can ensure a flush isn't happening and
@@ -310,7 +327,7 @@ type TxPool struct {
 	flushMtx *sync.Mutex
 }
 
-func New(newTxs chan types.Announcements, coreDB kv.RoDB, cfg txpoolcfg.Config, ethCfg *ethconfig.Config, cache kvcache.Cache, chainID uint256.Int, shanghaiTime *big.Int, londonBlock *big.Int) (*TxPool, error) {
+func New(newTxs chan types.Announcements, coreDB kv.RoDB, cfg txpoolcfg.Config, ethCfg *ethconfig.Config, cache kvcache.Cache, chainID uint256.Int, shanghaiTime *big.Int, londonBlock *big.Int, acl *ACL) (*TxPool, error) {
 	var err error
 	localsHistory, err := simplelru.NewLRU[string, struct{}](10_000, nil)
 	if err != nil {
@@ -351,6 +368,7 @@ func New(newTxs chan types.Announcements, coreDB kv.RoDB, cfg txpoolcfg.Config,
 		londonBlock:             londonBlock,
 		shanghaiTime:            shanghaiTime,
 		allowFreeTransactions:   ethCfg.AllowFreeTransactions,
+		acl:                     acl,
 		flushMtx:                &sync.Mutex{},
 	}, nil
 }
@@ -615,12 +633,12 @@ func (p *TxPool) ResetYieldedStatus() {
 }
 
 func (p *TxPool) YieldBest(n uint16, txs *types.TxsRlp, tx kv.Tx, onTopOf, availableGas uint64, toSkip mapset.Set[[32]byte]) (bool, int, error) {
-	return p.best(n, txs, tx, onTopOf, availableGas, toSkip)
+	return p.best(n, txs, tx, onTopOf, availableGas, toSkip, nil)
 }
 
 func (p *TxPool) PeekBest(n uint16, txs *types.TxsRlp, tx kv.Tx, onTopOf, availableGas uint64) (bool, error) {
 	set := mapset.NewThreadUnsafeSet[[32]byte]()
-	onTime, _, err := p.best(n, txs, tx, onTopOf, availableGas, set)
+	onTime, _, err := p.best(n, txs, tx, onTopOf, availableGas, set, nil)
 	return onTime, err
 }
 
@@ -656,6 +674,13 @@ func (p *TxPool) validateTx(txn *types.TxSlot, isLocal bool, stateCache kvcache.
 		return UnsupportedTx
 	}
 
+	if reason := p.checkACLLocked(txn); reason != Success {
+		if txn.Traced {
+			log.Info(fmt.Sprintf("TX TRACING: validateTx refused by acl idHash=%x reason=%s", txn.IDHash, reason))
+		}
+		return reason
+	}
+
 	// Drop non-local transactions under our own minimal accepted gas price or tip
 	if !isLocal && uint256.NewInt(p.cfg.MinFeeCap).Cmp(&txn.FeeCap) == 1 {
 		if txn.Traced {
@@ -1421,6 +1446,9 @@ func (p *TxPool) flushLocked(tx kv.RwTx) (err error) {
 			if err := tx.Delete(kv.PoolTransaction, idHash); err != nil {
 				return err
 			}
+			if err := deleteArrival(tx, idHash); err != nil {
+				return err
+			}
 		}
 		p.deletedTxs[i] = nil // for gc
 	}
@@ -1461,6 +1489,9 @@ func (p *TxPool) flushLocked(tx kv.RwTx) (err error) {
 			if err := tx.Put(kv.PoolTransaction, []byte(txHash), v); err != nil {
 				return err
 			}
+			if err := putArrival(tx, metaTx); err != nil {
+				return err
+			}
 		}
 		metaTx.Tx.Rlp = nil
 	}
@@ -1563,6 +1594,9 @@ func (p *TxPool) fromDB(ctx context.Context, tx kv.Tx, coreTx kv.Tx) error {
 		pendingBaseFee, math.MaxUint64 /* blockGasLimit */, p.pending, p.baseFee, p.queued, p.all, p.byHash, p.addLocked, p.discardLocked, false); err != nil {
 		return err
 	}
+	if err := restoreArrivals(tx, p.byHash); err != nil {
+		return err
+	}
 	p.pendingBaseFee.Store(pendingBaseFee)
 
 	return nil
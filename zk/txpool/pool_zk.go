@@ -2,7 +2,10 @@ package txpool
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
+	"sort"
+	"sync/atomic"
 
 	mapset "github.com/deckarep/golang-set/v2"
 	libcommon "github.com/gateway-fm/cdk-erigon-lib/common"
@@ -144,9 +147,125 @@ func onSenderStateChange(senderID uint64, senderNonce uint64, senderBalance uint
 	}
 }
 
+// arrivals numbers the transactions in the order the pool receives them
+var arrivals atomic.Uint64
+
+// PoolArrivalKeyPrefix keys the arrival of each transaction kept in the db, so the pool keeps its order over a restart
+var PoolArrivalKeyPrefix = []byte("arrival_")
+
+func arrivalKey(idHash []byte) []byte {
+	return append(libcommon.Copy(PoolArrivalKeyPrefix), idHash...)
+}
+
+func putArrival(tx kv.RwTx, mt *metaTx) error {
+	v := make([]byte, 8)
+	binary.BigEndian.PutUint64(v, mt.arrival)
+	return tx.Put(kv.PoolInfo, arrivalKey(mt.Tx.IDHash[:]), v)
+}
+
+func deleteArrival(tx kv.RwTx, idHash []byte) error {
+	return tx.Delete(kv.PoolInfo, arrivalKey(idHash))
+}
+
+// restoreArrivals gives the transactions loaded from the db the arrivals they were kept with, the transactions received
+// from then on arrive after them.  Those kept without one, by a pool from before arrivals were kept, arrive after those
+// with one.
+func restoreArrivals(tx kv.Tx, byHash map[string]*metaTx) error {
+	var unnumbered []*metaTx
+	for hash, mt := range byHash {
+		v, err := tx.GetOne(kv.PoolInfo, arrivalKey([]byte(hash)))
+		if err != nil {
+			return err
+		}
+		if len(v) != 8 {
+			unnumbered = append(unnumbered, mt)
+			continue
+		}
+		mt.arrival = binary.BigEndian.Uint64(v)
+		for {
+			last := arrivals.Load()
+			if last >= mt.arrival || arrivals.CompareAndSwap(last, mt.arrival) {
+				break
+			}
+		}
+	}
+	for _, mt := range unnumbered {
+		mt.arrival = arrivals.Add(1)
+	}
+	return nil
+}
+
+// PendingTx is what an ordering of the pending transactions knows about a transaction of the pool
+type PendingTx struct {
+	IDHash  [32]byte
+	Sender  libcommon.Address
+	Nonce   uint64
+	Tip     uint256.Int
+	FeeCap  uint256.Int
+	Gas     uint64
+	IsLocal bool
+	// Timestamp is the block the pool was at when it received the transaction
+	Timestamp uint64
+	// Arrival is the order the pool received the transactions in
+	Arrival uint64
+
+	mt *metaTx
+}
+
+// TxOrder orders the candidates for the transactions to yield.  Candidates it leaves out are not yielded this time,
+// and the transactions of a sender are yielded in nonce order whatever order it returns them in.
+type TxOrder func(candidates []*PendingTx) []*PendingTx
+
+// YieldBestOrdered is YieldBest with the pending transactions taken in the order of the given ordering instead of the
+// pool's price and nonce order
+func (p *TxPool) YieldBestOrdered(n uint16, txs *types.TxsRlp, tx kv.Tx, onTopOf, availableGas uint64, toSkip mapset.Set[[32]byte], order TxOrder) (bool, int, error) {
+	return p.best(n, txs, tx, onTopOf, availableGas, toSkip, order)
+}
+
+// orderLocked orders the pending transactions not yet yielded.  An ordering only decides which slots a sender gets,
+// the transactions of the sender fill them by nonce as a transaction can't be executed ahead of its lower nonces.
+func (p *TxPool) orderLocked(ms []*metaTx, toSkip mapset.Set[[32]byte], order TxOrder) []*metaTx {
+	candidates := make([]*PendingTx, 0, len(ms))
+	for _, mt := range ms {
+		if toSkip.Contains(mt.Tx.IDHash) {
+			continue
+		}
+		candidates = append(candidates, &PendingTx{
+			IDHash:    mt.Tx.IDHash,
+			Sender:    p.senders.senderID2Addr[mt.Tx.SenderID],
+			Nonce:     mt.Tx.Nonce,
+			Tip:       mt.Tx.Tip,
+			FeeCap:    mt.Tx.FeeCap,
+			Gas:       mt.Tx.Gas,
+			IsLocal:   mt.subPool&IsLocal > 0,
+			Timestamp: mt.timestamp,
+			Arrival:   mt.arrival,
+			mt:        mt,
+		})
+	}
+
+	ordered := order(candidates)
+
+	bySender := make(map[uint64][]*metaTx)
+	for _, c := range ordered {
+		bySender[c.mt.Tx.SenderID] = append(bySender[c.mt.Tx.SenderID], c.mt)
+	}
+	for _, senderTxs := range bySender {
+		sort.Slice(senderTxs, func(i, j int) bool { return senderTxs[i].Tx.Nonce < senderTxs[j].Tx.Nonce })
+	}
+	result := make([]*metaTx, len(ordered))
+	for i, c := range ordered {
+		senderTxs := bySender[c.mt.Tx.SenderID]
+		result[i] = senderTxs[0]
+		bySender[c.mt.Tx.SenderID] = senderTxs[1:]
+	}
+	return result
+}
+
 // zk: the implementation of best here is changed only to not take into account block gas limits as we don't care about
-// these in zk.  Instead we do a quick check on the transaction maximum gas in zk
-func (p *TxPool) best(n uint16, txs *types.TxsRlp, tx kv.Tx, onTopOf, availableGas uint64, toSkip mapset.Set[[32]byte]) (bool, int, error) {
+// these in zk.  Instead we do a quick check on the transaction maximum gas in zk.  An ordering replaces the order of the
+// pending pool for the transactions yielded.
+func (p *TxPool) best(n uint16, txs *types.TxsRlp, tx kv.Tx, onTopOf, availableGas uint64, toSkip mapset.Set[[32]byte], order TxOrder) (bool, int, error) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
@@ -158,19 +277,22 @@ func (p *TxPool) best(n uint16, txs *types.TxsRlp, tx kv.Tx, onTopOf, availableG
 	isShanghai := p.isShanghai()
 	isLondon := p.isLondon()
 	_ = isLondon
-	best := p.pending.best
+	candidates := p.pending.best.ms
+	if order != nil {
+		candidates = p.orderLocked(candidates, toSkip, order)
+	}
 
-	txs.Resize(uint(cmp.Min(int(n), len(best.ms))))
+	txs.Resize(uint(cmp.Min(int(n), len(candidates))))
 	var toRemove []*metaTx
 	count := 0
 
-	for i := 0; count < int(n) && i < len(best.ms); i++ {
+	for i := 0; count < int(n) && i < len(candidates); i++ {
 		// if we wouldn't have enough gas for a standard transaction then quit out early
 		if availableGas < fixedgas.TxGas {
 			break
 		}
 
-		mt := best.ms[i]
+		mt := candidates[i]
 
 		if toSkip.Contains(mt.Tx.IDHash) {
 			continue
@@ -226,6 +348,30 @@ func (p *TxPool) best(n uint16, txs *types.TxsRlp, tx kv.Tx, onTopOf, availableG
 	return true, count, nil
 }
 
+// ACL is the access control lists of the pool, nil if it has none or there is no pool
+func (p *TxPool) ACL() *ACL {
+	if p == nil {
+		return nil
+	}
+	return p.acl
+}
+
+// DiscardFromPending discards a pending transaction the sequencer refused to add to a block
+func (p *TxPool) DiscardFromPending(txHash libcommon.Hash, reason DiscardReason) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	best := p.pending.best
+	for i := 0; i < len(best.ms); i++ {
+		mt := best.ms[i]
+		if bytes.Equal(mt.Tx.IDHash[:], txHash[:]) {
+			p.pending.Remove(mt)
+			p.discardLocked(mt, reason)
+			break
+		}
+	}
+}
+
 func (p *TxPool) ForceUpdateLatestBlock(blockNumber uint64) {
 	if p != nil {
 		p.lastSeenBlock.Store(blockNumber)
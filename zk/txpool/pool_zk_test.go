@@ -0,0 +1,49 @@
+package txpool
+
+import (
+	"testing"
+
+	"github.com/gateway-fm/cdk-erigon-lib/kv/memdb"
+	"github.com/gateway-fm/cdk-erigon-lib/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestoreArrivals(t *testing.T) {
+	_, tx := memdb.NewTestPoolTx(t)
+
+	metaTxs := func(hashes ...byte) map[string]*metaTx {
+		byHash := make(map[string]*metaTx)
+		for _, hash := range hashes {
+			slot := &types.TxSlot{}
+			slot.IDHash[0] = hash
+			byHash[string(slot.IDHash[:])] = newMetaTx(slot, false, 0)
+		}
+		return byHash
+	}
+
+	// the first two are kept received in the reverse of their hash order, the third is dropped from the db
+	kept := metaTxs(1, 2, 3)
+	first, second, dropped := kept[string(hashOf(1))], kept[string(hashOf(2))], kept[string(hashOf(3))]
+	first.arrival, second.arrival = second.arrival, first.arrival
+	for _, mt := range kept {
+		require.NoError(t, putArrival(tx, mt))
+	}
+	require.NoError(t, deleteArrival(tx, dropped.Tx.IDHash[:]))
+
+	// after a restart the pool numbers from where it was, here it is behind the kept arrivals
+	arrivals.Store(0)
+	loaded := metaTxs(1, 2, 3)
+	require.NoError(t, restoreArrivals(tx, loaded))
+	require.Equal(t, first.arrival, loaded[string(hashOf(1))].arrival)
+	require.Equal(t, second.arrival, loaded[string(hashOf(2))].arrival)
+	require.Greater(t, loaded[string(hashOf(1))].arrival, loaded[string(hashOf(2))].arrival)
+	// the one kept without an arrival, and those received from then on, arrive after the kept ones
+	require.Greater(t, loaded[string(hashOf(3))].arrival, first.arrival)
+	require.Greater(t, newMetaTx(&types.TxSlot{}, false, 0).arrival, loaded[string(hashOf(3))].arrival)
+}
+
+func hashOf(b byte) []byte {
+	hash := make([]byte, 32)
+	hash[0] = b
+	return hash
+}
@@ -124,7 +124,12 @@ func AllComponents(ctx context.Context, cfg txpoolcfg.Config, ethCfg *ethconfig.
 		shanghaiTime = cfg.OverrideShanghaiTime
 	}
 
-	txPool, err := txpool.New(newTxs, chainDB, cfg, ethCfg, cache, *chainID, shanghaiTime, chainConfig.LondonBlock)
+	acl, err := txpool.NewACL(ctx, txPoolDB)
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
+	txPool, err := txpool.New(newTxs, chainDB, cfg, ethCfg, cache, *chainID, shanghaiTime, chainConfig.LondonBlock, acl)
 	if err != nil {
 		return nil, nil, nil, nil, nil, err
 	}
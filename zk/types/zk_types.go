@@ -76,6 +76,34 @@ func (l *L1InfoTreeUpdate) Unmarshall(input []byte) {
 	}
 }
 
+// ForkInterval is a fork activation as announced on L1 by the UpdateZkEVMVersion event
+type ForkInterval struct {
+	ForkId          uint64
+	FromBatchNumber uint64
+	L1BlockNumber   uint64
+	Version         string
+}
+
+func (f *ForkInterval) Marshall() []byte {
+	result := make([]byte, 0, 24+len(f.Version))
+	result = append(result, utils.Uint64ToLE(f.ForkId)...)
+	result = append(result, utils.Uint64ToLE(f.FromBatchNumber)...)
+	result = append(result, utils.Uint64ToLE(f.L1BlockNumber)...)
+	result = append(result, []byte(f.Version)...)
+	return result
+}
+
+func (f *ForkInterval) Unmarshall(input []byte) error {
+	if len(input) < 24 {
+		return fmt.Errorf("unmarshall error, input is too short")
+	}
+	f.ForkId = binary.LittleEndian.Uint64(input[:8])
+	f.FromBatchNumber = binary.LittleEndian.Uint64(input[8:16])
+	f.L1BlockNumber = binary.LittleEndian.Uint64(input[16:24])
+	f.Version = string(input[24:])
+	return nil
+}
+
 type L1InjectedBatch struct {
 	L1BlockNumber      uint64
 	Timestamp          uint64
@@ -117,3 +145,83 @@ func (ib *L1InjectedBatch) Unmarshall(input []byte) error {
 	ib.Transaction = append([]byte{}, input[132:]...)
 	return nil
 }
+
+// L1ForcedBatch is a batch forced on L1 through the rollup contract, it must be sequenced with the GER, timestamp and
+// L1 block hash it was forced with
+type L1ForcedBatch struct {
+	ForcedBatchNumber uint64
+	L1BlockNumber     uint64
+	Timestamp         uint64
+	L1ParentHash      common.Hash
+	GlobalExitRoot    common.Hash
+	Sequencer         common.Address
+	Transactions      []byte
+}
+
+func (fb *L1ForcedBatch) Marshall() []byte {
+	result := make([]byte, 0)
+	result = append(result, utils.Uint64ToLE(fb.ForcedBatchNumber)...)
+	result = append(result, utils.Uint64ToLE(fb.L1BlockNumber)...)
+	result = append(result, utils.Uint64ToLE(fb.Timestamp)...)
+	result = append(result, fb.L1ParentHash[:]...)
+	result = append(result, fb.GlobalExitRoot[:]...)
+	result = append(result, fb.Sequencer[:]...)
+	result = append(result, fb.Transactions...)
+	return result
+}
+
+func (fb *L1ForcedBatch) Unmarshall(input []byte) error {
+	if len(input) < 108 {
+		return fmt.Errorf("unmarshall error, input is too short")
+	}
+	fb.ForcedBatchNumber = binary.LittleEndian.Uint64(input[:8])
+	fb.L1BlockNumber = binary.LittleEndian.Uint64(input[8:16])
+	fb.Timestamp = binary.LittleEndian.Uint64(input[16:24])
+	copy(fb.L1ParentHash[:], input[24:56])
+	copy(fb.GlobalExitRoot[:], input[56:88])
+	copy(fb.Sequencer[:], input[88:108])
+	fb.Transactions = append([]byte{}, input[108:]...)
+	return nil
+}
+
+// UnexecutedTransaction is a transaction of a forced batch the executor doesn't execute, because it is invalid or the
+// batch is.  It stays in the batch data, so it is kept at its index in the block for the data stream.
+type UnexecutedTransaction struct {
+	Index                       uint64
+	EffectiveGasPricePercentage uint8
+	Encoded                     []byte // the transaction rlp encoded
+}
+
+// MarshallUnexecutedTransactions encodes the unexecuted transactions of a block, each as its index, percentage, the
+// length of its encoding and the encoding
+func MarshallUnexecutedTransactions(txs []UnexecutedTransaction) []byte {
+	result := make([]byte, 0)
+	for _, tx := range txs {
+		result = append(result, utils.Uint64ToLE(tx.Index)...)
+		result = append(result, tx.EffectiveGasPricePercentage)
+		result = append(result, utils.Uint64ToLE(uint64(len(tx.Encoded)))...)
+		result = append(result, tx.Encoded...)
+	}
+	return result
+}
+
+func UnmarshallUnexecutedTransactions(input []byte) ([]UnexecutedTransaction, error) {
+	var txs []UnexecutedTransaction
+	for len(input) > 0 {
+		if len(input) < 17 {
+			return nil, fmt.Errorf("unmarshall error, input is too short")
+		}
+		tx := UnexecutedTransaction{
+			Index:                       binary.LittleEndian.Uint64(input[:8]),
+			EffectiveGasPricePercentage: input[8],
+		}
+		size := binary.LittleEndian.Uint64(input[9:17])
+		if uint64(len(input)-17) < size {
+			return nil, fmt.Errorf("unmarshall error, transaction is too short")
+		}
+		tx.Encoded = append([]byte{}, input[17:17+size]...)
+		txs = append(txs, tx)
+		input = input[17+size:]
+	}
+	return txs, nil
+}
@@ -11,6 +11,12 @@ import (
 	"github.com/ledgerwatch/log/v3"
 )
 
+const (
+	preForkId7BlockGasLimit = 30_000_000
+	forkId7BlockGasLimit    = 18446744073709551615 // 0xffffffffffffffff
+	forkId8BlockGasLimit    = 1125899906842624     // 0x4000000000000
+)
+
 // if current sync is before verified batch - short circuit to verified batch, otherwise to enx of next batch
 // if there is no new fully downloaded batch - do not short circuit
 // returns (shouldShortCircuit, blockNumber, error)
@@ -123,3 +129,17 @@ func RecoverySetBlockConfigForks(blockNum uint64, forkId uint64, cfg ForkConfigW
 
 	return nil
 }
+
+func GetBlockGasLimitForFork(forkId uint64) uint64 {
+	if forkId >= 8 {
+		return forkId8BlockGasLimit
+	}
+
+	// [hack] the rpc returns forkid8 value, but forkid7 is used in execution
+	if forkId == 7 {
+		return forkId8BlockGasLimit
+		// return forkId7BlockGasLimit
+	}
+
+	return preForkId7BlockGasLimit
+}
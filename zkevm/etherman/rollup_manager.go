@@ -0,0 +1,107 @@
+package etherman
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	ethereum "github.com/ledgerwatch/erigon"
+	"github.com/ledgerwatch/erigon/accounts/abi"
+)
+
+// RollupManagerReadAbi is the subset of the rollup manager and rollup contracts needed to bootstrap a chain
+const RollupManagerReadAbi = `[
+	{"name":"rollupIDToRollupData","type":"function","stateMutability":"view","inputs":[{"name":"rollupID","type":"uint32"}],"outputs":[
+		{"name":"rollupContract","type":"address"},
+		{"name":"chainID","type":"uint64"},
+		{"name":"verifier","type":"address"},
+		{"name":"forkID","type":"uint64"},
+		{"name":"lastLocalExitRoot","type":"bytes32"},
+		{"name":"lastBatchSequenced","type":"uint64"},
+		{"name":"lastVerifiedBatch","type":"uint64"},
+		{"name":"lastPendingState","type":"uint64"},
+		{"name":"lastPendingStateConsolidated","type":"uint64"},
+		{"name":"lastVerifiedBatchBeforeUpgrade","type":"uint64"},
+		{"name":"rollupTypeID","type":"uint64"},
+		{"name":"rollupCompatibilityID","type":"uint8"}]},
+	{"name":"rollupTypeMap","type":"function","stateMutability":"view","inputs":[{"name":"rollupTypeID","type":"uint32"}],"outputs":[
+		{"name":"consensusImplementation","type":"address"},
+		{"name":"verifier","type":"address"},
+		{"name":"forkID","type":"uint64"},
+		{"name":"rollupCompatibilityID","type":"uint8"},
+		{"name":"obsolete","type":"bool"},
+		{"name":"genesis","type":"bytes32"}]},
+	{"name":"trustedSequencer","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]},
+	{"name":"globalExitRootManager","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]}
+]`
+
+// RollupInfo is the configuration of a rollup as registered in the rollup manager on L1
+type RollupInfo struct {
+	RollupContract        common.Address
+	ChainID               uint64
+	ForkID                uint64
+	RollupTypeID          uint64
+	GenesisRoot           common.Hash
+	TrustedSequencer      common.Address
+	GlobalExitRootManager common.Address
+}
+
+// GetRollupInfo reads the chain id, fork id, genesis root and trusted sequencer of a rollup from the rollup manager
+func GetRollupInfo(ctx context.Context, client ethereum.ContractCaller, rollupManager common.Address, rollupID uint64) (*RollupInfo, error) {
+	contractAbi, err := abi.JSON(strings.NewReader(RollupManagerReadAbi))
+	if err != nil {
+		return nil, err
+	}
+
+	call := func(to common.Address, method string, args ...interface{}) ([]interface{}, error) {
+		data, err := contractAbi.Pack(method, args...)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.CallContract(ctx, ethereum.CallMsg{To: &to, Data: data}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%s call failed: %w", method, err)
+		}
+		return contractAbi.Unpack(method, resp)
+	}
+
+	rollupData, err := call(rollupManager, "rollupIDToRollupData", uint32(rollupID))
+	if err != nil {
+		return nil, err
+	}
+
+	info := &RollupInfo{
+		RollupContract: rollupData[0].(common.Address),
+		ChainID:        rollupData[1].(uint64),
+		ForkID:         rollupData[3].(uint64),
+		RollupTypeID:   rollupData[10].(uint64),
+	}
+	if info.RollupContract == (common.Address{}) {
+		return nil, fmt.Errorf("rollup %d is not registered in the rollup manager %s", rollupID, rollupManager)
+	}
+	// rollups migrated from the legacy zkEVM contract have no rollup type holding their genesis
+	if info.RollupTypeID == 0 {
+		return nil, fmt.Errorf("rollup %d has no rollup type, its genesis root is not held by the rollup manager", rollupID)
+	}
+
+	rollupType, err := call(rollupManager, "rollupTypeMap", uint32(info.RollupTypeID))
+	if err != nil {
+		return nil, err
+	}
+	info.GenesisRoot = rollupType[5].([32]byte)
+
+	sequencer, err := call(info.RollupContract, "trustedSequencer")
+	if err != nil {
+		return nil, err
+	}
+	info.TrustedSequencer = sequencer[0].(common.Address)
+
+	gerManager, err := call(info.RollupContract, "globalExitRootManager")
+	if err != nil {
+		return nil, err
+	}
+	info.GlobalExitRootManager = gerManager[0].(common.Address)
+
+	return info, nil
+}
@@ -0,0 +1,93 @@
+package etherman
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/gateway-fm/cdk-erigon-lib/common"
+	ethereum "github.com/ledgerwatch/erigon"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/accounts/abi"
+)
+
+// rollupManagerCaller answers the view calls of GetRollupInfo from a rollup manager holding a single rollup
+type rollupManagerCaller struct {
+	abi           abi.ABI
+	rollupManager common.Address
+	rollupId      uint32
+	rollup        RollupInfo
+}
+
+func (c *rollupManagerCaller) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	method, err := c.abi.MethodById(msg.Data)
+	if err != nil {
+		return nil, err
+	}
+	args, err := method.Inputs.Unpack(msg.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case *msg.To == c.rollupManager && method.Name == "rollupIDToRollupData":
+		if args[0].(uint32) != c.rollupId {
+			return method.Outputs.Pack(common.Address{}, uint64(0), common.Address{}, uint64(0), [32]byte{}, uint64(0), uint64(0), uint64(0), uint64(0), uint64(0), uint64(0), uint8(0))
+		}
+		return method.Outputs.Pack(c.rollup.RollupContract, c.rollup.ChainID, common.Address{}, c.rollup.ForkID, [32]byte{}, uint64(0), uint64(0), uint64(0), uint64(0), uint64(0), c.rollup.RollupTypeID, uint8(0))
+	case *msg.To == c.rollupManager && method.Name == "rollupTypeMap" && uint64(args[0].(uint32)) == c.rollup.RollupTypeID:
+		return method.Outputs.Pack(common.Address{}, common.Address{}, c.rollup.ForkID, uint8(0), false, [32]byte(c.rollup.GenesisRoot))
+	case *msg.To == c.rollup.RollupContract && method.Name == "trustedSequencer":
+		return method.Outputs.Pack(c.rollup.TrustedSequencer)
+	case *msg.To == c.rollup.RollupContract && method.Name == "globalExitRootManager":
+		return method.Outputs.Pack(c.rollup.GlobalExitRootManager)
+	}
+	return nil, fmt.Errorf("execution reverted")
+}
+
+func newRollupManagerCaller(t *testing.T) *rollupManagerCaller {
+	contractAbi, err := abi.JSON(strings.NewReader(RollupManagerReadAbi))
+	require.NoError(t, err)
+	return &rollupManagerCaller{
+		abi:           contractAbi,
+		rollupManager: common.HexToAddress("0x01"),
+		rollupId:      1,
+		rollup: RollupInfo{
+			RollupContract:        common.HexToAddress("0x02"),
+			ChainID:               999999,
+			ForkID:                9,
+			RollupTypeID:          3,
+			GenesisRoot:           common.HexToHash("0x03"),
+			TrustedSequencer:      common.HexToAddress("0x04"),
+			GlobalExitRootManager: common.HexToAddress("0x05"),
+		},
+	}
+}
+
+func TestGetRollupInfo(t *testing.T) {
+	caller := newRollupManagerCaller(t)
+
+	info, err := GetRollupInfo(context.Background(), caller, caller.rollupManager, 1)
+	require.NoError(t, err)
+	require.Equal(t, caller.rollup, *info)
+}
+
+func TestGetRollupInfoErrors(t *testing.T) {
+	caller := newRollupManagerCaller(t)
+
+	// a rollup id the rollup manager doesn't know
+	_, err := GetRollupInfo(context.Background(), caller, caller.rollupManager, 2)
+	require.ErrorContains(t, err, "rollup 2 is not registered")
+
+	// a rollup migrated from the legacy zkEVM contract has no rollup type to take the genesis from
+	caller.rollup.RollupTypeID = 0
+	_, err = GetRollupInfo(context.Background(), caller, caller.rollupManager, 1)
+	require.ErrorContains(t, err, "has no rollup type")
+
+	// the calls to a contract that isn't the rollup manager revert
+	_, err = GetRollupInfo(context.Background(), caller, common.HexToAddress("0x06"), 1)
+	require.ErrorContains(t, err, "rollupIDToRollupData call failed")
+}